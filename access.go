@@ -0,0 +1,45 @@
+package structable
+
+// ColumnFilter returns a copy of the DbRecorder that only sees the columns
+// that role may read.
+//
+// Columns are restricted to roles with the RESTRICTED tag option. Several
+// roles are separated by |:
+//
+//	type User struct {
+//		Id    int    `stbl:"id,PRIMARY_KEY,SERIAL"`
+//		Name  string `stbl:"name"`
+//		Email string `stbl:"email,RESTRICTED=admin|support"`
+//	}
+//
+//	public := users.ColumnFilter("")
+//	items, err := structable.List(public)
+//
+// The copy is bound to the same Record. Restricted columns are left out of
+// everything it does: they are not selected, not returned by Columns or
+// Values, and not written by Insert or Update, so that fields which were never
+// loaded cannot overwrite stored values. Primary key columns cannot be
+// restricted.
+func (s *DbRecorder) ColumnFilter(role string) *DbRecorder {
+	c := *s
+	c.fields = make([]*field, 0, len(s.fields))
+	for _, f := range s.fields {
+		if f.isKey || f.allows(role) {
+			c.fields = append(c.fields, f)
+		}
+	}
+	return &c
+}
+
+// allows reports whether role may see the field.
+func (f *field) allows(role string) bool {
+	if f.roles == nil {
+		return true
+	}
+	for _, r := range f.roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package structable
+
+import (
+	"reflect"
+	"testing"
+)
+
+type member struct {
+	Id    int    `stbl:"id,PRIMARY_KEY,SERIAL"`
+	Name  string `stbl:"name"`
+	Email string `stbl:"email,RESTRICTED=admin|support"`
+	Notes string `stbl:"notes,RESTRICTED=admin"`
+}
+
+func TestColumnFilter(t *testing.T) {
+	db := &DBStub{}
+	m := &member{Id: 1, Name: "matt"}
+	r := New(db, "postgres")
+	r.Bind("members", m)
+
+	tests := map[string][]string{
+		"":        {"id", "name"},
+		"support": {"id", "name", "email"},
+		"admin":   {"id", "name", "email", "notes"},
+	}
+	for role, expect := range tests {
+		if cols := r.ColumnFilter(role).Columns(true); !reflect.DeepEqual(cols, expect) {
+			t.Errorf("Role %q: expected %v, got %v", role, expect, cols)
+		}
+	}
+	if len(r.Columns(true)) != 4 {
+		t.Error("Expected the original recorder to see every column")
+	}
+
+	public := r.ColumnFilter("")
+	if vals := public.Values(); len(vals) != 2 || vals["email"] != nil {
+		t.Errorf("Expected restricted values to be left out, got %v", vals)
+	}
+	if err := public.Update(); err != nil {
+		t.Fatal(err)
+	}
+	expect := "UPDATE members SET name = $1 WHERE id = $2"
+	if db.LastExecSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastExecSql)
+	}
+	if _, err := List(public); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "SELECT id, name FROM members"; db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+}
@@ -0,0 +1,137 @@
+package structable
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// Group is the number of rows that share one value of a column.
+type Group struct {
+	// Value is the column value. Text and binary values are returned as
+	// strings, and NULL as nil.
+	Value interface{}
+	// Count is the number of rows with that value.
+	Count int64
+}
+
+// CountWhere counts the rows in the Describer's table.
+//
+// The WhereFunc receives a `SELECT COUNT(*) FROM table` statement, and may add
+// conditions to it. It may be nil.
+func CountWhere(d Describer, fn WhereFunc) (uint64, error) {
+	var n uint64
+	err := aggregate(d, OpCount, "COUNT(*)", fn, &n)
+	return n, err
+}
+
+// SumWhere returns the sum of a column, or zero if there are no rows.
+//
+//	total, err := structable.SumWhere(order, "amount", func(d structable.Describer, q squirrel.SelectBuilder) (squirrel.SelectBuilder, error) {
+//		return q.Where("customer_id = ?", id), nil
+//	})
+//
+// The WhereFunc may be nil.
+func SumWhere(d Describer, column string, fn WhereFunc) (float64, error) {
+	var sum sql.NullFloat64
+	if err := checkColumns(d, column); err != nil {
+		return 0, err
+	}
+	err := aggregate(d, OpAggregate, fmt.Sprintf("SUM(%s)", column), fn, &sum)
+	return sum.Float64, err
+}
+
+// MinWhere stores the smallest value of a column in dest.
+//
+// The dest is scanned like a field: if there are no rows, the value is NULL,
+// so dest should be a pointer to a pointer or to a sql.Null* type. The
+// WhereFunc may be nil.
+func MinWhere(d Describer, dest interface{}, column string, fn WhereFunc) error {
+	if err := checkColumns(d, column); err != nil {
+		return err
+	}
+	return aggregate(d, OpAggregate, fmt.Sprintf("MIN(%s)", column), fn, dest)
+}
+
+// MaxWhere stores the largest value of a column in dest. See MinWhere.
+func MaxWhere(d Describer, dest interface{}, column string, fn WhereFunc) error {
+	if err := checkColumns(d, column); err != nil {
+		return err
+	}
+	return aggregate(d, OpAggregate, fmt.Sprintf("MAX(%s)", column), fn, dest)
+}
+
+// GroupCount counts the rows for each distinct value of a column, ordered by
+// value.
+//
+// The WhereFunc may be nil.
+func GroupCount(d Describer, column string, fn WhereFunc) ([]Group, error) {
+	buf := []Group{}
+	if err := checkColumns(d, column); err != nil {
+		return buf, err
+	}
+
+	q, err := aggregateQuery(d, []string{column, "COUNT(*)"}, fn)
+	if err != nil {
+		return buf, err
+	}
+	rows, err := runAggregate(d, q.GroupBy(column).OrderBy(column))
+	if err != nil || rows == nil {
+		return buf, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var g Group
+		if err := rows.Scan(&g.Value, &g.Count); err != nil {
+			return buf, err
+		}
+		if b, ok := g.Value.([]byte); ok {
+			g.Value = string(b)
+		}
+		buf = append(buf, g)
+	}
+	return buf, rows.Err()
+}
+
+// aggregate runs a single-value aggregate and scans it into dest.
+func aggregate(d Describer, op, expr string, fn WhereFunc, dest interface{}) error {
+	q, err := aggregateQuery(d, []string{expr}, fn)
+	if err != nil {
+		return err
+	}
+	if dr, ok := d.(*DbRecorder); ok {
+		return dr.queryRow(op, q).Scan(dest)
+	}
+	query, args, err := q.ToSql()
+	if err != nil {
+		return err
+	}
+	return d.DB().QueryRow(query, args...).Scan(dest)
+}
+
+// aggregateQuery builds a select of cols from the Describer's table, and lets
+// the WhereFunc modify it.
+func aggregateQuery(d Describer, cols []string, fn WhereFunc) (squirrel.SelectBuilder, error) {
+	q := d.Builder().Select(cols...).From(d.TableName())
+	if dr, ok := d.(*DbRecorder); ok {
+		q = q.Where(dr.tenantWhere())
+	}
+	if fn == nil {
+		return q, nil
+	}
+	return fn(d, q)
+}
+
+// runAggregate runs an aggregate that returns rows.
+func runAggregate(d Describer, q squirrel.SelectBuilder) (*sql.Rows, error) {
+	if dr, ok := d.(*DbRecorder); ok {
+		return dr.query(OpAggregate, q)
+	}
+	query, args, err := q.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	return d.DB().Query(query, args...)
+}
@@ -0,0 +1,46 @@
+package structable
+
+import "testing"
+
+func TestAggregates(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "postgres").Bind("test_table", newStool())
+	fn := WithWhereEq("material", "wood")
+
+	if _, err := SumWhere(r, "number_of_legs", fn); err != nil {
+		t.Fatal(err)
+	}
+	expect := "SELECT SUM(number_of_legs) FROM test_table WHERE material = $1"
+	if db.LastQueryRowSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQueryRowSql)
+	}
+
+	var legs *int
+	if err := MaxWhere(r, &legs, "number_of_legs", nil); err != nil {
+		t.Fatal(err)
+	}
+	expect = "SELECT MAX(number_of_legs) FROM test_table"
+	if db.LastQueryRowSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQueryRowSql)
+	}
+
+	if _, err := CountWhere(r, fn); err != nil {
+		t.Fatal(err)
+	}
+	expect = "SELECT COUNT(*) FROM test_table WHERE material = $1"
+	if db.LastQueryRowSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQueryRowSql)
+	}
+
+	if _, err := GroupCount(r, "material", nil); err != nil {
+		t.Fatal(err)
+	}
+	expect = "SELECT material, COUNT(*) FROM test_table GROUP BY material ORDER BY material"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+
+	if err := MinWhere(r, &legs, "nope", nil); err == nil {
+		t.Error("Expected unknown column to fail")
+	}
+}
@@ -0,0 +1,307 @@
+/*
+Package audit records the changes that Structable Recorders make, in an audit
+table.
+
+A Recorder wraps another Recorder. After each Insert, Update, and Delete, it
+writes a row to the audit table with the table and key of the changed row,
+the operation, the acting user and request (from the structable.Carrier in
+its context), the time, and a JSON diff of the changed columns:
+
+	{"total": {"before": 100, "after": 120}}
+
+Inserts only have "after" values, and deletes only have "before" values.
+Updates and deletes load the stored row first, so the diff shows what was
+actually in the database.
+
+The audit table looks like this (adjust the types for the database):
+
+	CREATE TABLE audit_log (
+		table_name VARCHAR(255) NOT NULL,
+		row_key    TEXT NOT NULL,
+		operation  VARCHAR(16) NOT NULL,
+		actor      VARCHAR(255) NOT NULL,
+		request_id VARCHAR(255) NOT NULL,
+		changed_at TIMESTAMP NOT NULL,
+		diff       TEXT NOT NULL
+	);
+
+What is audited is set per Recorder with a Policy:
+
+	policy := audit.Policy{Exclude: []string{"password_hash"}}
+	u.Recorder = audit.New(structable.New(tx, "postgres"), policy).
+		WithContext(req.Context()).
+		Bind("users", u)
+
+The audit row is written with the Recorder's own database handle. Give the
+Recorder a transaction to make the change and its audit row atomic.
+*/
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/Masterminds/structable"
+)
+
+// Policy configures what a Recorder audits.
+type Policy struct {
+	// Table is the audit table. It defaults to audit_log.
+	Table string
+	// Ops are the operations to audit: structable.OpInsert, OpUpdate, and
+	// OpDelete. If empty, all three are audited.
+	Ops []string
+	// Exclude lists columns that are never written to the audit table, such
+	// as password hashes. Changes to them are not recorded at all.
+	Exclude []string
+	// SkipUnchanged skips the audit row for updates that change nothing.
+	SkipUnchanged bool
+	// Now returns the time of a change. It defaults to time.Now.
+	Now func() time.Time
+}
+
+func (p Policy) table() string {
+	if p.Table == "" {
+		return "audit_log"
+	}
+	return p.Table
+}
+
+func (p Policy) audits(op string) bool {
+	if len(p.Ops) == 0 {
+		return true
+	}
+	for _, o := range p.Ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Policy) now() time.Time {
+	if p.Now == nil {
+		return time.Now()
+	}
+	return p.Now()
+}
+
+// Recorder is a structable.Recorder that audits its changes.
+type Recorder struct {
+	structable.Recorder
+	policy Policy
+	ctx    context.Context
+}
+
+// New wraps a Recorder, so that its changes are audited according to p.
+func New(rec structable.Recorder, p Policy) *Recorder {
+	return &Recorder{Recorder: rec, policy: p}
+}
+
+// Middleware returns a RecorderMiddleware that audits Recorders according to
+// p, for use with structable.Wrap.
+func Middleware(p Policy) structable.RecorderMiddleware {
+	return func(next structable.Recorder) structable.Recorder {
+		return New(next, p)
+	}
+}
+
+// WithContext returns a copy of the Recorder whose audit rows take the actor
+// and request ID from the structable.Carrier in ctx.
+//
+// Without it, the context of the underlying DbRecorder is used.
+func (r *Recorder) WithContext(ctx context.Context) *Recorder {
+	c := *r
+	c.ctx = ctx
+	return &c
+}
+
+// Bind binds the underlying Recorder, and returns the auditing Recorder.
+func (r *Recorder) Bind(table string, rec structable.Record) structable.Recorder {
+	r.Recorder = r.Recorder.Bind(table, rec)
+	return r
+}
+
+// Unwrap returns the underlying Recorder.
+func (r *Recorder) Unwrap() structable.Recorder {
+	return r.Recorder
+}
+
+// Insert inserts the record, and audits it.
+func (r *Recorder) Insert() error {
+	if !r.policy.audits(structable.OpInsert) {
+		return r.Recorder.Insert()
+	}
+	if err := r.Recorder.Insert(); err != nil {
+		return err
+	}
+	return r.write(structable.OpInsert, nil, r.snapshot(r))
+}
+
+// Update updates the record, and audits the columns that changed.
+func (r *Recorder) Update() error {
+	if !r.policy.audits(structable.OpUpdate) {
+		return r.Recorder.Update()
+	}
+	before, err := r.stored()
+	if err != nil {
+		return err
+	}
+	if err := r.Recorder.Update(); err != nil {
+		return err
+	}
+	return r.write(structable.OpUpdate, before, r.snapshot(r))
+}
+
+// Delete deletes the record, and audits it.
+func (r *Recorder) Delete() error {
+	if !r.policy.audits(structable.OpDelete) {
+		return r.Recorder.Delete()
+	}
+	before, err := r.stored()
+	if err != nil {
+		return err
+	}
+	if err := r.Recorder.Delete(); err != nil {
+		return err
+	}
+	return r.write(structable.OpDelete, before, nil)
+}
+
+// Change is the before and after value of one column.
+type Change struct {
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// Diff returns the changes between two snapshots of a row, by column. A nil
+// snapshot stands for a row that does not exist. Columns whose values encode
+// to the same JSON are unchanged.
+func Diff(before, after map[string]interface{}) (map[string]Change, error) {
+	diff := map[string]Change{}
+	for col, b := range before {
+		a, ok := after[col]
+		if ok {
+			same, err := sameJSON(a, b)
+			if err != nil {
+				return nil, err
+			}
+			if same {
+				continue
+			}
+		}
+		diff[col] = Change{Before: b, After: a}
+	}
+	for col, a := range after {
+		if _, ok := before[col]; !ok {
+			diff[col] = Change{After: a}
+		}
+	}
+	return diff, nil
+}
+
+func sameJSON(a, b interface{}) (bool, error) {
+	ja, err := json.Marshal(a)
+	if err != nil {
+		return false, err
+	}
+	jb, err := json.Marshal(b)
+	if err != nil {
+		return false, err
+	}
+	return string(ja) == string(jb), nil
+}
+
+// snapshot returns the values of a Recorder's columns, without the excluded
+// ones.
+func (r *Recorder) snapshot(d structable.Describer) map[string]interface{} {
+	cols := d.Columns(true)
+	vals := make(map[string]interface{}, len(cols))
+	for i, ref := range d.FieldReferences(true) {
+		vals[cols[i]] = reflect.ValueOf(ref).Elem().Interface()
+	}
+	for _, c := range r.policy.Exclude {
+		delete(vals, c)
+	}
+	return vals
+}
+
+// stored loads the row as it is in the database, and returns its snapshot.
+func (r *Recorder) stored() (map[string]interface{}, error) {
+	var c *structable.DbRecorder
+	if dr := dbRecorder(r.Recorder); dr != nil {
+		c = dr.Clone(nil)
+	} else {
+		rec := reflect.New(reflect.Indirect(reflect.ValueOf(r.Record())).Type()).Interface()
+		c = structable.New(r.DB(), r.Driver())
+		c.Bind(r.TableName(), rec)
+	}
+	if err := c.SetValues(r.WhereIds()); err != nil {
+		return nil, err
+	}
+	if err := c.Load(); err != nil {
+		return nil, fmt.Errorf("loading %s for audit: %w", r.TableName(), err)
+	}
+	return r.snapshot(c), nil
+}
+
+// write writes an audit row.
+func (r *Recorder) write(op string, before, after map[string]interface{}) error {
+	diff, err := Diff(before, after)
+	if err != nil {
+		return err
+	}
+	if len(diff) == 0 && op == structable.OpUpdate && r.policy.SkipUnchanged {
+		return nil
+	}
+	d, err := json.Marshal(diff)
+	if err != nil {
+		return err
+	}
+	key, err := json.Marshal(r.WhereIds())
+	if err != nil {
+		return err
+	}
+
+	c := structable.CarrierFrom(r.context())
+	q := r.Builder().Insert(r.policy.table()).
+		Columns("table_name", "row_key", "operation", "actor", "request_id", "changed_at", "diff").
+		Values(r.TableName(), string(key), op, c.Actor, c.RequestID, r.policy.now(), string(d))
+	query, args, err := q.ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err := r.DB().Exec(query, args...); err != nil {
+		return fmt.Errorf("writing audit row for %s: %w", r.TableName(), err)
+	}
+	return nil
+}
+
+// context returns the context set with WithContext, or the underlying
+// DbRecorder's.
+func (r *Recorder) context() context.Context {
+	if r.ctx != nil {
+		return r.ctx
+	}
+	if dr := dbRecorder(r.Recorder); dr != nil {
+		return dr.Context()
+	}
+	return context.Background()
+}
+
+// dbRecorder unwraps a Recorder to its *structable.DbRecorder, or returns nil.
+func dbRecorder(rec structable.Recorder) *structable.DbRecorder {
+	for {
+		switch r := rec.(type) {
+		case *structable.DbRecorder:
+			return r
+		case interface{ Unwrap() structable.Recorder }:
+			rec = r.Unwrap()
+		default:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,98 @@
+//go:build sqlite
+// +build sqlite
+
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/Masterminds/structable"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type account struct {
+	Id       int64  `stbl:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Name     string `stbl:"name"`
+	Password string `stbl:"password"`
+}
+
+type auditRow struct {
+	table, key, op, actor, request string
+	diff                           map[string]Change
+}
+
+func TestRecorder(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	_, err = db.Exec(`
+	CREATE TABLE accounts (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, password TEXT);
+	CREATE TABLE audit_log (
+		table_name TEXT, row_key TEXT, operation TEXT, actor TEXT,
+		request_id TEXT, changed_at TIMESTAMP, diff TEXT
+	);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := structable.WithCarrier(context.Background(), structable.Carrier{Actor: "matt", RequestID: "r1"})
+	a := &account{Name: "ada", Password: "secret"}
+	r := New(structable.New(structable.NewRunner(db), "sqlite3"), Policy{Exclude: []string{"password"}}).
+		WithContext(ctx)
+	r.Bind("accounts", a)
+
+	if err := r.Insert(); err != nil {
+		t.Fatalf("Failed Insert: %s", err)
+	}
+	a.Name = "ada lovelace"
+	a.Password = "changed"
+	if err := r.Update(); err != nil {
+		t.Fatalf("Failed Update: %s", err)
+	}
+	if err := r.Delete(); err != nil {
+		t.Fatalf("Failed Delete: %s", err)
+	}
+
+	rows, err := db.Query("SELECT table_name, row_key, operation, actor, request_id, diff FROM audit_log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	log := []auditRow{}
+	for rows.Next() {
+		var row auditRow
+		var diff string
+		if err := rows.Scan(&row.table, &row.key, &row.op, &row.actor, &row.request, &diff); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal([]byte(diff), &row.diff); err != nil {
+			t.Fatal(err)
+		}
+		log = append(log, row)
+	}
+
+	if len(log) != 3 {
+		t.Fatalf("Expected 3 audit rows, got %d", len(log))
+	}
+	for i, op := range []string{structable.OpInsert, structable.OpUpdate, structable.OpDelete} {
+		row := log[i]
+		if row.op != op || row.table != "accounts" || row.key != `{"id":1}` || row.actor != "matt" || row.request != "r1" {
+			t.Errorf("Unexpected audit row %d: %+v", i, row)
+		}
+		if _, ok := row.diff["password"]; ok {
+			t.Errorf("Expected the password to be excluded from row %d", i)
+		}
+	}
+	if c := log[1].diff; len(c) != 1 || c["name"].Before != "ada" || c["name"].After != "ada lovelace" {
+		t.Errorf("Expected the update to record the name change, got %v", c)
+	}
+	if c := log[2].diff; c["name"].Before != "ada lovelace" || c["name"].After != nil {
+		t.Errorf("Expected the delete to record the stored row, got %v", c)
+	}
+}
@@ -0,0 +1,40 @@
+package audit
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/structable"
+)
+
+func TestDiff(t *testing.T) {
+	before := map[string]interface{}{"name": "matt", "legs": 3, "gone": true}
+	after := map[string]interface{}{"name": "matt", "legs": 4, "new": "x"}
+	diff, err := Diff(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]Change{
+		"legs": {Before: 3, After: 4},
+		"gone": {Before: true},
+		"new":  {After: "x"},
+	}
+	if !reflect.DeepEqual(diff, expect) {
+		t.Errorf("Expected %v, got %v", expect, diff)
+	}
+
+	if diff, _ := Diff(nil, after); len(diff) != 3 {
+		t.Errorf("Expected every column of an insert, got %v", diff)
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	p := Policy{}
+	if p.table() != "audit_log" || !p.audits(structable.OpDelete) {
+		t.Error("Expected the default policy to audit everything to audit_log")
+	}
+	p.Ops = []string{structable.OpDelete}
+	if p.audits(structable.OpUpdate) || !p.audits(structable.OpDelete) {
+		t.Error("Expected only deletes to be audited")
+	}
+}
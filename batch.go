@@ -0,0 +1,169 @@
+package structable
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// DeleteAll deletes the records with the given primary key values in one
+// statement, and returns the number of records deleted.
+//
+// For a table with a single-column primary key, each key is a value:
+//
+//	n, err := structable.DeleteAll(r, []interface{}{1, 2, 3})
+//
+// For a composite key, each key is either a map of column names to values, as
+// returned by WhereIds, or a []interface{} of values in the order of the
+// sorted key column names:
+//
+//	n, err := structable.DeleteAll(r, []interface{}{
+//		map[string]interface{}{"id": 1, "id_two": 2},
+//		[]interface{}{3, 4},
+//	})
+//
+// On postgres and mysql, composite keys are matched with row values, as in
+// `WHERE (id, id_two) IN ((?,?),(?,?))`. On other flavors, the keys are OR'd
+// together.
+//
+// An empty list of keys deletes nothing.
+func DeleteAll(d Recorder, keys []interface{}) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	s := protoRecorder(d)
+	where, err := s.keysWhere(keys)
+	if err != nil {
+		return 0, err
+	}
+	q := s.builder.Delete(s.TableName()).Where(where).Where(s.tenantWhere())
+	res, err := s.exec(OpDelete, q)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// UpdateAll sets the same columns to the same values on the records with the
+// given primary key values in one statement, and returns the number of
+// records updated.
+//
+// Keys are given as for DeleteAll. Changes map column names to values, which
+// are converted to the types of their fields as by SetValues:
+//
+//	n, err := structable.UpdateAll(r, ids, map[string]interface{}{"status": "archived"})
+//
+// Primary key, tenant, READONLY, and OMIT_UPDATE columns cannot be changed,
+// nor can EXTERNAL columns, which are stored per record. Changing them
+// returns ErrColumnNotAllowed. An empty list of keys or changes updates
+// nothing.
+func UpdateAll(d Recorder, keys []interface{}, changes map[string]interface{}) (int64, error) {
+	s := protoRecorder(d)
+	tenant := s.tenantField()
+	for col := range changes {
+		f, err := s.fieldForColumn(col)
+		if err != nil || f.isKey || f.omitUpdate || f.isExternal || f == tenant {
+			return 0, fmt.Errorf("%w: %s.%s", ErrColumnNotAllowed, s.table, col)
+		}
+	}
+	if len(keys) == 0 || len(changes) == 0 {
+		return 0, nil
+	}
+
+	// Convert the changes by setting them on a scratch record, so that they
+	// are written just as Update would write them.
+	scratch := s.Clone(nil)
+	if err := scratch.SetValues(changes); err != nil {
+		return 0, err
+	}
+	vals := scratch.Values()
+	set := make(map[string]interface{}, len(changes))
+	for col := range changes {
+		f, _ := s.fieldForColumn(col)
+		set[col] = writeValue(f, vals[col])
+	}
+
+	where, err := s.keysWhere(keys)
+	if err != nil {
+		return 0, err
+	}
+	q := s.builder.Update(s.TableName()).SetMap(set).Where(where).Where(s.tenantWhere())
+	res, err := s.exec(OpUpdate, q)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// keysWhere builds a predicate that matches any of a list of primary keys.
+func (s *DbRecorder) keysWhere(keys []interface{}) (squirrel.Sqlizer, error) {
+	cols := s.Key()
+	switch len(cols) {
+	case 0:
+		return nil, fmt.Errorf("table %s has no primary key", s.table)
+	case 1:
+		vals := make([]interface{}, len(keys))
+		for i, k := range keys {
+			if m, ok := k.(map[string]interface{}); ok {
+				k = m[cols[0]]
+			}
+			vals[i] = k
+		}
+		return squirrel.Eq{cols[0]: vals}, nil
+	}
+
+	tuples := make([][]interface{}, len(keys))
+	for i, k := range keys {
+		t, err := keyTuple(cols, k)
+		if err != nil {
+			return nil, fmt.Errorf("key %d for table %s: %s", i, s.table, err)
+		}
+		tuples[i] = t
+	}
+
+	switch s.flavor {
+	case "postgres", "mysql":
+		row := "(" + strings.TrimSuffix(strings.Repeat("?,", len(cols)), ",") + ")"
+		rows := make([]string, len(tuples))
+		args := make([]interface{}, 0, len(tuples)*len(cols))
+		for i, t := range tuples {
+			rows[i] = row
+			args = append(args, t...)
+		}
+		pred := fmt.Sprintf("(%s) IN (%s)", strings.Join(cols, ", "), strings.Join(rows, ","))
+		return squirrel.Expr(pred, args...), nil
+	default:
+		or := make(squirrel.Or, len(tuples))
+		for i, t := range tuples {
+			eq := make(squirrel.Eq, len(cols))
+			for j, c := range cols {
+				eq[c] = t[j]
+			}
+			or[i] = eq
+		}
+		return or, nil
+	}
+}
+
+// keyTuple returns the values of a composite key in the order of cols.
+func keyTuple(cols []string, key interface{}) ([]interface{}, error) {
+	switch k := key.(type) {
+	case map[string]interface{}:
+		t := make([]interface{}, len(cols))
+		for i, c := range cols {
+			v, ok := k[c]
+			if !ok {
+				return nil, fmt.Errorf("missing column %s", c)
+			}
+			t[i] = v
+		}
+		return t, nil
+	case []interface{}:
+		if len(k) != len(cols) {
+			return nil, fmt.Errorf("expected %d values, got %d", len(cols), len(k))
+		}
+		return k, nil
+	}
+	return nil, fmt.Errorf("composite keys must be a map or []interface{}, got %T", key)
+}
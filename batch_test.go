@@ -0,0 +1,108 @@
+package structable
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDeleteAll(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "postgres").Bind("categories", &category{})
+
+	n, err := DeleteAll(r, []interface{}{3, 1, 2})
+	if err != nil || n != 1 {
+		t.Fatalf("Unexpected result %d, %v", n, err)
+	}
+	expect := "DELETE FROM categories WHERE id IN ($1,$2,$3)"
+	if db.LastExecSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastExecSql)
+	}
+
+	db.LastExecSql = ""
+	if n, err := DeleteAll(r, nil); err != nil || n != 0 || db.LastExecSql != "" {
+		t.Errorf("Expected no keys to delete nothing, got %d, %v", n, err)
+	}
+
+	keys := []interface{}{
+		map[string]interface{}{"id": 1, "id_two": 2},
+		[]interface{}{3, 4},
+	}
+	tests := map[string]string{
+		"postgres": "DELETE FROM test_table WHERE (id, id_two) IN (($1,$2),($3,$4))",
+		"mysql":    "DELETE FROM test_table WHERE (id, id_two) IN ((?,?),(?,?))",
+		"sqlite3":  "DELETE FROM test_table WHERE (id = ? AND id_two = ? OR id = ? AND id_two = ?)",
+	}
+	for flavor, expect := range tests {
+		r := New(db, flavor).Bind("test_table", newStool())
+		if _, err := DeleteAll(r, keys); err != nil {
+			t.Fatalf("%s: %s", flavor, err)
+		}
+		if db.LastExecSql != expect {
+			t.Errorf("%s: expected %q, got %q", flavor, expect, db.LastExecSql)
+		}
+		if !reflect.DeepEqual(db.LastExecArgs, []interface{}{1, 2, 3, 4}) {
+			t.Errorf("%s: unexpected args %v", flavor, db.LastExecArgs)
+		}
+	}
+
+	r = New(db, "mysql").Bind("test_table", newStool())
+	if _, err := DeleteAll(r, []interface{}{1}); err == nil {
+		t.Error("Expected a plain value for a composite key to fail")
+	}
+	if _, err := DeleteAll(r, []interface{}{map[string]interface{}{"id": 1}}); err == nil {
+		t.Error("Expected a partial composite key to fail")
+	}
+
+	// Slice keys follow the declared order of the key, not the sorted one.
+	type membership struct {
+		User  int `stbl:"user_id,PRIMARY_KEY"`
+		Group int `stbl:"group_id,PRIMARY_KEY"`
+	}
+	r = New(db, "postgres").Bind("memberships", &membership{})
+	if _, err := DeleteAll(r, []interface{}{[]interface{}{1, 2}}); err != nil {
+		t.Fatal(err)
+	}
+	expect = "DELETE FROM memberships WHERE (user_id, group_id) IN (($1,$2))"
+	if db.LastExecSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastExecSql)
+	}
+}
+
+func TestUpdateAll(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "postgres").Bind("things", &patchable{})
+
+	n, err := UpdateAll(r, []interface{}{1, 2}, map[string]interface{}{"legs": float64(4)})
+	if err != nil || n != 1 {
+		t.Fatalf("Unexpected result %d, %v", n, err)
+	}
+	expect := "UPDATE things SET legs = $1 WHERE id IN ($2,$3)"
+	if db.LastExecSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastExecSql)
+	}
+	if !reflect.DeepEqual(db.LastExecArgs, []interface{}{4, 1, 2}) {
+		t.Errorf("Unexpected args %v", db.LastExecArgs)
+	}
+
+	db.LastExecSql = ""
+	for _, col := range []string{"id", "nope"} {
+		_, err := UpdateAll(r, []interface{}{1}, map[string]interface{}{col: 2})
+		if !errors.Is(err, ErrColumnNotAllowed) || db.LastExecSql != "" {
+			t.Errorf("Expected %s to be rejected, got %v", col, err)
+		}
+	}
+	if n, err := UpdateAll(r, nil, map[string]interface{}{"legs": 4}); err != nil || n != 0 || db.LastExecSql != "" {
+		t.Errorf("Expected no keys to update nothing, got %d, %v", n, err)
+	}
+
+	s := New(db, "mysql").Bind("test_table", newStool())
+	keys := []interface{}{[]interface{}{1, 2}, []interface{}{3, 4}}
+	if _, err := UpdateAll(s, keys, map[string]interface{}{"material": "oak"}); err != nil {
+		t.Fatal(err)
+	}
+	expect = "UPDATE test_table SET material = ? WHERE (id, id_two) IN ((?,?),(?,?))"
+	if db.LastExecSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastExecSql)
+	}
+}
@@ -0,0 +1,172 @@
+package structable
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// BlobTable stores large payloads once, in a shared table keyed by a hash of
+// their content.
+//
+// A Record keeps only the hash, in a string field. Identical payloads are
+// stored once, and each blob counts the rows that refer to it, so that it can
+// be deleted once the last reference is gone:
+//
+//	CREATE TABLE blobs (
+//		hash CHAR(64) PRIMARY KEY,
+//		data BYTEA NOT NULL,
+//		refs INTEGER NOT NULL
+//	);
+//
+//	blobs := structable.NewBlobTable("blobs")
+//	err := blobs.Insert(attachment, "content_hash", payload)
+//
+// BlobTable runs its statements on the Recorder's own DB. If the Recorder was
+// created with a transaction, the Record and the reference counts change
+// together. Two transactions that add the same new payload at once may
+// conflict on the hash; retry the one that fails.
+type BlobTable struct {
+	// Table is the name of the blob table.
+	Table string
+	// HashColumn, DataColumn, and RefsColumn name the columns of the blob
+	// table. They default to hash, data, and refs.
+	HashColumn, DataColumn, RefsColumn string
+}
+
+// NewBlobTable creates a BlobTable with the default column names.
+func NewBlobTable(table string) *BlobTable {
+	return &BlobTable{
+		Table:      table,
+		HashColumn: "hash",
+		DataColumn: "data",
+		RefsColumn: "refs",
+	}
+}
+
+// BlobHash returns the content hash that a BlobTable stores data under: the
+// hex-encoded SHA-256 of the data.
+func BlobHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Insert stores data in the blob table, sets the Record's hash column to its
+// hash, and inserts the Record.
+func (b *BlobTable) Insert(r Recorder, column string, data []byte) error {
+	d := protoRecorder(r)
+	field, err := blobField(r, column)
+	if err != nil {
+		return err
+	}
+	hash, err := b.acquire(d, data)
+	if err != nil {
+		return err
+	}
+	field.SetString(hash)
+	return r.Insert()
+}
+
+// Update replaces the Record's payload with data, and updates the Record.
+//
+// The hash column must still hold the hash of the old payload, as it was
+// loaded. The old blob loses a reference, and is deleted if that was its
+// last one.
+func (b *BlobTable) Update(r Recorder, column string, data []byte) error {
+	d := protoRecorder(r)
+	field, err := blobField(r, column)
+	if err != nil {
+		return err
+	}
+	old := field.String()
+	hash, err := b.acquire(d, data)
+	if err != nil {
+		return err
+	}
+	field.SetString(hash)
+	if err := r.Update(); err != nil {
+		return err
+	}
+	return b.release(d, old)
+}
+
+// Delete deletes the Record, and releases its reference to its blob.
+func (b *BlobTable) Delete(r Recorder, column string) error {
+	d := protoRecorder(r)
+	field, err := blobField(r, column)
+	if err != nil {
+		return err
+	}
+	if err := r.Delete(); err != nil {
+		return err
+	}
+	return b.release(d, field.String())
+}
+
+// Load returns the payload that the Record's hash column refers to.
+func (b *BlobTable) Load(r Recorder, column string) ([]byte, error) {
+	d := protoRecorder(r)
+	field, err := blobField(r, column)
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	q := d.builder.Select(b.DataColumn).From(b.Table).Where(squirrel.Eq{b.HashColumn: field.String()})
+	err = d.queryRow(OpLoad, q).Scan(&data)
+	return data, err
+}
+
+// acquire adds a reference to the blob for data, storing it if it is new,
+// and returns its hash.
+func (b *BlobTable) acquire(d *DbRecorder, data []byte) (string, error) {
+	hash := BlobHash(data)
+	up := d.builder.Update(b.Table).
+		Set(b.RefsColumn, squirrel.Expr(b.RefsColumn+" + 1")).
+		Where(squirrel.Eq{b.HashColumn: hash})
+	res, err := d.exec(OpUpdate, up)
+	if err != nil {
+		return "", err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return hash, err
+	}
+
+	ins := d.builder.Insert(b.Table).
+		Columns(b.HashColumn, b.DataColumn, b.RefsColumn).
+		Values(hash, data, 1)
+	_, err = d.exec(OpInsert, ins)
+	return hash, err
+}
+
+// release removes a reference to a blob, and deletes the blob if no
+// references are left. An empty hash is ignored.
+func (b *BlobTable) release(d *DbRecorder, hash string) error {
+	if hash == "" {
+		return nil
+	}
+	where := squirrel.Eq{b.HashColumn: hash}
+	up := d.builder.Update(b.Table).
+		Set(b.RefsColumn, squirrel.Expr(b.RefsColumn+" - 1")).
+		Where(where)
+	if _, err := d.exec(OpUpdate, up); err != nil {
+		return err
+	}
+	del := d.builder.Delete(b.Table).Where(squirrel.And{where, squirrel.LtOrEq{b.RefsColumn: 0}})
+	_, err := d.exec(OpDelete, del)
+	return err
+}
+
+// blobField returns the string field of r's Record that holds a blob hash.
+func blobField(r Recorder, column string) (reflect.Value, error) {
+	f, err := fieldByColumn(r, column)
+	if err != nil {
+		return f, err
+	}
+	if f.Kind() != reflect.String {
+		return f, fmt.Errorf("column %q on table %s must be a string field to hold a blob hash", column, r.TableName())
+	}
+	return f, nil
+}
@@ -0,0 +1,36 @@
+package structable
+
+import (
+	"testing"
+)
+
+type attachment struct {
+	Id   int    `stbl:"id,PRIMARY_KEY,SERIAL"`
+	Name string `stbl:"name"`
+	Hash string `stbl:"content_hash"`
+}
+
+func TestBlobHash(t *testing.T) {
+	expect := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	if h := BlobHash([]byte("hello")); h != expect {
+		t.Errorf("Expected %s, got %s", expect, h)
+	}
+}
+
+func TestBlobTableInsert(t *testing.T) {
+	db := &DBStub{}
+	a := &attachment{Name: "greeting.txt"}
+	r := New(db, "postgres")
+	r.Bind("attachments", a)
+
+	if err := NewBlobTable("blobs").Insert(r, "content_hash", []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if a.Hash != BlobHash([]byte("hello")) {
+		t.Errorf("Expected the hash to be set, got %q", a.Hash)
+	}
+
+	if err := NewBlobTable("blobs").Insert(r, "id", nil); err == nil {
+		t.Error("Expected a non-string hash field to fail")
+	}
+}
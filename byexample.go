@@ -0,0 +1,73 @@
+package structable
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// ErrEmptyExample is returned by LoadByExample when no field of the Record is
+// set.
+var ErrEmptyExample = errors.New("no fields are set on the example record")
+
+// LoadByExample loads the first record whose columns equal every field that is
+// set on the bound Record.
+//
+// A field is set if it is not the zero value of its type. A pointer field is
+// set if it is not nil, so a pointer to false, 0, or "" matches that value:
+//
+//	u := &User{Name: "matt", Active: &yes}
+//	r.Bind("users", u)
+//	err := r.LoadByExample() // WHERE active = ? AND name = ?
+//
+// COMPRESSED and EXTERNAL fields are never compared. If no field is set,
+// ErrEmptyExample is returned, rather than loading an arbitrary record. If no
+// record matches, sql.ErrNoRows is returned.
+func (s *DbRecorder) LoadByExample() error {
+	pred := s.example()
+	if len(pred) == 0 {
+		return ErrEmptyExample
+	}
+	return s.LoadWhere(pred)
+}
+
+// ListByExample lists the Records whose columns equal every field that is set
+// on d's bound Record, as for LoadByExample.
+//
+// Options may be given as for List:
+//
+//	search := &Product{Category: "tools", InStock: &yes}
+//	r.Bind("products", search)
+//	found, err := structable.ListByExample(r, structable.WithOrderBy("name"), structable.WithLimit(20))
+//
+// If no field is set, every Record is listed.
+func ListByExample(d Recorder, opts ...WhereFunc) ([]Recorder, error) {
+	pred := protoRecorder(d).Clone(d.Interface()).example()
+	if len(pred) > 0 {
+		opts = append([]WhereFunc{WithWhere(pred)}, opts...)
+	}
+	return List(d, opts...)
+}
+
+// example builds a predicate from the fields that are set on the Record.
+func (s *DbRecorder) example() squirrel.Eq {
+	pred := squirrel.Eq{}
+	ar := reflect.Indirect(reflect.ValueOf(s.record))
+	for _, f := range s.fields {
+		if f.isCompressed || f.isExternal {
+			continue
+		}
+		v := ar.FieldByName(f.name)
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				continue
+			}
+			v = v.Elem()
+		} else if v.IsZero() {
+			continue
+		}
+		pred[f.column] = sqlValue(v.Interface())
+	}
+	return pred
+}
@@ -0,0 +1,51 @@
+package structable
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLoadByExample(t *testing.T) {
+	db := &DBStub{}
+	color := ""
+	s := &Stool{Legs: 3, Color: &color}
+	r := New(db, "mysql")
+	r.Bind("test_table", s)
+
+	// The stub returns no rows.
+	r.LoadByExample()
+	expect := "SELECT id, id_two, number_of_legs, material, color FROM test_table WHERE color = ? AND number_of_legs = ? LIMIT 1"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+	if !reflect.DeepEqual(db.LastQueryArgs, []interface{}{"", 3}) {
+		t.Errorf("Unexpected args %v", db.LastQueryArgs)
+	}
+
+	r.Bind("test_table", &Stool{})
+	if err := r.LoadByExample(); err != ErrEmptyExample {
+		t.Errorf("Expected ErrEmptyExample, got %v", err)
+	}
+}
+
+func TestListByExample(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "postgres").Bind("test_table", &Stool{Material: "wood"})
+
+	if _, err := ListByExample(r, WithOrderBy("id"), WithLimit(5)); err != nil {
+		t.Fatal(err)
+	}
+	expect := "SELECT id, id_two, number_of_legs, material, color FROM test_table WHERE material = $1 ORDER BY id LIMIT 5"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+
+	r = New(db, "postgres").Bind("test_table", &Stool{})
+	if _, err := ListByExample(r); err != nil {
+		t.Fatal(err)
+	}
+	expect = "SELECT id, id_two, number_of_legs, material, color FROM test_table"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+}
@@ -0,0 +1,333 @@
+/*
+Package cache adds a read-through, write-invalidate cache to Structable
+Recorders.
+
+A CachingRecorder wraps a Recorder. Load and Exists look the record up by its
+table and primary key in a Cache, and only go to the database on a miss.
+Insert, Update, and Delete remove the record from the Cache:
+
+	lru := cache.NewLRU(10000)
+	u := new(User)
+	u.Recorder = cache.New(structable.New(db, "postgres"), lru, time.Minute).Bind("users", u)
+
+The Cache is pluggable. LRU keeps records in memory, and Redis keeps them in
+Redis, so that several processes share them. Only changes made through
+CachingRecorders invalidate the Cache; other changes are seen once the TTL
+expires.
+
+Records are stored as JSON, one value per column, so every field must
+round-trip through encoding/json.
+
+With WithStaleIfError, a record can outlive its TTL in the Cache, and is
+served when the database fails, for read paths where slightly stale data is
+better than an error:
+
+	r := cache.New(structable.New(db, "postgres"), lru, time.Minute).WithStaleIfError(time.Hour)
+	err := r.Bind("users", u).Load()
+	if err == nil && r.Stale() {
+		w.Header().Set("Warning", `110 - "Response is Stale"`)
+	}
+
+With WithNegativeTTL, keys that have no record are remembered too, so that
+lookups of IDs that do not exist are answered from the Cache.
+*/
+package cache
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/structable"
+)
+
+// Cache stores encoded records by key.
+type Cache interface {
+	// Get returns the value for key, and whether it was found.
+	Get(key string) ([]byte, bool, error)
+	// Set stores a value for key, which expires after ttl.
+	Set(key string, value []byte, ttl time.Duration) error
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(key string) error
+}
+
+// CachingRecorder is a structable.Recorder that caches its records.
+type CachingRecorder struct {
+	structable.Recorder
+	cache    Cache
+	ttl      time.Duration
+	maxStale time.Duration
+	missTTL  time.Duration
+	stale    bool
+	prefix   string
+	now      func() time.Time
+}
+
+// New wraps a Recorder with a Cache. Records are kept for ttl.
+func New(rec structable.Recorder, c Cache, ttl time.Duration) *CachingRecorder {
+	return &CachingRecorder{Recorder: rec, cache: c, ttl: ttl, prefix: "stbl:", now: time.Now}
+}
+
+// Middleware returns a RecorderMiddleware that wraps Recorders with a Cache,
+// for use with structable.Wrap.
+func Middleware(c Cache, ttl time.Duration) structable.RecorderMiddleware {
+	return func(next structable.Recorder) structable.Recorder {
+		return New(next, c, ttl)
+	}
+}
+
+// WithPrefix sets the prefix of the Recorder's cache keys. The default is
+// "stbl:". Use different prefixes to keep several applications apart in a
+// shared Cache.
+func (r *CachingRecorder) WithPrefix(prefix string) *CachingRecorder {
+	r.prefix = prefix
+	return r
+}
+
+// WithStaleIfError keeps records in the Cache for maxStale past their TTL,
+// and lets Load serve such a stale record when the database fails, such as
+// when it is down or the read times out. Stale reports whether it did.
+//
+// Only failed reads are answered from stale records. A record that the
+// database reports missing is not served, and neither is a stale record
+// when the database answers.
+func (r *CachingRecorder) WithStaleIfError(maxStale time.Duration) *CachingRecorder {
+	r.maxStale = maxStale
+	return r
+}
+
+// WithNegativeTTL makes Load and Exists remember, for ttl, that there is no
+// record with a key, so that repeated lookups of keys that do not exist, such
+// as by a scraper enumerating IDs, do not all reach the database. Load then
+// returns sql.ErrNoRows, and Exists false, from the Cache.
+//
+// Insert removes the entry, as it removes any other. Rows inserted by other
+// means are seen once ttl expires, so keep it short.
+func (r *CachingRecorder) WithNegativeTTL(ttl time.Duration) *CachingRecorder {
+	r.missTTL = ttl
+	return r
+}
+
+// Stale reports whether the last Load served a stale record, because the
+// database failed.
+func (r *CachingRecorder) Stale() bool {
+	return r.stale
+}
+
+// Bind binds the underlying Recorder, and returns the caching Recorder.
+func (r *CachingRecorder) Bind(table string, rec structable.Record) structable.Recorder {
+	r.Recorder = r.Recorder.Bind(table, rec)
+	return r
+}
+
+// Unwrap returns the underlying Recorder.
+func (r *CachingRecorder) Unwrap() structable.Recorder {
+	return r.Recorder
+}
+
+// CacheKey returns the cache key of the bound record: the prefix, the table,
+// the tenant if the Recorder is scoped to one, and the primary key columns and
+// values.
+func (r *CachingRecorder) CacheKey() string {
+	ids := r.WhereIds()
+	parts := make([]string, 0, len(ids))
+	for c, v := range ids {
+		parts = append(parts, fmt.Sprintf("%s=%v", c, deref(v)))
+	}
+	sort.Strings(parts)
+	table := r.TableName()
+	if t := deref(r.tenant()); t != nil {
+		table += fmt.Sprintf("@%v", t)
+	}
+	return r.prefix + table + ":" + strings.Join(parts, ",")
+}
+
+// deref dereferences pointers, so that a key value is rendered by its value
+// and not by its address.
+func deref(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+	return rv.Interface()
+}
+
+// tenant returns the tenant of the underlying Recorder, looking through any
+// wrappers, or nil if it is not scoped to a tenant.
+func (r *CachingRecorder) tenant() interface{} {
+	var rec structable.Recorder = r.Recorder
+	for rec != nil {
+		if t, ok := rec.(interface{ Tenant() interface{} }); ok {
+			return t.Tenant()
+		}
+		w, ok := rec.(interface{ Unwrap() structable.Recorder })
+		if !ok {
+			return nil
+		}
+		rec = w.Unwrap()
+	}
+	return nil
+}
+
+// entry is what is stored in the Cache for a record.
+type entry struct {
+	// At is when the record was read from the database.
+	At  time.Time       `json:"at"`
+	Row json.RawMessage `json:"row,omitempty"`
+	// Missing records that there is no such record.
+	Missing bool `json:"missing,omitempty"`
+}
+
+// get returns the Cache entry for key. Cache errors, and entries that do not
+// decode, are treated as misses, so that a failing Cache does not stop reads.
+func (r *CachingRecorder) get(key string) (entry, bool) {
+	var e entry
+	data, ok, err := r.cache.Get(key)
+	if err != nil || !ok || json.Unmarshal(data, &e) != nil {
+		return e, false
+	}
+	return e, true
+}
+
+// fresh reports whether an entry is within its TTL.
+func (r *CachingRecorder) fresh(e entry) bool {
+	if e.Missing {
+		return r.now().Sub(e.At) < r.missTTL
+	}
+	return r.now().Sub(e.At) < r.ttl
+}
+
+// remember stores that there is no record with key, if negative caching is
+// on.
+func (r *CachingRecorder) remember(key string) {
+	if r.missTTL > 0 {
+		r.set(key, entry{At: r.now(), Missing: true}, r.missTTL)
+	}
+}
+
+// Load loads the record from the Cache, or from the database on a miss.
+//
+// Cache errors are treated as misses, so that a failing Cache does not stop
+// reads.
+func (r *CachingRecorder) Load() error {
+	r.stale = false
+	key := r.CacheKey()
+	e, hit := r.get(key)
+	if hit && r.fresh(e) {
+		if e.Missing {
+			return sql.ErrNoRows
+		}
+		if r.decode(e.Row) == nil {
+			return nil
+		}
+	}
+	if err := r.Recorder.Load(); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.remember(key)
+			return err
+		}
+		if hit && !e.Missing && r.maxStale > 0 &&
+			r.now().Sub(e.At) < r.ttl+r.maxStale && r.decode(e.Row) == nil {
+			r.stale = true
+			return nil
+		}
+		return err
+	}
+	if data, err := r.encode(); err == nil {
+		r.set(key, entry{At: r.now(), Row: data}, r.ttl+r.maxStale)
+	}
+	return nil
+}
+
+// set stores an entry for key. Cache errors are ignored, as in get.
+func (r *CachingRecorder) set(key string, e entry, ttl time.Duration) {
+	if data, err := json.Marshal(e); err == nil {
+		r.cache.Set(key, data, ttl)
+	}
+}
+
+// Exists returns true if the record is in the Cache, and otherwise checks the
+// database.
+func (r *CachingRecorder) Exists() (bool, error) {
+	key := r.CacheKey()
+	if e, ok := r.get(key); ok && r.fresh(e) {
+		return !e.Missing, nil
+	}
+	ok, err := r.Recorder.Exists()
+	if err == nil && !ok {
+		r.remember(key)
+	}
+	return ok, err
+}
+
+// Insert inserts the record, and removes it from the Cache.
+func (r *CachingRecorder) Insert() error {
+	return r.invalidate(r.Recorder.Insert())
+}
+
+// Update updates the record, and removes it from the Cache.
+func (r *CachingRecorder) Update() error {
+	return r.invalidate(r.Recorder.Update())
+}
+
+// Delete deletes the record, and removes it from the Cache.
+func (r *CachingRecorder) Delete() error {
+	return r.invalidate(r.Recorder.Delete())
+}
+
+// invalidate removes the record from the Cache after a write. The write's
+// error takes precedence. The record is removed even if the write failed,
+// since a failed write may still have changed it.
+func (r *CachingRecorder) invalidate(err error) error {
+	if derr := r.cache.Delete(r.CacheKey()); err == nil && derr != nil {
+		return fmt.Errorf("invalidating %s: %w", r.CacheKey(), derr)
+	}
+	return err
+}
+
+// encode encodes the bound record as a JSON object of column values.
+func (r *CachingRecorder) encode() ([]byte, error) {
+	cols := r.Columns(true)
+	vals := map[string]interface{}{}
+	for i, ref := range r.FieldReferences(true) {
+		vals[cols[i]] = reflect.ValueOf(ref).Elem().Interface()
+	}
+	return json.Marshal(vals)
+}
+
+// decode sets the bound record's fields from an encoded record. Nothing is
+// set unless every column decodes.
+func (r *CachingRecorder) decode(data []byte) error {
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	cols := r.Columns(true)
+	refs := r.FieldReferences(true)
+	vals := make([]reflect.Value, len(refs))
+	for i, ref := range refs {
+		v, ok := raw[cols[i]]
+		if !ok {
+			return fmt.Errorf("cached record has no column %s", cols[i])
+		}
+		vals[i] = reflect.New(reflect.TypeOf(ref).Elem())
+		if err := json.Unmarshal(v, vals[i].Interface()); err != nil {
+			return err
+		}
+	}
+	for i, ref := range refs {
+		reflect.ValueOf(ref).Elem().Set(vals[i].Elem())
+	}
+	return nil
+}
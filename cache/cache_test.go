@@ -0,0 +1,319 @@
+package cache
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/Masterminds/structable"
+)
+
+type stool struct {
+	Id       int    `stbl:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Legs     int    `stbl:"number_of_legs"`
+	Material string `stbl:"material"`
+}
+
+// dbStub counts the rows it is asked for, and scans nothing into them, or
+// fails with err.
+type dbStub struct {
+	rows int
+	err  error
+}
+
+func (d *dbStub) Exec(string, ...interface{}) (sql.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+func (d *dbStub) Query(string, ...interface{}) (*sql.Rows, error) { return nil, nil }
+func (d *dbStub) QueryRow(string, ...interface{}) squirrel.RowScanner {
+	d.rows++
+	return rowStub{d.err}
+}
+
+type rowStub struct{ err error }
+
+func (r rowStub) Scan(...interface{}) error { return r.err }
+
+func TestCachingRecorder(t *testing.T) {
+	db := &dbStub{}
+	s := &stool{Id: 1, Legs: 3, Material: "wood"}
+	r := New(structable.New(db, "postgres"), NewLRU(10), time.Minute)
+	r.Bind("stools", s)
+
+	if k := r.CacheKey(); k != "stbl:stools:id=1" {
+		t.Errorf("Unexpected key %s", k)
+	}
+
+	if err := r.Load(); err != nil {
+		t.Fatal(err)
+	}
+	s.Material = "steel"
+	if err := r.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if db.rows != 1 || s.Material != "wood" {
+		t.Errorf("Expected the second Load to come from the cache, got %d queries, %s", db.rows, s.Material)
+	}
+	if ok, err := r.Exists(); !ok || err != nil || db.rows != 1 {
+		t.Errorf("Expected Exists to come from the cache, got %t, %v", ok, err)
+	}
+
+	if err := r.Update(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if db.rows != 2 {
+		t.Errorf("Expected Update to invalidate the cache, got %d queries", db.rows)
+	}
+}
+
+func TestStaleIfError(t *testing.T) {
+	now := time.Date(2015, 6, 23, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	lru := NewLRU(10)
+	lru.now = clock
+	db := &dbStub{}
+	s := &stool{Id: 1, Material: "wood"}
+	r := New(structable.New(db, "postgres"), lru, time.Minute).WithStaleIfError(time.Hour)
+	r.now = clock
+	r.Bind("stools", s)
+
+	if err := r.Load(); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(10 * time.Minute)
+	db.err = errors.New("connection refused")
+	s.Material = "steel"
+	if err := r.Load(); err != nil || !r.Stale() || s.Material != "wood" {
+		t.Errorf("Expected the stale record, got %v, stale %t, %s", err, r.Stale(), s.Material)
+	}
+	if db.rows != 2 {
+		t.Errorf("Expected the database to be tried first, got %d queries", db.rows)
+	}
+
+	db.err = sql.ErrNoRows
+	if err := r.Load(); err != sql.ErrNoRows || r.Stale() {
+		t.Errorf("Expected a missing record not to be served stale, got %v", err)
+	}
+
+	db.err = errors.New("connection refused")
+	now = now.Add(time.Hour)
+	if err := r.Load(); err != db.err || r.Stale() {
+		t.Errorf("Expected the error past the stale window, got %v", err)
+	}
+
+	plain := New(structable.New(db, "postgres"), NewLRU(10), time.Minute)
+	plain.now = clock
+	plain.Bind("stools", &stool{Id: 2})
+	db.err = nil
+	plain.Load()
+	now = now.Add(2 * time.Minute)
+	db.err = errors.New("connection refused")
+	if err := plain.Load(); err != db.err {
+		t.Errorf("Expected no stale reads without WithStaleIfError, got %v", err)
+	}
+}
+
+func TestNegativeTTL(t *testing.T) {
+	now := time.Date(2015, 6, 23, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	lru := NewLRU(10)
+	lru.now = clock
+	db := &dbStub{err: sql.ErrNoRows}
+	r := New(structable.New(db, "postgres"), lru, time.Minute).WithNegativeTTL(10 * time.Second)
+	r.now = clock
+	r.Bind("stools", &stool{Id: 5})
+
+	if err := r.Load(); err != sql.ErrNoRows {
+		t.Fatalf("Expected sql.ErrNoRows, got %v", err)
+	}
+	if err := r.Load(); err != sql.ErrNoRows || db.rows != 1 {
+		t.Errorf("Expected the miss to be cached, got %v after %d queries", err, db.rows)
+	}
+	if ok, err := r.Exists(); ok || err != nil || db.rows != 1 {
+		t.Errorf("Expected Exists to use the cached miss, got %t, %v", ok, err)
+	}
+
+	db.err = nil
+	if err := r.Insert(); err != nil {
+		t.Fatal(err)
+	}
+	rows := db.rows
+	if err := r.Load(); err != nil || db.rows != rows+1 {
+		t.Errorf("Expected Insert to invalidate the miss, got %v", err)
+	}
+
+	r.Bind("stools", &stool{Id: 6})
+	if ok, err := r.Exists(); ok || err != nil {
+		t.Fatalf("Expected no stool 6, got %t, %v", ok, err)
+	}
+	rows = db.rows
+	if err := r.Load(); err != sql.ErrNoRows || db.rows != rows {
+		t.Errorf("Expected Exists to cache the miss, got %v", err)
+	}
+	now = now.Add(10 * time.Second)
+	if err := r.Load(); err != nil || db.rows != rows+1 {
+		t.Errorf("Expected the miss to expire, got %v", err)
+	}
+}
+
+// failingCache fails every operation.
+type failingCache struct{}
+
+func (failingCache) Get(string) ([]byte, bool, error) { return nil, false, errors.New("down") }
+func (failingCache) Set(string, []byte, time.Duration) error {
+	return errors.New("down")
+}
+func (failingCache) Delete(string) error { return errors.New("down") }
+
+func TestCachingRecorderErrors(t *testing.T) {
+	r := New(structable.New(&dbStub{}, "postgres"), failingCache{}, time.Minute)
+	r.Bind("stools", &stool{Id: 1})
+
+	if err := r.Load(); err != nil {
+		t.Errorf("Expected reads to ignore cache errors, got %s", err)
+	}
+	if err := r.Delete(); err == nil {
+		t.Error("Expected a failed invalidation to be reported")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	r := structable.New(&dbStub{}, "postgres")
+	r.Bind("stools", &stool{Id: 1})
+	w := structable.Wrap(r, Middleware(NewLRU(10), time.Minute))
+	if _, err := structable.List(w); err != nil {
+		t.Errorf("Expected List to accept a caching Recorder, got %s", err)
+	}
+}
+
+func TestLRU(t *testing.T) {
+	now := time.Date(2015, 6, 23, 0, 0, 0, 0, time.UTC)
+	c := NewLRU(2)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", []byte("1"), time.Second)
+	c.Set("b", []byte("2"), time.Second)
+	c.Get("a")
+	c.Set("c", []byte("3"), time.Second)
+	if _, ok, _ := c.Get("b"); ok {
+		t.Error("Expected the least recently used entry to be dropped")
+	}
+	if v, ok, _ := c.Get("a"); !ok || string(v) != "1" {
+		t.Errorf("Expected a to be kept, got %q", v)
+	}
+
+	c.Delete("a")
+	if _, ok, _ := c.Get("a"); ok || c.Len() != 1 {
+		t.Error("Expected a to be deleted")
+	}
+
+	now = now.Add(time.Second)
+	if _, ok, _ := c.Get("c"); ok {
+		t.Error("Expected c to expire")
+	}
+}
+
+// connStub is a RedisConn backed by a map.
+type connStub struct {
+	vals map[string][]byte
+	cmds []string
+}
+
+func (c *connStub) Do(cmd string, args ...interface{}) (interface{}, error) {
+	c.cmds = append(c.cmds, cmd)
+	key := args[0].(string)
+	switch cmd {
+	case "GET":
+		if v, ok := c.vals[key]; ok {
+			return v, nil
+		}
+		return nil, nil
+	case "SET":
+		if args[2] != "PX" || args[3].(int64) != 1500 {
+			return nil, errors.New("unexpected SET arguments")
+		}
+		c.vals[key] = args[1].([]byte)
+		return "OK", nil
+	case "DEL":
+		delete(c.vals, key)
+		return int64(1), nil
+	}
+	return nil, errors.New("unknown command")
+}
+
+func TestRedis(t *testing.T) {
+	conn := &connStub{vals: map[string][]byte{}}
+	c := Redis{Conn: conn}
+
+	if _, ok, err := c.Get("a"); ok || err != nil {
+		t.Errorf("Expected a miss, got %t, %v", ok, err)
+	}
+	if err := c.Set("a", []byte("1"), 1500*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok, err := c.Get("a"); !ok || err != nil || string(v) != "1" {
+		t.Errorf("Expected a hit, got %q, %v", v, err)
+	}
+	if err := c.Delete("a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := c.Get("a"); ok {
+		t.Error("Expected a to be deleted")
+	}
+}
+
+type invoice struct {
+	Id    int `stbl:"id,PRIMARY_KEY"`
+	OrgId int `stbl:"org_id,TENANT"`
+}
+
+func TestCacheKeyTenant(t *testing.T) {
+	db := &dbStub{}
+	scoped := func(org int) *CachingRecorder {
+		r := structable.New(db, "postgres")
+		r.Bind("invoices", &invoice{Id: 1})
+		return New(r.WithTenant(org), NewLRU(10), time.Minute)
+	}
+	if k := scoped(7).CacheKey(); k != "stbl:invoices@7:id=1" {
+		t.Errorf("Unexpected key %s", k)
+	}
+	if scoped(7).CacheKey() == scoped(8).CacheKey() {
+		t.Error("Expected tenants to have different keys")
+	}
+}
+
+type ptrStool struct {
+	Id   *int `stbl:"id,PRIMARY_KEY"`
+	Legs int  `stbl:"number_of_legs"`
+}
+
+func TestCacheKeyPointer(t *testing.T) {
+	db := &dbStub{}
+	lru := NewLRU(10)
+	one, other := 1, 1
+	a := New(structable.New(db, "postgres"), lru, time.Minute)
+	a.Bind("stools", &ptrStool{Id: &one})
+	b := New(structable.New(db, "postgres"), lru, time.Minute)
+	b.Bind("stools", &ptrStool{Id: &other})
+
+	if k := a.CacheKey(); k != "stbl:stools:id=1" || k != b.CacheKey() {
+		t.Errorf("Expected pointer keys to be rendered by value, got %s and %s", k, b.CacheKey())
+	}
+
+	// An Update through one Recorder invalidates what another loaded.
+	if err := a.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Update(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := lru.Get(a.CacheKey()); ok {
+		t.Error("Expected the Update to invalidate the cached record")
+	}
+}
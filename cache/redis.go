@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+)
+
+// RedisConn runs Redis commands. It is satisfied by the redis.Conn of
+// github.com/gomodule/redigo, and is easily adapted to other clients.
+type RedisConn interface {
+	Do(cmd string, args ...interface{}) (interface{}, error)
+}
+
+// Redis is a Cache that keeps entries in Redis, so that several processes
+// share them.
+//
+// A redigo connection is not safe for concurrent use. To share a Redis cache
+// between goroutines, use a RedisConn that takes a connection from a pool for
+// each command:
+//
+//	type pooled struct{ pool *redis.Pool }
+//
+//	func (p pooled) Do(cmd string, args ...interface{}) (interface{}, error) {
+//		c := p.pool.Get()
+//		defer c.Close()
+//		return c.Do(cmd, args...)
+//	}
+//
+//	c := cache.Redis{Conn: pooled{pool}}
+type Redis struct {
+	Conn RedisConn
+}
+
+// Get returns the value for key.
+func (r Redis) Get(key string) ([]byte, bool, error) {
+	reply, err := r.Conn.Do("GET", key)
+	if err != nil {
+		return nil, false, err
+	}
+	switch v := reply.(type) {
+	case nil:
+		return nil, false, nil
+	case []byte:
+		return v, true, nil
+	case string:
+		return []byte(v), true, nil
+	case error:
+		return nil, false, v
+	}
+	return nil, false, fmt.Errorf("unexpected reply type %T for GET", reply)
+}
+
+// Set stores a value for key, which expires after ttl. Redis expiry has a
+// resolution of one millisecond; shorter ttls are rounded up.
+func (r Redis) Set(key string, value []byte, ttl time.Duration) error {
+	ms := ttl.Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+	reply, err := r.Conn.Do("SET", key, value, "PX", ms)
+	if err != nil {
+		return err
+	}
+	if e, ok := reply.(error); ok {
+		return e
+	}
+	return nil
+}
+
+// Delete removes key.
+func (r Redis) Delete(key string) error {
+	reply, err := r.Conn.Do("DEL", key)
+	if err != nil {
+		return err
+	}
+	if e, ok := reply.(error); ok {
+		return e
+	}
+	return nil
+}
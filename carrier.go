@@ -0,0 +1,93 @@
+package structable
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Carrier holds the request-scoped values that describe who an operation is
+// for: the acting user, the request, and the tenant.
+//
+// HTTP middleware puts a Carrier in the request's context, and the context is
+// given to Recorders with SetContext. From there, the Carrier reaches every
+// Tracer (as part of the context), and the SQL comments added by
+// SetSQLComments:
+//
+//	ctx := structable.WithCarrier(r.Context(), structable.Carrier{
+//		Actor:     user.Email,
+//		RequestID: r.Header.Get("X-Request-Id"),
+//	})
+//	u.SetContext(ctx)
+//
+// Tracers read it back with CarrierFrom.
+type Carrier struct {
+	// Actor identifies the user or service that is acting.
+	Actor string
+	// RequestID identifies the request that the operation is part of.
+	RequestID string
+	// Tenant identifies the tenant whose data is being used.
+	Tenant string
+}
+
+type carrierKey struct{}
+
+// WithCarrier returns a copy of ctx that holds c.
+func WithCarrier(ctx context.Context, c Carrier) context.Context {
+	return context.WithValue(ctx, carrierKey{}, c)
+}
+
+// CarrierFrom returns the Carrier held by ctx, or an empty Carrier.
+func CarrierFrom(ctx context.Context) Carrier {
+	c, _ := ctx.Value(carrierKey{}).(Carrier)
+	return c
+}
+
+// Comment formats the Carrier's values as an SQL comment, in the sqlcommenter
+// format:
+//
+//	/*actor='alice',request_id='c4f1'*/
+//
+// Keys are sorted, values are URL-encoded (so they cannot end the comment),
+// and empty values are left out. An empty Carrier has no comment.
+func (c Carrier) Comment() string {
+	vals := map[string]string{
+		"actor":      c.Actor,
+		"request_id": c.RequestID,
+		"tenant":     c.Tenant,
+	}
+	parts := []string{}
+	for k, v := range vals {
+		if v != "" {
+			parts = append(parts, k+"='"+url.PathEscape(v)+"'")
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	sort.Strings(parts)
+	return "/*" + strings.Join(parts, ",") + "*/"
+}
+
+// SetSQLComments sets whether the Carrier in the recorder's context is added
+// to each statement as a trailing SQL comment.
+//
+// Databases log the comment with the statement, so that slow query logs and
+// tools like pg_stat_statements show who ran it. Note that each distinct
+// comment makes a distinct statement for prepared statement caches.
+func (s *DbRecorder) SetSQLComments(on bool) *DbRecorder {
+	s.comments = on
+	return s
+}
+
+// comment adds the Carrier comment to a statement, if comments are on.
+func (s *DbRecorder) comment(query string) string {
+	if !s.comments {
+		return query
+	}
+	if c := CarrierFrom(s.Context()).Comment(); c != "" {
+		return query + " " + c
+	}
+	return query
+}
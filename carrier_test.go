@@ -0,0 +1,60 @@
+package structable
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCarrier(t *testing.T) {
+	if c := CarrierFrom(context.Background()); c != (Carrier{}) {
+		t.Errorf("Expected an empty Carrier, got %v", c)
+	}
+
+	c := Carrier{Actor: "matt o'brien", RequestID: "c4f1"}
+	ctx := WithCarrier(context.Background(), c)
+	if got := CarrierFrom(ctx); got != c {
+		t.Errorf("Expected %v, got %v", c, got)
+	}
+
+	expect := `/*actor='matt%20o%27brien',request_id='c4f1'*/`
+	if got := c.Comment(); got != expect {
+		t.Errorf("Expected %s, got %s", expect, got)
+	}
+	if got := (Carrier{}).Comment(); got != "" {
+		t.Errorf("Expected no comment, got %s", got)
+	}
+}
+
+func TestSQLComments(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "mysql")
+	r.Bind("test_table", newStool())
+	r.SetContext(WithCarrier(context.Background(), Carrier{Actor: "matt"}))
+
+	if err := r.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "DELETE FROM test_table WHERE id = ? AND id_two = ?"; db.LastExecSql != expect {
+		t.Errorf("Expected no comment until comments are on, got %q", db.LastExecSql)
+	}
+
+	r.SetSQLComments(true)
+	if err := r.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "DELETE FROM test_table WHERE id = ? AND id_two = ? /*actor='matt'*/"; db.LastExecSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastExecSql)
+	}
+
+	var traced context.Context
+	r.SetTracer(TracerFunc(func(ctx context.Context, query string, args []interface{}, d time.Duration, err error) {
+		traced = ctx
+	}))
+	if _, err := r.Exists(); err != nil {
+		t.Fatal(err)
+	}
+	if CarrierFrom(traced).Actor != "matt" {
+		t.Error("Expected the Tracer to receive the Carrier")
+	}
+}
@@ -0,0 +1,284 @@
+/*
+Package chaos injects faults into Structable Recorders and Runners.
+
+An Injector adds latency, transient errors, and dropped connections to a
+fraction of the operations that pass through it. Faults are chosen by a
+seeded random source, so a test that runs the same operations in the same
+order sees the same faults every time:
+
+	inj := chaos.New(chaos.Options{
+		Seed:      1,
+		ErrorRate: 0.2,
+		DropRate:  0.05,
+	})
+	u := new(User)
+	u.Recorder = inj.Recorder(structable.New(db, "postgres").Bind("users", u))
+
+	err := withRetries(u.Insert)
+
+Wrapping a Runner instead faults every statement, including those of List
+and other package functions, and those run inside a transaction:
+
+	r := structable.New(inj.Runner(structable.NewRunner(tx)), "postgres")
+
+An operation that fails with ErrTransient never reached the database. An
+operation that fails with ErrDropped did run, and its result was lost, as
+when a connection drops while the client waits for a reply.
+*/
+package chaos
+
+import (
+	"database/sql"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/Masterminds/structable"
+)
+
+// ErrTransient is returned by operations that were failed before they ran.
+var ErrTransient = errors.New("chaos: transient error")
+
+// ErrDropped is returned by operations that ran, but whose connection was
+// dropped before the result came back.
+var ErrDropped = errors.New("chaos: connection dropped")
+
+// Options configures an Injector. Rates are fractions of operations, from 0
+// (never) to 1 (always).
+type Options struct {
+	// Seed seeds the random source that decides which operations fail.
+	Seed int64
+	// Latency is added to a fraction LatencyRate of operations.
+	Latency     time.Duration
+	LatencyRate float64
+	// ErrorRate is the fraction of operations that fail with ErrTransient.
+	ErrorRate float64
+	// DropRate is the fraction of operations that fail with ErrDropped.
+	DropRate float64
+	// Sleep waits out injected latency. Defaults to time.Sleep.
+	Sleep func(time.Duration)
+}
+
+// Stats counts the operations an Injector has seen, and the faults it has
+// injected.
+type Stats struct {
+	Ops, Delays, Errors, Drops int
+}
+
+// Injector decides which operations fail, and how.
+//
+// An Injector may be shared by several Recorders and Runners, and is safe
+// for concurrent use, though faults are only reproducible when operations
+// happen in a fixed order.
+type Injector struct {
+	opts Options
+
+	mu    sync.Mutex
+	rnd   *rand.Rand
+	stats Stats
+}
+
+// New creates an Injector.
+func New(opts Options) *Injector {
+	if opts.Sleep == nil {
+		opts.Sleep = time.Sleep
+	}
+	return &Injector{
+		opts: opts,
+		rnd:  rand.New(rand.NewSource(opts.Seed)),
+	}
+}
+
+// Stats returns the counts so far.
+func (i *Injector) Stats() Stats {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.stats
+}
+
+// fault is the outcome chosen for one operation.
+type fault struct {
+	delay time.Duration
+	err   error
+	drop  bool
+}
+
+// next chooses the fault for the next operation. Every operation draws the
+// same number of random values, so later choices do not depend on earlier
+// outcomes.
+func (i *Injector) next() fault {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	delay, fail, drop := i.rnd.Float64(), i.rnd.Float64(), i.rnd.Float64()
+
+	var f fault
+	i.stats.Ops++
+	if delay < i.opts.LatencyRate {
+		f.delay = i.opts.Latency
+		i.stats.Delays++
+	}
+	if fail < i.opts.ErrorRate {
+		f.err = ErrTransient
+		i.stats.Errors++
+	} else if drop < i.opts.DropRate {
+		f.drop = true
+		i.stats.Drops++
+	}
+	return f
+}
+
+// do runs op under the next fault.
+func (i *Injector) do(op func() error) error {
+	f := i.next()
+	if f.delay > 0 {
+		i.opts.Sleep(f.delay)
+	}
+	if f.err != nil {
+		return f.err
+	}
+	err := op()
+	if f.drop {
+		return ErrDropped
+	}
+	return err
+}
+
+// Recorder is a structable.Recorder that injects faults into its operations.
+type Recorder struct {
+	structable.Recorder
+	inj *Injector
+}
+
+// Recorder wraps a Recorder, so that its operations are subject to faults.
+func (i *Injector) Recorder(rec structable.Recorder) *Recorder {
+	return &Recorder{Recorder: rec, inj: i}
+}
+
+// Bind binds the underlying Recorder, and returns the faulty Recorder.
+func (r *Recorder) Bind(table string, rec structable.Record) structable.Recorder {
+	r.Recorder = r.Recorder.Bind(table, rec)
+	return r
+}
+
+// Load loads the record, unless a fault is injected.
+func (r *Recorder) Load() error {
+	return r.inj.do(r.Recorder.Load)
+}
+
+// LoadWhere loads the record, unless a fault is injected.
+func (r *Recorder) LoadWhere(pred interface{}, args ...interface{}) error {
+	return r.inj.do(func() error {
+		return r.Recorder.LoadWhere(pred, args...)
+	})
+}
+
+// Reload reloads the record, unless a fault is injected.
+func (r *Recorder) Reload() error {
+	return r.inj.do(r.Recorder.Reload)
+}
+
+// LoadByKey loads the record by its key, unless a fault is injected.
+func (r *Recorder) LoadByKey(values ...interface{}) error {
+	return r.inj.do(func() error {
+		return r.Recorder.LoadByKey(values...)
+	})
+}
+
+// Exists checks for the record, unless a fault is injected.
+func (r *Recorder) Exists() (bool, error) {
+	var ok bool
+	err := r.inj.do(func() (err error) {
+		ok, err = r.Recorder.Exists()
+		return err
+	})
+	return ok && err == nil, err
+}
+
+// ExistsWhere checks for records, unless a fault is injected.
+func (r *Recorder) ExistsWhere(pred interface{}, args ...interface{}) (bool, error) {
+	var ok bool
+	err := r.inj.do(func() (err error) {
+		ok, err = r.Recorder.ExistsWhere(pred, args...)
+		return err
+	})
+	return ok && err == nil, err
+}
+
+// Insert inserts the record, unless a fault is injected.
+func (r *Recorder) Insert() error {
+	return r.inj.do(r.Recorder.Insert)
+}
+
+// Update updates the record, unless a fault is injected.
+func (r *Recorder) Update() error {
+	return r.inj.do(r.Recorder.Update)
+}
+
+// Delete deletes the record, unless a fault is injected.
+func (r *Recorder) Delete() error {
+	return r.inj.do(r.Recorder.Delete)
+}
+
+// Runner wraps a Runner, so that its statements are subject to faults.
+func (i *Injector) Runner(run structable.Runner) structable.Runner {
+	return &runner{Runner: run, inj: i}
+}
+
+type runner struct {
+	structable.Runner
+	inj *Injector
+}
+
+func (r *runner) Exec(query string, args ...interface{}) (sql.Result, error) {
+	var res sql.Result
+	err := r.inj.do(func() (err error) {
+		res, err = r.Runner.Exec(query, args...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (r *runner) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := r.inj.do(func() (err error) {
+		rows, err = r.Runner.Query(query, args...)
+		return err
+	})
+	if err != nil {
+		if rows != nil {
+			rows.Close()
+		}
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (r *runner) QueryRow(query string, args ...interface{}) squirrel.RowScanner {
+	var row squirrel.RowScanner
+	err := r.inj.do(func() error {
+		row = r.Runner.QueryRow(query, args...)
+		return nil
+	})
+	if err == ErrDropped {
+		// Scanning a row releases its connection.
+		row.Scan()
+	}
+	if err != nil {
+		return errRow{err}
+	}
+	return row
+}
+
+// errRow is a row whose Scan fails.
+type errRow struct {
+	err error
+}
+
+func (r errRow) Scan(...interface{}) error {
+	return r.err
+}
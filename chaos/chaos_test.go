@@ -0,0 +1,89 @@
+package chaos
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/Masterminds/structable"
+)
+
+type stool struct {
+	Id   int `stbl:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Legs int `stbl:"number_of_legs"`
+}
+
+// dbStub counts the statements that reach it.
+type dbStub struct {
+	execs int
+}
+
+func (d *dbStub) Prepare(string) (*sql.Stmt, error) { return nil, nil }
+func (d *dbStub) Begin() (*sql.Tx, error)           { return nil, nil }
+func (d *dbStub) Exec(string, ...interface{}) (sql.Result, error) {
+	d.execs++
+	return nil, nil
+}
+func (d *dbStub) Query(string, ...interface{}) (*sql.Rows, error) { return nil, nil }
+func (d *dbStub) QueryRow(string, ...interface{}) squirrel.RowScanner {
+	return rowStub{}
+}
+
+type rowStub struct{}
+
+func (rowStub) Scan(...interface{}) error { return nil }
+
+func TestFaults(t *testing.T) {
+	db := &dbStub{}
+	rec := structable.New(db, "postgres").Bind("stools", &stool{Id: 1})
+
+	failing := New(Options{ErrorRate: 1}).Recorder(rec)
+	if err := failing.Delete(); err != ErrTransient || db.execs != 0 {
+		t.Errorf("Expected a transient error before the delete, got %v after %d execs", err, db.execs)
+	}
+
+	dropping := New(Options{DropRate: 1}).Recorder(rec)
+	if err := dropping.Delete(); err != ErrDropped || db.execs != 1 {
+		t.Errorf("Expected a dropped connection after the delete, got %v after %d execs", err, db.execs)
+	}
+
+	var slept time.Duration
+	slow := New(Options{Latency: time.Second, LatencyRate: 1, Sleep: func(d time.Duration) { slept += d }})
+	if _, err := slow.Runner(db).Exec("DELETE FROM stools"); err != nil || slept != time.Second {
+		t.Errorf("Expected a delay of 1s, got %s and %v", slept, err)
+	}
+	if s := slow.Stats(); s.Ops != 1 || s.Delays != 1 || s.Errors != 0 || s.Drops != 0 {
+		t.Errorf("Unexpected stats %+v", s)
+	}
+
+	row := New(Options{ErrorRate: 1}).Runner(db).QueryRow("SELECT 1")
+	if err := row.Scan(); err != ErrTransient {
+		t.Errorf("Expected the row to fail, got %v", err)
+	}
+}
+
+func TestDeterministic(t *testing.T) {
+	outcomes := func() []error {
+		rec := New(Options{Seed: 42, ErrorRate: 0.3, DropRate: 0.3}).
+			Recorder(structable.New(&dbStub{}, "postgres").Bind("stools", &stool{Id: 1}))
+		errs := make([]error, 20)
+		for i := range errs {
+			errs[i] = rec.Update()
+		}
+		return errs
+	}
+	first := outcomes()
+	if !reflect.DeepEqual(first, outcomes()) {
+		t.Error("Expected the same seed to inject the same faults")
+	}
+
+	seen := map[error]bool{}
+	for _, err := range first {
+		seen[err] = true
+	}
+	if !seen[nil] || !seen[ErrTransient] || !seen[ErrDropped] {
+		t.Errorf("Expected a mix of outcomes, got %v", first)
+	}
+}
@@ -0,0 +1,262 @@
+package structable
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// ClosureTable maintains a closure table for hierarchical Records.
+//
+// A closure table stores one row for every ancestor/descendant pair in a
+// hierarchy, including a row linking each node to itself at depth 0:
+//
+//	CREATE TABLE category_tree (
+//		ancestor INTEGER NOT NULL,
+//		descendant INTEGER NOT NULL,
+//		depth INTEGER NOT NULL,
+//		PRIMARY KEY (ancestor, descendant)
+//	);
+//
+// Subtree and ancestry queries are then a single join, with no recursion.
+// This makes a closure table a good fit for databases that do not support
+// WITH RECURSIVE (see LoadDescendants).
+//
+// Insert, Move, and Delete each run in a transaction on the Recorder's DB, as
+// by Transact, so that the Record and its links change together. If the
+// Recorder already runs in a transaction (a *sql.Tx, a Transact scope, or a
+// UnitOfWork), they run in a savepoint of it instead. The Record is written
+// with its *DbRecorder, so middleware added with Wrap is skipped. The Record's
+// table must have exactly one primary key column.
+type ClosureTable struct {
+	// Table is the name of the closure table.
+	Table string
+	// AncestorColumn, DescendantColumn, and DepthColumn name the columns of
+	// the closure table. They default to ancestor, descendant, and depth.
+	AncestorColumn, DescendantColumn, DepthColumn string
+}
+
+// NewClosureTable creates a ClosureTable with the default column names.
+func NewClosureTable(table string) *ClosureTable {
+	return &ClosureTable{
+		Table:            table,
+		AncestorColumn:   "ancestor",
+		DescendantColumn: "descendant",
+		DepthColumn:      "depth",
+	}
+}
+
+// closureLink is one row of a closure table, seen from one end.
+type closureLink struct {
+	node  interface{}
+	depth int
+}
+
+// Insert inserts the Record, and links it beneath parent.
+//
+// The parent is the primary key value of the parent Record. If parent is
+// nil, the Record is inserted as a root.
+func (c *ClosureTable) Insert(r Recorder, parent interface{}) error {
+	return c.transact(r, func(d *DbRecorder) error {
+		if err := d.Insert(); err != nil {
+			return err
+		}
+		return c.link(d, c.id(d), parent)
+	})
+}
+
+// link adds the links of a new node beneath parent.
+func (c *ClosureTable) link(d *DbRecorder, id, parent interface{}) error {
+	links := []closureLink{{id, 0}}
+	if parent != nil {
+		ancestors, err := c.links(d, c.DescendantColumn, c.AncestorColumn, parent)
+		if err != nil {
+			return err
+		}
+		for _, a := range ancestors {
+			links = append(links, closureLink{a.node, a.depth + 1})
+		}
+	}
+
+	q := d.builder.Insert(c.Table).Columns(c.AncestorColumn, c.DescendantColumn, c.DepthColumn)
+	for _, l := range links {
+		q = q.Values(l.node, id, l.depth)
+	}
+	_, err := d.exec(OpInsert, q)
+	return err
+}
+
+// Move moves the Record, along with all of its descendants, beneath parent.
+//
+// If parent is nil, the Record becomes a root. Moving a Record beneath one of
+// its own descendants is an error.
+//
+// Move only changes the closure table. If the Record also has a parent column,
+// update it separately.
+func (c *ClosureTable) Move(r Recorder, parent interface{}) error {
+	return c.transact(r, func(d *DbRecorder) error {
+		return c.move(d, c.id(d), parent)
+	})
+}
+
+// move relinks the subtree of id beneath parent.
+func (c *ClosureTable) move(d *DbRecorder, id, parent interface{}) error {
+	subtree, err := c.links(d, c.AncestorColumn, c.DescendantColumn, id)
+	if err != nil {
+		return err
+	}
+	nodes := make([]interface{}, len(subtree))
+	for i, s := range subtree {
+		nodes[i] = s.node
+		if parent != nil && fmt.Sprint(s.node) == fmt.Sprint(parent) {
+			return fmt.Errorf("cannot move %v beneath its own descendant %v", id, parent)
+		}
+	}
+
+	// Detach the subtree from its old ancestors.
+	old, err := c.links(d, c.DescendantColumn, c.AncestorColumn, id)
+	if err != nil {
+		return err
+	}
+	ancestors := []interface{}{}
+	for _, a := range old {
+		if a.depth > 0 {
+			ancestors = append(ancestors, a.node)
+		}
+	}
+	if len(ancestors) > 0 {
+		q := d.builder.Delete(c.Table).Where(squirrel.Eq{
+			c.DescendantColumn: nodes,
+			c.AncestorColumn:   ancestors,
+		})
+		if _, err := d.exec(OpDelete, q); err != nil {
+			return err
+		}
+	}
+	if parent == nil {
+		return nil
+	}
+
+	// Attach it to the new parent's ancestors.
+	above, err := c.links(d, c.DescendantColumn, c.AncestorColumn, parent)
+	if err != nil {
+		return err
+	}
+	if len(above) == 0 {
+		return nil
+	}
+	q := d.builder.Insert(c.Table).Columns(c.AncestorColumn, c.DescendantColumn, c.DepthColumn)
+	for _, a := range above {
+		for _, s := range subtree {
+			q = q.Values(a.node, s.node, a.depth+s.depth+1)
+		}
+	}
+	_, err = d.exec(OpInsert, q)
+	return err
+}
+
+// Delete deletes the Record and all of its descendants, along with their links.
+func (c *ClosureTable) Delete(r Recorder) error {
+	return c.transact(r, c.delete)
+}
+
+// delete deletes the subtree of d's Record.
+func (c *ClosureTable) delete(d *DbRecorder) error {
+	subtree, err := c.links(d, c.AncestorColumn, c.DescendantColumn, c.id(d))
+	if err != nil {
+		return err
+	}
+	if len(subtree) == 0 {
+		// The Record was never linked.
+		return d.Delete()
+	}
+	nodes := make([]interface{}, len(subtree))
+	for i, s := range subtree {
+		nodes[i] = s.node
+	}
+
+	key := d.Key()[0]
+	if _, err := d.exec(OpDelete, d.builder.Delete(d.TableName()).Where(squirrel.Eq{key: nodes}).Where(d.tenantWhere())); err != nil {
+		return err
+	}
+	_, err = d.exec(OpDelete, d.builder.Delete(c.Table).Where(squirrel.Eq{c.DescendantColumn: nodes}))
+	return err
+}
+
+// Descendants returns a WhereFunc that lists the descendants of node.
+//
+// The node is a primary key value. Results are ordered by depth:
+//
+//	children, err := structable.ListWhere(r, tree.Descendants(category.Id))
+func (c *ClosureTable) Descendants(node interface{}) WhereFunc {
+	return c.related(c.DescendantColumn, c.AncestorColumn, node)
+}
+
+// Ancestors returns a WhereFunc that lists the ancestors of node, nearest
+// first.
+func (c *ClosureTable) Ancestors(node interface{}) WhereFunc {
+	return c.related(c.AncestorColumn, c.DescendantColumn, node)
+}
+
+// related joins the closure table so that only rows whose key is in the
+// join column of a link from node are selected.
+func (c *ClosureTable) related(join, from string, node interface{}) WhereFunc {
+	return func(desc Describer, q squirrel.SelectBuilder) (squirrel.SelectBuilder, error) {
+		keys := desc.Key()
+		if len(keys) != 1 {
+			return q, fmt.Errorf("table %s must have exactly one primary key for a closure table", desc.TableName())
+		}
+		q = q.Join(fmt.Sprintf("%[1]s ON %[1]s.%[2]s = %[3]s.%[4]s", c.Table, join, desc.TableName(), keys[0])).
+			Where(squirrel.Eq{c.Table + "." + from: node}).
+			Where(fmt.Sprintf("%s.%s > 0", c.Table, c.DepthColumn)).
+			OrderBy(c.Table + "." + c.DepthColumn)
+		return q, nil
+	}
+}
+
+// transact calls fn with a copy of r's DbRecorder whose statements run in a
+// transaction. If r already runs in a transaction, the copy runs in a
+// savepoint of it.
+func (c *ClosureTable) transact(r Recorder, fn func(d *DbRecorder) error) error {
+	if len(r.Key()) != 1 {
+		return fmt.Errorf("table %s must have exactly one primary key for a closure table", r.TableName())
+	}
+	proto := protoRecorder(r)
+	db := proto.db
+	if inTransaction(db) {
+		db = &txRunner{Runner: db, flavor: proto.flavor}
+	}
+	return Transact(db, proto.flavor, func(tx Runner) error {
+		d := proto.Clone(r.Interface())
+		d.Init(tx, proto.flavor)
+		return fn(d)
+	})
+}
+
+// id returns the primary key value of d's Record.
+func (c *ClosureTable) id(d *DbRecorder) interface{} {
+	return d.WhereIds()[d.Key()[0]]
+}
+
+// links returns the other end and depth of every link where the column where
+// equals node.
+func (c *ClosureTable) links(d *DbRecorder, where, other string, node interface{}) ([]closureLink, error) {
+	q := d.builder.Select(other, c.DepthColumn).From(c.Table).
+		Where(squirrel.Eq{where: node}).
+		OrderBy(c.DepthColumn)
+	rows, err := d.query(OpList, q)
+	if err != nil || rows == nil {
+		return []closureLink{}, err
+	}
+	defer rows.Close()
+
+	buf := []closureLink{}
+	for rows.Next() {
+		var l closureLink
+		if err := rows.Scan(&l.node, &l.depth); err != nil {
+			return buf, err
+		}
+		buf = append(buf, l)
+	}
+	return buf, rows.Err()
+}
@@ -0,0 +1,34 @@
+package structable
+
+import "testing"
+
+func TestClosureTableDescendants(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "postgres").Bind("categories", &category{})
+	tree := NewClosureTable("category_tree")
+
+	if _, err := ListWhere(r, tree.Descendants(3)); err != nil {
+		t.Fatal(err)
+	}
+	expect := "SELECT id, parent_id, name FROM categories " +
+		"JOIN category_tree ON category_tree.descendant = categories.id " +
+		"WHERE category_tree.ancestor = $1 AND category_tree.depth > 0 ORDER BY category_tree.depth"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+
+	if _, err := ListWhere(r, tree.Ancestors(3)); err != nil {
+		t.Fatal(err)
+	}
+	expect = "SELECT id, parent_id, name FROM categories " +
+		"JOIN category_tree ON category_tree.ancestor = categories.id " +
+		"WHERE category_tree.descendant = $1 AND category_tree.depth > 0 ORDER BY category_tree.depth"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+
+	r = New(db, "postgres").Bind("test_table", newStool())
+	if _, err := ListWhere(r, tree.Descendants(3)); err == nil {
+		t.Error("Expected composite key to fail")
+	}
+}
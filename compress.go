@@ -0,0 +1,89 @@
+package structable
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+)
+
+// compressedMagic starts every value that Structable has compressed. Values
+// without it are read as they are, so rows written before a field was marked
+// COMPRESSED can still be loaded.
+var compressedMagic = []byte("STBL\x00GZ\x01")
+
+// compress gzips a value, and prefixes it with compressedMagic.
+func compress(b []byte) []byte {
+	var buf bytes.Buffer
+	buf.Write(compressedMagic)
+	zw := gzip.NewWriter(&buf)
+	// Writes to a bytes.Buffer cannot fail.
+	zw.Write(b)
+	zw.Close()
+	return buf.Bytes()
+}
+
+// decompress reverses compress. Values without compressedMagic are returned
+// unchanged.
+func decompress(b []byte) ([]byte, error) {
+	if !bytes.HasPrefix(b, compressedMagic) {
+		return b, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(b[len(compressedMagic):]))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return ioutil.ReadAll(zr)
+}
+
+// compressValue compresses the value of a COMPRESSED field for storage. Nil
+// pointers are returned as they are, and stored as NULL.
+func compressValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return compress([]byte(v))
+	case *string:
+		if v != nil {
+			return compress([]byte(*v))
+		}
+	case []byte:
+		return compress(v)
+	case *[]byte:
+		if v != nil {
+			return compress(*v)
+		}
+	}
+	return v
+}
+
+// decompressValue decompresses a driver value read from a COMPRESSED column.
+func decompressValue(v interface{}) (interface{}, error) {
+	switch b := v.(type) {
+	case []byte:
+		return decompress(b)
+	case string:
+		return decompress([]byte(b))
+	}
+	return v, nil
+}
+
+// checkCompressed checks that every COMPRESSED field is a string or []byte.
+func (s *DbRecorder) checkCompressed() error {
+	t := reflect.Indirect(reflect.ValueOf(s.record)).Type()
+	for _, f := range s.fields {
+		if !f.isCompressed {
+			continue
+		}
+		sf, _ := t.FieldByName(f.name)
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() != reflect.String && !(ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.Uint8) {
+			return fmt.Errorf("field %s on table %s cannot be COMPRESSED: %s is not a string or []byte", f.name, s.table, sf.Type)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package structable
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type document struct {
+	Id   int     `stbl:"id,PRIMARY_KEY,SERIAL"`
+	Body string  `stbl:"body,COMPRESSED"`
+	Raw  []byte  `stbl:"raw,COMPRESSED"`
+	Note *string `stbl:"note,COMPRESSED"`
+}
+
+func TestCompressRoundTrip(t *testing.T) {
+	body := strings.Repeat(`{"hello": "world"}`, 100)
+	z := compress([]byte(body))
+	if !bytes.HasPrefix(z, compressedMagic) || len(z) >= len(body) {
+		t.Errorf("Expected a short, marked value, got %d bytes", len(z))
+	}
+	if b, err := decompress(z); err != nil || string(b) != body {
+		t.Errorf("Expected the body back, got %v", err)
+	}
+	if b, err := decompress([]byte("plain")); err != nil || string(b) != "plain" {
+		t.Errorf("Expected unmarked values to pass through, got %q, %v", b, err)
+	}
+}
+
+func TestCompressedFields(t *testing.T) {
+	db := &DBStub{}
+	doc := &document{Id: 1, Body: "hello", Raw: []byte("raw")}
+	r := New(db, "mysql")
+	r.Bind("documents", doc)
+
+	if err := r.Update(); err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range db.LastExecArgs {
+		if b, ok := a.([]byte); ok && !bytes.HasPrefix(b, compressedMagic) {
+			t.Errorf("Expected compressed args, got %q", b)
+		}
+	}
+
+	// The nil note is left out of the update.
+	if len(db.LastExecArgs) != 3 {
+		t.Errorf("Expected 3 args, got %d", len(db.LastExecArgs))
+	}
+
+	loaded := &document{}
+	r.Bind("documents", loaded)
+	row := &driverRow{&valueRow{[]interface{}{int64(1), compress([]byte("hello")), []byte("legacy"), nil}}}
+	if err := r.scan(row, true); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Body != "hello" || string(loaded.Raw) != "legacy" || loaded.Note != nil {
+		t.Errorf("Unexpected document %+v", loaded)
+	}
+
+	bad := New(db, "mysql")
+	bad.Bind("bad", &struct {
+		N int `stbl:"n,COMPRESSED"`
+	}{})
+	if bad.BindError() == nil {
+		t.Error("Expected a COMPRESSED int to fail")
+	}
+}
@@ -0,0 +1,88 @@
+package structable
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrNotNull is returned by Insert and Update when a NOT_NULL field without a
+// DEFAULT holds its zero value.
+var ErrNotNull = errors.New("NOT NULL column has no value")
+
+// ErrTooLong is returned by Insert and Update when a value is longer than its
+// column's SIZE.
+var ErrTooLong = errors.New("value is longer than the column's SIZE")
+
+// SplitTag splits a stbl tag into its parts.
+//
+// Commas inside parentheses or single quotes do not split, so that options
+// like TYPE=NUMERIC(10,2) and DEFAULT('a,b') are kept whole.
+func SplitTag(tag string) []string {
+	parts := []string{}
+	depth, quoted, start := 0, false, 0
+	for i, c := range tag {
+		switch {
+		case c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')' && depth > 0:
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, tag[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, tag[start:])
+}
+
+// tagArg returns the argument of a tag option of the form NAME(arg).
+func tagArg(part, name string) (string, bool) {
+	if !strings.HasPrefix(part, name+"(") || !strings.HasSuffix(part, ")") {
+		return "", false
+	}
+	return strings.TrimSpace(part[len(name)+1 : len(part)-1]), true
+}
+
+// validate checks the Record against the NOT_NULL and SIZE options of the
+// fields that op writes, before they are written.
+func (s *DbRecorder) validate(op string) error {
+	ar := reflect.Indirect(reflect.ValueOf(s.record))
+	for _, f := range s.fields {
+		if !f.notNull && f.size == 0 {
+			continue
+		}
+		if (op == OpInsert && f.omitInsert) || (op == OpUpdate && f.omitUpdate) {
+			continue
+		}
+		if _, ok := s.exprs[f.column]; ok {
+			continue
+		}
+		v := ar.FieldByName(f.name)
+		if f.notNull && !f.hasDefault && !f.isAuto && v.IsZero() {
+			return fmt.Errorf("%w: field %s (column %s on table %s) is empty", ErrNotNull, f.name, f.column, s.table)
+		}
+		if f.size > 0 && !f.isCompressed && !f.isExternal {
+			if n := valueLen(reflect.Indirect(v)); n > f.size {
+				return fmt.Errorf("%w: field %s (column %s on table %s) has length %d, SIZE is %d", ErrTooLong, f.name, f.column, s.table, n, f.size)
+			}
+		}
+	}
+	return nil
+}
+
+// valueLen returns the length of a string, in characters, or of a []byte, in
+// bytes. Other values have no length.
+func valueLen(v reflect.Value) int {
+	switch {
+	case v.Kind() == reflect.String:
+		return utf8.RuneCountInString(v.String())
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		return v.Len()
+	}
+	return 0
+}
@@ -0,0 +1,102 @@
+package structable
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type draft struct {
+	Id     int     `stbl:"id,PRIMARY_KEY,SERIAL"`
+	Title  string  `stbl:"title,NOT_NULL,SIZE(8)"`
+	Status string  `stbl:"status,NOT_NULL,DEFAULT('a,b')"`
+	Note   *string `stbl:"note,NOT NULL"`
+}
+
+func TestSplitTag(t *testing.T) {
+	tests := map[string][]string{
+		"name":                             {"name"},
+		"id,PRIMARY_KEY,SERIAL":            {"id", "PRIMARY_KEY", "SERIAL"},
+		"price,TYPE=NUMERIC(10,2),SIZE(3)": {"price", "TYPE=NUMERIC(10,2)", "SIZE(3)"},
+		"status,DEFAULT('a,(b'),UNIQUE":    {"status", "DEFAULT('a,(b')", "UNIQUE"},
+	}
+	for tag, expect := range tests {
+		if got := SplitTag(tag); !reflect.DeepEqual(got, expect) {
+			t.Errorf("%s: expected %q, got %q", tag, expect, got)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	note := ""
+	db := &DBStub{}
+	r := New(db, "mysql")
+	d := &draft{Title: "ok", Note: &note}
+	r.Bind("drafts", d)
+
+	f, _ := r.fieldForColumn("status")
+	if !f.notNull || !f.hasDefault || f.defaultValue != "'a,b'" {
+		t.Errorf("Unexpected status field %+v", f)
+	}
+
+	if err := r.Insert(); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "INSERT INTO drafts (title,note) VALUES (?,?)"; db.LastExecSql != expect {
+		t.Errorf("Expected the DEFAULT column to be left out, got %q", db.LastExecSql)
+	}
+
+	d.Title = ""
+	if err := r.Insert(); !errors.Is(err, ErrNotNull) {
+		t.Errorf("Expected ErrNotNull for an empty title, got %v", err)
+	}
+	d.Title, d.Note = "ok", nil
+	if err := r.Update(); !errors.Is(err, ErrNotNull) {
+		t.Errorf("Expected ErrNotNull for a nil note, got %v", err)
+	}
+	d.Title, d.Note = "ünïcödé!", &note
+	if err := r.Insert(); err != nil {
+		t.Errorf("Expected 8 characters to fit, got %v", err)
+	}
+	d.Title = "too long!"
+	if err := r.Insert(); !errors.Is(err, ErrTooLong) {
+		t.Errorf("Expected ErrTooLong, got %v", err)
+	}
+}
+
+type searchDoc struct {
+	Id      int    `stbl:"id,PRIMARY_KEY,SERIAL"`
+	Body    string `stbl:"body"`
+	Tokens  string `stbl:"tokens,READONLY,NOT_NULL"`
+	Created string `stbl:"created,OMIT_UPDATE"`
+	Touched string `stbl:"touched,OMIT_INSERT"`
+}
+
+func TestReadOnly(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "mysql")
+	d := &searchDoc{Id: 1, Body: "b", Tokens: "t", Created: "c", Touched: "u"}
+	r.Bind("docs", d)
+
+	if err := r.Insert(); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "INSERT INTO docs (body,created) VALUES (?,?)"; db.LastExecSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastExecSql)
+	}
+	d.Tokens = ""
+	if err := r.Update(); err != nil {
+		t.Fatalf("Expected an empty READONLY field to pass validation, got %s", err)
+	}
+	if expect := "UPDATE docs SET body = ?, touched = ? WHERE id = ?"; db.LastExecSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastExecSql)
+	}
+	if cols := r.Columns(true); len(cols) != 5 {
+		t.Errorf("Expected READONLY columns to be loaded, got %v", cols)
+	}
+	for _, c := range []string{"tokens", "created"} {
+		if err := r.ApplyChanges(map[string]interface{}{c: "x"}, c); !errors.Is(err, ErrColumnNotAllowed) {
+			t.Errorf("Expected %s not to be changeable, got %v", c, err)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package structable
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// Dataloader coalesces Loads of the same table into a single query.
+//
+// Loads that arrive within the Dataloader's wait window are collected, and
+// then fetched together with one `SELECT ... WHERE key IN (...)`. This is
+// useful for avoiding N+1 query patterns, for example in GraphQL resolvers
+// where many independent resolvers each load one record.
+//
+// A Dataloader is safe for concurrent use. It is intended to be request
+// scoped; see WithDataloader and LoadContext.
+type Dataloader struct {
+	wait    time.Duration
+	mu      sync.Mutex
+	batches map[string]*loadBatch
+}
+
+// loadBatch is a set of pending Loads on one table, database handle, and
+// tenant.
+type loadBatch struct {
+	recs    []Recorder
+	results []Recorder
+	errs    []error
+	done    chan struct{}
+}
+
+// NewDataloader creates a Dataloader that waits the given duration before
+// running a batch.
+func NewDataloader(wait time.Duration) *Dataloader {
+	return &Dataloader{
+		wait:    wait,
+		batches: map[string]*loadBatch{},
+	}
+}
+
+// Load loads the Recorder by its primary key, like Recorder.Load.
+//
+// The call blocks until the batch it joined has run, or until the context is
+// done. If no record matches the key, sql.ErrNoRows is returned.
+func (l *Dataloader) Load(ctx context.Context, r Recorder) error {
+	key := batchKey(r)
+
+	l.mu.Lock()
+	b, ok := l.batches[key]
+	if !ok {
+		b = &loadBatch{done: make(chan struct{})}
+		l.batches[key] = b
+		time.AfterFunc(l.wait, func() { l.flush(key, b) })
+	}
+	i := len(b.recs)
+	b.recs = append(b.recs, r)
+	l.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-b.done:
+	}
+
+	if b.errs[i] != nil {
+		return b.errs[i]
+	}
+	return copyFields(r, b.results[i])
+}
+
+func (l *Dataloader) flush(key string, b *loadBatch) {
+	l.mu.Lock()
+	if l.batches[key] == b {
+		delete(l.batches, key)
+	}
+	l.mu.Unlock()
+
+	b.run()
+	close(b.done)
+}
+
+// run fetches every record in the batch with one query.
+func (b *loadBatch) run() {
+	b.results = make([]Recorder, len(b.recs))
+	b.errs = make([]error, len(b.recs))
+
+	first := b.recs[0]
+	cols := first.Key()
+
+	var pred squirrel.Sqlizer
+	if len(cols) == 1 {
+		vals := make([]interface{}, len(b.recs))
+		for i, r := range b.recs {
+			vals[i] = r.WhereIds()[cols[0]]
+		}
+		pred = squirrel.Eq{cols[0]: vals}
+	} else {
+		or := make(squirrel.Or, len(b.recs))
+		for i, r := range b.recs {
+			or[i] = squirrel.Eq(r.WhereIds())
+		}
+		pred = or
+	}
+
+	fn := func(desc Describer, q squirrel.SelectBuilder) (squirrel.SelectBuilder, error) {
+		return q.Where(pred), nil
+	}
+	found, err := ListWhere(protoRecorder(first), fn)
+	if err != nil {
+		for i := range b.errs {
+			b.errs[i] = err
+		}
+		return
+	}
+
+	byKey := make(map[string]Recorder, len(found))
+	for _, f := range found {
+		byKey[keyString(f.WhereIds())] = f
+	}
+	for i, r := range b.recs {
+		if f, ok := byKey[keyString(r.WhereIds())]; ok {
+			b.results[i] = f
+		} else {
+			b.errs[i] = sql.ErrNoRows
+		}
+	}
+}
+
+// protoRecorder returns a *DbRecorder for r's table and Record type.
+//
+// Recorders made by Wrap are unwrapped, and Records that embed their Recorder
+// are looked into, so that the DbRecorder's settings, like its tenant, are
+// kept. If there is no DbRecorder underneath, a new one is created with the
+// same database and flavor.
+func protoRecorder(r Recorder) *DbRecorder {
+	for {
+		if dr, ok := r.(*DbRecorder); ok {
+			return dr
+		}
+		next := underlying(r)
+		if next == nil {
+			break
+		}
+		r = next
+	}
+	rec := reflect.New(reflect.Indirect(reflect.ValueOf(r.Record())).Type())
+	dr := New(r.DB(), r.Driver())
+	dr.Bind(r.TableName(), rec.Interface())
+	return dr
+}
+
+// underlying returns the Recorder that r delegates to: the Recorder that a
+// middleware wraps, or the Recorder that a Record embeds. It returns nil if
+// there is none.
+func underlying(r Recorder) Recorder {
+	if w, ok := r.(interface{ Unwrap() Recorder }); ok {
+		return w.Unwrap()
+	}
+	v := reflect.ValueOf(r)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	v = v.Elem()
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		if !f.Anonymous || f.PkgPath != "" || !f.Type.Implements(recorderType) {
+			continue
+		}
+		if fv := v.Field(i); !fv.IsNil() {
+			return fv.Interface().(Recorder)
+		}
+	}
+	return nil
+}
+
+var recorderType = reflect.TypeOf((*Recorder)(nil)).Elem()
+
+// batchKey returns the key of the batch that r joins. Loads are only batched
+// together if they run on the same table, database handle, and tenant.
+func batchKey(r Recorder) string {
+	table := r.TableName()
+	var db interface{} = r.DB()
+	switch w := db.(type) {
+	case *stdRunner:
+		db = w.db
+	case *baseRunner:
+		db = w.BaseRunner
+	}
+	var tenant interface{}
+	for r != nil {
+		if t, ok := r.(interface{ Tenant() interface{} }); ok {
+			tenant = t.Tenant()
+			break
+		}
+		r = underlying(r)
+	}
+	return fmt.Sprintf("%s\x00%T:%p\x00%v", table, db, db, keyValue(tenant))
+}
+
+// keyString renders a set of key values so that it can be used as a map key.
+func keyString(ids map[string]interface{}) string {
+	cols := make([]string, 0, len(ids))
+	for c := range ids {
+		cols = append(cols, c)
+	}
+	sort.Strings(cols)
+
+	parts := make([]string, len(cols))
+	for i, c := range cols {
+		parts[i] = fmt.Sprintf("%s=%v", c, keyValue(ids[c]))
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// keyValue dereferences pointers, so that a key is rendered by its value and
+// not by its address.
+func keyValue(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+	return rv.Interface()
+}
+
+// copyFields copies the values of every mapped field from src to dest.
+//
+// Both Recorders must be bound to Records of the same type.
+func copyFields(dest, src Recorder) error {
+	d := dest.FieldReferences(true)
+	s := src.FieldReferences(true)
+	if len(d) != len(s) {
+		return fmt.Errorf("cannot copy %d fields into %d fields", len(s), len(d))
+	}
+	for i := range d {
+		reflect.ValueOf(d[i]).Elem().Set(reflect.ValueOf(s[i]).Elem())
+	}
+	return nil
+}
+
+type dataloaderKey struct{}
+
+// WithDataloader returns a copy of ctx that carries the given Dataloader.
+func WithDataloader(ctx context.Context, l *Dataloader) context.Context {
+	return context.WithValue(ctx, dataloaderKey{}, l)
+}
+
+// DataloaderFrom returns the Dataloader carried by ctx, or nil.
+func DataloaderFrom(ctx context.Context) *Dataloader {
+	l, _ := ctx.Value(dataloaderKey{}).(*Dataloader)
+	return l
+}
+
+// LoadContext loads a Recorder, using the context's Dataloader if it has one.
+//
+// Without a Dataloader, this is the same as calling r.Load(). This allows
+// Record code to stay the same whether or not batching is enabled:
+//
+//	// In HTTP middleware:
+//	ctx = structable.WithDataloader(ctx, structable.NewDataloader(2*time.Millisecond))
+//
+//	// In a resolver:
+//	u := NewUser(db, "postgres")
+//	u.Id = id
+//	err := structable.LoadContext(ctx, u)
+func LoadContext(ctx context.Context, r Recorder) error {
+	if l := DataloaderFrom(ctx); l != nil {
+		return l.Load(ctx, r)
+	}
+	return r.Load()
+}
@@ -0,0 +1,114 @@
+package structable
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDataloader(t *testing.T) {
+	db := &DBStub{}
+	l := NewDataloader(10 * time.Millisecond)
+	ctx := WithDataloader(context.Background(), l)
+
+	if DataloaderFrom(ctx) != l {
+		t.Fatal("Expected to get the Dataloader back from the context")
+	}
+
+	count := 0
+	tracer := TracerFunc(func(context.Context, string, []interface{}, time.Duration, error) {
+		count++
+	})
+
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			a := &ActRec{Id: i + 1}
+			r := New(db, "mysql").SetTracer(tracer)
+			r.Bind("my_table", a)
+			errs[i] = LoadContext(ctx, r)
+		}(i)
+	}
+	wg.Wait()
+
+	// The stub returns no rows, so every load misses.
+	for _, err := range errs {
+		if err != sql.ErrNoRows {
+			t.Errorf("Expected sql.ErrNoRows, got %v", err)
+		}
+	}
+	if count != 1 {
+		t.Errorf("Expected one query, got %d", count)
+	}
+	expect := "SELECT id, name FROM my_table WHERE id IN (?,?,?)"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+}
+
+func TestDataloaderCanceled(t *testing.T) {
+	l := NewDataloader(time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r := New(&DBStub{}, "mysql").Bind("my_table", &ActRec{Id: 1})
+	if err := l.Load(ctx, r); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestDataloaderSeparatesBatches(t *testing.T) {
+	l := NewDataloader(10 * time.Millisecond)
+	ctx := WithDataloader(context.Background(), l)
+	db1, db2 := &DBStub{}, &DBStub{}
+
+	recs := []*DbRecorder{
+		New(db1, "postgres").WithTenant(1),
+		New(db1, "postgres").WithTenant(2),
+		New(db2, "postgres").WithTenant(1),
+	}
+	var wg sync.WaitGroup
+	for i, r := range recs {
+		r.Bind("invoices", &invoice{Id: i + 1})
+		wg.Add(1)
+		go func(r *DbRecorder) {
+			defer wg.Done()
+			LoadContext(ctx, r)
+		}(r)
+	}
+	wg.Wait()
+
+	if batchKey(recs[0]) == batchKey(recs[1]) || batchKey(recs[0]) == batchKey(recs[2]) {
+		t.Error("Expected different tenants and databases to get different batches")
+	}
+	if db2.LastQuerySql == "" || !reflect.DeepEqual(db2.LastQueryArgs, []interface{}{1, 3}) {
+		t.Errorf("Expected the second database to load its own record, got %q %v", db2.LastQuerySql, db2.LastQueryArgs)
+	}
+
+	sqlDB := &sql.DB{}
+	if batchKey(New(sqlDB, "postgres").Bind("invoices", &invoice{})) != batchKey(New(sqlDB, "postgres").Bind("invoices", &invoice{})) {
+		t.Error("Expected Recorders on the same *sql.DB to share a batch")
+	}
+}
+
+func TestKeyString(t *testing.T) {
+	a := keyString(map[string]interface{}{"id": 1, "id_two": 2})
+	b := keyString(map[string]interface{}{"id_two": 2, "id": 1})
+	if a != b {
+		t.Errorf("Expected %q to equal %q", a, b)
+	}
+
+	one, other := 1, 1
+	if keyString(map[string]interface{}{"id": &one}) != keyString(map[string]interface{}{"id": &other}) {
+		t.Error("Expected pointer keys to be compared by value")
+	}
+	if keyString(map[string]interface{}{"id": &one}) != "id=1" {
+		t.Errorf("Expected a pointer key to render as its value, got %q", keyString(map[string]interface{}{"id": &one}))
+	}
+}
@@ -0,0 +1,159 @@
+package structable
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Divergence records a write that succeeded on the primary Recorder of a
+// DualWriter, but failed on the secondary.
+type Divergence struct {
+	// Op is one of OpInsert, OpUpdate, or OpDelete.
+	Op string
+	// Keys are the primary key values of the primary Record.
+	Keys map[string]interface{}
+	// Err is the secondary's error.
+	Err error
+}
+
+// MapFunc copies the values of a primary Record into a secondary Record.
+type MapFunc func(primary, secondary Record) error
+
+// DualWriter mirrors writes from one Recorder to another.
+//
+// It is meant for migrations between tables, struct versions, or databases.
+// Reads (Load, Exists, and so on) use only the primary. Insert, Update, and
+// Delete run on the primary first; if that succeeds, the primary Record is
+// mapped onto the secondary Record, and the same operation runs on the
+// secondary:
+//
+//	w := structable.NewDualWriter(oldUser, newUser, func(p, s structable.Record) error {
+//		s.(*UserV2).Id = p.(*UserV1).Id
+//		s.(*UserV2).Email = p.(*UserV1).Email
+//		return nil
+//	})
+//	err := w.Insert()
+//
+// Errors from the secondary are never returned. They are collected as
+// Divergences instead, so that the secondary cannot break the primary path
+// during a cutover.
+type DualWriter struct {
+	Recorder
+	secondary Recorder
+	mapFn     MapFunc
+
+	async   bool
+	pending sync.WaitGroup
+	last    chan struct{}
+
+	mu          sync.Mutex
+	divergences []Divergence
+}
+
+// NewDualWriter creates a DualWriter.
+//
+// If mapFn is nil, each mapped field of the primary Record is copied to the
+// field with the same name on the secondary Record, and the types of those
+// fields must match.
+func NewDualWriter(primary, secondary Recorder, mapFn MapFunc) *DualWriter {
+	if mapFn == nil {
+		fields := protoRecorder(primary).fields
+		mapFn = func(p, s Record) error {
+			pv, sv := reflect.Indirect(reflect.ValueOf(p)), reflect.Indirect(reflect.ValueOf(s))
+			for _, f := range fields {
+				from, to := pv.FieldByName(f.name), sv.FieldByName(f.name)
+				if !to.IsValid() || to.Type() != from.Type() {
+					return fmt.Errorf("cannot copy field %s into %s without a MapFunc", f.name, sv.Type())
+				}
+				to.Set(from)
+			}
+			return nil
+		}
+	}
+	return &DualWriter{
+		Recorder:  primary,
+		secondary: secondary,
+		mapFn:     mapFn,
+	}
+}
+
+// SetAsync toggles asynchronous writes to the secondary.
+//
+// In async mode, writes to the secondary happen in the background, in the
+// same order as the writes to the primary. The primary Record is copied
+// before each write, so it may be changed as soon as the call returns. Use
+// Wait to block until the secondary has caught up.
+func (w *DualWriter) SetAsync(async bool) *DualWriter {
+	w.async = async
+	return w
+}
+
+// Wait blocks until all asynchronous writes to the secondary have finished.
+func (w *DualWriter) Wait() {
+	w.pending.Wait()
+}
+
+// Divergences returns the writes that failed on the secondary so far.
+func (w *DualWriter) Divergences() []Divergence {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]Divergence(nil), w.divergences...)
+}
+
+// Insert inserts the Record on the primary, and then on the secondary.
+func (w *DualWriter) Insert() error {
+	return w.write(OpInsert, w.Recorder.Insert, w.secondary.Insert)
+}
+
+// Update updates the Record on the primary, and then on the secondary.
+func (w *DualWriter) Update() error {
+	return w.write(OpUpdate, w.Recorder.Update, w.secondary.Update)
+}
+
+// Delete deletes the Record on the primary, and then on the secondary.
+func (w *DualWriter) Delete() error {
+	return w.write(OpDelete, w.Recorder.Delete, w.secondary.Delete)
+}
+
+func (w *DualWriter) write(op string, primary, secondary func() error) error {
+	if err := primary(); err != nil {
+		return err
+	}
+	keys := w.Recorder.WhereIds()
+	if !w.async {
+		w.mirror(op, keys, w.Recorder.Interface(), secondary)
+		return nil
+	}
+
+	// Copy the primary Record, since the caller may change it at any time.
+	orig := reflect.ValueOf(w.Recorder.Interface()).Elem()
+	snapshot := reflect.New(orig.Type())
+	snapshot.Elem().Set(orig)
+
+	prev, done := w.last, make(chan struct{})
+	w.last = done
+	w.pending.Add(1)
+	go func() {
+		defer w.pending.Done()
+		defer close(done)
+		if prev != nil {
+			<-prev
+		}
+		w.mirror(op, keys, snapshot.Interface(), secondary)
+	}()
+	return nil
+}
+
+// mirror maps the primary Record onto the secondary and runs the write.
+func (w *DualWriter) mirror(op string, keys map[string]interface{}, primary Record, secondary func() error) {
+	err := w.mapFn(primary, w.secondary.Interface())
+	if err == nil {
+		err = secondary()
+	}
+	if err != nil {
+		w.mu.Lock()
+		w.divergences = append(w.divergences, Divergence{Op: op, Keys: keys, Err: err})
+		w.mu.Unlock()
+	}
+}
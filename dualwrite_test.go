@@ -0,0 +1,74 @@
+package structable
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// failRunner fails every statement.
+type failRunner struct{}
+
+func (failRunner) Exec(string, ...interface{}) (sql.Result, error) {
+	return nil, errors.New("secondary is down")
+}
+func (failRunner) Query(string, ...interface{}) (*sql.Rows, error) {
+	return nil, errors.New("secondary is down")
+}
+func (failRunner) QueryRow(string, ...interface{}) squirrel.RowScanner {
+	return &errRow{errors.New("secondary is down")}
+}
+
+func TestDualWriter(t *testing.T) {
+	pdb, sdb := &DBStub{}, &DBStub{}
+	p := &category{Id: 1, Name: "Go"}
+	s := &category{}
+	w := NewDualWriter(New(pdb, "mysql").Bind("categories", p), New(sdb, "mysql").Bind("categories_v2", s), nil)
+
+	if err := w.Update(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sdb.LastExecSql, "UPDATE categories_v2 SET ") || s.Name != "Go" {
+		t.Errorf("Expected the secondary to be updated, got %q %+v", sdb.LastExecSql, s)
+	}
+
+	w = NewDualWriter(New(pdb, "mysql").Bind("categories", p), New(failRunner{}, "mysql").Bind("categories_v2", s), nil)
+	if err := w.Delete(); err != nil {
+		t.Errorf("Expected secondary errors to be hidden, got %s", err)
+	}
+	d := w.Divergences()
+	if len(d) != 1 || d[0].Op != OpDelete || d[0].Keys["id"] != 1 {
+		t.Errorf("Unexpected divergences %+v", d)
+	}
+
+	w = NewDualWriter(New(pdb, "mysql").Bind("categories", p), New(sdb, "mysql").Bind("test_table", newStool()), nil)
+	w.Update()
+	if len(w.Divergences()) != 1 {
+		t.Error("Expected mismatched Records to diverge")
+	}
+}
+
+func TestDualWriterAsync(t *testing.T) {
+	pdb, sdb := &DBStub{}, &DBStub{}
+	p := &category{Id: 1, Name: "Go"}
+	names := []string{}
+	w := NewDualWriter(New(pdb, "mysql").Bind("categories", p), New(sdb, "mysql").Bind("categories_v2", &category{}),
+		func(p, s Record) error {
+			names = append(names, p.(*category).Name)
+			return nil
+		}).SetAsync(true)
+
+	for _, name := range []string{"Go", "Rust", "Scala"} {
+		p.Name = name
+		if err := w.Update(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Wait()
+	if len(names) != 3 || names[0] != "Go" || names[2] != "Scala" {
+		t.Errorf("Expected secondary writes in order, got %v", names)
+	}
+}
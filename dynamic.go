@@ -0,0 +1,176 @@
+package structable
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// DynamicColumn describes a column of a DynamicRecord.
+type DynamicColumn struct {
+	Name string
+	// Key makes the column part of the primary key, and Auto makes it an
+	// AUTO_INCREMENT column, as the stbl tags PRIMARY_KEY and AUTO_INCREMENT
+	// do.
+	Key, Auto bool
+	// Type is the Go type that the column is scanned into. It defaults to
+	// interface{}, which holds whatever value the driver returns, or nil for
+	// NULL.
+	Type reflect.Type
+}
+
+// DynamicRecord is a Record whose columns are given at run time, instead of
+// by a struct type. It is meant for admin tools and migration scripts that
+// work with tables they do not know at compile time.
+//
+// A DynamicRecord is bound like any other Record, and the same statements are
+// made for it:
+//
+//	rec, err := structable.NewDynamicRecord(
+//		structable.DynamicColumn{Name: "id", Key: true, Auto: true},
+//		structable.DynamicColumn{Name: "name"},
+//	)
+//	r := structable.New(db, "postgres").Bind(table, rec)
+//	rec.Set("id", 7)
+//	if err := r.Load(); err != nil { ... }
+//	fmt.Println(rec.Get("name"))
+//
+// Under the hood, a struct type with one stbl-tagged field per column is
+// made with reflect.StructOf, and the DbRecorder is bound to a value of that
+// type. So Record returns a pointer to that struct rather than the
+// DynamicRecord; DynamicOf gets the DynamicRecord back, such as for the
+// Records returned by ListWhere:
+//
+//	items, err := structable.List(r)
+//	for _, item := range items {
+//		row, _ := structable.DynamicOf(item.Record())
+//		fmt.Println(row.Map())
+//	}
+//
+// A DbRecorder only reads the stbl tag of a DynamicRecord, so its tag keys
+// must include stbl.
+type DynamicRecord struct {
+	cols []DynamicColumn
+	// rec points to the struct that holds the values.
+	rec reflect.Value
+}
+
+// dynamicTypes maps the struct types made for DynamicRecords to their
+// columns.
+var dynamicTypes sync.Map
+
+var interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+
+// NewDynamicRecord creates a DynamicRecord with the given columns, in order.
+// Every value starts out as the zero value of its column type.
+func NewDynamicRecord(cols ...DynamicColumn) (*DynamicRecord, error) {
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("a dynamic record needs at least one column")
+	}
+	cols = append([]DynamicColumn(nil), cols...)
+	fields := make([]reflect.StructField, len(cols))
+	seen := make(map[string]bool, len(cols))
+	for i := range cols {
+		c := &cols[i]
+		if c.Name == "" || strings.ContainsAny(c.Name, ",\"`") {
+			return nil, fmt.Errorf("invalid dynamic column name %q", c.Name)
+		}
+		if seen[c.Name] {
+			return nil, fmt.Errorf("dynamic column %s is given twice", c.Name)
+		}
+		seen[c.Name] = true
+		if c.Type == nil {
+			c.Type = interfaceType
+		}
+		tag := c.Name
+		if c.Key {
+			tag += ",PRIMARY_KEY"
+		}
+		if c.Auto {
+			tag += ",AUTO_INCREMENT"
+		}
+		fields[i] = reflect.StructField{
+			Name: fmt.Sprintf("F%d", i),
+			Type: c.Type,
+			Tag:  reflect.StructTag(fmt.Sprintf("%s:%q", StructableTag, tag)),
+		}
+	}
+	t := reflect.StructOf(fields)
+	dynamicTypes.LoadOrStore(t, cols)
+	return &DynamicRecord{cols: cols, rec: reflect.New(t)}, nil
+}
+
+// DynamicOf returns the DynamicRecord of a Record that a DbRecorder bound to
+// a DynamicRecord returned, such as from Record, Clone, or ListWhere. The
+// DynamicRecord shares the Record's values.
+func DynamicOf(rec Record) (*DynamicRecord, error) {
+	if d, ok := rec.(*DynamicRecord); ok {
+		return d, nil
+	}
+	v := reflect.ValueOf(rec)
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		if cols, ok := dynamicTypes.Load(v.Elem().Type()); ok {
+			return &DynamicRecord{cols: cols.([]DynamicColumn), rec: v}, nil
+		}
+	}
+	return nil, fmt.Errorf("%T is not a dynamic record", rec)
+}
+
+// Columns returns the names of the columns, in order.
+func (d *DynamicRecord) Columns() []string {
+	names := make([]string, len(d.cols))
+	for i, c := range d.cols {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// index returns the position of a column, or -1.
+func (d *DynamicRecord) index(col string) int {
+	for i, c := range d.cols {
+		if c.Name == col {
+			return i
+		}
+	}
+	return -1
+}
+
+// Get returns the value of a column, or nil for an unknown column.
+func (d *DynamicRecord) Get(col string) interface{} {
+	i := d.index(col)
+	if i < 0 {
+		return nil
+	}
+	return d.rec.Elem().Field(i).Interface()
+}
+
+// Set sets the value of a column. The value is converted to the column type
+// as by DbRecorder.SetValues.
+func (d *DynamicRecord) Set(col string, v interface{}) error {
+	i := d.index(col)
+	if i < 0 {
+		return fmt.Errorf("unknown dynamic column %q", col)
+	}
+	fv, err := convertField(d.cols[i].Type, v)
+	if err != nil {
+		return fmt.Errorf("cannot set dynamic column %s: %w", col, err)
+	}
+	d.rec.Elem().Field(i).Set(fv)
+	return nil
+}
+
+// Map returns the values, keyed by column name.
+func (d *DynamicRecord) Map() map[string]interface{} {
+	m := make(map[string]interface{}, len(d.cols))
+	for i, c := range d.cols {
+		m[c.Name] = d.rec.Elem().Field(i).Interface()
+	}
+	return m
+}
+
+// Record returns the pointer to the struct that holds the values, which is
+// what a DbRecorder is bound to.
+func (d *DynamicRecord) Record() Record {
+	return d.rec.Interface()
+}
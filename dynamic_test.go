@@ -0,0 +1,73 @@
+package structable
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDynamicRecord(t *testing.T) {
+	rec, err := NewDynamicRecord(
+		DynamicColumn{Name: "id", Key: true, Auto: true, Type: reflect.TypeOf(0)},
+		DynamicColumn{Name: "name"},
+		DynamicColumn{Name: "price"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := &DBStub{}
+	r := New(db, "postgres")
+	r.Bind("items", rec)
+	if err := r.BindError(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rec.Columns(), []string{"id", "name", "price"}) || !reflect.DeepEqual(r.Key(), []string{"id"}) {
+		t.Errorf("Unexpected columns %v, key %v", rec.Columns(), r.Key())
+	}
+
+	if err := rec.Set("id", "7"); err != nil {
+		t.Fatal(err)
+	}
+	rec.Set("name", "Widget")
+	rec.Set("price", 2.5)
+	if rec.Get("id") != 7 || rec.Get("name") != "Widget" || rec.Get("nope") != nil {
+		t.Errorf("Unexpected values %v", rec.Map())
+	}
+	if err := rec.Set("nope", 1); err == nil {
+		t.Error("Expected an unknown column to fail")
+	}
+
+	if err := r.Update(); err != nil {
+		t.Fatal(err)
+	}
+	expect := "UPDATE items SET name = $1, price = $2 WHERE id = $3"
+	if db.LastExecSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastExecSql)
+	}
+	if !reflect.DeepEqual(db.LastExecArgs, []interface{}{"Widget", 2.5, 7}) {
+		t.Errorf("Unexpected args %v", db.LastExecArgs)
+	}
+
+	back, err := DynamicOf(r.Clone(nil).Record())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back.Columns(), rec.Columns()) || back.Get("name") != nil {
+		t.Errorf("Expected an empty dynamic record, got %v", back.Map())
+	}
+	if _, err := DynamicOf(&category{}); err == nil {
+		t.Error("Expected a struct Record not to be dynamic")
+	}
+}
+
+func TestNewDynamicRecordErrors(t *testing.T) {
+	for _, cols := range [][]DynamicColumn{
+		nil,
+		{{Name: ""}},
+		{{Name: "a,b"}},
+		{{Name: "a"}, {Name: "a"}},
+	} {
+		if _, err := NewDynamicRecord(cols...); err == nil {
+			t.Errorf("Expected %v to fail", cols)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package structable
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Plan is the query plan of one statement, as reported by the database.
+type Plan struct {
+	// Query and Args are the statement that was explained.
+	Query string
+	Args  []interface{}
+	// Lines are the rows of the EXPLAIN output, one per plan step.
+	Lines []string
+
+	flavor string
+}
+
+func (p Plan) String() string {
+	return p.Query + "\n\t" + strings.Join(p.Lines, "\n\t")
+}
+
+var (
+	pgSeqScan     = regexp.MustCompile(`Seq Scan on (\S+)`)
+	sqliteScan    = regexp.MustCompile(`^SCAN (?:TABLE )?(\S+)`)
+	mysqlFullScan = regexp.MustCompile(`(?:^| )table=(\S+) .*type=ALL(?: |$)`)
+)
+
+// FullScans returns the tables that the plan reads in full, rather than
+// through an index.
+//
+// Full scans are recognized for postgres (Seq Scan), sqlite3 (SCAN without an
+// index), and mysql (access type ALL).
+func (p Plan) FullScans() []string {
+	tables := []string{}
+	for _, l := range p.Lines {
+		var m []string
+		switch p.flavor {
+		case "postgres":
+			m = pgSeqScan.FindStringSubmatch(l)
+		case "sqlite3":
+			if !strings.Contains(l, " USING ") {
+				m = sqliteScan.FindStringSubmatch(l)
+			}
+		case "mysql":
+			m = mysqlFullScan.FindStringSubmatch(l)
+		}
+		if m != nil {
+			tables = append(tables, m[1])
+		}
+	}
+	return tables
+}
+
+// Explain runs op, and returns the query plans of the statements that the
+// DbRecorder ran during op.
+//
+//	plans, err := r.Explain(func() error {
+//		return r.LoadWhere("email = ?", email)
+//	})
+//
+// The statements are captured with a Tracer, which is installed for the
+// duration of op (any existing Tracer is still called), and explained after op
+// returns. Statements run through copies of the DbRecorder, such as those of
+// List, are included. Explain must not be used concurrently with other
+// operations on the same DbRecorder.
+func (s *DbRecorder) Explain(op func() error) ([]Plan, error) {
+	plans := []Plan{}
+	prev := s.tracer
+	s.tracer = TracerFunc(func(ctx context.Context, query string, args []interface{}, d time.Duration, err error) {
+		plans = append(plans, Plan{Query: query, Args: args, flavor: s.flavor})
+		if prev != nil {
+			prev.Trace(ctx, query, args, d, err)
+		}
+	})
+	err := op()
+	s.tracer = prev
+	if err != nil {
+		return plans, err
+	}
+
+	for i := range plans {
+		if plans[i].Lines, err = s.explain(plans[i].Query, plans[i].Args); err != nil {
+			return plans, err
+		}
+	}
+	return plans, nil
+}
+
+// explain runs EXPLAIN for a statement, and formats each row as a line.
+func (s *DbRecorder) explain(query string, args []interface{}) ([]string, error) {
+	prefix := "EXPLAIN "
+	if s.flavor == "sqlite3" {
+		prefix = "EXPLAIN QUERY PLAN "
+	}
+	rows, err := queryContext(s.Context(), s.db, prefix+query, args...)
+	if err != nil || rows == nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	lines := []string{}
+	for rows.Next() {
+		vals := make([]sql.NullString, len(cols))
+		dest := make([]interface{}, len(cols))
+		for i := range vals {
+			dest[i] = &vals[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return lines, err
+		}
+		lines = append(lines, planLine(s.flavor, cols, vals))
+	}
+	return lines, rows.Err()
+}
+
+// planLine formats one row of EXPLAIN output. Postgres returns the plan as
+// text, and sqlite3 describes each step in its last column. Other databases
+// return one column per property, which are listed as name=value pairs.
+func planLine(flavor string, cols []string, vals []sql.NullString) string {
+	if len(vals) == 1 || flavor == "sqlite3" {
+		return vals[len(vals)-1].String
+	}
+	parts := make([]string, 0, len(cols))
+	for i, c := range cols {
+		if vals[i].Valid {
+			parts = append(parts, fmt.Sprintf("%s=%s", c, vals[i].String))
+		}
+	}
+	return strings.Join(parts, " ")
+}
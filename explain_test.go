@@ -0,0 +1,63 @@
+package structable
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlanFullScans(t *testing.T) {
+	tests := []struct {
+		flavor string
+		lines  []string
+		expect []string
+	}{
+		{"postgres", []string{
+			"Nested Loop  (cost=0.29..16.32 rows=1 width=40)",
+			"  ->  Seq Scan on users  (cost=0.00..8.00 rows=1 width=36)",
+			"  ->  Index Scan using profiles_user_id on profiles  (cost=0.29..8.30 rows=1 width=4)",
+		}, []string{"users"}},
+		{"sqlite3", []string{
+			"SCAN users",
+			"SEARCH profiles USING INDEX profiles_user_id (user_id=?)",
+			"SCAN sessions USING COVERING INDEX sessions_token",
+		}, []string{"users"}},
+		{"sqlite3", []string{"SCAN TABLE users"}, []string{"users"}},
+		{"mysql", []string{
+			"id=1 select_type=SIMPLE table=users type=ALL rows=1000",
+			"id=1 select_type=SIMPLE table=profiles type=ref key=profiles_user_id rows=1",
+		}, []string{"users"}},
+	}
+	for _, tt := range tests {
+		p := Plan{Lines: tt.lines, flavor: tt.flavor}
+		if got := p.FullScans(); !reflect.DeepEqual(got, tt.expect) {
+			t.Errorf("%s: expected %v, got %v", tt.flavor, tt.expect, got)
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "postgres")
+	r.Bind("test_table", newStool())
+
+	var traced int
+	r.SetTracer(TracerFunc(func(_ context.Context, _ string, _ []interface{}, _ time.Duration, _ error) {
+		traced++
+	}))
+	plans, err := r.Explain(r.Delete)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plans) != 1 || !strings.HasPrefix(plans[0].Query, "DELETE FROM test_table") {
+		t.Fatalf("Expected the delete to be captured, got %v", plans)
+	}
+	if db.LastQuerySql != "EXPLAIN "+plans[0].Query {
+		t.Errorf("Expected the delete to be explained, got %q", db.LastQuerySql)
+	}
+	if traced != 1 || r.tracer == nil {
+		t.Error("Expected the existing tracer to be kept")
+	}
+}
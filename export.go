@@ -0,0 +1,228 @@
+package structable
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Format is a file format for ExportWhere.
+type Format int
+
+const (
+	// FormatCSV writes a header row of column names, and a row per record.
+	FormatCSV Format = iota
+	// FormatJSON writes an array of objects keyed by column name.
+	FormatJSON
+	// FormatNDJSON writes an object per line, as newline-delimited JSON.
+	FormatNDJSON
+)
+
+// String returns the usual file extension of the format, without the dot.
+func (f Format) String() string {
+	switch f {
+	case FormatCSV:
+		return "csv"
+	case FormatJSON:
+		return "json"
+	case FormatNDJSON:
+		return "ndjson"
+	}
+	return "Format(" + strconv.Itoa(int(f)) + ")"
+}
+
+// ExportWhere writes the records selected as by ListWhere to w, and returns
+// the number of records written.
+//
+// Rows are written as they are read, so that a large table can be exported
+// without holding it in memory. Each row holds the columns of the select that
+// map to fields of the Record, in the order of the select; the WhereFunc may
+// change them with WithColumns:
+//
+//	f, _ := os.Create("users.csv")
+//	n, err := structable.ExportWhere(r, structable.WithWhere("active = ?", true), f, structable.FormatCSV)
+//
+// In CSV, times are written in RFC 3339, and NULL is an empty cell. In JSON,
+// fields are encoded with encoding/json, and NULL is null. The output can be
+// read back with ImportCSV or ImportJSON, except for []byte fields in JSON,
+// which encoding/json writes in base64.
+//
+// The SetMaxRows cap does not apply, since rows are not kept. If an error
+// occurs after rows were written, the output is cut short.
+func ExportWhere(r Recorder, fn WhereFunc, w io.Writer, format Format) (int64, error) {
+	if format < FormatCSV || format > FormatNDJSON {
+		return 0, fmt.Errorf("unknown export format %s", format)
+	}
+	proto := protoRecorder(r)
+	q := proto.builder.Select(proto.Columns(true)...).From(proto.TableName()).Where(proto.tenantWhere())
+	var err error
+	if fn != nil {
+		if q, err = fn(r, q); err != nil {
+			return 0, err
+		}
+	}
+
+	rows, err := proto.query(OpList, q)
+	if err != nil || rows == nil {
+		return 0, err
+	}
+	defer rows.Close()
+	names, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	cs := proto.columnScanner(names)
+
+	var e exporter
+	if format == FormatCSV {
+		e = &csvExporter{w: csv.NewWriter(w)}
+	} else {
+		e = &jsonExporter{w: bufio.NewWriter(w), array: format == FormatJSON}
+	}
+	if err := e.begin(cs.fields); err != nil {
+		return 0, err
+	}
+
+	// One Record is scanned over and over, since each row is written before
+	// the next is read.
+	s := proto.Clone(nil)
+	ar := reflect.Indirect(reflect.ValueOf(s.record))
+	vals := make([]interface{}, len(cs.fields))
+	var n int64
+	for rows.Next() {
+		if err := cs.scan(s, rows); err != nil {
+			return n, err
+		}
+		for i, f := range cs.fields {
+			vals[i] = exportValue(ar.FieldByName(f.name))
+		}
+		if err := e.row(vals); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return n, err
+	}
+	return n, e.end()
+}
+
+// exportValue returns the value of a field, with pointers followed. A nil
+// pointer is nil.
+func exportValue(v reflect.Value) interface{} {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	return v.Interface()
+}
+
+// exporter writes rows in a Format.
+type exporter interface {
+	begin(fields []*field) error
+	row(vals []interface{}) error
+	end() error
+}
+
+type csvExporter struct {
+	w     *csv.Writer
+	cells []string
+}
+
+func (e *csvExporter) begin(fields []*field) error {
+	e.cells = make([]string, len(fields))
+	for i, f := range fields {
+		e.cells[i] = f.column
+	}
+	return e.w.Write(e.cells)
+}
+
+func (e *csvExporter) row(vals []interface{}) error {
+	for i, v := range vals {
+		switch v := v.(type) {
+		case nil:
+			e.cells[i] = ""
+		case time.Time:
+			e.cells[i] = v.Format(time.RFC3339Nano)
+		case []byte:
+			e.cells[i] = string(v)
+		default:
+			e.cells[i] = fmt.Sprint(v)
+		}
+	}
+	return e.w.Write(e.cells)
+}
+
+func (e *csvExporter) end() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type jsonExporter struct {
+	w *bufio.Writer
+	// keys are the encoded column names, with their colons.
+	keys  [][]byte
+	array bool
+	n     int
+}
+
+func (e *jsonExporter) begin(fields []*field) error {
+	e.keys = make([][]byte, len(fields))
+	for i, f := range fields {
+		k, err := json.Marshal(f.column)
+		if err != nil {
+			return err
+		}
+		e.keys[i] = append(k, ':')
+	}
+	if e.array {
+		return e.w.WriteByte('[')
+	}
+	return nil
+}
+
+// row writes an object by hand, since a map would not keep the columns in
+// order.
+func (e *jsonExporter) row(vals []interface{}) error {
+	if e.array && e.n > 0 {
+		e.w.WriteByte(',')
+	}
+	if e.array {
+		e.w.WriteByte('\n')
+	}
+	e.n++
+	e.w.WriteByte('{')
+	for i, v := range vals {
+		if i > 0 {
+			e.w.WriteByte(',')
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("encoding column %s: %w", e.keys[i][:len(e.keys[i])-1], err)
+		}
+		e.w.Write(e.keys[i])
+		e.w.Write(b)
+	}
+	e.w.WriteByte('}')
+	if !e.array {
+		e.w.WriteByte('\n')
+	}
+	return nil
+}
+
+func (e *jsonExporter) end() error {
+	if e.array {
+		if e.n > 0 {
+			e.w.WriteByte('\n')
+		}
+		e.w.WriteString("]\n")
+	}
+	return e.w.Flush()
+}
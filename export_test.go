@@ -0,0 +1,27 @@
+package structable
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestExportWhere(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "postgres").Bind("people", &importee{})
+
+	var buf bytes.Buffer
+	if _, err := ExportWhere(r, WithWhere("age > ?", 30), &buf, FormatNDJSON); err != nil {
+		t.Fatal(err)
+	}
+	expect := "SELECT id, name, age, born FROM people WHERE age > $1"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+
+	if _, err := ExportWhere(r, nil, &buf, Format(7)); err == nil {
+		t.Error("Expected an unknown format to fail")
+	}
+	if FormatNDJSON.String() != "ndjson" || Format(7).String() != "Format(7)" {
+		t.Errorf("Unexpected format names %s, %s", FormatNDJSON, Format(7))
+	}
+}
@@ -0,0 +1,50 @@
+package structable
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// SetExpr writes a SQL expression to a column, instead of its field's value,
+// on the next Insert or Update:
+//
+//	r.SetExpr("counter", squirrel.Expr("counter + ?", 1))
+//	r.SetExpr("updated_at", "NOW()")
+//	err := r.Update()
+//
+// The expression is either a squirrel.Sqlizer or a string of SQL. It is used
+// for one statement only: Insert and Update clear every expression once they
+// have run, whether or not they succeed. Columns that the statement does not
+// write, such as the keys on Update, are not affected.
+//
+// The field is not changed. On postgres, Insert loads the stored value back
+// into the Record; otherwise, Load the Record to see it.
+func (s *DbRecorder) SetExpr(column string, expr interface{}) error {
+	if _, err := s.fieldForColumn(column); err != nil {
+		return err
+	}
+	var sq squirrel.Sqlizer
+	switch e := expr.(type) {
+	case squirrel.Sqlizer:
+		sq = e
+	case string:
+		sq = squirrel.Expr(e)
+	default:
+		return fmt.Errorf("expression for column %s on table %s must be a string or squirrel.Sqlizer, got %T", column, s.table, expr)
+	}
+
+	// Copy, so that clones do not share expressions.
+	exprs := make(map[string]squirrel.Sqlizer, len(s.exprs)+1)
+	for c, e := range s.exprs {
+		exprs[c] = e
+	}
+	exprs[column] = sq
+	s.exprs = exprs
+	return nil
+}
+
+// clearExprs removes the expressions set with SetExpr.
+func (s *DbRecorder) clearExprs() {
+	s.exprs = nil
+}
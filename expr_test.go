@@ -0,0 +1,62 @@
+package structable
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+)
+
+type counter struct {
+	Id      int    `stbl:"id,PRIMARY_KEY,SERIAL"`
+	Hits    int    `stbl:"hits"`
+	Updated string `stbl:"updated_at,NOT_NULL"`
+}
+
+func TestSetExpr(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "mysql")
+	c := &counter{Id: 1, Hits: 5}
+	r.Bind("counters", c)
+
+	if err := r.SetExpr("hits", squirrel.Expr("hits + ?", 2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetExpr("updated_at", "NOW()"); err != nil {
+		t.Fatal(err)
+	}
+	clone := r.Clone(nil)
+	if err := r.Update(); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "UPDATE counters SET hits = hits + ?, updated_at = NOW() WHERE id = ?"; db.LastExecSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastExecSql)
+	}
+	if expect := []interface{}{2, 1}; !reflect.DeepEqual(db.LastExecArgs, expect) {
+		t.Errorf("Expected %v, got %v", expect, db.LastExecArgs)
+	}
+
+	// Expressions are used once.
+	c.Updated = "now"
+	if err := r.Update(); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "UPDATE counters SET hits = ?, updated_at = ? WHERE id = ?"; db.LastExecSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastExecSql)
+	}
+
+	// The clone still has its own.
+	if err := clone.Insert(); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "INSERT INTO counters (hits,updated_at) VALUES (hits + ?,NOW())"; db.LastExecSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastExecSql)
+	}
+
+	if err := r.SetExpr("nope", "1"); err == nil {
+		t.Error("Expected an error for an unknown column")
+	}
+	if err := r.SetExpr("hits", 1); err == nil {
+		t.Error("Expected an error for a value that is not an expression")
+	}
+}
@@ -0,0 +1,207 @@
+package structable
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// BlobStore keeps the payloads of EXTERNAL fields outside of the database,
+// for example in S3 or GCS.
+//
+// Payloads are content-addressed: the key is the BlobHash of the data, so a
+// payload that is written twice is stored under the same key. Structable
+// never deletes payloads; use the store's own lifecycle rules to remove the
+// ones that are no longer referenced.
+type BlobStore interface {
+	// Put stores data under key.
+	Put(key string, data []byte) error
+	// Get returns the data stored under key.
+	Get(key string) ([]byte, error)
+}
+
+// ErrNoBlobStore is returned when a Record has EXTERNAL fields, but the
+// DbRecorder has no BlobStore.
+var ErrNoBlobStore = errors.New("no BlobStore is set for EXTERNAL fields")
+
+// SetBlobStore sets the BlobStore that holds the payloads of EXTERNAL fields.
+//
+// On Insert and Update, the payload of each EXTERNAL field is put in the
+// store, and only its key is written to the column. When a Record is loaded,
+// string and []byte fields are fetched from the store right away, while Blob
+// fields are fetched when they are first read:
+//
+//	type Video struct {
+//		Id     int              `stbl:"id,PRIMARY_KEY,SERIAL"`
+//		Poster []byte           `stbl:"poster_key,EXTERNAL"`
+//		Data   structable.Blob  `stbl:"data_key,EXTERNAL"`
+//	}
+//
+// Empty payloads are stored as NULL, and are not put in the store.
+func (s *DbRecorder) SetBlobStore(bs BlobStore) *DbRecorder {
+	s.blobs = bs
+	return s
+}
+
+// Blob is a handle to the payload of an EXTERNAL field, which is fetched from
+// the BlobStore on first use.
+type Blob struct {
+	key    string
+	data   []byte
+	loaded bool
+	store  BlobStore
+}
+
+// NewBlob creates a Blob that holds data, to be stored on the next Insert or
+// Update.
+func NewBlob(data []byte) Blob {
+	return Blob{data: data, loaded: true}
+}
+
+// Key returns the BlobStore key of the payload, or "" if it is empty.
+func (b *Blob) Key() string {
+	if b.loaded && b.key == "" && len(b.data) > 0 {
+		b.key = BlobHash(b.data)
+	}
+	return b.key
+}
+
+// Bytes returns the payload, fetching it from the BlobStore if it has not
+// been fetched yet.
+func (b *Blob) Bytes() ([]byte, error) {
+	if b.loaded || b.key == "" {
+		return b.data, nil
+	}
+	if b.store == nil {
+		return nil, ErrNoBlobStore
+	}
+	data, err := b.store.Get(b.key)
+	if err != nil {
+		return nil, err
+	}
+	b.data, b.loaded = data, true
+	return data, nil
+}
+
+var blobType = reflect.TypeOf(Blob{})
+
+// externalPayload returns the payload held by the value of an EXTERNAL field,
+// and whether it has to be put in the store.
+func externalPayload(v interface{}) ([]byte, bool) {
+	switch v := v.(type) {
+	case string:
+		return []byte(v), true
+	case *string:
+		if v != nil {
+			return []byte(*v), true
+		}
+	case []byte:
+		return v, true
+	case *[]byte:
+		if v != nil {
+			return *v, true
+		}
+	case Blob:
+		// Blobs that were loaded by key are already in the store.
+		return v.data, v.loaded
+	}
+	return nil, false
+}
+
+// externalKey returns the value to store in the column of an EXTERNAL field.
+func externalKey(v interface{}) interface{} {
+	if b, ok := v.(Blob); ok {
+		if k := b.Key(); k != "" {
+			return k
+		}
+		return nil
+	}
+	if data, _ := externalPayload(v); len(data) > 0 {
+		return BlobHash(data)
+	}
+	return nil
+}
+
+// putExternal puts the payloads of the given EXTERNAL fields in the
+// BlobStore.
+func (s *DbRecorder) putExternal(fields []*field) error {
+	ar := reflect.Indirect(reflect.ValueOf(s.record))
+	for _, f := range fields {
+		if !f.isExternal {
+			continue
+		}
+		data, put := externalPayload(ar.FieldByName(f.name).Interface())
+		if !put || len(data) == 0 {
+			continue
+		}
+		if s.blobs == nil {
+			return ErrNoBlobStore
+		}
+		if err := s.blobs.Put(BlobHash(data), data); err != nil {
+			return fmt.Errorf("storing field %s: %w", f.name, err)
+		}
+	}
+	return nil
+}
+
+// loadExternal stores the payload for key in the EXTERNAL field f. The ref
+// points to the field's value.
+func (s *DbRecorder) loadExternal(f *field, ref interface{}, key interface{}) error {
+	fv := reflect.Indirect(reflect.ValueOf(s.record)).FieldByName(f.name)
+	var k string
+	switch key := key.(type) {
+	case []byte:
+		k = string(key)
+	case string:
+		k = key
+	case nil:
+	default:
+		return fmt.Errorf("unsupported key type %T", key)
+	}
+
+	if fv.Type() == blobType {
+		// Keep a Blob that already holds this payload, as after an insert.
+		if cur := fv.Interface().(Blob); cur.Key() != k || k == "" {
+			fv.Set(reflect.ValueOf(Blob{key: k, store: s.blobs}))
+		}
+		return nil
+	}
+	if k == "" {
+		return convertAssign(ref, nil)
+	}
+	// The payload may already be in the field, as after an insert.
+	if data, _ := externalPayload(fv.Interface()); len(data) > 0 && BlobHash(data) == k {
+		return nil
+	}
+	if s.blobs == nil {
+		return ErrNoBlobStore
+	}
+	data, err := s.blobs.Get(k)
+	if err != nil {
+		return err
+	}
+	return convertAssign(ref, data)
+}
+
+// checkExternal checks that every EXTERNAL field is a string, []byte, or
+// Blob.
+func (s *DbRecorder) checkExternal() error {
+	t := reflect.Indirect(reflect.ValueOf(s.record)).Type()
+	for _, f := range s.fields {
+		if !f.isExternal {
+			continue
+		}
+		sf, _ := t.FieldByName(f.name)
+		ft := sf.Type
+		if ft == blobType {
+			continue
+		}
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() != reflect.String && !(ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.Uint8) {
+			return fmt.Errorf("field %s on table %s cannot be EXTERNAL: %s is not a string, []byte, or Blob", f.name, s.table, sf.Type)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package structable
+
+import (
+	"errors"
+	"testing"
+)
+
+// memStore is a BlobStore in memory.
+type memStore struct {
+	blobs map[string][]byte
+	gets  int
+}
+
+func (m *memStore) Put(key string, data []byte) error {
+	if m.blobs == nil {
+		m.blobs = map[string][]byte{}
+	}
+	m.blobs[key] = data
+	return nil
+}
+
+func (m *memStore) Get(key string) ([]byte, error) {
+	m.gets++
+	data, ok := m.blobs[key]
+	if !ok {
+		return nil, errors.New("no such blob")
+	}
+	return data, nil
+}
+
+type video struct {
+	Id     int    `stbl:"id,PRIMARY_KEY,SERIAL"`
+	Poster string `stbl:"poster_key,EXTERNAL"`
+	Data   Blob   `stbl:"data_key,EXTERNAL"`
+}
+
+func TestExternalInsert(t *testing.T) {
+	db := &DBStub{}
+	store := &memStore{}
+	v := &video{Poster: "poster", Data: NewBlob([]byte("frames"))}
+	r := New(db, "mysql").SetBlobStore(store)
+	r.Bind("videos", v)
+
+	if err := r.Insert(); err != nil {
+		t.Fatal(err)
+	}
+	poster, data := BlobHash([]byte("poster")), BlobHash([]byte("frames"))
+	if string(store.blobs[poster]) != "poster" || string(store.blobs[data]) != "frames" {
+		t.Errorf("Expected both payloads in the store, got %v", store.blobs)
+	}
+	if len(db.LastExecArgs) != 2 || db.LastExecArgs[0] != poster || db.LastExecArgs[1] != data {
+		t.Errorf("Expected the keys to be written, got %v", db.LastExecArgs)
+	}
+
+	r.SetBlobStore(nil)
+	if err := r.Update(); err != ErrNoBlobStore {
+		t.Errorf("Expected ErrNoBlobStore, got %v", err)
+	}
+}
+
+func TestExternalLoad(t *testing.T) {
+	store := &memStore{}
+	store.Put(BlobHash([]byte("poster")), []byte("poster"))
+	store.Put(BlobHash([]byte("frames")), []byte("frames"))
+
+	v := &video{}
+	r := New(&DBStub{}, "mysql").SetBlobStore(store)
+	r.Bind("videos", v)
+	row := &driverRow{&valueRow{[]interface{}{int64(1), []byte(BlobHash([]byte("poster"))), BlobHash([]byte("frames"))}}}
+	if err := r.scan(row, true); err != nil {
+		t.Fatal(err)
+	}
+	if v.Poster != "poster" || store.gets != 1 {
+		t.Errorf("Expected the poster to be fetched right away, got %q after %d gets", v.Poster, store.gets)
+	}
+	if b, err := v.Data.Bytes(); err != nil || string(b) != "frames" || store.gets != 2 {
+		t.Errorf("Expected the data to be fetched on first use, got %q, %v", b, err)
+	}
+	if _, err := v.Data.Bytes(); err != nil || store.gets != 2 {
+		t.Error("Expected the data to be fetched once")
+	}
+
+	bad := New(&DBStub{}, "mysql")
+	bad.Bind("bad", &struct {
+		N int `stbl:"n,EXTERNAL"`
+	}{})
+	if bad.BindError() == nil {
+		t.Error("Expected an EXTERNAL int to fail")
+	}
+}
@@ -0,0 +1,74 @@
+package structable
+
+import (
+	"reflect"
+	"sync"
+)
+
+// RecorderFactory vends DbRecorders for one table and Record type, reusing
+// them to save allocations in busy services.
+//
+// The factory is made from a bound template, whose settings every DbRecorder
+// it vends starts with. The template's struct tags are parsed once:
+//
+//	users := structable.NewRecorderFactory(structable.New(db, "postgres").Bind("users", &User{}).(*structable.DbRecorder))
+//
+//	func handle(id int) (string, error) {
+//		r := users.Get()
+//		defer users.Put(r)
+//		u := r.Record().(*User)
+//		u.Id = id
+//		if err := r.Load(); err != nil {
+//			return "", err
+//		}
+//		return u.Name, nil
+//	}
+//
+// Each DbRecorder from Get is bound to an empty Record of the template's type.
+// A DbRecorder given back with Put is reused, along with its Record, so
+// neither may be used after Put. Records that are kept, for example because
+// they are returned to a caller, should simply not be put back.
+//
+// A RecorderFactory is safe for concurrent use. Records that embed their
+// Recorder must have it set after Get, since the Record is empty.
+type RecorderFactory struct {
+	tmpl DbRecorder
+	typ  reflect.Type
+	pool sync.Pool
+}
+
+// NewRecorderFactory creates a RecorderFactory from a bound DbRecorder.
+//
+// The template's settings are copied, so later changes to it do not affect
+// the factory.
+func NewRecorderFactory(tmpl *DbRecorder) *RecorderFactory {
+	f := &RecorderFactory{tmpl: *tmpl}
+	f.typ, _ = recordType(tmpl.record)
+	return f
+}
+
+// Get returns a DbRecorder with the template's settings, bound to an empty
+// Record.
+func (f *RecorderFactory) Get() *DbRecorder {
+	if r, ok := f.pool.Get().(*DbRecorder); ok {
+		rec := r.record
+		*r = f.tmpl
+		r.record = rec
+		return r
+	}
+	return f.tmpl.Clone(nil)
+}
+
+// Put gives a DbRecorder back to the factory for reuse. The Record is
+// cleared. DbRecorders bound to another type of Record are ignored.
+func (f *RecorderFactory) Put(r *DbRecorder) {
+	if r == nil || f.typ == nil {
+		return
+	}
+	if t, err := recordType(r.record); err != nil || t != f.typ {
+		return
+	}
+	reflect.ValueOf(r.record).Elem().Set(reflect.Zero(f.typ))
+	*r = DbRecorder{record: r.record}
+	f.pool.Put(r)
+}
@@ -0,0 +1,175 @@
+/*
+Package factory builds and inserts Records for tests, with defaults that
+only need to be written once.
+
+A factory is defined by name, with a template Record and overrides that fill
+in the fields that must differ between Records, such as unique emails:
+
+	factory.Define("users", &User{Name: "Jane", Active: true},
+		factory.Sequence("email", "user%d@example.com"))
+	factory.Define("users:admin", &User{Name: "Admin", Role: "admin"},
+		factory.Sequence("email", "admin%d@example.com"))
+
+A test then creates the Records it needs, overriding only the fields that
+matter to it:
+
+	u, err := factory.Create(tx, "users", factory.Set("name", "Bob"))
+	admin, err := factory.Create(tx, "users:admin")
+
+The table of a factory is its name, up to a colon, so several factories may
+fill one table. Records are inserted with DbRecorder.Insert, so their
+AUTO_INCREMENT keys are set.
+
+The package-level functions use the Default Registry, whose flavor is
+postgres. Tests against another database set it once:
+
+	factory.Default.SetFlavor("sqlite3")
+*/
+package factory
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/structable"
+)
+
+// Override changes a Record that is being built. It is given the DbRecorder
+// that the Record is bound to, and the sequence number of the Record, which
+// counts up from 1 for each factory.
+type Override func(r *structable.DbRecorder, n int) error
+
+// Set returns an Override that sets a column to a value. The value is
+// converted to the field type as by DbRecorder.SetValues.
+func Set(column string, value interface{}) Override {
+	return func(r *structable.DbRecorder, n int) error {
+		return r.SetValues(map[string]interface{}{column: value})
+	}
+}
+
+// Sequence returns an Override that sets a column to a value formatted with
+// the sequence number of the Record, such as "user%d@example.com", so that
+// every Record of a factory gets a unique value.
+func Sequence(column, format string) Override {
+	return func(r *structable.DbRecorder, n int) error {
+		return r.SetValues(map[string]interface{}{column: fmt.Sprintf(format, n)})
+	}
+}
+
+// Registry holds factory definitions.
+//
+// A Registry is safe for concurrent use.
+type Registry struct {
+	mu        sync.Mutex
+	flavor    string
+	factories map[string]*definition
+}
+
+// definition is a defined factory.
+type definition struct {
+	table     string
+	template  reflect.Value
+	overrides []Override
+	seq       int
+}
+
+// Default is the Registry used by the package-level functions.
+var Default = New("postgres")
+
+// New creates an empty Registry, whose Records are inserted with the SQL of a
+// flavor.
+func New(flavor string) *Registry {
+	return &Registry{flavor: flavor, factories: map[string]*definition{}}
+}
+
+// SetFlavor sets the flavor that Records are inserted with.
+func (reg *Registry) SetFlavor(flavor string) *Registry {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.flavor = flavor
+	return reg
+}
+
+// Define defines a factory, replacing any factory with the same name. The
+// template must be a pointer to a struct. It is copied, so later changes to
+// it do not change the factory, and copied again for each Record, to which
+// the overrides are applied in order.
+func (reg *Registry) Define(name string, template structable.Record, overrides ...Override) {
+	v := reflect.ValueOf(template)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("factory %s: template must be a pointer to a struct, not %T", name, template))
+	}
+	tmpl := reflect.New(v.Elem().Type()).Elem()
+	tmpl.Set(v.Elem())
+	table := name
+	if i := strings.Index(name, ":"); i >= 0 {
+		table = name[:i]
+	}
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.factories[name] = &definition{table: table, template: tmpl, overrides: overrides}
+}
+
+// Build builds a Record with a factory, without inserting it. The overrides
+// are applied after those of the factory.
+func (reg *Registry) Build(db structable.Runner, name string, overrides ...Override) (structable.Record, error) {
+	r, err := reg.build(db, name, overrides)
+	if err != nil {
+		return nil, err
+	}
+	return r.Record(), nil
+}
+
+// Create builds a Record with a factory, as by Build, and inserts it.
+func (reg *Registry) Create(db structable.Runner, name string, overrides ...Override) (structable.Record, error) {
+	r, err := reg.build(db, name, overrides)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.Insert(); err != nil {
+		return nil, fmt.Errorf("factory %s: %w", name, err)
+	}
+	return r.Record(), nil
+}
+
+// build copies the template of a factory, binds it, and applies the
+// overrides.
+func (reg *Registry) build(db structable.Runner, name string, overrides []Override) (*structable.DbRecorder, error) {
+	reg.mu.Lock()
+	d, ok := reg.factories[name]
+	if !ok {
+		reg.mu.Unlock()
+		return nil, fmt.Errorf("no factory is defined for %s", name)
+	}
+	d.seq++
+	n, flavor := d.seq, reg.flavor
+	reg.mu.Unlock()
+
+	rec := reflect.New(d.template.Type())
+	rec.Elem().Set(d.template)
+	r := structable.New(db, flavor)
+	r.Bind(d.table, rec.Interface())
+	for _, o := range append(d.overrides[:len(d.overrides):len(d.overrides)], overrides...) {
+		if err := o(r, n); err != nil {
+			return nil, fmt.Errorf("factory %s: %w", name, err)
+		}
+	}
+	return r, nil
+}
+
+// Define defines a factory in the Default Registry.
+func Define(name string, template structable.Record, overrides ...Override) {
+	Default.Define(name, template, overrides...)
+}
+
+// Build builds a Record with a factory of the Default Registry.
+func Build(db structable.Runner, name string, overrides ...Override) (structable.Record, error) {
+	return Default.Build(db, name, overrides...)
+}
+
+// Create builds and inserts a Record with a factory of the Default Registry.
+func Create(db structable.Runner, name string, overrides ...Override) (structable.Record, error) {
+	return Default.Create(db, name, overrides...)
+}
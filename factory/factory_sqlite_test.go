@@ -0,0 +1,47 @@
+//go:build sqlite
+// +build sqlite
+
+package factory
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/Masterminds/structable"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestCreate(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	_, err = db.Exec(`CREATE TABLE users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, email TEXT UNIQUE, role TEXT, active BOOLEAN
+	)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reg := New("sqlite3")
+	reg.Define("users", &user{Name: "Jane", Active: true}, Sequence("email", "user%d@example.com"))
+	runner := structable.NewRunner(db)
+	for i := 1; i <= 3; i++ {
+		u, err := reg.Create(runner, "users")
+		if err != nil {
+			t.Fatalf("Expected unique emails, got %s", err)
+		}
+		if u.(*user).Id != i {
+			t.Errorf("Expected ID %d, got %d", i, u.(*user).Id)
+		}
+	}
+
+	var emails string
+	if err := db.QueryRow("SELECT group_concat(email, ',') FROM users ORDER BY id").Scan(&emails); err != nil {
+		t.Fatal(err)
+	}
+	if emails != "user1@example.com,user2@example.com,user3@example.com" {
+		t.Errorf("Unexpected emails %q", emails)
+	}
+}
@@ -0,0 +1,70 @@
+package factory
+
+import (
+	"testing"
+
+	"github.com/Masterminds/structable/stest"
+)
+
+type user struct {
+	Id     int    `stbl:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Name   string `stbl:"name"`
+	Email  string `stbl:"email"`
+	Role   string `stbl:"role"`
+	Active bool   `stbl:"active"`
+}
+
+func TestBuild(t *testing.T) {
+	reg := New("mysql")
+	tmpl := &user{Name: "Jane", Active: true}
+	reg.Define("users", tmpl, Sequence("email", "user%d@example.com"))
+	reg.Define("users:admin", &user{Name: "Admin", Role: "admin"}, Sequence("name", "Admin %d"))
+	tmpl.Name = "Changed"
+
+	db := &stest.DB{}
+	a, err := reg.Build(db, "users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := reg.Build(db, "users", Set("name", "Bob"), Set("active", "false"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u := a.(*user); u.Name != "Jane" || u.Email != "user1@example.com" || !u.Active {
+		t.Errorf("Unexpected first user %+v", u)
+	}
+	if u := b.(*user); u.Name != "Bob" || u.Email != "user2@example.com" || u.Active {
+		t.Errorf("Unexpected second user %+v", u)
+	}
+	if len(db.Queries()) != 0 {
+		t.Errorf("Expected Build not to insert, got %v", db.Queries())
+	}
+
+	admin, err := reg.Create(db, "users:admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u := admin.(*user); u.Id != 1 || u.Name != "Admin 1" || u.Role != "admin" {
+		t.Errorf("Unexpected admin %+v", u)
+	}
+	stest.AssertQueries(t, db, "INSERT INTO users (name,email,role,active) VALUES (?,?,?,?)")
+
+	if _, err := reg.Build(db, "nope"); err == nil {
+		t.Error("Expected an undefined factory to fail")
+	}
+	if _, err := reg.Build(db, "users", Set("shoe_size", 7)); err == nil {
+		t.Error("Expected an unknown column to fail")
+	}
+}
+
+func TestDefault(t *testing.T) {
+	defer Default.SetFlavor("postgres")
+	Default.SetFlavor("sqlite3")
+	Define("users", &user{Name: "Jane"})
+
+	db := &stest.DB{}
+	if _, err := Create(db, "users"); err != nil {
+		t.Fatal(err)
+	}
+	stest.AssertQueries(t, db, "INSERT INTO users (name,email,role,active) VALUES (?,?,?,?)")
+}
@@ -0,0 +1,83 @@
+package structable
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestRecorderFactory(t *testing.T) {
+	db := &DBStub{}
+	tmpl := New(db, "postgres").SetLenient(true)
+	tmpl.Bind("test_table", newStool())
+	f := NewRecorderFactory(tmpl)
+
+	r := f.Get()
+	s := r.Record().(*Stool)
+	if s.Id != 0 || r.TableName() != "test_table" || !r.lenient {
+		t.Fatalf("Expected an empty Stool with the template's settings, got %+v", s)
+	}
+	s.Id, s.Id2 = 5, 6
+	if err := r.Load(); err != nil {
+		t.Fatal(err)
+	}
+	expect := "SELECT number_of_legs, material, color FROM test_table WHERE id = $1 AND id_two = $2"
+	if db.LastQueryRowSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQueryRowSql)
+	}
+
+	r.SetLenient(false)
+	f.Put(r)
+	if s.Id != 0 || s.Id2 != 0 {
+		t.Errorf("Expected Put to clear the Record, got %+v", s)
+	}
+	if r = f.Get(); r.Record().(*Stool).Id != 0 || !r.lenient {
+		t.Errorf("Expected a reset DbRecorder, got %+v", r.Record())
+	}
+
+	// Other Record types are not pooled.
+	other := New(db, "postgres").Bind("categories", &category{Id: 3}).(*DbRecorder)
+	f.Put(other)
+	if other.Record().(*category).Id != 3 {
+		t.Error("Expected a foreign DbRecorder to be left alone")
+	}
+	f.Put(nil)
+}
+
+func TestRecorderFactoryConcurrent(t *testing.T) {
+	db := &DBStub{}
+	f := NewRecorderFactory(New(db, "mysql").Bind("test_table", newStool()).(*DbRecorder))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r := f.Get()
+			defer f.Put(r)
+			if id := r.Record().(*Stool).Id; id != 0 {
+				t.Errorf("Expected an empty Record, got ID %d", id)
+			}
+			r.Record().(*Stool).Id = i
+			r.WhereIds()
+		}(i)
+	}
+	wg.Wait()
+}
+
+func BenchmarkRecorderFactory(b *testing.B) {
+	f := NewRecorderFactory(New(new(DBStub), "postgres").Bind("test_table", newStool()).(*DbRecorder))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r := f.Get()
+		r.Record().(*Stool).Id = i
+		f.Put(r)
+	}
+}
+
+func BenchmarkClone(b *testing.B) {
+	tmpl := New(new(DBStub), "postgres").Bind("test_table", newStool()).(*DbRecorder)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		tmpl.Clone(nil).Record().(*Stool).Id = i
+	}
+}
@@ -0,0 +1,98 @@
+package structable
+
+import (
+	"reflect"
+	"strings"
+)
+
+// FieldInfo describes a field of a Record, as parsed from its tags.
+type FieldInfo struct {
+	// Column is the column name, and Name the struct field name.
+	Column, Name string
+	// Type is the Go type of the field.
+	Type reflect.Type
+	// Key and Auto are set by PRIMARY_KEY and AUTO_INCREMENT.
+	Key, Auto bool
+	// Unique is set by UNIQUE.
+	Unique bool
+	// NotNull is set by NOT_NULL, and Nullable by NULLABLE.
+	NotNull, Nullable bool
+	// OmitInsert and OmitUpdate are set by OMIT_INSERT and OMIT_UPDATE, or
+	// both by READONLY.
+	OmitInsert, OmitUpdate bool
+	// Tenant is set by TENANT.
+	Tenant bool
+	// TolerateMissing is set by TOLERATE_MISSING.
+	TolerateMissing bool
+	// SqlType is the type declared with TYPE=, or empty.
+	SqlType string
+	// Default is the SQL literal declared with DEFAULT, if HasDefault is set.
+	HasDefault bool
+	Default    string
+	// Size is the length declared with SIZE, or 0.
+	Size int
+}
+
+// Fields returns the fields of the bound Record that are mapped to columns,
+// in the order they are declared.
+//
+// This is the metadata that Bind parses from the struct tags, so tools such
+// as validators and form generators do not need to parse the tags again:
+//
+//	for _, f := range r.Fields() {
+//		if !f.Key && !f.OmitUpdate {
+//			form.AddInput(f.Column, f.Type)
+//		}
+//	}
+//
+// Columns set as missing with SetMissingColumns are left out. The FieldInfos
+// are copies, so changing them does not change the DbRecorder. If no Record
+// is bound, or Bind failed, Fields returns nil.
+func (s *DbRecorder) Fields() []FieldInfo {
+	if s.bindErr != nil {
+		return nil
+	}
+	t, err := recordType(s.record)
+	if err != nil {
+		return nil
+	}
+	infos := make([]FieldInfo, len(s.fields))
+	for i, f := range s.fields {
+		sf, _ := t.FieldByName(f.name)
+		infos[i] = f.info(sf.Type)
+	}
+	return infos
+}
+
+// ParseTag parses a stbl tag, as Bind does, for tools that read tags without
+// a Record to bind, such as code generators. The Name and Type of the
+// FieldInfo are left empty.
+//
+//	f := structable.ParseTag("title,NOT_NULL,SIZE(64)")
+func ParseTag(tag string) FieldInfo {
+	parts := SplitTag(tag)
+	parts[0] = strings.TrimSpace(parts[0])
+	return parseField("", parts).info(nil)
+}
+
+// info returns the FieldInfo of a field whose Go type is t.
+func (f *field) info(t reflect.Type) FieldInfo {
+	return FieldInfo{
+		Column:          f.column,
+		Name:            f.name,
+		Type:            t,
+		Key:             f.isKey,
+		Auto:            f.isAuto,
+		Unique:          f.isUnique,
+		NotNull:         f.notNull,
+		Nullable:        f.nullable,
+		OmitInsert:      f.omitInsert,
+		OmitUpdate:      f.omitUpdate,
+		Tenant:          f.isTenant,
+		TolerateMissing: f.tolerateMissing,
+		SqlType:         f.sqlType,
+		HasDefault:      f.hasDefault,
+		Default:         f.defaultValue,
+		Size:            f.size,
+	}
+}
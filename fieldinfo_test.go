@@ -0,0 +1,60 @@
+package structable
+
+import (
+	"reflect"
+	"testing"
+)
+
+type described struct {
+	Id      int     `stbl:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Email   string  `stbl:"email,UNIQUE,NOT_NULL,SIZE(255)"`
+	Nick    *string `stbl:"nick,NULLABLE"`
+	Created string  `stbl:"created,READONLY,TYPE=timestamp,DEFAULT(now())"`
+	Skipped string
+}
+
+func TestFields(t *testing.T) {
+	var r Recorder = New(&DBStub{}, "postgres").Bind("people", &described{})
+	fields := r.Fields()
+	if len(fields) != 4 {
+		t.Fatalf("Expected 4 fields, got %+v", fields)
+	}
+	expect := []FieldInfo{
+		{Column: "id", Name: "Id", Type: reflect.TypeOf(0), Key: true, Auto: true},
+		{Column: "email", Name: "Email", Type: reflect.TypeOf(""), Unique: true, NotNull: true, Size: 255},
+		{Column: "nick", Name: "Nick", Type: reflect.TypeOf((*string)(nil)), Nullable: true},
+		{Column: "created", Name: "Created", Type: reflect.TypeOf(""), OmitInsert: true, OmitUpdate: true,
+			SqlType: "timestamp", HasDefault: true, Default: "now()"},
+	}
+	for i, f := range fields {
+		if !reflect.DeepEqual(f, expect[i]) {
+			t.Errorf("Expected %+v, got %+v", expect[i], f)
+		}
+	}
+
+	fields[0].Column = "changed"
+	if r.Fields()[0].Column != "id" {
+		t.Error("Expected Fields to return copies")
+	}
+}
+
+func TestFieldsUnbound(t *testing.T) {
+	if fields := New(&DBStub{}, "mysql").Fields(); fields != nil {
+		t.Errorf("Expected no fields before Bind, got %+v", fields)
+	}
+	r := New(&DBStub{}, "mysql")
+	r.Bind("things", nil)
+	if fields := r.Fields(); fields != nil {
+		t.Errorf("Expected no fields after a failed Bind, got %+v", fields)
+	}
+}
+
+func TestParseTag(t *testing.T) {
+	f := ParseTag(" price ,TYPE=NUMERIC(10,2),NOT_NULL,DEFAULT('0'),TOLERATE_MISSING")
+	if f.Column != "price" || f.SqlType != "NUMERIC(10,2)" || !f.NotNull || f.Default != "'0'" || !f.TolerateMissing {
+		t.Errorf("Unexpected FieldInfo %+v", f)
+	}
+	if f := ParseTag("id,PRIMARY_KEY,SERIAL"); !f.Key || !f.Auto {
+		t.Errorf("Unexpected FieldInfo %+v", f)
+	}
+}
@@ -0,0 +1,83 @@
+package structable
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+// FindOrCreate loads the Record that matches a predicate, or inserts the bound
+// Record if none does. It reports whether the Record was inserted.
+//
+// The predicate is given as for LoadWhere. If a row matches, it is loaded as by
+// LoadWhere. Otherwise, the current values of the Record are inserted:
+//
+//	u := &User{Email: email, Name: name}
+//	r.Bind("users", u)
+//	created, err := r.FindOrCreate("email = ?", email)
+//
+// Two callers may both find nothing, and both insert. To keep only one row,
+// the predicate should be backed by a UNIQUE constraint. On postgres, mysql,
+// and sqlite, the insert then ignores the conflict, with ON CONFLICT DO
+// NOTHING, INSERT IGNORE, or INSERT OR IGNORE, and the row that the other
+// caller inserted is loaded instead. Note that MySQL's INSERT IGNORE also
+// ignores some other errors, such as truncated values.
+//
+// On other flavors, the second insert fails with the constraint violation.
+// To avoid that, run FindOrCreate on a Runner made from a *sql.Tx, with an
+// isolation level that prevents the race.
+func (s *DbRecorder) FindOrCreate(pred interface{}, args ...interface{}) (bool, error) {
+	err := s.LoadWhere(pred, args...)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+	created, err := s.insertIgnore()
+	if err != nil || created {
+		return created, err
+	}
+	// Another writer inserted a matching row first.
+	return false, s.LoadWhere(pred, args...)
+}
+
+// insertIgnore inserts the Record, unless that would violate a constraint. It
+// reports whether the Record was inserted.
+func (s *DbRecorder) insertIgnore() (bool, error) {
+	defer s.clearExprs()
+	if err := s.setTenant(); err != nil {
+		return false, err
+	}
+	if err := s.validate(OpInsert); err != nil {
+		return false, err
+	}
+	if err := s.putExternal(s.fields); err != nil {
+		return false, err
+	}
+
+	cols, vals := s.colValLists(OpInsert, true, false)
+	q := s.builder.Insert(s.TableName()).Columns(cols...).Values(vals...)
+	switch s.flavor {
+	case "postgres":
+		q = q.Suffix("ON CONFLICT DO NOTHING RETURNING " + strings.Join(s.colList(true, false), ","))
+		err := s.scan(s.queryRow(OpInsert, q), true)
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return err == nil, err
+	case "mysql":
+		q = q.Options("IGNORE")
+	case "sqlite3", "sqlite":
+		q = q.Options("OR IGNORE")
+	}
+
+	ret, err := s.exec(OpInsert, q)
+	if err != nil {
+		return false, err
+	}
+	if n, err := ret.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+	if err := s.setAutos(ret); err != nil || !s.refreshAfterWrite {
+		return true, err
+	}
+	return true, s.Reload()
+}
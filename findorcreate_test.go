@@ -0,0 +1,57 @@
+package structable
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+)
+
+func TestFindOrCreate(t *testing.T) {
+	tests := map[string]string{
+		"mysql":    "INSERT IGNORE INTO categories (parent_id,name) VALUES (?,?)",
+		"sqlite3":  "INSERT OR IGNORE INTO categories (parent_id,name) VALUES (?,?)",
+		"mssql":    "INSERT INTO categories (parent_id,name) VALUES (?,?)",
+		"postgres": "INSERT INTO categories (parent_id,name) VALUES ($1,$2) ON CONFLICT DO NOTHING RETURNING id,parent_id,name",
+	}
+	for flavor, expect := range tests {
+		db := &DBStub{}
+		c := &category{Name: "tools"}
+		r := New(db, flavor)
+		r.Bind("categories", c)
+
+		// The stub finds no rows, so the record is inserted.
+		created, err := r.FindOrCreate("name = ?", "tools")
+		if err != nil || !created {
+			t.Errorf("%s: expected the record to be created, got %v", flavor, err)
+		}
+		got := db.LastExecSql
+		if flavor == "postgres" {
+			got = db.LastQueryRowSql
+		}
+		if got != expect {
+			t.Errorf("%s: expected %q, got %q", flavor, expect, got)
+		}
+		if flavor != "postgres" && c.Id != 1 {
+			t.Errorf("%s: expected the ID to be set, got %d", flavor, c.Id)
+		}
+	}
+}
+
+// noRowsStub reports a missing row with a wrapped sql.ErrNoRows, as a
+// driver wrapper might.
+type noRowsStub struct{ DBStub }
+
+func (s *noRowsStub) Query(string, ...interface{}) (*sql.Rows, error) {
+	return nil, fmt.Errorf("stub: %w", sql.ErrNoRows)
+}
+
+func TestFindOrCreateWrappedNoRows(t *testing.T) {
+	db := &noRowsStub{}
+	r := New(db, "mysql")
+	r.Bind("categories", &category{Name: "tools"})
+
+	created, err := r.FindOrCreate("name = ?", "tools")
+	if err != nil || !created {
+		t.Errorf("Expected the record to be created, got %v", err)
+	}
+}
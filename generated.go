@@ -0,0 +1,88 @@
+package structable
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Generated is implemented by Records with methods generated by the
+// structable-gen command. A DbRecorder bound to such a Record calls these
+// methods for Columns, FieldReferences, WhereIds, and the values written by
+// Insert and Update, instead of reading the Record with reflection.
+//
+//	//go:generate structable-gen -t User
+//	type User struct {
+//		Id   int    `stbl:"id,PRIMARY_KEY,SERIAL"`
+//		Name string `stbl:"name"`
+//	}
+//
+// The methods are only used if StructableSignature matches the fields that
+// Bind found, so methods generated from outdated tags, or for other tag keys
+// or another NamingStrategy, are ignored rather than trusted. They are also
+// not used while some columns are left out, as by SetMissingColumns or
+// ColumnFilter, or for Records with COMPRESSED or EXTERNAL fields.
+type Generated interface {
+	// StructableSignature describes the fields the methods were generated
+	// for.
+	StructableSignature() string
+	// StructableColumns is Columns.
+	StructableColumns(withKeys bool) []string
+	// StructableFieldReferences is FieldReferences.
+	StructableFieldReferences(withKeys bool) []interface{}
+	// StructableColValLists returns the columns and values to write, for an
+	// insert or an update.
+	StructableColValLists(insert, withKeys, withAutos bool) ([]string, []interface{})
+	// StructableWhereIds is WhereIds.
+	StructableWhereIds() map[string]interface{}
+}
+
+// generated returns the bound Record's generated methods, if they can be used
+// for the fields that are bound now.
+func (s *DbRecorder) generated() Generated {
+	if s.gen == 0 || len(s.fields) != s.gen {
+		return nil
+	}
+	g, _ := s.record.(Generated)
+	return g
+}
+
+// generatedFits reports whether the generated methods of a Record type, if it
+// has them, match its parsed fields.
+func generatedFits(t reflect.Type, fields []*field) bool {
+	g, ok := reflect.New(t).Interface().(Generated)
+	if !ok || len(fields) == 0 {
+		return false
+	}
+	for _, f := range fields {
+		if f.isCompressed || f.isExternal {
+			return false
+		}
+	}
+	return g.StructableSignature() == fieldSignature(fields)
+}
+
+// fieldSignature describes the fields and options that the generated methods
+// depend on. structable-gen writes the same description.
+func fieldSignature(fields []*field) string {
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		p := []string{f.name + ":" + f.column}
+		for _, o := range []struct {
+			on   bool
+			name string
+		}{
+			{f.isKey, "PRIMARY_KEY"},
+			{f.isAuto, "AUTO_INCREMENT"},
+			{f.omitInsert, "OMIT_INSERT"},
+			{f.omitUpdate, "OMIT_UPDATE"},
+			{f.hasDefault, "DEFAULT"},
+			{f.nullable, "NULLABLE"},
+		} {
+			if o.on {
+				p = append(p, o.name)
+			}
+		}
+		parts[i] = strings.Join(p, " ")
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,95 @@
+// Code generated by structable-gen. DO NOT EDIT.
+
+package structable
+
+import (
+	"time"
+)
+
+// StructableSignature describes the fields that the methods of gadget were
+// generated for.
+func (x *gadget) StructableSignature() string {
+	return "Id:id PRIMARY_KEY AUTO_INCREMENT,Region:region PRIMARY_KEY,Name:name,Color:color,Note:note NULLABLE,Status:status DEFAULT,Made:made NULLABLE,Alias:alias,Revision:revision OMIT_INSERT OMIT_UPDATE,CreatedAt:created_at OMIT_UPDATE"
+}
+
+// StructableColumns returns the columns of gadget.
+func (x *gadget) StructableColumns(withKeys bool) []string {
+	if withKeys {
+		return []string{"id", "region", "name", "color", "note", "status", "made", "alias", "revision", "created_at"}
+	}
+	return []string{"name", "color", "note", "status", "made", "alias", "revision", "created_at"}
+}
+
+// StructableFieldReferences returns references to the fields of gadget.
+func (x *gadget) StructableFieldReferences(withKeys bool) []interface{} {
+	refs := make([]interface{}, 0, 10)
+	if withKeys {
+		refs = append(refs, &x.Id)
+	}
+	if withKeys {
+		refs = append(refs, &x.Region)
+	}
+	refs = append(refs, &x.Name)
+	if x.Color == nil {
+		x.Color = new(string)
+	}
+	refs = append(refs, x.Color)
+	refs = append(refs, &x.Note)
+	refs = append(refs, &x.Status)
+	refs = append(refs, &x.Made)
+	refs = append(refs, &x.Alias)
+	refs = append(refs, &x.Revision)
+	refs = append(refs, &x.CreatedAt)
+	return refs
+}
+
+// StructableColValLists returns the columns and values of gadget to write.
+func (x *gadget) StructableColValLists(insert, withKeys, withAutos bool) ([]string, []interface{}) {
+	cols := make([]string, 0, 10)
+	vals := make([]interface{}, 0, 10)
+	if withKeys && withAutos {
+		cols = append(cols, "id")
+		vals = append(vals, x.Id)
+	}
+	if withKeys {
+		cols = append(cols, "region")
+		vals = append(vals, x.Region)
+	}
+	cols = append(cols, "name")
+	vals = append(vals, x.Name)
+	if x.Color != nil {
+		cols = append(cols, "color")
+		vals = append(vals, x.Color)
+	}
+	cols = append(cols, "note")
+	if x.Note == "" {
+		vals = append(vals, nil)
+	} else {
+		vals = append(vals, x.Note)
+	}
+	if !insert || x.Status != "" {
+		cols = append(cols, "status")
+		vals = append(vals, x.Status)
+	}
+	cols = append(cols, "made")
+	if x.Made == *new(time.Time) {
+		vals = append(vals, nil)
+	} else {
+		vals = append(vals, x.Made)
+	}
+	cols = append(cols, "alias")
+	vals = append(vals, x.Alias)
+	if insert {
+		cols = append(cols, "created_at")
+		vals = append(vals, x.CreatedAt)
+	}
+	return cols, vals
+}
+
+// StructableWhereIds returns the primary key of gadget.
+func (x *gadget) StructableWhereIds() map[string]interface{} {
+	return map[string]interface{}{
+		"id":     x.Id,
+		"region": x.Region,
+	}
+}
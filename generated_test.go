@@ -0,0 +1,132 @@
+package structable
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+//go:generate go run ./structable-gen -t gadget -o generated_gadget_test.go generated_test.go
+
+// gadget has methods generated by structable-gen, in
+// generated_gadget_test.go.
+type gadget struct {
+	Id        int            `stbl:"id,PRIMARY_KEY,SERIAL"`
+	Region    string         `stbl:"region,PRIMARY_KEY"`
+	Name      string         `stbl:"name,NOT_NULL,SIZE(20)"`
+	Color     *string        `stbl:"color"`
+	Note      string         `stbl:"note,NULLABLE"`
+	Status    string         `stbl:"status,DEFAULT('new')"`
+	Made      time.Time      `stbl:"made,NULLABLE"`
+	Alias     sql.NullString `stbl:"alias"`
+	Revision  int            `stbl:"revision,READONLY"`
+	CreatedAt time.Time      `stbl:"created_at,OMIT_UPDATE"`
+	Ignored   string
+}
+
+// staleGadget has generated methods whose signature does not match its tags.
+type staleGadget struct {
+	Id   int    `stbl:"id,PRIMARY_KEY"`
+	Name string `stbl:"title"`
+}
+
+func (x *staleGadget) StructableSignature() string { return "Id:id PRIMARY_KEY,Name:name" }
+func (x *staleGadget) StructableColumns(bool) []string {
+	return []string{"id", "name"}
+}
+func (x *staleGadget) StructableFieldReferences(bool) []interface{} { return nil }
+func (x *staleGadget) StructableColValLists(bool, bool, bool) ([]string, []interface{}) {
+	return nil, nil
+}
+func (x *staleGadget) StructableWhereIds() map[string]interface{} { return nil }
+
+func TestGenerated(t *testing.T) {
+	color := "red"
+	made := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	records := []*gadget{
+		{},
+		{Id: 1, Region: "eu", Name: "lamp", Color: &color, Note: "n", Status: "used", Made: made,
+			Alias: sql.NullString{String: "l", Valid: true}, Revision: 3, CreatedAt: made},
+	}
+	db := &DBStub{}
+	for _, g := range records {
+		r := New(db, "postgres")
+		r.Bind("gadgets", g)
+		if r.generated() == nil {
+			t.Fatal("Expected the generated methods to be used")
+		}
+		refl := r.Clone(g)
+		refl.gen = 0
+
+		for _, keys := range []bool{true, false} {
+			if a, b := r.Columns(keys), refl.Columns(keys); !reflect.DeepEqual(a, b) {
+				t.Errorf("Columns(%t): generated %v, reflected %v", keys, a, b)
+			}
+			if a, b := r.FieldReferences(keys), refl.FieldReferences(keys); !reflect.DeepEqual(a, b) {
+				t.Errorf("FieldReferences(%t): generated %v, reflected %v", keys, a, b)
+			}
+		}
+		if a, b := r.WhereIds(), refl.WhereIds(); !reflect.DeepEqual(a, b) {
+			t.Errorf("WhereIds: generated %v, reflected %v", a, b)
+		}
+		for _, args := range []struct {
+			op              string
+			withKeys, autos bool
+		}{{OpInsert, true, false}, {OpInsert, true, true}, {OpUpdate, false, true}} {
+			ac, av := r.colValLists(args.op, args.withKeys, args.autos)
+			bc, bv := refl.colValLists(args.op, args.withKeys, args.autos)
+			if !reflect.DeepEqual(ac, bc) || !reflect.DeepEqual(av, bv) {
+				t.Errorf("colValLists%v: generated %v %v, reflected %v %v", args, ac, av, bc, bv)
+			}
+		}
+	}
+
+	// Expressions are only written by the reflective path.
+	r := New(db, "postgres")
+	r.Bind("gadgets", &gadget{Name: "lamp"})
+	r.SetExpr("status", "'fresh'")
+	if err := r.Insert(); err != nil {
+		t.Fatal(err)
+	}
+	expect := "INSERT INTO gadgets (region,name,note,status,made,alias,created_at) VALUES ($1,$2,$3,'fresh',$4,$5,$6) RETURNING id,region,name,color,note,status,made,alias,revision,created_at"
+	if db.LastQueryRowSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQueryRowSql)
+	}
+
+	// Columns left out, as by SetMissingColumns, are not known to the
+	// generated methods.
+	c := r.Clone(nil)
+	c.fields = c.fields[:3]
+	if c.generated() != nil {
+		t.Error("Expected a DbRecorder with fewer fields not to use the generated methods")
+	}
+
+	s := New(db, "postgres")
+	s.Bind("stale", &staleGadget{})
+	if s.generated() != nil {
+		t.Error("Expected outdated generated methods to be ignored")
+	}
+	if cols := s.Columns(true); !reflect.DeepEqual(cols, []string{"id", "title"}) {
+		t.Errorf("Expected the tags to be read, got %v", cols)
+	}
+}
+
+func BenchmarkColValListsGenerated(b *testing.B) {
+	r := New(new(DBStub), "postgres")
+	r.Bind("gadgets", &gadget{Id: 1, Region: "eu", Name: "lamp"})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r.colValLists(OpUpdate, false, true)
+	}
+}
+
+func BenchmarkColValListsReflect(b *testing.B) {
+	r := New(new(DBStub), "postgres")
+	r.Bind("gadgets", &gadget{Id: 1, Region: "eu", Name: "lamp"})
+	r.gen = 0
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r.colValLists(OpUpdate, false, true)
+	}
+}
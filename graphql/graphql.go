@@ -0,0 +1,515 @@
+/*
+Package graphql stands up GraphQL queries for Records: it writes the schema of
+a Record type from its structable tags, and resolves the queries of that
+schema with a DbRecorder.
+
+The schema is meant for gqlgen. Its type and field names follow the Go names
+of the struct, so gqlgen binds the type to the struct itself, and only the
+Query resolvers need to be written:
+
+	users := graphql.New(structable.New(db, "postgres").Bind("users", &User{}))
+	os.WriteFile("graph/users.graphqls", []byte(graphql.Schema(users)), 0644)
+
+For a User struct with Id, Name, and Age fields, the schema has:
+
+	type User {
+		id: ID!
+		name: String!
+		age: Int
+	}
+
+	input UserFilter {
+		id: ID
+		idNe: ID
+		idIn: [ID!]
+		...
+	}
+
+	type Query {
+		user(id: ID!): User
+		users(filter: UserFilter, limit: Int, offset: Int, orderBy: [String!]): [User!]!
+	}
+
+With UserFilter bound to map[string]interface{} in gqlgen.yml, the resolvers
+are:
+
+	func (r *queryResolver) User(ctx context.Context, id string) (*User, error) {
+		rec, err := r.Users.Get(ctx, id)
+		if rec == nil {
+			return nil, err
+		}
+		return rec.(*User), nil
+	}
+
+	func (r *queryResolver) Users(ctx context.Context, filter map[string]interface{},
+		limit, offset *int, orderBy []string) ([]*User, error) {
+		recs, err := r.Users.List(ctx, graphql.ListArgs{Filter: filter, Limit: limit, Offset: offset, OrderBy: orderBy})
+		users := make([]*User, len(recs))
+		for i, rec := range recs {
+			users[i] = rec.(*User)
+		}
+		return users, err
+	}
+*/
+package graphql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Masterminds/structable"
+)
+
+// Resolver resolves the queries of a Record type.
+//
+// A Resolver is safe for concurrent use, as long as its prototype is not
+// changed.
+type Resolver struct {
+	proto  *structable.DbRecorder
+	name   string
+	fields []gqlField
+}
+
+// gqlField is a field of the Record that is in the schema.
+type gqlField struct {
+	structable.FieldInfo
+	// name is the GraphQL field name, and typ the GraphQL type, without "!".
+	name, typ string
+	nullable  bool
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// New creates a Resolver for the Record bound to r. The queries are run on
+// clones of r's DbRecorder, so they have its database, flavor, and other
+// settings. If r was made by Wrap, its middleware is not run.
+//
+// The type name is the name of the Record's struct type, or the table name,
+// in CamelCase, for a struct without a name, such as a DynamicRecord.
+func New(r structable.Recorder) *Resolver {
+	proto := dbRecorder(r)
+	t := reflect.Indirect(reflect.ValueOf(proto.Record())).Type()
+	name := t.Name()
+	if name == "" {
+		name = camel(proto.TableName())
+	}
+	res := &Resolver{proto: proto, name: name}
+	for _, f := range proto.Fields() {
+		typ, nullable, ok := gqlType(f)
+		if !ok {
+			continue
+		}
+		res.fields = append(res.fields, gqlField{FieldInfo: f, name: lowerCamel(f.Name), typ: typ, nullable: nullable})
+	}
+	return res
+}
+
+// dbRecorder returns the DbRecorder of r, looking through Wrap.
+func dbRecorder(r structable.Recorder) *structable.DbRecorder {
+	for {
+		if dr, ok := r.(*structable.DbRecorder); ok {
+			return dr
+		}
+		w, ok := r.(interface{ Unwrap() structable.Recorder })
+		if !ok {
+			break
+		}
+		r = w.Unwrap()
+	}
+	dr := structable.New(r.DB(), r.Driver())
+	dr.Bind(r.TableName(), r.Record())
+	return dr
+}
+
+// SetName sets the GraphQL type name. The query names are made from it.
+func (res *Resolver) SetName(name string) *Resolver {
+	res.name = name
+	return res
+}
+
+// Name returns the GraphQL type name.
+func (res *Resolver) Name() string {
+	return res.name
+}
+
+// gqlType returns the GraphQL type of a field, and whether it may be null.
+// Fields of types that GraphQL has no scalar for are left out.
+func gqlType(f structable.FieldInfo) (string, bool, bool) {
+	t := f.Type
+	nullable := f.Nullable
+	if t.Kind() == reflect.Ptr {
+		t, nullable = t.Elem(), true
+	}
+	var typ string
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		typ = "Int"
+	case reflect.Float32, reflect.Float64:
+		typ = "Float"
+	case reflect.String:
+		typ = "String"
+	case reflect.Bool:
+		typ = "Boolean"
+	case reflect.Slice:
+		if t.Elem().Kind() != reflect.Uint8 {
+			return "", false, false
+		}
+		typ, nullable = "String", true
+	case reflect.Struct:
+		if t != timeType {
+			return "", false, false
+		}
+		typ = "Time"
+	default:
+		return "", false, false
+	}
+	if f.Key && typ != "Float" && typ != "Boolean" {
+		typ = "ID"
+	}
+	return typ, nullable, true
+}
+
+// queryNames returns the names of the load-by-key and list queries.
+func (res *Resolver) queryNames() (string, string) {
+	one := lowerCamel(res.name)
+	return one, plural(one)
+}
+
+// keyFields returns the key fields that are in the schema.
+func (res *Resolver) keyFields() []gqlField {
+	var keys []gqlField
+	for _, f := range res.fields {
+		if f.Key {
+			keys = append(keys, f)
+		}
+	}
+	return keys
+}
+
+// Schema returns the GraphQL schema of the Resolvers' types, with a Query
+// type that has a load-by-key and a list query for each.
+//
+// The load-by-key query takes the key fields as arguments, and is left out
+// for types without a key. The list query takes a filter, and the limit,
+// offset, and orderBy arguments of ListArgs. If any type has a time.Time
+// field, the Time scalar is declared, which gqlgen maps to time.Time.
+func Schema(resolvers ...*Resolver) string {
+	var b, query strings.Builder
+	for _, res := range resolvers {
+		if res.hasTime() {
+			b.WriteString("scalar Time\n\n")
+			break
+		}
+	}
+	for _, res := range resolvers {
+		res.writeTypes(&b)
+		res.writeQueries(&query)
+	}
+	b.WriteString("type Query {\n")
+	b.WriteString(query.String())
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// hasTime reports whether any field is a Time.
+func (res *Resolver) hasTime() bool {
+	for _, f := range res.fields {
+		if f.typ == "Time" {
+			return true
+		}
+	}
+	return false
+}
+
+// writeTypes writes the object type and its filter input type.
+func (res *Resolver) writeTypes(b *strings.Builder) {
+	fmt.Fprintf(b, "type %s {\n", res.name)
+	for _, f := range res.fields {
+		bang := "!"
+		if f.nullable {
+			bang = ""
+		}
+		fmt.Fprintf(b, "\t%s: %s%s\n", f.name, f.typ, bang)
+	}
+	b.WriteString("}\n\n")
+
+	fmt.Fprintf(b, "input %sFilter {\n", res.name)
+	for _, f := range res.fields {
+		for _, op := range ops(f) {
+			typ := f.typ
+			switch op.suffix {
+			case "In":
+				typ = "[" + typ + "!]"
+			case "IsNull":
+				typ = "Boolean"
+			}
+			fmt.Fprintf(b, "\t%s%s: %s\n", f.name, op.suffix, typ)
+		}
+	}
+	b.WriteString("}\n\n")
+}
+
+// writeQueries writes the fields of the Query type.
+func (res *Resolver) writeQueries(b *strings.Builder) {
+	one, many := res.queryNames()
+	if keys := res.keyFields(); len(keys) > 0 {
+		args := make([]string, len(keys))
+		for i, f := range keys {
+			args[i] = fmt.Sprintf("%s: %s!", f.name, f.typ)
+		}
+		fmt.Fprintf(b, "\t%s(%s): %s\n", one, strings.Join(args, ", "), res.name)
+	}
+	fmt.Fprintf(b, "\t%s(filter: %sFilter, limit: Int, offset: Int, orderBy: [String!]): [%s!]!\n", many, res.name, res.name)
+}
+
+// filterOp is a comparison of a filter. The filter key is the field name
+// followed by the suffix.
+type filterOp struct {
+	suffix string
+	spec   func(column string, v interface{}) structable.Spec
+}
+
+var (
+	opEq  = filterOp{"", structable.Eq}
+	opNe  = filterOp{"Ne", structable.NotEq}
+	opIn  = filterOp{"In", structable.In}
+	opLt  = filterOp{"Lt", structable.Lt}
+	opLte = filterOp{"Lte", structable.LtOrEq}
+	opGt  = filterOp{"Gt", structable.Gt}
+	opGte = filterOp{"Gte", structable.GtOrEq}
+	// opIsNull takes a Boolean, which says whether the column must be NULL.
+	opIsNull = filterOp{"IsNull", func(column string, v interface{}) structable.Spec {
+		if v == true {
+			return structable.IsNull(column)
+		}
+		return structable.Not(structable.IsNull(column))
+	}}
+)
+
+// ops returns the comparisons that a field can be filtered with.
+func ops(f gqlField) []filterOp {
+	list := []filterOp{opEq, opNe}
+	if f.typ != "Boolean" {
+		list = append(list, opIn)
+	}
+	if f.typ != "Boolean" && f.typ != "ID" {
+		list = append(list, opLt, opLte, opGt, opGte)
+	}
+	if f.nullable {
+		list = append(list, opIsNull)
+	}
+	return list
+}
+
+// ListArgs are the arguments of a list query.
+type ListArgs struct {
+	// Filter holds the fields of the filter input, keyed by their GraphQL
+	// names, such as "age" or "ageGte". Null values are ignored.
+	Filter map[string]interface{}
+	// Limit and Offset page through the Records, if they are set.
+	Limit, Offset *int
+	// OrderBy holds GraphQL field names to sort by, each with a leading "-"
+	// to sort in descending order.
+	OrderBy []string
+}
+
+// Get loads the Record with a key, given in the order of the key fields in
+// the schema. If there is no such Record, it returns nil and no error, as a
+// GraphQL query of a nullable type does.
+func (res *Resolver) Get(ctx context.Context, key ...interface{}) (structable.Record, error) {
+	r := res.proto.Clone(nil).SetContext(ctx)
+	err := r.LoadByKey(key...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.Record(), nil
+}
+
+// List lists the Records that match the arguments.
+func (res *Resolver) List(ctx context.Context, args ListArgs) ([]structable.Record, error) {
+	fn, err := res.Where(args)
+	if err != nil {
+		return nil, err
+	}
+	items, err := structable.ListWhere(res.proto.Clone(nil).SetContext(ctx), fn)
+	if err != nil {
+		return nil, err
+	}
+	recs := make([]structable.Record, len(items))
+	for i, item := range items {
+		recs[i] = item.Record()
+	}
+	return recs, nil
+}
+
+// Where maps the arguments of a list query to a WhereFunc, for callers that
+// run the query themselves, such as to add conditions of their own.
+//
+// Filter values are converted to the types of their fields, so IDs given as
+// strings match integer keys. An error is returned for unknown filter keys
+// and orderBy fields, and for negative limits and offsets.
+func (res *Resolver) Where(args ListArgs) (structable.WhereFunc, error) {
+	var specs []structable.Spec
+	known := 0
+	for _, f := range res.fields {
+		for _, op := range ops(f) {
+			v, ok := args.Filter[f.name+op.suffix]
+			if !ok {
+				continue
+			}
+			known++
+			if v == nil {
+				continue
+			}
+			v, err := res.convert(f, op, v)
+			if err != nil {
+				return nil, fmt.Errorf("filter %s%s: %w", f.name, op.suffix, err)
+			}
+			specs = append(specs, op.spec(f.Column, v))
+		}
+	}
+	if known < len(args.Filter) {
+		for k := range args.Filter {
+			if !res.isFilterKey(k) {
+				return nil, fmt.Errorf("unknown filter %q on %s", k, res.name)
+			}
+		}
+	}
+
+	fns := []structable.WhereFunc{structable.WithSpec(structable.And(specs...))}
+	if len(args.OrderBy) > 0 {
+		cols := make([]string, len(args.OrderBy))
+		for i, name := range args.OrderBy {
+			dir := ""
+			if strings.HasPrefix(name, "-") {
+				name, dir = name[1:], " DESC"
+			}
+			f, ok := res.field(name)
+			if !ok {
+				return nil, fmt.Errorf("cannot order %s by unknown field %q", res.name, name)
+			}
+			cols[i] = f.Column + dir
+		}
+		fns = append(fns, structable.WithOrderBy(cols...))
+	}
+	if args.Limit != nil {
+		if *args.Limit < 0 {
+			return nil, fmt.Errorf("limit must not be negative, got %d", *args.Limit)
+		}
+		fns = append(fns, structable.WithLimit(uint64(*args.Limit)))
+	}
+	if args.Offset != nil {
+		if *args.Offset < 0 {
+			return nil, fmt.Errorf("offset must not be negative, got %d", *args.Offset)
+		}
+		fns = append(fns, structable.WithOffset(uint64(*args.Offset)))
+	}
+	return structable.Compose(fns...), nil
+}
+
+// field returns the field with a GraphQL name.
+func (res *Resolver) field(name string) (gqlField, bool) {
+	for _, f := range res.fields {
+		if f.name == name {
+			return f, true
+		}
+	}
+	return gqlField{}, false
+}
+
+// isFilterKey reports whether k is a key of the filter input type.
+func (res *Resolver) isFilterKey(k string) bool {
+	for _, f := range res.fields {
+		for _, op := range ops(f) {
+			if k == f.name+op.suffix {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// convert converts a filter value to the type of its field. The values of
+// In are converted one by one, and IsNull takes a bool as it is.
+func (res *Resolver) convert(f gqlField, op filterOp, v interface{}) (interface{}, error) {
+	switch op.suffix {
+	case opIsNull.suffix:
+		if _, ok := v.(bool); !ok {
+			return nil, fmt.Errorf("want a Boolean, got %T", v)
+		}
+		return v, nil
+	case opIn.suffix:
+		list := reflect.ValueOf(v)
+		if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+			return nil, fmt.Errorf("want a list, got %T", v)
+		}
+		vals := make([]interface{}, list.Len())
+		for i := range vals {
+			var err error
+			if vals[i], err = res.convertValue(f, list.Index(i).Interface()); err != nil {
+				return nil, err
+			}
+		}
+		return vals, nil
+	}
+	return res.convertValue(f, v)
+}
+
+// convertValue converts a value to the type of a field, as by
+// DbRecorder.SetValues, with pointers followed.
+func (res *Resolver) convertValue(f gqlField, v interface{}) (interface{}, error) {
+	scratch := res.proto.Clone(nil)
+	if err := scratch.SetValues(map[string]interface{}{f.Column: v}); err != nil {
+		return nil, err
+	}
+	return reflect.Indirect(reflect.ValueOf(scratch.Values()[f.Column])).Interface(), nil
+}
+
+// lowerCamel lowers the leading capitals of a Go name, keeping the last one
+// of an initialism that starts a word, as in "ID" to "id", "DtRelease" to
+// "dtRelease", and "URLPath" to "urlPath".
+func lowerCamel(s string) string {
+	r := []rune(s)
+	for i := range r {
+		if !unicode.IsUpper(r[i]) {
+			break
+		}
+		if i > 0 && i+1 < len(r) && unicode.IsLower(r[i+1]) {
+			break
+		}
+		r[i] = unicode.ToLower(r[i])
+	}
+	return string(r)
+}
+
+// camel turns a snake_case name, such as a table name, into CamelCase.
+func camel(s string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return r == '_' || r == '.' }) {
+		r := []rune(part)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+	return b.String()
+}
+
+// plural makes an English plural, well enough for type names.
+func plural(s string) string {
+	switch {
+	case strings.HasSuffix(s, "y") && len(s) > 1 && !strings.ContainsRune("aeiou", rune(s[len(s)-2])):
+		return s[:len(s)-1] + "ies"
+	case strings.HasSuffix(s, "s"), strings.HasSuffix(s, "x"), strings.HasSuffix(s, "ch"), strings.HasSuffix(s, "sh"):
+		return s + "es"
+	}
+	return s + "s"
+}
@@ -0,0 +1,65 @@
+//go:build sqlite
+// +build sqlite
+
+package graphql
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/Masterminds/structable"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type product struct {
+	Id    int     `stbl:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Name  string  `stbl:"name"`
+	Price float64 `stbl:"price"`
+	Note  string  `stbl:"note,NULLABLE"`
+}
+
+func TestResolve(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	_, err = db.Exec(`CREATE TABLE products (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, price REAL, note TEXT);
+		INSERT INTO products (name, price, note) VALUES ('boots', 90, 'waterproof'), ('socks', 5, NULL), ('hat', 20, NULL)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := New(structable.New(structable.NewRunner(db), "sqlite3").Bind("products", &product{}))
+	ctx := context.Background()
+
+	rec, err := res.Get(ctx, "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := rec.(*product); p.Name != "socks" || p.Note != "" {
+		t.Errorf("Unexpected product %+v", p)
+	}
+	if rec, err := res.Get(ctx, "99"); rec != nil || err != nil {
+		t.Errorf("Expected a missing product to be nil, got %v, %v", rec, err)
+	}
+
+	limit := 1
+	recs, err := res.List(ctx, ListArgs{
+		Filter:  map[string]interface{}{"priceGt": 10, "noteIsNull": true},
+		OrderBy: []string{"-price"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 1 || recs[0].(*product).Name != "hat" {
+		t.Errorf("Expected the hat, got %+v", recs)
+	}
+	recs, err = res.List(ctx, ListArgs{Filter: map[string]interface{}{"idIn": []interface{}{"1", "3"}}, OrderBy: []string{"name"}, Limit: &limit})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 1 || recs[0].(*product).Name != "boots" {
+		t.Errorf("Expected the boots, got %+v", recs)
+	}
+}
@@ -0,0 +1,195 @@
+package graphql
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/Masterminds/structable"
+	"github.com/Masterminds/structable/stest"
+)
+
+type Category struct {
+	ID       int       `stbl:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Name     string    `stbl:"name"`
+	Rank     float64   `stbl:"rank"`
+	Hidden   bool      `stbl:"hidden"`
+	ParentID *int      `stbl:"parent_id"`
+	Created  time.Time `stbl:"created_at"`
+	Tags     []string  `stbl:"tags"`
+	Note     string    `stbl:"note,NULLABLE"`
+}
+
+type lineItem struct {
+	OrderID int `stbl:"order_id,PRIMARY_KEY"`
+	LineNo  int `stbl:"line_no,PRIMARY_KEY"`
+	Qty     int `stbl:"qty"`
+}
+
+func TestSchema(t *testing.T) {
+	db := &stest.DB{}
+	cats := New(structable.New(db, "mysql").Bind("categories", &Category{}))
+	lines := New(structable.New(db, "mysql").Bind("line_items", &lineItem{})).SetName("LineItem")
+
+	expect := `scalar Time
+
+type Category {
+	id: ID!
+	name: String!
+	rank: Float!
+	hidden: Boolean!
+	parentID: Int
+	created: Time!
+	note: String
+}
+
+input CategoryFilter {
+	id: ID
+	idNe: ID
+	idIn: [ID!]
+	name: String
+	nameNe: String
+	nameIn: [String!]
+	nameLt: String
+	nameLte: String
+	nameGt: String
+	nameGte: String
+	rank: Float
+	rankNe: Float
+	rankIn: [Float!]
+	rankLt: Float
+	rankLte: Float
+	rankGt: Float
+	rankGte: Float
+	hidden: Boolean
+	hiddenNe: Boolean
+	parentID: Int
+	parentIDNe: Int
+	parentIDIn: [Int!]
+	parentIDLt: Int
+	parentIDLte: Int
+	parentIDGt: Int
+	parentIDGte: Int
+	parentIDIsNull: Boolean
+	created: Time
+	createdNe: Time
+	createdIn: [Time!]
+	createdLt: Time
+	createdLte: Time
+	createdGt: Time
+	createdGte: Time
+	note: String
+	noteNe: String
+	noteIn: [String!]
+	noteLt: String
+	noteLte: String
+	noteGt: String
+	noteGte: String
+	noteIsNull: Boolean
+}
+
+type LineItem {
+	orderID: ID!
+	lineNo: ID!
+	qty: Int!
+}
+
+input LineItemFilter {
+	orderID: ID
+	orderIDNe: ID
+	orderIDIn: [ID!]
+	lineNo: ID
+	lineNoNe: ID
+	lineNoIn: [ID!]
+	qty: Int
+	qtyNe: Int
+	qtyIn: [Int!]
+	qtyLt: Int
+	qtyLte: Int
+	qtyGt: Int
+	qtyGte: Int
+}
+
+type Query {
+	category(id: ID!): Category
+	categories(filter: CategoryFilter, limit: Int, offset: Int, orderBy: [String!]): [Category!]!
+	lineItem(orderID: ID!, lineNo: ID!): LineItem
+	lineItems(filter: LineItemFilter, limit: Int, offset: Int, orderBy: [String!]): [LineItem!]!
+}
+`
+	if got := Schema(cats, lines); got != expect {
+		t.Errorf("Unexpected schema:\n%s", got)
+	}
+	if strings.Contains(Schema(lines), "scalar Time") {
+		t.Error("Expected no Time scalar without time fields")
+	}
+}
+
+func TestWhere(t *testing.T) {
+	cats := New(structable.New(&stest.DB{}, "mysql").Bind("categories", &Category{}))
+	limit, offset := 10, 20
+	fn, err := cats.Where(ListArgs{
+		Filter: map[string]interface{}{
+			"idIn":           []interface{}{"1", "2"},
+			"name":           "shoes",
+			"rankGte":        1.5,
+			"parentIDIsNull": true,
+			"hidden":         nil,
+		},
+		Limit:   &limit,
+		Offset:  &offset,
+		OrderBy: []string{"-rank", "name"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := structable.New(&stest.DB{}, "mysql").Bind("categories", &Category{})
+	q, err := fn(r, squirrel.Select("id").From("categories"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sql, args, err := q.ToSql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "SELECT id FROM categories WHERE (id IN (?,?) AND name = ? AND rank >= ? AND parent_id IS NULL) ORDER BY rank DESC, name LIMIT 10 OFFSET 20"
+	if sql != expect {
+		t.Errorf("Expected %s, got %s", expect, sql)
+	}
+	if want := []interface{}{1, 2, "shoes", 1.5}; !reflect.DeepEqual(args, want) {
+		t.Errorf("Expected args %v, got %v", want, args)
+	}
+
+	bad := []ListArgs{
+		{Filter: map[string]interface{}{"shoeSize": 7}},
+		{Filter: map[string]interface{}{"hiddenLt": true}},
+		{Filter: map[string]interface{}{"idIn": "1"}},
+		{Filter: map[string]interface{}{"id": "one"}},
+		{OrderBy: []string{"-tags"}},
+		{Limit: new(int)},
+	}
+	*bad[len(bad)-1].Limit = -1
+	for _, args := range bad {
+		if _, err := cats.Where(args); err == nil {
+			t.Errorf("Expected %+v to fail", args)
+		}
+	}
+}
+
+func TestNames(t *testing.T) {
+	for in, out := range map[string]string{"ID": "id", "DtRelease": "dtRelease", "URLPath": "urlPath", "Name": "name", "x": "x"} {
+		if got := lowerCamel(in); got != out {
+			t.Errorf("lowerCamel(%s): expected %s, got %s", in, out, got)
+		}
+	}
+	for in, out := range map[string]string{"user": "users", "category": "categories", "day": "days", "box": "boxes", "status": "statuses"} {
+		if got := plural(in); got != out {
+			t.Errorf("plural(%s): expected %s, got %s", in, out, got)
+		}
+	}
+	if got := camel("line_items"); got != "LineItems" {
+		t.Errorf("Expected LineItems, got %s", got)
+	}
+}
@@ -0,0 +1,97 @@
+package structable
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Operation is one statement in a DbRecorder's History.
+type Operation struct {
+	// Op is the operation, such as OpLoad or OpInsert.
+	Op string
+	// Query is the statement, as it was sent to the database.
+	Query string
+	// Args are the types of the statement's arguments. The values
+	// themselves are not kept, so that a History can be logged safely.
+	Args []string
+	// At is when the statement started, and Duration is how long it took.
+	At       time.Time
+	Duration time.Duration
+	// Err is the error the statement returned, if any.
+	Err error
+}
+
+func (o Operation) String() string {
+	s := fmt.Sprintf("%s %s %s %v (%s)", o.At.Format(time.RFC3339Nano), o.Op, o.Query, o.Args, o.Duration)
+	if o.Err != nil {
+		s += ": " + o.Err.Error()
+	}
+	return s
+}
+
+// history is a ring buffer of the most recent Operations.
+type history struct {
+	mu   sync.Mutex
+	ops  []Operation
+	next int
+	full bool
+}
+
+// SetHistory keeps the last n statements that this DbRecorder runs, for
+// debugging. Pass 0 to stop keeping them.
+//
+// The History is shared with copies of the DbRecorder, such as those made by
+// Clone and List, so it covers everything done on behalf of one Record:
+//
+//	r := NewUser(db, "postgres").SetHistory(20)
+//	if err := handle(r); err != nil {
+//		for _, op := range r.History() {
+//			log.Println(op)
+//		}
+//	}
+//
+// Argument values are redacted: only their types are kept.
+func (s *DbRecorder) SetHistory(n int) *DbRecorder {
+	s.history = nil
+	if n > 0 {
+		s.history = &history{ops: make([]Operation, n)}
+	}
+	return s
+}
+
+// History returns the statements that this DbRecorder ran most recently,
+// oldest first, or nil if SetHistory was not called.
+func (s *DbRecorder) History() []Operation {
+	h := s.history
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.full {
+		return append([]Operation{}, h.ops[:h.next]...)
+	}
+	return append(append([]Operation{}, h.ops[h.next:]...), h.ops[:h.next]...)
+}
+
+// record adds an Operation to the History, replacing the oldest one if the
+// History is full.
+func (h *history) record(op Operation) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.ops[h.next] = op
+	h.next++
+	if h.next == len(h.ops) {
+		h.next, h.full = 0, true
+	}
+}
+
+// redact returns the types of args.
+func redact(args []interface{}) []string {
+	types := make([]string, len(args))
+	for i, a := range args {
+		types[i] = fmt.Sprintf("%T", a)
+	}
+	return types
+}
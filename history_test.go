@@ -0,0 +1,45 @@
+package structable
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "mysql")
+	r.Bind("test_table", newStool())
+	if r.History() != nil {
+		t.Error("Expected no History by default")
+	}
+
+	r.SetHistory(2)
+	if err := r.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if ops := r.History(); len(ops) != 1 || ops[0].Op != OpLoad || ops[0].Query != db.LastQueryRowSql {
+		t.Fatalf("Unexpected History %v", ops)
+	}
+
+	if err := r.Update(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	ops := r.History()
+	if len(ops) != 2 || ops[0].Op != OpUpdate || ops[1].Op != OpDelete {
+		t.Fatalf("Expected the oldest operation to be dropped, got %v", ops)
+	}
+	if !reflect.DeepEqual(ops[1].Args, []string{"int", "int"}) {
+		t.Errorf("Expected the arguments to be redacted, got %v", ops[1].Args)
+	}
+
+	// Copies share the History.
+	if err := r.Clone(nil).Insert(); err != nil {
+		t.Fatal(err)
+	}
+	if ops := r.History(); ops[1].Op != OpInsert {
+		t.Errorf("Expected the clone's insert, got %v", ops)
+	}
+}
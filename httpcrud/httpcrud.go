@@ -0,0 +1,483 @@
+/*
+Package httpcrud serves the rows of a table over HTTP, as JSON, for quick
+internal tools and admin APIs.
+
+A Handler is made from a Factory, which binds a new Record for each request,
+and is mounted under a path with http.StripPrefix:
+
+	users := &httpcrud.Handler{Factory: httpcrud.Table(db, "postgres", "users", &User{})}
+	http.Handle("/users/", http.StripPrefix("/users", users))
+
+It then serves:
+
+	GET    /users          list a page of users
+	POST   /users          insert a user
+	GET    /users/{id}     load a user
+	PUT    /users/{id}     replace a user
+	PATCH  /users/{id}     change some columns of a user
+	DELETE /users/{id}     delete a user
+
+Records are read and written as JSON objects keyed by column name, with the
+values of the fields encoded by encoding/json. The Recorders of Table do not
+see RESTRICTED columns, so they are neither served nor written. A table with a composite key
+takes one path segment per key column, in the order of Key, as in
+/line_items/{order_id}/{line_no}.
+
+The list takes these query parameters:
+
+	limit       the page size, up to MaxPageSize
+	page_token  the next_page_token of the previous page
+	order       a column to order by, with a leading "-" for descending order
+	{column}    a value that the column must equal, or "null"
+
+and returns an object with the items, the total number of items, and the
+next_page_token, as by structable.Paginate.
+
+Errors are returned as an object with an "error" message. The messages of
+errors of the database are not shown to clients; set OnError to log them.
+*/
+package httpcrud
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/structable"
+)
+
+// Factory returns a Recorder bound to a new, empty Record, for a request.
+//
+// It may use the request to pick the database or tenant, or to set the
+// context of the Recorder.
+type Factory func(req *http.Request) (structable.Recorder, error)
+
+// Table returns a Factory that binds a new Record of rec's type to a table,
+// with the context of the request.
+//
+// RESTRICTED columns are left out, as by DbRecorder.ColumnFilter with no
+// role: they are neither served nor written. A Factory that serves them to
+// some roles binds its own Recorder, and filters it with the role of the
+// request.
+func Table(db structable.Runner, flavor, table string, rec structable.Record) Factory {
+	t := reflect.Indirect(reflect.ValueOf(rec)).Type()
+	return func(req *http.Request) (structable.Recorder, error) {
+		r := structable.New(db, flavor).SetContext(req.Context())
+		r.Bind(table, reflect.New(t).Interface())
+		return r.ColumnFilter(""), nil
+	}
+}
+
+// Handler is an http.Handler that serves the rows of a table.
+type Handler struct {
+	Factory Factory
+	// PageSize is the size of a list page without a limit. It defaults to 50.
+	PageSize uint64
+	// MaxPageSize is the largest limit of a list page. It defaults to 500.
+	MaxPageSize uint64
+	// MaxBodySize is the largest request body, in bytes. It defaults to 1MB.
+	MaxBodySize int64
+	// ReadOnly turns off POST, PUT, PATCH, and DELETE.
+	ReadOnly bool
+	// OnError is called with the errors that are answered with a 500
+	// Internal Server Error. They are dropped if it is nil.
+	OnError func(req *http.Request, err error)
+}
+
+// errBadRequest and errNotFound mark errors that are the client's fault.
+var (
+	errBadRequest = errors.New("bad request")
+	errNotFound   = errors.New("not found")
+)
+
+func (h *Handler) pageSize() uint64 {
+	if h.PageSize == 0 {
+		return 50
+	}
+	return h.PageSize
+}
+
+func (h *Handler) maxPageSize() uint64 {
+	if h.MaxPageSize == 0 {
+		return 500
+	}
+	return h.MaxPageSize
+}
+
+func (h *Handler) maxBodySize() int64 {
+	if h.MaxBodySize == 0 {
+		return 1 << 20
+	}
+	return h.MaxBodySize
+}
+
+// ServeHTTP routes a request by its method, and by whether its path is empty
+// or names a row.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r, err := h.Factory(req)
+	if err != nil {
+		h.fail(w, req, err)
+		return
+	}
+	path := strings.Trim(req.URL.EscapedPath(), "/")
+	allow := "GET, POST"
+	if path != "" {
+		allow = "GET, PUT, PATCH, DELETE"
+	}
+	if h.ReadOnly {
+		allow = "GET"
+	}
+	if !allowed(allow, req.Method) {
+		w.Header().Set("Allow", allow)
+		writeJSON(w, http.StatusMethodNotAllowed, errorBody(http.StatusText(http.StatusMethodNotAllowed)))
+		return
+	}
+	if req.Body != nil {
+		req.Body = http.MaxBytesReader(w, req.Body, h.maxBodySize())
+	}
+
+	status := http.StatusOK
+	var body interface{}
+	if path == "" {
+		switch req.Method {
+		case "GET":
+			body, err = h.list(r, req.URL.Query())
+		case "POST":
+			status = http.StatusCreated
+			body, err = h.create(r, req)
+		}
+	} else {
+		if err = setKey(r, path); err == nil {
+			switch req.Method {
+			case "GET":
+				body, err = h.get(r)
+			case "PUT":
+				body, err = h.replace(r, req)
+			case "PATCH":
+				body, err = h.patch(r, req)
+			case "DELETE":
+				status = http.StatusNoContent
+				err = h.delete(r)
+			}
+		}
+	}
+	if err != nil {
+		h.fail(w, req, err)
+		return
+	}
+	writeJSON(w, status, body)
+}
+
+// allowed reports whether a method is in an Allow list.
+func allowed(allow, method string) bool {
+	for _, m := range strings.Split(allow, ", ") {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// fail answers with the status of an error.
+func (h *Handler) fail(w http.ResponseWriter, req *http.Request, err error) {
+	var maxErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, errNotFound):
+		writeJSON(w, http.StatusNotFound, errorBody(err.Error()))
+	case errors.Is(err, sql.ErrNoRows):
+		writeJSON(w, http.StatusNotFound, errorBody(http.StatusText(http.StatusNotFound)))
+	case errors.As(err, &maxErr):
+		writeJSON(w, http.StatusRequestEntityTooLarge, errorBody(err.Error()))
+	case errors.Is(err, errBadRequest), errors.Is(err, structable.ErrBadPageToken),
+		errors.Is(err, structable.ErrNotNull), errors.Is(err, structable.ErrTooLong):
+		writeJSON(w, http.StatusBadRequest, errorBody(err.Error()))
+	default:
+		if h.OnError != nil {
+			h.OnError(req, err)
+		}
+		writeJSON(w, http.StatusInternalServerError, errorBody(http.StatusText(http.StatusInternalServerError)))
+	}
+}
+
+func errorBody(msg string) interface{} {
+	return map[string]string{"error": msg}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	if status == http.StatusNoContent {
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// setKey sets the key of r from the segments of a path.
+func setKey(r structable.Recorder, path string) error {
+	segs := strings.Split(path, "/")
+	if len(segs) != len(r.Key()) {
+		return fmt.Errorf("%w: %s/%s", errNotFound, r.TableName(), path)
+	}
+	vals := make([]interface{}, len(segs))
+	for i, s := range segs {
+		v, err := url.PathUnescape(s)
+		if err != nil {
+			return fmt.Errorf("%w: %s", errBadRequest, err)
+		}
+		vals[i] = v
+	}
+	if err := r.SetKey(vals...); err != nil {
+		return fmt.Errorf("%w: %s", errBadRequest, err)
+	}
+	return nil
+}
+
+// exists returns errNotFound if the row of r's key does not exist.
+func exists(r structable.Recorder) error {
+	ok, err := r.Exists()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s %v", errNotFound, r.TableName(), r.KeyValues())
+	}
+	return nil
+}
+
+func (h *Handler) get(r structable.Recorder) (interface{}, error) {
+	if err := r.Load(); err != nil {
+		return nil, err
+	}
+	return recordJSON(r)
+}
+
+func (h *Handler) create(r structable.Recorder, req *http.Request) (interface{}, error) {
+	set, err := decode(r, req, "POST")
+	if err != nil {
+		return nil, err
+	}
+	apply(r, set, false)
+	if err := r.Insert(); err != nil {
+		return nil, err
+	}
+	return recordJSON(r)
+}
+
+// replace sets every column of a row from the body, as PUT does. Columns
+// that are left out of the body are set to their zero values.
+func (h *Handler) replace(r structable.Recorder, req *http.Request) (interface{}, error) {
+	set, err := decode(r, req, "PUT")
+	if err != nil {
+		return nil, err
+	}
+	if err := exists(r); err != nil {
+		return nil, err
+	}
+	apply(r, set, true)
+	if err := r.Update(); err != nil {
+		return nil, err
+	}
+	return recordJSON(r)
+}
+
+// patch sets the columns of a row that are in the body.
+func (h *Handler) patch(r structable.Recorder, req *http.Request) (interface{}, error) {
+	set, err := decode(r, req, "PATCH")
+	if err != nil {
+		return nil, err
+	}
+	if err := r.Load(); err != nil {
+		return nil, err
+	}
+	apply(r, set, false)
+	if err := r.Update(); err != nil {
+		return nil, err
+	}
+	return recordJSON(r)
+}
+
+func (h *Handler) delete(r structable.Recorder) error {
+	if err := exists(r); err != nil {
+		return err
+	}
+	return r.Delete()
+}
+
+// listBody is the body of a list page.
+type listBody struct {
+	Items         []json.RawMessage `json:"items"`
+	Total         uint64            `json:"total"`
+	NextPageToken string            `json:"next_page_token,omitempty"`
+}
+
+func (h *Handler) list(r structable.Recorder, query url.Values) (interface{}, error) {
+	page := structable.PageRequest{Size: h.pageSize(), Token: query.Get("page_token")}
+	if s := query.Get("limit"); s != "" {
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil || n == 0 {
+			return nil, fmt.Errorf("%w: limit must be a positive number", errBadRequest)
+		}
+		if n > h.maxPageSize() {
+			n = h.maxPageSize()
+		}
+		page.Size = n
+	}
+	fields := r.Fields()
+	if order := query.Get("order"); order != "" {
+		if strings.HasPrefix(order, "-") {
+			order, page.Desc = order[1:], true
+		}
+		if _, ok := fieldFor(fields, order); !ok {
+			return nil, fmt.Errorf("%w: unknown column %q", errBadRequest, order)
+		}
+		page.OrderBy = order
+	}
+
+	var where []structable.WhereFunc
+	for name, vals := range query {
+		if name == "limit" || name == "page_token" || name == "order" {
+			continue
+		}
+		f, ok := fieldFor(fields, name)
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown column %q", errBadRequest, name)
+		}
+		v, err := parseParam(f, vals[0])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %s", errBadRequest, name, err)
+		}
+		where = append(where, structable.WithWhereEq(f.Column, v))
+	}
+	page.Where = structable.Compose(where...)
+
+	res, err := structable.Paginate(r, page)
+	if err != nil {
+		return nil, err
+	}
+	body := listBody{Items: make([]json.RawMessage, len(res.Items)), Total: res.Total, NextPageToken: res.NextToken}
+	for i, item := range res.Items {
+		if body.Items[i], err = recordJSON(item); err != nil {
+			return nil, err
+		}
+	}
+	return body, nil
+}
+
+// fieldFor returns the field of a column.
+func fieldFor(fields []structable.FieldInfo, col string) (structable.FieldInfo, bool) {
+	for _, f := range fields {
+		if f.Column == col {
+			return f, true
+		}
+	}
+	return structable.FieldInfo{}, false
+}
+
+// parseParam parses a query parameter as JSON of the field type, or else as
+// a JSON string, so that numbers and strings need no quotes. Pointers are
+// followed, so "null" is nil.
+func parseParam(f structable.FieldInfo, s string) (interface{}, error) {
+	p := reflect.New(f.Type)
+	if err := json.Unmarshal([]byte(s), p.Interface()); err != nil {
+		q, _ := json.Marshal(s)
+		if json.Unmarshal(q, p.Interface()) != nil {
+			return nil, err
+		}
+	}
+	v := p.Elem()
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	return v.Interface(), nil
+}
+
+// decode reads a JSON object keyed by column name, and returns the values
+// of the fields it sets, keyed by field name. The columns that the method may
+// not set are rejected: the TENANT column, and generated and READONLY
+// columns, as well as the key, which is given by the path for PUT and PATCH.
+func decode(r structable.Recorder, req *http.Request, method string) (map[string]reflect.Value, error) {
+	var body map[string]json.RawMessage
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: the body must be a JSON object: %s", errBadRequest, err)
+	}
+	fields := r.Fields()
+	for col := range body {
+		f, ok := fieldFor(fields, col)
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("%w: unknown column %q", errBadRequest, col)
+		case f.Tenant,
+			method == "POST" && (f.Auto || f.OmitInsert),
+			method != "POST" && (f.Key || f.OmitUpdate):
+			return nil, fmt.Errorf("%w: column %s may not be set", errBadRequest, col)
+		}
+	}
+
+	set := make(map[string]reflect.Value, len(body))
+	for _, f := range fields {
+		raw, ok := body[f.Column]
+		if !ok {
+			continue
+		}
+		p := reflect.New(f.Type)
+		if err := json.Unmarshal(raw, p.Interface()); err != nil {
+			return nil, fmt.Errorf("%w: column %s: %s", errBadRequest, f.Column, err)
+		}
+		set[f.Name] = p.Elem()
+	}
+	return set, nil
+}
+
+// apply sets the fields of r that decode returned. If reset is true, the
+// other fields that an update writes are set to their zero values first.
+func apply(r structable.Recorder, set map[string]reflect.Value, reset bool) {
+	ar := reflect.Indirect(reflect.ValueOf(r.Record()))
+	if reset {
+		for _, f := range r.Fields() {
+			if !f.Key && !f.Tenant && !f.OmitUpdate {
+				ar.FieldByName(f.Name).Set(reflect.Zero(f.Type))
+			}
+		}
+	}
+	for name, v := range set {
+		ar.FieldByName(name).Set(v)
+	}
+}
+
+// recordJSON encodes the fields of a Record as an object keyed by column, in
+// the order of the fields.
+func recordJSON(r structable.Recorder) (json.RawMessage, error) {
+	ar := reflect.Indirect(reflect.ValueOf(r.Record()))
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, f := range r.Fields() {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		k, _ := json.Marshal(f.Column)
+		v, err := json.Marshal(ar.FieldByName(f.Name).Interface())
+		if err != nil {
+			return nil, fmt.Errorf("encoding column %s: %w", f.Column, err)
+		}
+		b.Write(k)
+		b.WriteByte(':')
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
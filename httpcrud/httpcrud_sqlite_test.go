@@ -0,0 +1,132 @@
+//go:build sqlite
+// +build sqlite
+
+package httpcrud
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/Masterminds/structable"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type item struct {
+	Id    int    `stbl:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Name  string `stbl:"name"`
+	Color string `stbl:"color"`
+	Stock int    `stbl:"stock"`
+}
+
+func TestCRUD(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE items (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, color TEXT, stock INTEGER)`); err != nil {
+		t.Fatal(err)
+	}
+	h := &Handler{Factory: Table(structable.NewRunner(db), "sqlite3", "items", &item{}), PageSize: 2}
+
+	for _, body := range []string{
+		`{"name": "chair", "color": "red", "stock": 3}`,
+		`{"name": "table", "color": "oak", "stock": 1}`,
+		`{"name": "lamp", "color": "red", "stock": 9}`,
+	} {
+		if w := serve(h, "POST", "/items", body); w.Code != http.StatusCreated {
+			t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body)
+		}
+	}
+
+	var page struct {
+		Items         []item `json:"items"`
+		Total         int    `json:"total"`
+		NextPageToken string `json:"next_page_token"`
+	}
+	w := serve(h, "GET", "/items?color=red&order=-stock", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 2 || len(page.Items) != 2 || page.Items[0].Name != "lamp" || page.NextPageToken != "" {
+		t.Errorf("Unexpected page %s", w.Body)
+	}
+	w = serve(h, "GET", "/items?limit=1", "")
+	json.Unmarshal(w.Body.Bytes(), &page)
+	if page.Total != 3 || len(page.Items) != 1 || page.Items[0].Name != "chair" || page.NextPageToken == "" {
+		t.Fatalf("Unexpected first page %s", w.Body)
+	}
+	w = serve(h, "GET", "/items?limit=1&page_token="+page.NextPageToken, "")
+	json.Unmarshal(w.Body.Bytes(), &page)
+	if len(page.Items) != 1 || page.Items[0].Name != "table" {
+		t.Errorf("Unexpected second page %s", w.Body)
+	}
+
+	if w := serve(h, "PATCH", "/items/2", `{"stock": 5}`); w.Body.String() != `{"id":2,"name":"table","color":"oak","stock":5}`+"\n" {
+		t.Errorf("Unexpected patched item %d: %s", w.Code, w.Body)
+	}
+	if w := serve(h, "PUT", "/items/2", `{"name": "desk"}`); w.Body.String() != `{"id":2,"name":"desk","color":"","stock":0}`+"\n" {
+		t.Errorf("Unexpected replaced item %d: %s", w.Code, w.Body)
+	}
+	if w := serve(h, "GET", "/items/2", ""); w.Body.String() != `{"id":2,"name":"desk","color":"","stock":0}`+"\n" {
+		t.Errorf("Unexpected item %d: %s", w.Code, w.Body)
+	}
+	if w := serve(h, "DELETE", "/items/2", ""); w.Code != http.StatusNoContent {
+		t.Errorf("Expected 204, got %d: %s", w.Code, w.Body)
+	}
+	if w := serve(h, "GET", "/items/2", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected a deleted item to be gone, got %d: %s", w.Code, w.Body)
+	}
+}
+
+type account struct {
+	Id     int    `stbl:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Name   string `stbl:"name"`
+	Salary int    `stbl:"salary,RESTRICTED=admin"`
+}
+
+func TestRestricted(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE accounts (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, salary INTEGER)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO accounts (name, salary) VALUES ('Jane', 100)`); err != nil {
+		t.Fatal(err)
+	}
+	h := &Handler{Factory: Table(structable.NewRunner(db), "sqlite3", "accounts", &account{})}
+
+	if w := serve(h, "GET", "/accounts/1", ""); w.Body.String() != `{"id":1,"name":"Jane"}`+"\n" {
+		t.Errorf("Expected no salary, got %d: %s", w.Code, w.Body)
+	}
+	if w := serve(h, "GET", "/accounts", ""); w.Body.String() != `{"items":[{"id":1,"name":"Jane"}],"total":1}`+"\n" {
+		t.Errorf("Expected no salaries, got %d: %s", w.Code, w.Body)
+	}
+	if w := serve(h, "GET", "/accounts?salary=100", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected a filter on salary to be refused, got %d: %s", w.Code, w.Body)
+	}
+	for _, method := range []string{"POST", "PUT", "PATCH"} {
+		target := "/accounts/1"
+		if method == "POST" {
+			target = "/accounts"
+		}
+		if w := serve(h, method, target, `{"salary": 5}`); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected salary to be refused, got %d: %s", method, w.Code, w.Body)
+		}
+	}
+	if w := serve(h, "PUT", "/accounts/1", `{"name": "Janet"}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body)
+	}
+	var salary int
+	if err := db.QueryRow(`SELECT salary FROM accounts WHERE id = 1`).Scan(&salary); err != nil {
+		t.Fatal(err)
+	}
+	if salary != 100 {
+		t.Errorf("Expected PUT to keep the salary, got %d", salary)
+	}
+}
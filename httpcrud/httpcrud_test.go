@@ -0,0 +1,107 @@
+package httpcrud
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/structable/stest"
+)
+
+type user struct {
+	Id      int     `stbl:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Name    string  `stbl:"name"`
+	Email   *string `stbl:"email"`
+	Created string  `stbl:"created_at,READONLY"`
+}
+
+// serve serves a request with h mounted under the first segment of its path.
+func serve(h http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	prefix := "/" + strings.SplitN(req.URL.Path[1:], "/", 2)[0]
+	http.StripPrefix(prefix, h).ServeHTTP(w, req)
+	return w
+}
+
+func TestCreate(t *testing.T) {
+	db := &stest.DB{}
+	h := &Handler{Factory: Table(db, "mysql", "users", &user{})}
+
+	w := serve(h, "POST", "/users", `{"name": "Jane", "email": "jane@example.com"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body)
+	}
+	expect := `{"id":1,"name":"Jane","email":"jane@example.com","created_at":""}` + "\n"
+	if w.Body.String() != expect {
+		t.Errorf("Expected %s, got %s", expect, w.Body)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected JSON, got %s", ct)
+	}
+	stest.AssertQueries(t, db, "INSERT INTO users (name,email) VALUES (?,?)")
+}
+
+func TestErrors(t *testing.T) {
+	db := &stest.DB{}
+	var logged error
+	h := &Handler{
+		Factory: Table(db, "mysql", "users", &user{}),
+		OnError: func(req *http.Request, err error) { logged = err },
+	}
+	tests := []struct {
+		method, target, body string
+		code                 int
+	}{
+		{"DELETE", "/users", "", http.StatusMethodNotAllowed},
+		{"POST", "/users/1", "{}", http.StatusMethodNotAllowed},
+		{"POST", "/users", `{"shoe_size": 7}`, http.StatusBadRequest},
+		{"POST", "/users", `{"id": 7}`, http.StatusBadRequest},
+		{"POST", "/users", `{"created_at": "today"}`, http.StatusBadRequest},
+		{"POST", "/users", `{"name": 7}`, http.StatusBadRequest},
+		{"POST", "/users", `[]`, http.StatusBadRequest},
+		{"PATCH", "/users/1", `{"id": 2}`, http.StatusBadRequest},
+		{"GET", "/users/one", "", http.StatusBadRequest},
+		{"GET", "/users/1/2", "", http.StatusNotFound},
+		{"DELETE", "/users/1", "", http.StatusNotFound},
+		{"PUT", "/users/1", `{"name": "Bob"}`, http.StatusNotFound},
+		{"GET", "/users?limit=0", "", http.StatusBadRequest},
+		{"GET", "/users?order=-shoe_size", "", http.StatusBadRequest},
+		{"GET", "/users?shoe_size=7", "", http.StatusBadRequest},
+		{"GET", "/users?id=one", "", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := serve(h, tt.method, tt.target, tt.body)
+		if w.Code != tt.code {
+			t.Errorf("%s %s: expected %d, got %d: %s", tt.method, tt.target, tt.code, w.Code, w.Body)
+		}
+		if !strings.Contains(w.Body.String(), `"error"`) {
+			t.Errorf("%s %s: expected an error body, got %s", tt.method, tt.target, w.Body)
+		}
+	}
+	if w := serve(h, "DELETE", "/users", ""); w.Header().Get("Allow") != "GET, POST" {
+		t.Errorf("Expected an Allow header, got %q", w.Header().Get("Allow"))
+	}
+	if logged != nil {
+		t.Errorf("Expected client errors not to be logged, got %s", logged)
+	}
+	if len(db.Queries()) != 2 {
+		t.Errorf("Expected only the existence checks, got %v", db.Queries())
+	}
+
+	db.Err = errors.New("connection refused")
+	w := serve(h, "GET", "/users/1", "")
+	if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "refused") {
+		t.Errorf("Expected a 500 without the database error, got %d: %s", w.Code, w.Body)
+	}
+	if logged != db.Err {
+		t.Errorf("Expected the error to be logged, got %v", logged)
+	}
+
+	h.ReadOnly = true
+	if w := serve(h, "POST", "/users", `{}`); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected a read-only handler to refuse POST, got %d", w.Code)
+	}
+}
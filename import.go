@@ -0,0 +1,317 @@
+package structable
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ImportOptions configures ImportCSV and ImportJSON.
+type ImportOptions struct {
+	// Columns maps names used in the file to column names, for names that do
+	// not match a column. A name mapped to "-" is skipped.
+	//
+	// Names that are not in Columns are matched to a column name, or else to a
+	// field name, ignoring case.
+	Columns map[string]string
+	// IgnoreUnknown skips names that match no column, instead of failing.
+	IgnoreUnknown bool
+	// BatchSize is the number of rows inserted per transaction. It defaults
+	// to 1000.
+	BatchSize int
+	// Keyed inserts the rows with InsertKeyed, keeping the AUTO_INCREMENT
+	// values given in the file.
+	Keyed bool
+	// Comma is the CSV field delimiter. It defaults to ','.
+	Comma rune
+	// Header names the CSV columns, for files that have no header row. If it
+	// is empty, the first row is the header.
+	Header []string
+}
+
+// importTimeLayouts are the layouts tried for time.Time fields, after
+// RFC 3339.
+var importTimeLayouts = []string{"2006-01-02 15:04:05", "2006-01-02"}
+
+// ImportCSV inserts the rows of a CSV file into the table of a Recorder, and
+// returns the number of rows inserted.
+//
+// The header row names the column of each field of the file (see
+// ImportOptions.Columns). Cells are converted to the field types as by
+// SetValues. An empty cell is NULL, or the zero value, for any field but a
+// string. Time fields accept RFC 3339, "2006-01-02 15:04:05", and
+// "2006-01-02":
+//
+//	f, _ := os.Open("users.csv")
+//	n, err := structable.ImportCSV(structable.New(db, "postgres").Bind("users", &User{}), f,
+//		structable.ImportOptions{Columns: map[string]string{"E-mail": "email"}})
+//
+// The rows are inserted in batches of BatchSize, each in a transaction, as by
+// Transact. If a row fails, its batch is rolled back, and the error, with the
+// row number, is returned along with the number of rows inserted by the
+// batches before it. If the Recorder's database cannot begin a transaction,
+// such as when it is already a *sql.Tx, the rows are inserted on it directly.
+//
+// The Recorder's Record is only used for its type; it is left unchanged.
+func ImportCSV(r Recorder, reader io.Reader, opts ImportOptions) (int64, error) {
+	s := protoRecorder(r)
+	cr := csv.NewReader(reader)
+	if opts.Comma != 0 {
+		cr.Comma = opts.Comma
+	}
+	cr.ReuseRecord = true
+
+	header, row := opts.Header, 0
+	if len(header) == 0 {
+		h, err := cr.Read()
+		if err == io.EOF {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("reading the CSV header: %w", err)
+		}
+		header, row = append([]string(nil), h...), 1
+	}
+	fields := make([]*field, len(header))
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		f, err := s.importField(name, opts)
+		if err != nil {
+			return 0, err
+		}
+		fields[i] = f
+	}
+
+	imp := &importer{s: s, opts: opts}
+	for {
+		cells, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		row++
+		if err != nil {
+			return imp.n, fmt.Errorf("reading CSV row %d: %w", row, err)
+		}
+		vals := make(map[string]interface{}, len(fields))
+		for i, cell := range cells {
+			if i >= len(fields) || fields[i] == nil {
+				continue
+			}
+			vals[fields[i].column] = cell
+			if cell == "" && s.fieldType(fields[i]).Kind() != reflect.String {
+				vals[fields[i].column] = nil
+			}
+		}
+		if err := imp.add(row, vals); err != nil {
+			return imp.n, err
+		}
+	}
+	return imp.n, imp.flush()
+}
+
+// ImportJSON inserts the objects of a JSON file into the table of a
+// Recorder, and returns the number of rows inserted.
+//
+// The file holds either an array of objects, or objects one after the other,
+// as in newline-delimited JSON. Object keys are matched to columns as the
+// header of ImportCSV is, and values are converted as by SetValues; a null
+// is NULL, or the zero value. Numbers are decoded exactly, so large IDs are
+// not rounded.
+//
+// Rows are inserted in batches, as by ImportCSV.
+func ImportJSON(r Recorder, reader io.Reader, opts ImportOptions) (int64, error) {
+	s := protoRecorder(r)
+	br := bufio.NewReader(reader)
+	array, err := jsonIsArray(br)
+	if err != nil {
+		return 0, err
+	}
+	dec := json.NewDecoder(br)
+	dec.UseNumber()
+	if array {
+		if _, err := dec.Token(); err != nil {
+			return 0, fmt.Errorf("reading JSON: %w", err)
+		}
+	}
+
+	imp := &importer{s: s, opts: opts}
+	fields := map[string]*field{}
+	for row := 1; !array || dec.More(); row++ {
+		var obj map[string]interface{}
+		if err := dec.Decode(&obj); err != nil {
+			if err == io.EOF && !array {
+				break
+			}
+			return imp.n, fmt.Errorf("reading JSON object %d: %w", row, err)
+		}
+		vals := make(map[string]interface{}, len(obj))
+		for name, v := range obj {
+			f, ok := fields[name]
+			if !ok {
+				if f, err = s.importField(name, opts); err != nil {
+					return imp.n, fmt.Errorf("JSON object %d: %w", row, err)
+				}
+				fields[name] = f
+			}
+			if f != nil {
+				vals[f.column] = v
+			}
+		}
+		if err := imp.add(row, vals); err != nil {
+			return imp.n, err
+		}
+	}
+	return imp.n, imp.flush()
+}
+
+// jsonIsArray reports whether the JSON in br starts with an array, without
+// consuming it.
+func jsonIsArray(br *bufio.Reader) (bool, error) {
+	for {
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("reading JSON: %w", err)
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b == '[', br.UnreadByte()
+	}
+}
+
+// importField returns the field for a name used in an import file, or nil if
+// the name is skipped.
+func (s *DbRecorder) importField(name string, opts ImportOptions) (*field, error) {
+	name = strings.TrimSpace(name)
+	if col, ok := opts.Columns[name]; ok {
+		if col == "-" {
+			return nil, nil
+		}
+		return s.fieldForColumn(col)
+	}
+	if f, err := s.fieldForColumn(name); err == nil {
+		return f, nil
+	}
+	for _, f := range s.fields {
+		if strings.EqualFold(f.column, name) || strings.EqualFold(f.name, name) {
+			return f, nil
+		}
+	}
+	if opts.IgnoreUnknown {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown column %q on table %s", name, s.table)
+}
+
+// fieldType returns the type of a field, without its pointer.
+func (s *DbRecorder) fieldType(f *field) reflect.Type {
+	t, _ := reflect.Indirect(reflect.ValueOf(s.record)).Type().FieldByName(f.name)
+	if t.Type.Kind() == reflect.Ptr {
+		return t.Type.Elem()
+	}
+	return t.Type
+}
+
+// importRow is a row read from an import file.
+type importRow struct {
+	row  int
+	vals map[string]interface{}
+}
+
+// importer inserts rows in batches.
+type importer struct {
+	s     *DbRecorder
+	opts  ImportOptions
+	batch []importRow
+	n     int64
+}
+
+// add adds a row to the batch, and inserts the batch once it is full.
+func (imp *importer) add(row int, vals map[string]interface{}) error {
+	size := imp.opts.BatchSize
+	if size <= 0 {
+		size = 1000
+	}
+	imp.batch = append(imp.batch, importRow{row, vals})
+	if len(imp.batch) < size {
+		return nil
+	}
+	return imp.flush()
+}
+
+// flush inserts the batch in a transaction.
+func (imp *importer) flush() error {
+	batch := imp.batch
+	imp.batch = imp.batch[:0]
+	if len(batch) == 0 {
+		return nil
+	}
+	err := Transact(imp.s.db, imp.s.flavor, func(tx Runner) error {
+		return imp.insert(tx, batch)
+	})
+	if err == ErrNoTransactions {
+		err = imp.insert(imp.s.db, batch)
+	}
+	if err != nil {
+		return err
+	}
+	imp.n += int64(len(batch))
+	return nil
+}
+
+// insert inserts the rows of a batch on db.
+func (imp *importer) insert(db Runner, batch []importRow) error {
+	c := *imp.s
+	c.Init(db, imp.s.flavor)
+	for _, row := range batch {
+		r := c.Clone(nil)
+		for col, v := range row.vals {
+			f, _ := r.fieldForColumn(col)
+			if str, ok := v.(string); ok && r.fieldType(f) == reflect.TypeOf(time.Time{}) {
+				t, err := parseImportTime(str)
+				if err != nil {
+					return fmt.Errorf("row %d: cannot set field %s from column %s: %w", row.row, f.name, col, err)
+				}
+				row.vals[col] = t
+			}
+		}
+		if err := r.SetValues(row.vals); err != nil {
+			return fmt.Errorf("row %d: %w", row.row, err)
+		}
+		var err error
+		if imp.opts.Keyed {
+			err = r.InsertKeyed()
+		} else {
+			err = r.Insert()
+		}
+		if err != nil {
+			return fmt.Errorf("inserting row %d into %s: %w", row.row, imp.s.table, err)
+		}
+	}
+	return nil
+}
+
+// parseImportTime parses a time in one of the layouts accepted by imports.
+func parseImportTime(str string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, str)
+	if err == nil {
+		return t, nil
+	}
+	for _, layout := range importTimeLayouts {
+		if t, lerr := time.Parse(layout, str); lerr == nil {
+			return t, nil
+		}
+	}
+	return t, err
+}
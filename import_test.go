@@ -0,0 +1,115 @@
+package structable
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type importee struct {
+	Id   int        `stbl:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Name string     `stbl:"name"`
+	Age  int        `stbl:"age"`
+	Born *time.Time `stbl:"born,NULLABLE"`
+}
+
+// argLog is a DBStub that keeps the arguments of every statement it runs.
+type argLog struct {
+	DBStub
+	stmts []string
+	args  [][]interface{}
+}
+
+func (l *argLog) Exec(query string, args ...interface{}) (sql.Result, error) {
+	l.stmts = append(l.stmts, query)
+	l.args = append(l.args, args)
+	return l.DBStub.Exec(query, args...)
+}
+
+func TestImportCSV(t *testing.T) {
+	db := &argLog{}
+	// A Transact scope, so that each batch runs in a savepoint.
+	tx := &txRunner{Runner: db, flavor: "mysql"}
+	r := New(tx, "mysql").Bind("people", &importee{})
+
+	in := "\ufeffNAME, age ,Date of Birth,notes\nAda,36,1815-12-10,x\nAlan,,,\nGrace,85,1906-12-09 10:00:00,\n"
+	n, err := ImportCSV(r, strings.NewReader(in), ImportOptions{
+		Columns:   map[string]string{"Date of Birth": "born", "notes": "-"},
+		BatchSize: 2,
+	})
+	if err != nil || n != 3 {
+		t.Fatalf("Expected 3 rows, got %d, %v", n, err)
+	}
+
+	insert := "INSERT INTO people (name,age,born) VALUES (?,?,?)"
+	expect := []string{
+		"SAVEPOINT structable_1", insert, "INSERT INTO people (name,age) VALUES (?,?)", "RELEASE SAVEPOINT structable_1",
+		"SAVEPOINT structable_1", insert, "RELEASE SAVEPOINT structable_1",
+	}
+	if !reflect.DeepEqual(db.stmts, expect) {
+		t.Fatalf("Expected %v, got %v", expect, db.stmts)
+	}
+	born := time.Date(1815, 12, 10, 0, 0, 0, 0, time.UTC)
+	if args := db.args[1]; args[0] != "Ada" || args[1] != 36 || !args[2].(*time.Time).Equal(born) {
+		t.Errorf("Unexpected args %v", args)
+	}
+	if args := db.args[2]; args[0] != "Alan" || args[1] != 0 {
+		t.Errorf("Expected empty cells to be NULL, got %v", args)
+	}
+	if r.Record().(*importee).Name != "" {
+		t.Error("Expected the Recorder's Record to be left unchanged")
+	}
+}
+
+func TestImportCSVErrors(t *testing.T) {
+	// A Runner without Begin, so that the rows are inserted directly.
+	r := New(struct{ Runner }{&DBStub{}}, "mysql").Bind("people", &importee{})
+
+	if _, err := ImportCSV(r, strings.NewReader("name,shoe_size\n"), ImportOptions{}); err == nil {
+		t.Error("Expected an unknown column to fail")
+	}
+	n, err := ImportCSV(r, strings.NewReader("name,shoe_size\nAda,7\n"), ImportOptions{IgnoreUnknown: true})
+	if err != nil || n != 1 {
+		t.Errorf("Expected the unknown column to be skipped, got %d, %v", n, err)
+	}
+
+	db := &argLog{}
+	r = New(&txRunner{Runner: db, flavor: "mysql"}, "mysql").Bind("people", &importee{})
+	n, err = ImportCSV(r, strings.NewReader("Ada;36\nAlan;old\n"), ImportOptions{Header: []string{"name", "age"}, Comma: ';'})
+	if err == nil || !strings.Contains(err.Error(), "row 2") || n != 0 {
+		t.Errorf("Expected row 2 to fail, got %d, %v", n, err)
+	}
+	if last := db.stmts[len(db.stmts)-1]; last != "ROLLBACK TO SAVEPOINT structable_1" {
+		t.Errorf("Expected the batch to be rolled back, got %q", last)
+	}
+}
+
+func TestImportJSON(t *testing.T) {
+	for name, in := range map[string]string{
+		"array":  ` [{"name": "Ada", "age": 36, "born": "1815-12-10T00:00:00Z"}, {"Name": "Alan", "age": null}]`,
+		"ndjson": "{\"name\": \"Ada\", \"age\": 36, \"born\": \"1815-12-10T00:00:00Z\"}\n{\"Name\": \"Alan\", \"age\": null}\n",
+	} {
+		db := &argLog{}
+		r := New(&txRunner{Runner: db, flavor: "mysql"}, "mysql").Bind("people", &importee{})
+		n, err := ImportJSON(r, strings.NewReader(in), ImportOptions{})
+		if err != nil || n != 2 {
+			t.Fatalf("%s: expected 2 rows, got %d, %v", name, n, err)
+		}
+		if args := db.args[1]; args[0] != "Ada" || args[1] != 36 || args[2] == nil {
+			t.Errorf("%s: unexpected args %v", name, args)
+		}
+		if args := db.args[2]; args[0] != "Alan" || args[1] != 0 {
+			t.Errorf("%s: unexpected args %v", name, args)
+		}
+	}
+
+	r := New(struct{ Runner }{&DBStub{}}, "mysql").Bind("people", &importee{})
+	if _, err := ImportJSON(r, strings.NewReader(`[{"name": "Ada"}, 7]`), ImportOptions{}); err == nil {
+		t.Error("Expected a value that is not an object to fail")
+	}
+	if n, err := ImportJSON(r, strings.NewReader(""), ImportOptions{}); err != nil || n != 0 {
+		t.Errorf("Expected an empty file to import nothing, got %d, %v", n, err)
+	}
+}
@@ -0,0 +1,265 @@
+package structable
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// ErrNoTable is returned by DescribeTable for a table that does not exist.
+var ErrNoTable = errors.New("table does not exist")
+
+// TableSchema describes a table, as read from the database.
+type TableSchema struct {
+	Name string
+	// Columns are in table order.
+	Columns []ColumnSchema
+	// Key names the primary key columns, in key order.
+	Key []string
+}
+
+// ColumnSchema describes a column of a table.
+type ColumnSchema struct {
+	Name string
+	// Type is the database's own name for the column type, such as "integer"
+	// or "varchar(255)".
+	Type     string
+	Nullable bool
+	// Key is true for primary key columns, and Auto for columns whose values
+	// the database generates, such as SERIAL, IDENTITY, and AUTO_INCREMENT
+	// columns.
+	Key, Auto bool
+	// Default is the SQL of the column default, or empty if there is none.
+	Default string
+}
+
+// Column returns the column with a name.
+func (t TableSchema) Column(name string) (ColumnSchema, bool) {
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return ColumnSchema{}, false
+}
+
+// NewRecord creates a DynamicRecord with the columns of the table.
+//
+//	ts, err := structable.DescribeTable(db, "postgres", table)
+//	if err != nil { ... }
+//	rec, err := ts.NewRecord()
+//	r := structable.New(db, "postgres").Bind(table, rec)
+func (t TableSchema) NewRecord() (*DynamicRecord, error) {
+	cols := make([]DynamicColumn, len(t.Columns))
+	for i, c := range t.Columns {
+		cols[i] = DynamicColumn{Name: c.Name, Key: c.Key, Auto: c.Auto}
+	}
+	return NewDynamicRecord(cols...)
+}
+
+// DescribeTable reads the columns and primary key of a table from the
+// database.
+//
+// The table may be qualified with its schema, as in "audit.events";
+// otherwise it is looked up in the current schema. Postgres, MySQL, and MSSQL
+// are read from INFORMATION_SCHEMA, SQLite with PRAGMA table_info, and Oracle
+// from the USER_ views, so on Oracle only the user's own tables can be
+// described, and their names must be given in the case they are stored in.
+// Other flavors are read from INFORMATION_SCHEMA, as for Postgres.
+//
+// If the table does not exist, or has no columns that the user can see, an
+// error wrapping ErrNoTable is returned.
+func DescribeTable(db Runner, flavor, table string) (TableSchema, error) {
+	ts := TableSchema{Name: table, Columns: []ColumnSchema{}, Key: []string{}}
+	var err error
+	switch flavor {
+	case "sqlite3", "sqlite":
+		err = describeSqlite(db, &ts)
+	default:
+		err = describeInfoSchema(db, flavor, &ts)
+	}
+	if err != nil {
+		return ts, fmt.Errorf("describing table %s: %w", table, err)
+	}
+	if len(ts.Columns) == 0 {
+		return ts, fmt.Errorf("%w: %s", ErrNoTable, table)
+	}
+	return ts, nil
+}
+
+// describeQueries are the queries that read a table's columns and key, by
+// flavor. The columns query returns the name, type, nullability, default,
+// and a flag that tells whether the database generates the column. A %s in a
+// query is replaced with the schema.
+var describeQueries = map[string][2]string{
+	"postgres": {
+		`SELECT column_name, data_type, is_nullable, COALESCE(column_default, ''), COALESCE(is_identity, 'NO')
+		FROM information_schema.columns
+		WHERE table_schema = %s AND table_name = ? ORDER BY ordinal_position`,
+		infoSchemaKey,
+	},
+	"mysql": {
+		`SELECT column_name, column_type, is_nullable, COALESCE(column_default, ''), extra
+		FROM information_schema.columns
+		WHERE table_schema = %s AND table_name = ? ORDER BY ordinal_position`,
+		infoSchemaKey,
+	},
+	"mssql": {
+		`SELECT column_name, data_type, is_nullable, COALESCE(column_default, ''),
+			CAST(COLUMNPROPERTY(OBJECT_ID(QUOTENAME(table_schema) + '.' + QUOTENAME(table_name)), column_name, 'IsIdentity') AS VARCHAR(1))
+		FROM information_schema.columns
+		WHERE table_schema = %s AND table_name = ? ORDER BY ordinal_position`,
+		infoSchemaKey,
+	},
+	"oracle": {
+		`SELECT column_name, data_type, nullable, '', identity_column
+		FROM user_tab_columns WHERE table_name = ? ORDER BY column_id`,
+		`SELECT cc.column_name FROM user_constraints c
+		JOIN user_cons_columns cc ON cc.constraint_name = c.constraint_name
+		WHERE c.constraint_type = 'P' AND c.table_name = ? ORDER BY cc.position`,
+	},
+}
+
+// infoSchemaKey reads the primary key from INFORMATION_SCHEMA. The table name
+// is part of the join, since MySQL names every primary key PRIMARY.
+const infoSchemaKey = `SELECT k.column_name FROM information_schema.table_constraints t
+	JOIN information_schema.key_column_usage k ON k.constraint_schema = t.constraint_schema
+		AND k.constraint_name = t.constraint_name AND k.table_name = t.table_name
+	WHERE t.constraint_type = 'PRIMARY KEY' AND t.table_schema = %s AND t.table_name = ?
+	ORDER BY k.ordinal_position`
+
+// currentSchema is the SQL for the current schema of each flavor.
+var currentSchema = map[string]string{
+	"postgres": "current_schema()",
+	"mysql":    "DATABASE()",
+	"mssql":    "SCHEMA_NAME()",
+}
+
+func describeInfoSchema(db Runner, flavor string, ts *TableSchema) error {
+	queries, ok := describeQueries[flavor]
+	if !ok {
+		queries, flavor = describeQueries["postgres"], "postgres"
+	}
+	args := []interface{}{ts.Name}
+	schema := currentSchema[flavor]
+	if i := strings.LastIndex(ts.Name, "."); i >= 0 && flavor != "oracle" {
+		schema, args = "?", []interface{}{ts.Name[:i], ts.Name[i+1:]}
+	}
+	query := func(q string) (*sql.Rows, error) {
+		if strings.Contains(q, "%s") {
+			q = fmt.Sprintf(q, schema)
+		}
+		if flavor == "postgres" {
+			var err error
+			if q, err = squirrel.Dollar.ReplacePlaceholders(q); err != nil {
+				return nil, err
+			}
+		}
+		return db.Query(q, args...)
+	}
+
+	rows, err := query(queries[0])
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c ColumnSchema
+		var nullable, auto string
+		if err := rows.Scan(&c.Name, &c.Type, &nullable, &c.Default, &auto); err != nil {
+			return err
+		}
+		c.Nullable = nullable == "YES" || nullable == "Y"
+		c.Auto = auto == "YES" || auto == "1" || strings.Contains(auto, "auto_increment") ||
+			strings.HasPrefix(c.Default, "nextval(")
+		ts.Columns = append(ts.Columns, c)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	keys, err := query(queries[1])
+	if err != nil {
+		return err
+	}
+	defer keys.Close()
+	for keys.Next() {
+		var k string
+		if err := keys.Scan(&k); err != nil {
+			return err
+		}
+		ts.Key = append(ts.Key, k)
+	}
+	if err := keys.Err(); err != nil {
+		return err
+	}
+	markKeys(ts)
+	return nil
+}
+
+// describeSqlite uses PRAGMA table_info, since SQLite has no
+// INFORMATION_SCHEMA.
+func describeSqlite(db Runner, ts *TableSchema) error {
+	pragma := fmt.Sprintf("PRAGMA table_info(%s)", QuoteIdent("sqlite3", ts.Name))
+	if i := strings.LastIndex(ts.Name, "."); i >= 0 {
+		pragma = fmt.Sprintf("PRAGMA %s.table_info(%s)", QuoteIdent("sqlite3", ts.Name[:i]), QuoteIdent("sqlite3", ts.Name[i+1:]))
+	}
+	rows, err := db.Query(pragma)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	// pos maps the position of each key column, from 1, to its name.
+	pos := map[int]string{}
+	for rows.Next() {
+		var (
+			c       ColumnSchema
+			cid, pk int
+			notnull bool
+			dflt    sql.NullString
+		)
+		if err := rows.Scan(&cid, &c.Name, &c.Type, &notnull, &dflt, &pk); err != nil {
+			return err
+		}
+		c.Nullable = !notnull
+		c.Default = dflt.String
+		if pk > 0 {
+			pos[pk] = c.Name
+		}
+		ts.Columns = append(ts.Columns, c)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for i := 1; i <= len(pos); i++ {
+		ts.Key = append(ts.Key, pos[i])
+	}
+	markKeys(ts)
+
+	// A lone INTEGER PRIMARY KEY is an alias for the ROWID, and so is
+	// assigned by the database.
+	if len(ts.Key) == 1 {
+		for i := range ts.Columns {
+			if c := &ts.Columns[i]; c.Key && strings.EqualFold(c.Type, "integer") {
+				c.Auto = true
+			}
+		}
+	}
+	return nil
+}
+
+// markKeys sets Key on the columns named in ts.Key.
+func markKeys(ts *TableSchema) {
+	for _, k := range ts.Key {
+		for i := range ts.Columns {
+			if ts.Columns[i].Name == k {
+				ts.Columns[i].Key = true
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package structable
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// queryLog is a DBStub that keeps every query it runs.
+type queryLog struct {
+	DBStub
+	queries []string
+	args    [][]interface{}
+}
+
+func (l *queryLog) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	l.queries = append(l.queries, query)
+	l.args = append(l.args, args)
+	return nil, errors.New("stub")
+}
+
+func TestDescribeTableQueries(t *testing.T) {
+	tests := []struct {
+		flavor, table, contains string
+		args                    []interface{}
+	}{
+		{"postgres", "users", "table_schema = current_schema() AND table_name = $1", []interface{}{"users"}},
+		{"postgres", "audit.events", "table_schema = $1 AND table_name = $2", []interface{}{"audit", "events"}},
+		{"mysql", "users", "table_schema = DATABASE() AND table_name = ?", []interface{}{"users"}},
+		{"mssql", "users", "table_schema = SCHEMA_NAME() AND table_name = ?", []interface{}{"users"}},
+		{"oracle", "USERS", "FROM user_tab_columns WHERE table_name = ?", []interface{}{"USERS"}},
+		{"other", "users", "table_schema = current_schema() AND table_name = $1", []interface{}{"users"}},
+		{"sqlite3", "users", `PRAGMA table_info("users")`, nil},
+		{"sqlite3", "main.users", `PRAGMA "main".table_info("users")`, nil},
+	}
+	for _, tt := range tests {
+		db := &queryLog{}
+		if _, err := DescribeTable(db, tt.flavor, tt.table); err == nil {
+			t.Errorf("%s: expected the stub error", tt.flavor)
+		}
+		if len(db.queries) != 1 || !strings.Contains(db.queries[0], tt.contains) {
+			t.Errorf("%s: expected a query with %q, got %v", tt.flavor, tt.contains, db.queries)
+			continue
+		}
+		if len(db.args[0]) != len(tt.args) || len(tt.args) > 0 && !reflect.DeepEqual(db.args[0], tt.args) {
+			t.Errorf("%s: expected args %v, got %v", tt.flavor, tt.args, db.args[0])
+		}
+	}
+}
+
+func TestTableSchemaNewRecord(t *testing.T) {
+	ts := TableSchema{
+		Name: "items",
+		Columns: []ColumnSchema{
+			{Name: "id", Type: "integer", Key: true, Auto: true},
+			{Name: "name", Type: "text", Nullable: true},
+		},
+		Key: []string{"id"},
+	}
+	if c, ok := ts.Column("name"); !ok || !c.Nullable {
+		t.Errorf("Expected the name column, got %+v", c)
+	}
+	if _, ok := ts.Column("nope"); ok {
+		t.Error("Expected no column nope")
+	}
+
+	rec, err := ts.NewRecord()
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := &DBStub{}
+	r := New(db, "mysql")
+	r.Bind("items", rec)
+	rec.Set("name", "Widget")
+	if err := r.Insert(); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "INSERT INTO items (name) VALUES (?)"; db.LastExecSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastExecSql)
+	}
+}
@@ -0,0 +1,163 @@
+package structable
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// Joined scans the rows of a join into several Records, one per table.
+//
+// Each table's columns are selected with the table name as a prefix, and
+// scanned into the fields of that table's Record:
+//
+//	j := structable.Join(user, profile).On("profiles.user_id = users.id")
+//	err := j.LoadWhere("users.email = ?", email)
+//	// user and profile are now both loaded.
+//
+// Joins are inner joins, in the order the Recorders are given. Relations stay
+// explicit: Joined only removes the Scan plumbing. A table may only appear
+// once in a join.
+type Joined struct {
+	recs []*DbRecorder
+	on   []joinOn
+}
+
+type joinOn struct {
+	pred string
+	args []interface{}
+}
+
+// Join creates a Joined for the given Recorders.
+//
+// The first Recorder's table is the FROM table. Every other table needs a
+// join condition, given with On.
+func Join(first Recorder, rest ...Recorder) *Joined {
+	j := &Joined{recs: []*DbRecorder{boundRecorder(first)}}
+	for _, r := range rest {
+		j.recs = append(j.recs, boundRecorder(r))
+	}
+	return j
+}
+
+// On adds the join condition for the next table that does not have one.
+//
+// Arguments are passed to squirrel along with the condition.
+func (j *Joined) On(pred string, args ...interface{}) *Joined {
+	j.on = append(j.on, joinOn{pred: pred, args: args})
+	return j
+}
+
+// Columns returns the table-qualified columns of every table in the join.
+func (j *Joined) Columns() []string {
+	cols := []string{}
+	for _, r := range j.recs {
+		for _, c := range r.Columns(true) {
+			cols = append(cols, r.TableName()+"."+c)
+		}
+	}
+	return cols
+}
+
+// LoadWhere loads the first row that matches a WHERE clause into the bound
+// Records.
+//
+// Column names in the clause should be qualified with their table names. If
+// no row matches, sql.ErrNoRows is returned.
+func (j *Joined) LoadWhere(pred interface{}, args ...interface{}) error {
+	q, err := j.query()
+	if err != nil {
+		return err
+	}
+	return j.scan(j.recs, j.recs[0].queryRow(OpLoadWhere, q.Where(pred, args...)))
+}
+
+// ListWhere runs the join and returns one row of Recorders per result.
+//
+// Each row holds new Recorders, bound to new Records, in the same order as
+// the Recorders given to Join. The WhereFunc receives the first Recorder and
+// the select; it may be nil.
+func (j *Joined) ListWhere(fn WhereFunc) ([][]Recorder, error) {
+	buf := [][]Recorder{}
+	q, err := j.query()
+	if err != nil {
+		return buf, err
+	}
+	if fn != nil {
+		if q, err = fn(j.recs[0], q); err != nil {
+			return buf, err
+		}
+	}
+
+	rows, err := j.recs[0].query(OpList, q)
+	if err != nil || rows == nil {
+		return buf, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		recs := make([]*DbRecorder, len(j.recs))
+		row := make([]Recorder, len(j.recs))
+		for i, r := range j.recs {
+			recs[i] = r.Clone(nil)
+			row[i] = recs[i]
+		}
+		if err := j.scan(recs, rows); err != nil {
+			return buf, err
+		}
+		buf = append(buf, row)
+	}
+	return buf, rows.Err()
+}
+
+// query builds the join, without any conditions.
+func (j *Joined) query() (squirrel.SelectBuilder, error) {
+	first := j.recs[0]
+	q := first.builder.Select(j.Columns()...).From(first.TableName())
+	if len(j.on) != len(j.recs)-1 {
+		return q, fmt.Errorf("a join of %d tables needs %d On conditions, got %d", len(j.recs), len(j.recs)-1, len(j.on))
+	}
+	for i, r := range j.recs[1:] {
+		q = q.Join(r.TableName()+" ON "+j.on[i].pred, j.on[i].args...)
+	}
+	for _, r := range j.recs {
+		if err := r.checkTenant(); err != nil {
+			return q, err
+		}
+		if f := r.tenantField(); f != nil {
+			q = q.Where(squirrel.Eq{r.TableName() + "." + f.column: r.tenant})
+		}
+	}
+	return q, nil
+}
+
+// scan scans one row of the join into the Records of recs.
+func (j *Joined) scan(recs []*DbRecorder, row squirrel.RowScanner) error {
+	dest := []interface{}{}
+	finish := make([]func() error, len(recs))
+	for i, r := range recs {
+		var d []interface{}
+		d, finish[i] = r.scanDest(r.fieldList(true))
+		dest = append(dest, d...)
+	}
+	if err := row.Scan(dest...); err != nil {
+		return err
+	}
+	for _, f := range finish {
+		if err := f(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// boundRecorder returns a *DbRecorder that is bound to r's Record, so that
+// loading through it fills in r.
+func boundRecorder(r Recorder) *DbRecorder {
+	if dr, ok := r.(*DbRecorder); ok {
+		return dr
+	}
+	dr := New(r.DB(), r.Driver())
+	dr.Bind(r.TableName(), r.Interface())
+	return dr
+}
@@ -0,0 +1,30 @@
+package structable
+
+import (
+	"testing"
+)
+
+type profile struct {
+	Id        int    `stbl:"id,PRIMARY_KEY,SERIAL"`
+	AccountId int    `stbl:"account_id"`
+	Bio       string `stbl:"bio"`
+}
+
+func TestJoinLoadWhere(t *testing.T) {
+	db := &DBStub{}
+	a := New(db, "postgres").Bind("accounts", &account{})
+	p := New(db, "postgres").Bind("profiles", &profile{})
+
+	err := Join(a, p).On("profiles.account_id = accounts.id").LoadWhere("accounts.email = ?", "matt@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "SELECT accounts.id, accounts.email, accounts.name, profiles.id, profiles.account_id, profiles.bio FROM accounts JOIN profiles ON profiles.account_id = accounts.id WHERE accounts.email = $1"
+	if db.LastQueryRowSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQueryRowSql)
+	}
+
+	if err := Join(a, p).LoadWhere("accounts.id = ?", 1); err == nil {
+		t.Error("Expected a join without On to fail")
+	}
+}
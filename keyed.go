@@ -0,0 +1,93 @@
+package structable
+
+import (
+	"fmt"
+	"strings"
+)
+
+// InsertKeyed inserts the bound Record with the values of its AUTO_INCREMENT
+// fields, instead of letting the database generate them.
+//
+// This is meant for data migrations and fixtures, which must keep the IDs of
+// the rows they copy. Since the IDs are given, they are not read back with
+// LastInsertId. On postgres, every column is still read back with RETURNING,
+// as by Insert.
+//
+// Inserting an explicit value does not advance a postgres sequence, so later
+// calls to Insert may generate IDs that are already taken. Call
+// ResetSequences after the rows are inserted, or turn on
+// SetResetSequences to have InsertKeyed do it after every row. MySQL and
+// SQLite advance AUTO_INCREMENT by themselves. On MSSQL, IDENTITY_INSERT must
+// be turned on for the table first.
+func (s *DbRecorder) InsertKeyed() error {
+	defer s.clearExprs()
+	if err := s.setTenant(); err != nil {
+		return err
+	}
+	if err := s.validate(OpInsert); err != nil {
+		return err
+	}
+	if err := s.putExternal(s.fields); err != nil {
+		return err
+	}
+
+	cols, vals := s.colValLists(OpInsert, true, true)
+	q := s.builder.Insert(s.TableName()).Columns(cols...).Values(vals...)
+	if s.flavor == "postgres" {
+		q = q.Suffix("RETURNING " + strings.Join(s.colList(true, false), ","))
+		if err := s.scan(s.queryRow(OpInsert, q), true); err != nil {
+			return err
+		}
+		if s.resetSequences {
+			return s.ResetSequences()
+		}
+		return nil
+	}
+
+	if _, err := s.exec(OpInsert, q); err != nil || !s.refreshAfterWrite {
+		return err
+	}
+	return s.Reload()
+}
+
+// SetResetSequences sets whether InsertKeyed resets the postgres sequences of
+// the table after each row. See ResetSequences.
+//
+// For bulk loads, it is cheaper to leave this off and call ResetSequences
+// once, after the last row.
+func (s *DbRecorder) SetResetSequences(on bool) *DbRecorder {
+	s.resetSequences = on
+	return s
+}
+
+// ResetSequences moves the postgres sequence of each AUTO_INCREMENT column
+// past the largest value in the table, so that the next generated ID is not
+// already taken:
+//
+//	for _, r := range rows {
+//		if err := r.InsertKeyed(); err != nil {
+//			return err
+//		}
+//	}
+//	return rows[0].ResetSequences()
+//
+// The sequence is found with pg_get_serial_sequence, so the column must be a
+// SERIAL or IDENTITY column. On other flavors, this does nothing.
+func (s *DbRecorder) ResetSequences() error {
+	if s.flavor != "postgres" {
+		return nil
+	}
+	for _, f := range s.fields {
+		if !f.isAuto {
+			continue
+		}
+		q := s.builder.Select().
+			Column(fmt.Sprintf("setval(pg_get_serial_sequence(?, ?), COALESCE(MAX(%s), 0) + 1, false)", f.column), s.TableName(), f.column).
+			From(s.TableName())
+		var next int64
+		if err := s.queryRow(OpQuery, q).Scan(&next); err != nil {
+			return fmt.Errorf("could not reset the sequence of %s.%s: %s", s.table, f.column, err)
+		}
+	}
+	return nil
+}
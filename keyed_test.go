@@ -0,0 +1,44 @@
+package structable
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInsertKeyed(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "mysql").Bind("test_table", newStool())
+
+	if err := r.(*DbRecorder).InsertKeyed(); err != nil {
+		t.Fatal(err)
+	}
+	expect := "INSERT INTO test_table (id,id_two,number_of_legs,material) VALUES (?,?,?,?)"
+	if db.LastExecSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastExecSql)
+	}
+	if !reflect.DeepEqual(db.LastExecArgs, []interface{}{1, 2, 3, "Stainless Steel"}) {
+		t.Errorf("Unexpected args %v", db.LastExecArgs)
+	}
+
+	db = &DBStub{}
+	pg := New(db, "postgres")
+	pg.Bind("categories", &category{Id: 7, Name: "tools"})
+	if err := pg.InsertKeyed(); err != nil {
+		t.Fatal(err)
+	}
+	expect = "INSERT INTO categories (id,parent_id,name) VALUES ($1,$2,$3) RETURNING id,parent_id,name"
+	if db.LastQueryRowSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQueryRowSql)
+	}
+
+	if err := pg.SetResetSequences(true).InsertKeyed(); err != nil {
+		t.Fatal(err)
+	}
+	expect = "SELECT setval(pg_get_serial_sequence($1, $2), COALESCE(MAX(id), 0) + 1, false) FROM categories"
+	if db.LastQueryRowSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQueryRowSql)
+	}
+	if !reflect.DeepEqual(db.LastQueryRowArgs, []interface{}{"categories", "id"}) {
+		t.Errorf("Unexpected args %v", db.LastQueryRowArgs)
+	}
+}
@@ -0,0 +1,51 @@
+package structable
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrMissingKey is returned by Update, Delete, and ApplyChanges when every
+// primary key field of the Record holds its zero value.
+var ErrMissingKey = errors.New("primary key is not set")
+
+// SetAllowZeroKeys sets whether Update, Delete, and ApplyChanges may write
+// with a primary key that holds only zero values.
+//
+// By default, they return ErrMissingKey instead, since a Record whose key was
+// never set would otherwise update or delete WHERE id = 0, which matches
+// nothing, or a row that really has the key 0. Tables that do use zero keys
+// can turn the guard off here, or for a single write with ForceKeyedWrite.
+func (s *DbRecorder) SetAllowZeroKeys(on bool) *DbRecorder {
+	s.allowZeroKeys = on
+	return s
+}
+
+// ForceKeyedWrite returns a copy of the DbRecorder that writes even if the
+// primary key holds only zero values. The copy is bound to the same Record:
+//
+//	root.Id = 0
+//	err := root.ForceKeyedWrite().Update()
+//
+// See SetAllowZeroKeys.
+func (s *DbRecorder) ForceKeyedWrite() *DbRecorder {
+	c := *s
+	c.allowZeroKeys = true
+	return &c
+}
+
+// checkKeys returns ErrMissingKey if the Record has a primary key, and every
+// key field holds its zero value.
+func (s *DbRecorder) checkKeys() error {
+	if s.allowZeroKeys || len(s.key) == 0 {
+		return nil
+	}
+	ar := reflect.Indirect(reflect.ValueOf(s.record))
+	for _, f := range s.key {
+		if !ar.FieldByName(f.name).IsZero() {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: table %s", ErrMissingKey, s.table)
+}
@@ -0,0 +1,46 @@
+package structable
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMissingKey(t *testing.T) {
+	db := &DBStub{}
+	stool := &Stool{Material: "wood"}
+	r := New(db, "mysql")
+	r.Bind("test_table", stool)
+
+	if err := r.Update(); !errors.Is(err, ErrMissingKey) {
+		t.Errorf("Expected ErrMissingKey from Update, got %v", err)
+	}
+	if err := r.Delete(); !errors.Is(err, ErrMissingKey) {
+		t.Errorf("Expected ErrMissingKey from Delete, got %v", err)
+	}
+	if err := r.ApplyChanges(map[string]interface{}{"material": "oak"}, "material"); !errors.Is(err, ErrMissingKey) {
+		t.Errorf("Expected ErrMissingKey from ApplyChanges, got %v", err)
+	}
+	if _, _, err := r.UpdateSQL(); !errors.Is(err, ErrMissingKey) {
+		t.Errorf("Expected ErrMissingKey from UpdateSQL, got %v", err)
+	}
+	if db.LastExecSql != "" || stool.Material != "wood" {
+		t.Errorf("Expected nothing to be written, got %q", db.LastExecSql)
+	}
+
+	if err := r.ForceKeyedWrite().Delete(); err != nil {
+		t.Errorf("Expected ForceKeyedWrite to delete, got %v", err)
+	}
+	if err := r.Delete(); !errors.Is(err, ErrMissingKey) {
+		t.Errorf("Expected ForceKeyedWrite to leave the DbRecorder guarded, got %v", err)
+	}
+	if err := r.SetAllowZeroKeys(true).Update(); err != nil {
+		t.Errorf("Expected SetAllowZeroKeys to allow the update, got %v", err)
+	}
+
+	// One set field of a composite key is enough.
+	r.SetAllowZeroKeys(false)
+	stool.Id2 = 2
+	if err := r.Update(); err != nil {
+		t.Errorf("Expected a partly set key to update, got %v", err)
+	}
+}
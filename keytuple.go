@@ -0,0 +1,65 @@
+package structable
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// KeyValues returns the values of the primary key fields, in the order of
+// the columns returned by Key.
+//
+// Together with TableName, the values identify a row without the caller
+// knowing the names of the key fields, so generic code, such as a cache, can
+// key records of any type uniformly:
+//
+//	id := fmt.Sprint(r.TableName(), r.KeyValues())
+//
+// Note that DeleteAll and UpdateAll take key tuples in the order of the
+// sorted key column names, which may differ from the order of Key.
+func (s *DbRecorder) KeyValues() []interface{} {
+	ar := reflect.Indirect(reflect.ValueOf(s.record))
+	vals := make([]interface{}, len(s.key))
+	for i, f := range s.key {
+		vals[i] = ar.FieldByName(f.name).Interface()
+	}
+	return vals
+}
+
+// SetKey sets the primary key fields of the bound Record, from values in the
+// order of the columns returned by Key. Values are converted to the field
+// types as by SetValues.
+//
+// There must be exactly one value per key column. If there is not, or a value
+// cannot be converted, an error is returned and the Record is left unchanged.
+func (s *DbRecorder) SetKey(values ...interface{}) error {
+	if len(s.key) == 0 {
+		return fmt.Errorf("table %s has no primary key", s.table)
+	}
+	if len(values) != len(s.key) {
+		return fmt.Errorf("table %s has %d key columns, got %d values", s.table, len(s.key), len(values))
+	}
+	ar := reflect.Indirect(reflect.ValueOf(s.record))
+	set := make([]reflect.Value, len(values))
+	for i, f := range s.key {
+		fv, err := convertField(ar.FieldByName(f.name).Type(), values[i])
+		if err != nil {
+			return fmt.Errorf("cannot set field %s from column %s: %w", f.name, f.column, err)
+		}
+		set[i] = fv
+	}
+	for i, f := range s.key {
+		ar.FieldByName(f.name).Set(set[i])
+	}
+	return nil
+}
+
+// LoadByKey sets the primary key of the bound Record, as by SetKey, and loads
+// the Record by it, as by Load:
+//
+//	err := r.LoadByKey(orderId, lineNo)
+func (s *DbRecorder) LoadByKey(values ...interface{}) error {
+	if err := s.SetKey(values...); err != nil {
+		return err
+	}
+	return s.Load()
+}
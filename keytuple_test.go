@@ -0,0 +1,63 @@
+package structable
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeyValues(t *testing.T) {
+	s := newStool()
+	r := New(&DBStub{}, "mysql")
+	r.Bind("test_table", s)
+	if vals := r.KeyValues(); !reflect.DeepEqual(vals, []interface{}{1, 2}) {
+		t.Errorf("Expected [1 2], got %v", vals)
+	}
+
+	if err := r.SetKey("3", 4); err != nil {
+		t.Fatal(err)
+	}
+	if s.Id != 3 || s.Id2 != 4 {
+		t.Errorf("Expected the key to be set, got %d, %d", s.Id, s.Id2)
+	}
+	if err := r.SetKey(5); err == nil {
+		t.Error("Expected too few values to fail")
+	}
+	if err := r.SetKey(5, "six"); err == nil || s.Id != 3 {
+		t.Errorf("Expected a bad value to fail and leave the key alone, got %v, %d", err, s.Id)
+	}
+
+	r = New(&DBStub{}, "mysql")
+	r.Bind("things", &struct {
+		Name string `stbl:"name"`
+	}{})
+	if err := r.SetKey(1); err == nil {
+		t.Error("Expected a table without a key to fail")
+	}
+}
+
+func TestLoadByKey(t *testing.T) {
+	db := &DBStub{}
+	s := new(Stool)
+	r := New(db, "postgres")
+	r.Bind("test_table", s)
+	if err := r.LoadByKey(7, 8); err != nil {
+		t.Fatal(err)
+	}
+	expect := "SELECT number_of_legs, material, color FROM test_table WHERE id = $1 AND id_two = $2"
+	if db.LastQueryRowSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQueryRowSql)
+	}
+	if !reflect.DeepEqual(db.LastQueryRowArgs, []interface{}{7, 8}) {
+		t.Errorf("Unexpected args %v", db.LastQueryRowArgs)
+	}
+}
+
+func TestRecorderKey(t *testing.T) {
+	var r Recorder = New(&DBStub{}, "mysql").Bind("test_table", newStool())
+	if !reflect.DeepEqual(r.Key(), []string{"id", "id_two"}) {
+		t.Errorf("Unexpected key columns %v", r.Key())
+	}
+	if !reflect.DeepEqual(r.KeyFields(), []string{"Id", "Id2"}) {
+		t.Errorf("Unexpected key fields %v", r.KeyFields())
+	}
+}
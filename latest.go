@@ -0,0 +1,111 @@
+package structable
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// LatestPerGroup lists the newest Record in each group.
+//
+// Rows are grouped by groupColumn, and within each group the row with the
+// greatest orderColumn is returned. For example, to get the most recent
+// release of each language:
+//
+//	latest, err := structable.LatestPerGroup(r, "name", "dt_release")
+//
+// On Postgres and SQLite this uses ROW_NUMBER() OVER (PARTITION BY ...), which
+// returns exactly one row per group. Other flavors use a correlated subquery
+// against MAX(orderColumn), which returns every row that ties for the newest
+// in a group.
+//
+// If d is scoped to a tenant, only that tenant's rows are compared.
+//
+// Both columns must be columns on the bound Record.
+func LatestPerGroup(d Recorder, groupColumn, orderColumn string) ([]Recorder, error) {
+	if err := checkColumns(d, groupColumn, orderColumn); err != nil {
+		return []Recorder{}, err
+	}
+	return ListWhere(d, latestPerGroup(protoRecorder(d), groupColumn, orderColumn))
+}
+
+// latestPerGroup builds the WhereFunc for LatestPerGroup. Rows are ranked
+// among the rows of dr's tenant only.
+func latestPerGroup(dr *DbRecorder, group, order string) WhereFunc {
+	return func(desc Describer, q squirrel.SelectBuilder) (squirrel.SelectBuilder, error) {
+		tn := desc.TableName()
+		switch desc.Driver() {
+		case "postgres", "sqlite3", "sqlite":
+			rn := fmt.Sprintf("ROW_NUMBER() OVER (PARTITION BY %s ORDER BY %s DESC) AS structable_rn", group, order)
+			cols := append(desc.Columns(true), rn)
+			sub := desc.Builder().Select(cols...).From(tn).Where(dr.tenantWhere())
+			return q.FromSelect(sub, "structable_latest").Where("structable_rn = 1"), nil
+		default:
+			scope, args := "", []interface{}{}
+			if f := dr.tenantField(); f != nil && dr.tenant != nil {
+				scope = fmt.Sprintf(" AND structable_latest.%s = ?", f.column)
+				args = append(args, dr.tenant)
+			}
+			return q.Where(fmt.Sprintf(
+				"%[1]s.%[3]s = (SELECT MAX(structable_latest.%[3]s) FROM %[1]s structable_latest WHERE structable_latest.%[2]s = %[1]s.%[2]s%[4]s)",
+				tn, group, order, scope), args...), nil
+		}
+	}
+}
+
+// First loads the Record that sorts first into d's bound Record.
+//
+// By default, Records are sorted by their primary key. Order clauses may be
+// given instead, as for WithOrderBy:
+//
+//	err := structable.First(r)
+//	err := structable.First(r, "dt_release", "name DESC")
+//
+// If the table is empty, sql.ErrNoRows is returned.
+func First(d Recorder, orderBys ...string) error {
+	return loadFirst(d, orderBys, false)
+}
+
+// Last loads the Record that sorts last into d's bound Record.
+//
+// It takes the same order clauses as First, and reverses each of them, so
+// Last(r, "dt_release") loads the Record with the latest dt_release.
+func Last(d Recorder, orderBys ...string) error {
+	return loadFirst(d, orderBys, true)
+}
+
+// loadFirst loads the first Record in the given order, or in the reverse
+// order, into d.
+func loadFirst(d Recorder, orderBys []string, reverse bool) error {
+	if len(orderBys) == 0 {
+		orderBys = d.Key()
+		if len(orderBys) == 0 {
+			return fmt.Errorf("table %s has no primary key to order by", d.TableName())
+		}
+	}
+	if reverse {
+		rev := make([]string, len(orderBys))
+		for i, o := range orderBys {
+			parts := strings.Fields(o)
+			if len(parts) == 2 && strings.ToUpper(parts[1]) == "DESC" {
+				rev[i] = parts[0] + " ASC"
+			} else if len(parts) > 0 {
+				rev[i] = parts[0] + " DESC"
+			} else {
+				rev[i] = o
+			}
+		}
+		orderBys = rev
+	}
+
+	found, err := ListWhere(d, Compose(WithOrderBy(orderBys...), WithLimit(1)))
+	if err != nil {
+		return err
+	}
+	if len(found) == 0 {
+		return sql.ErrNoRows
+	}
+	return copyFields(d, found[0])
+}
@@ -0,0 +1,117 @@
+package structable
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestLatestPerGroup(t *testing.T) {
+	stool := newStool()
+	db := &DBStub{}
+	r := New(db, "postgres").Bind("test_table", stool)
+
+	if _, err := LatestPerGroup(r, "material", "id"); err != nil {
+		t.Fatal(err)
+	}
+	expect := "SELECT id, id_two, number_of_legs, material, color FROM " +
+		"(SELECT id, id_two, number_of_legs, material, color, ROW_NUMBER() OVER (PARTITION BY material ORDER BY id DESC) AS structable_rn FROM test_table) AS structable_latest " +
+		"WHERE structable_rn = 1"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+
+	r = New(db, "mysql").Bind("test_table", stool)
+	if _, err := LatestPerGroup(r, "material", "id"); err != nil {
+		t.Fatal(err)
+	}
+	expect = "SELECT id, id_two, number_of_legs, material, color FROM test_table " +
+		"WHERE test_table.id = (SELECT MAX(structable_latest.id) FROM test_table structable_latest WHERE structable_latest.material = test_table.material)"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+
+	r = New(db, "sqlite").Bind("test_table", stool)
+	if _, err := LatestPerGroup(r, "material", "id"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(db.LastQuerySql, "ROW_NUMBER() OVER") {
+		t.Errorf("Expected the sqlite flavor to use a window function, got %q", db.LastQuerySql)
+	}
+
+	if _, err := LatestPerGroup(r, "material", "nope"); err == nil {
+		t.Error("Expected unknown column to fail")
+	}
+}
+
+func TestLatestPerGroupTenant(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "postgres").WithTenant(7)
+	r.Bind("invoices", &invoice{})
+
+	if _, err := LatestPerGroup(r, "total", "id"); err != nil {
+		t.Fatal(err)
+	}
+	expect := "SELECT id, org_id, total FROM " +
+		"(SELECT id, org_id, total, ROW_NUMBER() OVER (PARTITION BY total ORDER BY id DESC) AS structable_rn FROM invoices WHERE org_id = $1) AS structable_latest " +
+		"WHERE org_id = $2 AND structable_rn = 1"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+
+	r = New(db, "mysql").WithTenant(7)
+	r.Bind("invoices", &invoice{})
+	if _, err := LatestPerGroup(r, "total", "id"); err != nil {
+		t.Fatal(err)
+	}
+	expect = "SELECT id, org_id, total FROM invoices WHERE org_id = ? AND " +
+		"invoices.id = (SELECT MAX(structable_latest.id) FROM invoices structable_latest WHERE structable_latest.total = invoices.total AND structable_latest.org_id = ?)"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+	if len(db.LastQueryArgs) != 2 || db.LastQueryArgs[1] != 7 {
+		t.Errorf("Unexpected args %v", db.LastQueryArgs)
+	}
+}
+
+func TestFirstLast(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "mysql").Bind("test_table", newStool())
+
+	// The stub returns no rows.
+	if err := First(r); err != sql.ErrNoRows {
+		t.Errorf("Expected sql.ErrNoRows, got %v", err)
+	}
+	expect := "SELECT id, id_two, number_of_legs, material, color FROM test_table ORDER BY id, id_two LIMIT 1"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+
+	Last(r)
+	expect = "SELECT id, id_two, number_of_legs, material, color FROM test_table ORDER BY id DESC, id_two DESC LIMIT 1"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+
+	Last(r, "material", "number_of_legs desc")
+	expect = "SELECT id, id_two, number_of_legs, material, color FROM test_table ORDER BY material DESC, number_of_legs ASC LIMIT 1"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+
+	if err := First(r, "nope"); err == nil || err == sql.ErrNoRows {
+		t.Errorf("Expected unknown column to fail, got %v", err)
+	}
+
+	// The default order follows the declared order of the key.
+	type edition struct {
+		Year  int `stbl:"year,PRIMARY_KEY"`
+		Issue int `stbl:"issue,PRIMARY_KEY"`
+	}
+	r = New(db, "mysql").Bind("editions", &edition{})
+	Last(r)
+	expect = "SELECT year, issue FROM editions ORDER BY year DESC, issue DESC LIMIT 1"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+}
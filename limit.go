@@ -0,0 +1,38 @@
+package structable
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTooManyRows is returned when a list exceeds a recorder's MaxRows.
+var ErrTooManyRows = errors.New("too many rows")
+
+// OverflowFunc decides what happens when a list exceeds MaxRows.
+//
+// If it returns an error, the list operation returns that error along with
+// the first max rows. If it returns nil, the list is silently truncated to max
+// rows. The callback is a convenient place to log the offending table.
+type OverflowFunc func(table string, max uint64) error
+
+// SetMaxRows caps the number of rows that List and ListWhere will read.
+//
+// When max is greater than zero, list queries get a `LIMIT max+1` unless the
+// WhereFunc sets its own limit. If more than max rows come back, onOverflow
+// is called. A nil onOverflow returns an error wrapping ErrTooManyRows.
+//
+// This protects services from accidentally reading entire large tables into
+// memory.
+func (s *DbRecorder) SetMaxRows(max uint64, onOverflow OverflowFunc) *DbRecorder {
+	s.maxRows = max
+	s.onOverflow = onOverflow
+	return s
+}
+
+// overflow reports that a list on this recorder went past maxRows.
+func (s *DbRecorder) overflow() error {
+	if s.onOverflow == nil {
+		return fmt.Errorf("%w: more than %d rows in %s", ErrTooManyRows, s.maxRows, s.table)
+	}
+	return s.onOverflow(s.table, s.maxRows)
+}
@@ -0,0 +1,34 @@
+package structable
+
+import (
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+)
+
+func TestMaxRowsLimit(t *testing.T) {
+	stool := newStool()
+	db := &DBStub{}
+
+	r := New(db, "mysql").SetMaxRows(100, nil)
+	r.Bind("test_table", stool)
+
+	if _, err := ListWhere(r, func(d Describer, q squirrel.SelectBuilder) (squirrel.SelectBuilder, error) {
+		return q, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	expect := "SELECT id, id_two, number_of_legs, material, color FROM test_table LIMIT 101"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+
+	// An explicit limit wins.
+	if _, err := List(r, WithLimit(10), WithOffset(0)); err != nil {
+		t.Fatal(err)
+	}
+	expect = "SELECT id, id_two, number_of_legs, material, color FROM test_table LIMIT 10 OFFSET 0"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+}
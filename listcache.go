@@ -0,0 +1,155 @@
+package structable
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ListCache caches the results of List and ListWhere for a short time.
+//
+// Results are keyed by the normalized text of the select and its arguments,
+// so two lists that run the same statement share an entry. Every statement
+// that a DbRecorder with the cache runs outside of List (Insert, Update,
+// Delete, and so on) invalidates the entries of its table:
+//
+//	lists := structable.NewListCache(5 * time.Second)
+//	r := structable.New(db, "postgres").SetListCache(lists)
+//
+// Only writes made through DbRecorders that share the cache invalidate it.
+// Writes made elsewhere, by other processes or with raw SQL, are seen once
+// the TTL expires, so keep it short. Entries are only invalidated by writes
+// to the bound table: a WhereFunc that joins other tables sees their changes
+// after the TTL.
+//
+// The cache holds shallow copies of the listed Records, and each List
+// returns new copies, so changing a listed Record does not change the cache.
+// A ListCache is safe for concurrent use.
+type ListCache struct {
+	// TTL is how long results are kept.
+	TTL time.Duration
+	// MaxEntries caps the number of cached results. Zero means no limit.
+	MaxEntries int
+
+	mu      sync.Mutex
+	entries map[string]listEntry
+	gens    map[string]uint64
+	now     func() time.Time
+}
+
+type listEntry struct {
+	table   string
+	gen     uint64
+	expires time.Time
+	records []Record
+}
+
+// NewListCache creates a ListCache that keeps results for ttl.
+func NewListCache(ttl time.Duration) *ListCache {
+	return &ListCache{
+		TTL:     ttl,
+		entries: map[string]listEntry{},
+		gens:    map[string]uint64{},
+		now:     time.Now,
+	}
+}
+
+// SetListCache sets the ListCache for List and ListWhere on this recorder.
+//
+// Pass nil to stop caching.
+func (s *DbRecorder) SetListCache(c *ListCache) *DbRecorder {
+	s.lists = c
+	return s
+}
+
+// Invalidate drops every cached result for a table.
+func (c *ListCache) Invalidate(table string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gens[table]++
+	for k, e := range c.entries {
+		if e.table == table {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// Len returns the number of cached results, including expired ones that have
+// not been dropped yet.
+func (c *ListCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// listKey builds the cache key for a statement. Runs of whitespace in the
+// statement are collapsed, so formatting does not matter.
+func listKey(query string, args []interface{}) string {
+	return strings.Join(strings.Fields(query), " ") + "\x00" + fmt.Sprintf("%#v", args)
+}
+
+// get returns copies of the cached Records for key, and the generation of the
+// table, which must be passed to put.
+func (c *ListCache) get(table, key string) ([]Record, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	gen := c.gens[table]
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, gen, false
+	}
+	if e.gen != gen || !c.now().Before(e.expires) {
+		delete(c.entries, key)
+		return nil, gen, false
+	}
+	return copyRecords(e.records), gen, true
+}
+
+// put caches copies of records, unless the table was written to since gen was
+// read.
+func (c *ListCache) put(table, key string, gen uint64, records []Record) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.gens[table] != gen {
+		return
+	}
+	now := c.now()
+	if c.MaxEntries > 0 && len(c.entries) >= c.MaxEntries {
+		for k, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= c.MaxEntries {
+			return
+		}
+	}
+	c.entries[key] = listEntry{
+		table:   table,
+		gen:     gen,
+		expires: now.Add(c.TTL),
+		records: copyRecords(records),
+	}
+}
+
+// copyRecords returns shallow copies of records, which are pointers to
+// structs.
+func copyRecords(records []Record) []Record {
+	out := make([]Record, len(records))
+	for i, r := range records {
+		v := reflect.ValueOf(r)
+		c := reflect.New(v.Elem().Type())
+		c.Elem().Set(v.Elem())
+		out[i] = c.Interface()
+	}
+	return out
+}
+
+// invalidateLists drops the cached lists of the bound table after a write.
+func (s *DbRecorder) invalidateLists() {
+	if s.lists != nil {
+		s.lists.Invalidate(s.TableName())
+	}
+}
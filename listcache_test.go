@@ -0,0 +1,82 @@
+package structable
+
+import (
+	"testing"
+	"time"
+)
+
+func TestListKey(t *testing.T) {
+	a := listKey("SELECT id\n\tFROM t  WHERE a = ?", []interface{}{1})
+	b := listKey("SELECT id FROM t WHERE a = ?", []interface{}{1})
+	if a != b {
+		t.Errorf("Expected whitespace to be normalized, got %q and %q", a, b)
+	}
+	if a == listKey("SELECT id FROM t WHERE a = ?", []interface{}{"1"}) {
+		t.Error("Expected arguments of different types to have different keys")
+	}
+}
+
+func TestListCache(t *testing.T) {
+	now := time.Date(2015, 6, 23, 0, 0, 0, 0, time.UTC)
+	c := NewListCache(time.Second)
+	c.now = func() time.Time { return now }
+
+	stool := newStool()
+	_, gen, ok := c.get("test_table", "k")
+	if ok {
+		t.Fatal("Expected a miss on an empty cache")
+	}
+	c.put("test_table", "k", gen, []Record{stool})
+
+	recs, _, ok := c.get("test_table", "k")
+	if !ok || len(recs) != 1 {
+		t.Fatalf("Expected a hit, got %v", recs)
+	}
+	if recs[0] == Record(stool) || recs[0].(*Stool).Material != stool.Material {
+		t.Error("Expected a copy of the cached Record")
+	}
+	recs[0].(*Stool).Material = "Wood"
+	if recs, _, _ = c.get("test_table", "k"); recs[0].(*Stool).Material == "Wood" {
+		t.Error("Expected changes to a listed Record to leave the cache alone")
+	}
+
+	now = now.Add(time.Second)
+	if _, _, ok := c.get("test_table", "k"); ok {
+		t.Error("Expected the entry to expire")
+	}
+
+	// A write between the read and the put keeps stale results out.
+	_, gen, _ = c.get("test_table", "k")
+	c.Invalidate("test_table")
+	c.put("test_table", "k", gen, []Record{stool})
+	if c.Len() != 0 {
+		t.Error("Expected results read before a write not to be cached")
+	}
+
+	c.MaxEntries = 1
+	_, gen, _ = c.get("test_table", "k")
+	c.put("test_table", "k", gen, []Record{stool})
+	c.put("test_table", "k2", gen, []Record{stool})
+	if c.Len() != 1 {
+		t.Errorf("Expected MaxEntries to cap the cache, got %d entries", c.Len())
+	}
+}
+
+func TestListCacheInvalidation(t *testing.T) {
+	c := NewListCache(time.Minute)
+	_, gen, _ := c.get("test_table", "k")
+	c.put("test_table", "k", gen, []Record{newStool()})
+	c.put("other_table", "o", 0, []Record{newStool()})
+
+	r := New(&DBStub{}, "mysql").SetListCache(c)
+	r.Bind("test_table", newStool())
+	if err := r.Update(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := c.get("test_table", "k"); ok {
+		t.Error("Expected an update to invalidate the table's lists")
+	}
+	if _, _, ok := c.get("other_table", "o"); !ok {
+		t.Error("Expected other tables to stay cached")
+	}
+}
@@ -0,0 +1,64 @@
+package structable
+
+import (
+	"fmt"
+)
+
+// MissingKeysError reports the keys that LoadAllByKeys did not find.
+type MissingKeysError struct {
+	Table string
+	Keys  []interface{}
+}
+
+func (e *MissingKeysError) Error() string {
+	return fmt.Sprintf("%d keys not found in %s: %v", len(e.Keys), e.Table, e.Keys)
+}
+
+// LoadAllByKeys loads the Records with the given primary key values in one
+// query.
+//
+// This issues a single `SELECT ... WHERE key IN (...)` instead of one Load per
+// key. The returned Recorders are in the same order as keys. If any keys are
+// not found, the Records that were found are returned along with a
+// *MissingKeysError:
+//
+//	recs, err := structable.LoadAllByKeys(r, ids)
+//	if merr, ok := err.(*structable.MissingKeysError); ok {
+//		log.Printf("skipping %v", merr.Keys)
+//	} else if err != nil {
+//		return err
+//	}
+//
+// The table must have a single-column primary key.
+func LoadAllByKeys(d Recorder, keys []interface{}) ([]Recorder, error) {
+	buf := []Recorder{}
+	cols := d.Key()
+	if len(cols) != 1 {
+		return buf, fmt.Errorf("table %s must have exactly one primary key to load by keys", d.TableName())
+	}
+	if len(keys) == 0 {
+		return buf, nil
+	}
+
+	found, err := ListWhere(protoRecorder(d), WithWhereEq(cols[0], keys))
+	if err != nil {
+		return buf, err
+	}
+	byKey := make(map[string]Recorder, len(found))
+	for _, f := range found {
+		byKey[fmt.Sprint(f.WhereIds()[cols[0]])] = f
+	}
+
+	var missing []interface{}
+	for _, k := range keys {
+		if f, ok := byKey[fmt.Sprint(k)]; ok {
+			buf = append(buf, f)
+		} else {
+			missing = append(missing, k)
+		}
+	}
+	if len(missing) > 0 {
+		return buf, &MissingKeysError{Table: d.TableName(), Keys: missing}
+	}
+	return buf, nil
+}
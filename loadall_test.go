@@ -0,0 +1,31 @@
+package structable
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLoadAllByKeys(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "postgres").Bind("categories", &category{})
+
+	recs, err := LoadAllByKeys(r, []interface{}{3, 1, 2})
+	expect := "SELECT id, parent_id, name FROM categories WHERE id IN ($1,$2,$3)"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+	// The stub returns no rows, so every key is missing.
+	merr, ok := err.(*MissingKeysError)
+	if !ok || !reflect.DeepEqual(merr.Keys, []interface{}{3, 1, 2}) || len(recs) != 0 {
+		t.Errorf("Expected all keys to be missing, got %v", err)
+	}
+
+	if recs, err := LoadAllByKeys(r, nil); err != nil || len(recs) != 0 {
+		t.Errorf("Expected no keys to load nothing, got %v", err)
+	}
+
+	r = New(db, "postgres").Bind("test_table", newStool())
+	if _, err := LoadAllByKeys(r, []interface{}{1}); err == nil {
+		t.Error("Expected composite key to fail")
+	}
+}
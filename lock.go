@@ -0,0 +1,75 @@
+package structable
+
+import "github.com/Masterminds/squirrel"
+
+// LockMode is the row lock that a load takes, inside a transaction.
+type LockMode int
+
+const (
+	// LockNone takes no lock. It is the default.
+	LockNone LockMode = iota
+	// LockForUpdate locks the rows against other writers and lockers, so
+	// that they can be changed safely.
+	LockForUpdate
+	// LockForShare locks the rows against writers, but lets other
+	// transactions read and share-lock them.
+	LockForShare
+)
+
+// WithLock returns a copy of the DbRecorder whose loads lock the rows they
+// read: Load, LoadWhere, LoadColumns, and LoadByUnique. The copy is bound to
+// the same Record.
+//
+// The locking clause is written in the flavor's dialect: FOR UPDATE or FOR
+// SHARE on postgres, FOR UPDATE or LOCK IN SHARE MODE on mysql, and the
+// UPDLOCK or HOLDLOCK table hint on mssql. SQLite locks the whole database
+// instead, so no clause is added for sqlite3.
+//
+// Row locks are only held until the end of the transaction, so the
+// DbRecorder should run on a *sql.Tx:
+//
+//	tx, _ := db.Begin()
+//	acct := NewAccount(structable.NewRunner(tx), "postgres")
+//	acct.Id = id
+//	if err := acct.WithLock(structable.LockForUpdate).Load(); err != nil { ... }
+//	acct.Balance -= amount
+//	acct.Update()
+//	tx.Commit()
+func (s *DbRecorder) WithLock(mode LockMode) *DbRecorder {
+	c := *s
+	c.lock = mode
+	return &c
+}
+
+// LoadForUpdate loads the Record like Load, and locks its row FOR UPDATE. See
+// WithLock.
+func (s *DbRecorder) LoadForUpdate() error {
+	return s.WithLock(LockForUpdate).Load()
+}
+
+// lockSelect adds the locking clause of the DbRecorder's LockMode to a
+// select from its table.
+func (s *DbRecorder) lockSelect(q squirrel.SelectBuilder) squirrel.SelectBuilder {
+	if s.lock == LockNone {
+		return q
+	}
+	share := s.lock == LockForShare
+	switch s.flavor {
+	case "postgres":
+		if share {
+			return q.Suffix("FOR SHARE")
+		}
+		return q.Suffix("FOR UPDATE")
+	case "mysql":
+		if share {
+			return q.Suffix("LOCK IN SHARE MODE")
+		}
+		return q.Suffix("FOR UPDATE")
+	case "mssql":
+		if share {
+			return q.From(s.TableName() + " WITH (HOLDLOCK, ROWLOCK)")
+		}
+		return q.From(s.TableName() + " WITH (UPDLOCK, ROWLOCK)")
+	}
+	return q
+}
@@ -0,0 +1,56 @@
+package structable
+
+import "testing"
+
+func TestWithLock(t *testing.T) {
+	tests := []struct {
+		flavor string
+		mode   LockMode
+		expect string
+	}{
+		{"postgres", LockForUpdate, "SELECT name FROM users WHERE id = $1 FOR UPDATE"},
+		{"postgres", LockForShare, "SELECT name FROM users WHERE id = $1 FOR SHARE"},
+		{"mysql", LockForUpdate, "SELECT name FROM users WHERE id = ? FOR UPDATE"},
+		{"mysql", LockForShare, "SELECT name FROM users WHERE id = ? LOCK IN SHARE MODE"},
+		{"mssql", LockForUpdate, "SELECT name FROM users WITH (UPDLOCK, ROWLOCK) WHERE id = ?"},
+		{"sqlite3", LockForUpdate, "SELECT name FROM users WHERE id = ?"},
+		{"mysql", LockNone, "SELECT name FROM users WHERE id = ?"},
+	}
+	for _, tt := range tests {
+		db := &DBStub{}
+		r := New(db, tt.flavor)
+		r.Bind("users", &lockUser{Id: 1})
+		if err := r.WithLock(tt.mode).Load(); err != nil {
+			t.Fatal(err)
+		}
+		if db.LastQueryRowSql != tt.expect {
+			t.Errorf("%s %d: expected %q, got %q", tt.flavor, tt.mode, tt.expect, db.LastQueryRowSql)
+		}
+	}
+
+	db := &DBStub{}
+	r := New(db, "postgres")
+	u := &lockUser{Id: 1}
+	r.Bind("users", u)
+	if err := r.LoadForUpdate(); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "SELECT name FROM users WHERE id = $1 FOR UPDATE"; db.LastQueryRowSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQueryRowSql)
+	}
+
+	// The original does not lock.
+	r.LoadWhere("name = ?", "x")
+	if expect := "SELECT id, name FROM users WHERE name = $1 LIMIT 1"; db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+	r.WithLock(LockForUpdate).LoadWhere("name = ?", "x")
+	if expect := "SELECT id, name FROM users WHERE name = $1 LIMIT 1 FOR UPDATE"; db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+}
+
+type lockUser struct {
+	Id   int    `stbl:"id,PRIMARY_KEY"`
+	Name string `stbl:"name"`
+}
@@ -0,0 +1,43 @@
+package structable
+
+import "time"
+
+// Operation names, as reported to Metrics.
+const (
+	OpLoad        = "load"
+	OpLoadWhere   = "load_where"
+	OpExists      = "exists"
+	OpExistsWhere = "exists_where"
+	OpInsert      = "insert"
+	OpUpdate      = "update"
+	OpDelete      = "delete"
+	OpList        = "list"
+	OpCount       = "count"
+	OpAggregate   = "aggregate"
+	OpQuery       = "query"
+)
+
+// Metrics receives the outcome of every statement that a DbRecorder runs.
+//
+// It is intended for maintaining counters and histograms (for example, in
+// Prometheus) per table and per operation. The op is one of the Op*
+// constants, and table is the name of the bound table.
+type Metrics interface {
+	ObserveQuery(op, table string, d time.Duration, err error)
+}
+
+// MetricsFunc adapts an ordinary function to the Metrics interface.
+type MetricsFunc func(op, table string, d time.Duration, err error)
+
+// ObserveQuery calls f(op, table, d, err).
+func (f MetricsFunc) ObserveQuery(op, table string, d time.Duration, err error) {
+	f(op, table, d, err)
+}
+
+// SetMetrics sets the Metrics that observe every statement.
+//
+// Pass nil to remove it.
+func (s *DbRecorder) SetMetrics(m Metrics) *DbRecorder {
+	s.metrics = m
+	return s
+}
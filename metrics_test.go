@@ -0,0 +1,41 @@
+package structable
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	stool := newStool()
+	db := &DBStub{}
+
+	ops := []string{}
+	m := MetricsFunc(func(op, table string, d time.Duration, err error) {
+		if table != "test_table" {
+			t.Errorf("Expected table test_table, got %s", table)
+		}
+		ops = append(ops, op)
+	})
+
+	r := New(db, "mysql").SetMetrics(m)
+	r.Bind("test_table", stool)
+
+	r.Load()
+	r.LoadWhere("number_of_legs = ?", 3)
+	r.Exists()
+	r.ExistsWhere("number_of_legs = ?", 3)
+	r.Insert()
+	r.Update()
+	r.Delete()
+	List(r, WithLimit(10), WithOffset(0))
+
+	expect := []string{OpLoad, OpLoadWhere, OpExists, OpExistsWhere, OpInsert, OpUpdate, OpDelete, OpList}
+	if len(ops) != len(expect) {
+		t.Fatalf("Expected %v, got %v", expect, ops)
+	}
+	for i := range expect {
+		if ops[i] != expect[i] {
+			t.Errorf("Expected %s, got %s", expect[i], ops[i])
+		}
+	}
+}
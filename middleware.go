@@ -0,0 +1,60 @@
+package structable
+
+// RecorderMiddleware adds behavior to a Recorder by wrapping it.
+//
+// The Recorder it returns usually embeds next, and overrides the operations it
+// is interested in:
+//
+//	type auditRecorder struct {
+//		structable.Recorder
+//		log *log.Logger
+//	}
+//
+//	func (a *auditRecorder) Delete() error {
+//		a.log.Printf("delete from %s: %v", a.TableName(), a.WhereIds())
+//		return a.Recorder.Delete()
+//	}
+//
+//	func Audit(l *log.Logger) structable.RecorderMiddleware {
+//		return func(next structable.Recorder) structable.Recorder {
+//			return &auditRecorder{Recorder: next, log: l}
+//		}
+//	}
+type RecorderMiddleware func(next Recorder) Recorder
+
+// Wrap applies middleware to a Recorder. The first middleware is the
+// outermost, so it sees each operation first.
+//
+//	r := structable.Wrap(rec, Audit(logger), tenancy)
+//
+// List and ListWhere accept the wrapped Recorder. They run their queries
+// through the innermost *DbRecorder, with its settings, and wrap each Recorder
+// they return with the same middleware.
+func Wrap(rec Recorder, mids ...RecorderMiddleware) Recorder {
+	next := rec
+	for i := len(mids) - 1; i >= 0; i-- {
+		next = mids[i](next)
+	}
+	return &wrapped{Recorder: next, base: rec, mids: mids}
+}
+
+// wrapped is a Recorder with middleware, which remembers the Recorder and the
+// middleware it was built from.
+type wrapped struct {
+	Recorder
+	base Recorder
+	mids []RecorderMiddleware
+}
+
+// Unwrap returns the Recorder that the middleware was applied to.
+func (w *wrapped) Unwrap() Recorder {
+	return w.base
+}
+
+// wrapLike applies the middleware of d, if any, to r.
+func wrapLike(d, r Recorder) Recorder {
+	if w, ok := d.(*wrapped); ok {
+		return Wrap(r, w.mids...)
+	}
+	return r
+}
@@ -0,0 +1,56 @@
+package structable
+
+import (
+	"reflect"
+	"testing"
+)
+
+type loggingRecorder struct {
+	Recorder
+	name string
+	log  *[]string
+}
+
+func (l *loggingRecorder) Insert() error {
+	*l.log = append(*l.log, l.name)
+	return l.Recorder.Insert()
+}
+
+func logging(name string, log *[]string) RecorderMiddleware {
+	return func(next Recorder) Recorder {
+		return &loggingRecorder{Recorder: next, name: name, log: log}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "mysql")
+	r.Bind("test_table", newStool())
+
+	log := []string{}
+	w := Wrap(r, logging("outer", &log), logging("inner", &log))
+	if err := w.Insert(); err != nil {
+		t.Fatal(err)
+	}
+	if expect := []string{"outer", "inner"}; !reflect.DeepEqual(log, expect) {
+		t.Errorf("Expected middleware to run in order %v, got %v", expect, log)
+	}
+	if db.LastExecSql == "" {
+		t.Error("Expected the insert to reach the database")
+	}
+
+	if protoRecorder(w) != r {
+		t.Error("Expected a wrapped Recorder to unwrap to its DbRecorder")
+	}
+	if protoRecorder(Wrap(w)) != r {
+		t.Error("Expected nested wrappers to unwrap to the DbRecorder")
+	}
+
+	log = log[:0]
+	if err := wrapLike(w, r.Clone(nil)).Insert(); err != nil {
+		t.Fatal(err)
+	}
+	if len(log) != 2 {
+		t.Errorf("Expected copies to have the same middleware, got %v", log)
+	}
+}
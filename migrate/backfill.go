@@ -0,0 +1,104 @@
+package migrate
+
+import (
+	"github.com/Masterminds/structable"
+)
+
+// DefaultBatchSize is the number of rows per batch of a Backfill or
+// DataMigration when no batch size is given.
+const DefaultBatchSize = 500
+
+// Progress reports how far a Backfill or DataMigration has gone.
+type Progress struct {
+	// Done is the number of rows processed so far in this run.
+	Done uint64
+	// Total is the number of rows in the table when the run started.
+	Total uint64
+	// Token resumes the run after the last completed batch. It is empty
+	// once the whole table has been processed.
+	Token string
+}
+
+// ProgressFunc is called after each batch of a Backfill or DataMigration. If
+// it returns an error, the run stops and returns that error.
+type ProgressFunc func(Progress) error
+
+// BackfillOptions configures a Backfill.
+type BackfillOptions struct {
+	// BatchSize is the number of rows per batch. Defaults to DefaultBatchSize.
+	BatchSize uint64
+	// Resume is a Progress.Token from an earlier run. The Backfill continues
+	// after the last batch that run completed.
+	Resume string
+	// Progress, if set, is called after each batch.
+	Progress ProgressFunc
+}
+
+// Backfill populates a column for every existing row of the Recorder's table.
+//
+// Rows are read in batches, in primary key order, as Records of the bound
+// type. For each one, valueFn computes the column's value from the Record's
+// other fields, and the row is updated:
+//
+//	err := migrate.Backfill(NewUser(db, "postgres"), "display_name",
+//		func(r structable.Record) interface{} {
+//			u := r.(*User)
+//			return u.First + " " + u.Last
+//		},
+//		migrate.BackfillOptions{
+//			Progress: func(p migrate.Progress) error {
+//				log.Printf("%d/%d, resume with %q", p.Done, p.Total, p.Token)
+//				return nil
+//			},
+//		})
+//
+// If a Backfill is interrupted, pass the last Progress.Token as Resume to
+// continue from there. The table must have a single-column primary key.
+func Backfill(rec structable.Recorder, column string, valueFn func(structable.Record) interface{}, opts BackfillOptions) error {
+	page := structable.PageRequest{
+		Size:      opts.BatchSize,
+		Token:     opts.Resume,
+		Keyset:    true,
+		SkipTotal: true,
+	}
+	if page.Size == 0 {
+		page.Size = DefaultBatchSize
+	}
+
+	var p Progress
+	var err error
+	if p.Total, err = structable.CountWhere(rec, nil); err != nil {
+		return err
+	}
+
+	for {
+		res, err := structable.Paginate(rec, page)
+		if err != nil {
+			return err
+		}
+		for _, item := range res.Items {
+			q := rec.Builder().Update(rec.TableName()).
+				Set(column, valueFn(item.Interface())).
+				Where(item.WhereIds())
+			sql, args, err := q.ToSql()
+			if err != nil {
+				return err
+			}
+			if _, err := rec.DB().Exec(sql, args...); err != nil {
+				return err
+			}
+			p.Done++
+		}
+
+		p.Token = res.NextToken
+		if opts.Progress != nil {
+			if err := opts.Progress(p); err != nil {
+				return err
+			}
+		}
+		if p.Token == "" {
+			return nil
+		}
+		page.Token = p.Token
+	}
+}
@@ -0,0 +1,73 @@
+//go:build sqlite
+// +build sqlite
+
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/Masterminds/structable"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type person struct {
+	Id          int64  `stbl:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	First       string `stbl:"first"`
+	Last        string `stbl:"last"`
+	DisplayName string `stbl:"display_name"`
+}
+
+func TestBackfill(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("CREATE TABLE people (id INTEGER PRIMARY KEY AUTOINCREMENT, first TEXT, last TEXT, display_name TEXT DEFAULT '')"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := db.Exec("INSERT INTO people (first, last) VALUES ('Ada', ?)", string(rune('A'+i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rec := structable.New(structable.NewRunner(db), "sqlite3").Bind("people", &person{})
+	valueFn := func(r structable.Record) interface{} {
+		p := r.(*person)
+		return p.First + " " + p.Last
+	}
+
+	// Stop after the first batch, and then resume.
+	stop := errors.New("stop")
+	var last Progress
+	err = Backfill(rec, "display_name", valueFn, BackfillOptions{
+		BatchSize: 2,
+		Progress: func(p Progress) error {
+			last = p
+			return stop
+		},
+	})
+	if err != stop || last.Done != 2 || last.Total != 5 || last.Token == "" {
+		t.Fatalf("Unexpected first run: %v %+v", err, last)
+	}
+
+	batches := 0
+	err = Backfill(rec, "display_name", valueFn, BackfillOptions{
+		BatchSize: 2,
+		Resume:    last.Token,
+		Progress: func(p Progress) error {
+			batches++
+			last = p
+			return nil
+		},
+	})
+	if err != nil || batches != 2 || last.Done != 3 || last.Token != "" {
+		t.Fatalf("Unexpected resumed run: %v %d %+v", err, batches, last)
+	}
+
+	var missing int
+	if err := db.QueryRow("SELECT COUNT(*) FROM people WHERE display_name <> 'Ada ' || last").Scan(&missing); err != nil || missing != 0 {
+		t.Errorf("Expected every row to be backfilled, %d were not (%v)", missing, err)
+	}
+}
@@ -0,0 +1,134 @@
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/Masterminds/structable"
+)
+
+// ErrSkipRow may be returned by a DataMigration's Transform to leave a row out
+// of the new table.
+var ErrSkipRow = errors.New("skip row")
+
+// TxBeginner starts transactions. *sql.DB implements it.
+type TxBeginner interface {
+	Begin() (*sql.Tx, error)
+}
+
+// DataMigration copies rows from one Record type to another, transforming
+// each row on the way.
+//
+// Rows are read from Source in primary key order, in batches. Each batch is
+// written in its own transaction, and Progress is called once the transaction
+// has committed:
+//
+//	m := &migrate.DataMigration{
+//		DB:     db,
+//		Source: NewUserV1(db, "postgres"),
+//		Target: func(tx structable.Runner) structable.Recorder {
+//			return NewUserV2(tx, "postgres")
+//		},
+//		Transform: func(old, new structable.Record) error {
+//			o, n := old.(*UserV1), new.(*UserV2)
+//			n.Id = o.Id
+//			n.Email = strings.ToLower(o.Email)
+//			return nil
+//		},
+//		Progress: func(p migrate.Progress) error {
+//			return saveCheckpoint(p.Token)
+//		},
+//	}
+//	err := m.Run()
+//
+// If a run is interrupted, set Resume to the last checkpointed token to
+// continue after the last committed batch. A run that stops between a commit
+// and its Progress call will write that batch again when resumed, so targets
+// should have a key that rejects (or Transforms that tolerate) duplicates.
+type DataMigration struct {
+	// DB starts the transactions that new rows are written in.
+	DB TxBeginner
+	// Source is bound to the old table and Record type. It must have a
+	// single-column primary key.
+	Source structable.Recorder
+	// Target returns a new Recorder, bound to an empty Record of the new type,
+	// that runs its statements on the given transaction.
+	Target func(tx structable.Runner) structable.Recorder
+	// Transform fills in the new Record from the old one. It may return
+	// ErrSkipRow to skip a row.
+	Transform func(old, new structable.Record) error
+	// BatchSize is the number of rows per batch. Defaults to DefaultBatchSize.
+	BatchSize uint64
+	// Resume is a Progress.Token from an earlier run.
+	Resume string
+	// Progress, if set, is called after each batch commits. If it returns an
+	// error, the migration stops.
+	Progress ProgressFunc
+}
+
+// Run runs the migration until every row has been copied, or until an error
+// occurs.
+func (m *DataMigration) Run() error {
+	page := structable.PageRequest{
+		Size:      m.BatchSize,
+		Token:     m.Resume,
+		Keyset:    true,
+		SkipTotal: true,
+	}
+	if page.Size == 0 {
+		page.Size = DefaultBatchSize
+	}
+
+	var p Progress
+	var err error
+	if p.Total, err = structable.CountWhere(m.Source, nil); err != nil {
+		return err
+	}
+
+	for {
+		res, err := structable.Paginate(m.Source, page)
+		if err != nil {
+			return err
+		}
+		n, err := m.write(res.Items)
+		if err != nil {
+			return err
+		}
+		p.Done += n
+
+		p.Token = res.NextToken
+		if m.Progress != nil {
+			if err := m.Progress(p); err != nil {
+				return err
+			}
+		}
+		if p.Token == "" {
+			return nil
+		}
+		page.Token = p.Token
+	}
+}
+
+// write transforms and inserts one batch in a transaction. It returns the
+// number of rows read, including skipped rows.
+func (m *DataMigration) write(items []structable.Recorder) (uint64, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	runner := structable.NewRunner(tx)
+	for _, item := range items {
+		target := m.Target(runner)
+		if err := m.Transform(item.Interface(), target.Interface()); err == ErrSkipRow {
+			continue
+		} else if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		if err := target.Insert(); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+	return uint64(len(items)), tx.Commit()
+}
@@ -0,0 +1,111 @@
+//go:build sqlite
+// +build sqlite
+
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/structable"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type contact struct {
+	Id   int64  `stbl:"id,PRIMARY_KEY"`
+	Name string `stbl:"name"`
+}
+
+func TestDataMigration(t *testing.T) {
+	db, err := sql.Open("sqlite3", "file:datamigration?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	_, err = db.Exec(`
+	CREATE TABLE people (id INTEGER PRIMARY KEY AUTOINCREMENT, first TEXT, last TEXT, display_name TEXT DEFAULT '');
+	CREATE TABLE contacts (id INTEGER PRIMARY KEY, name TEXT);
+	INSERT INTO people (first, last) VALUES ('Ada', 'Lovelace'), ('Alan', 'Turing'), ('Skip', 'Me'), ('Grace', 'Hopper');
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop := errors.New("stop")
+	m := &DataMigration{
+		DB:     db,
+		Source: structable.New(structable.NewRunner(db), "sqlite3").Bind("people", &person{}),
+		Target: func(tx structable.Runner) structable.Recorder {
+			return structable.New(tx, "sqlite3").Bind("contacts", &contact{})
+		},
+		Transform: func(old, new structable.Record) error {
+			o, n := old.(*person), new.(*contact)
+			if o.First == "Skip" {
+				return ErrSkipRow
+			}
+			n.Id = o.Id
+			n.Name = strings.ToUpper(o.Last)
+			return nil
+		},
+		BatchSize: 2,
+		Progress: func(p Progress) error {
+			if p.Done != 2 || p.Total != 4 {
+				t.Errorf("Unexpected progress %+v", p)
+			}
+			return stop
+		},
+	}
+	if err := m.Run(); err != stop {
+		t.Fatalf("Expected the first run to stop, got %v", err)
+	}
+
+	var last Progress
+	m.Progress = func(p Progress) error {
+		last = p
+		return nil
+	}
+	m.Resume = "bad"
+	if err := m.Run(); err != structable.ErrBadPageToken {
+		t.Fatalf("Expected a bad token, got %v", err)
+	}
+	m.Resume = ""
+	// Rerunning from the start would insert duplicate keys.
+	if err := m.Run(); err == nil {
+		t.Fatal("Expected duplicate keys to fail")
+	}
+
+	var count int
+	db.QueryRow("SELECT COUNT(*) FROM contacts").Scan(&count)
+	if count != 2 {
+		t.Errorf("Expected the failed batch to roll back, got %d contacts", count)
+	}
+
+	m.Resume = resumeAfter(t, m, 2)
+	if err := m.Run(); err != nil {
+		t.Fatalf("Failed resumed run: %s", err)
+	}
+	if last.Done != 2 || last.Token != "" {
+		t.Errorf("Unexpected final progress %+v", last)
+	}
+	names := []string{}
+	rows, _ := db.Query("SELECT name FROM contacts ORDER BY id")
+	for rows.Next() {
+		var n string
+		rows.Scan(&n)
+		names = append(names, n)
+	}
+	if strings.Join(names, ",") != "LOVELACE,TURING,HOPPER" {
+		t.Errorf("Unexpected contacts %v", names)
+	}
+}
+
+// resumeAfter returns the token for the page after the first n source rows.
+func resumeAfter(t *testing.T, m *DataMigration, n uint64) string {
+	res, err := structable.Paginate(m.Source, structable.PageRequest{Size: n, Keyset: true, SkipTotal: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res.NextToken
+}
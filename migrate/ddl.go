@@ -0,0 +1,113 @@
+package migrate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/structable"
+)
+
+// ColumnDef describes a column for the purposes of generating DDL.
+type ColumnDef struct {
+	Name string
+	// Type is the SQL type, as returned by SqlType.
+	Type     string
+	Nullable bool
+	Key      bool
+	Auto     bool
+	// Default is the column's DEFAULT, as an SQL literal or expression. It
+	// is omitted if empty.
+	Default string
+}
+
+// TagColumnDef builds the ColumnDef for a field with the given stbl tag.
+//
+// The sqlType is the column's type, as returned by SqlType, and nullable
+// tells whether the field's Go type can hold NULL. The tag's TYPE=, NOT_NULL,
+// NULLABLE, DEFAULT, and SIZE options override them: TYPE= replaces the
+// type, and a TEXT column with a SIZE becomes a VARCHAR.
+func TagColumnDef(tag, sqlType string, nullable bool) ColumnDef {
+	return FieldColumnDef(structable.ParseTag(tag), sqlType, nullable)
+}
+
+// FieldColumnDef builds the ColumnDef for a field from the metadata that
+// structable parsed from its tag, as returned by ParseTag or
+// DbRecorder.Fields. The sqlType and nullable are used as by TagColumnDef.
+func FieldColumnDef(f structable.FieldInfo, sqlType string, nullable bool) ColumnDef {
+	col := ColumnDef{
+		Name:     f.Column,
+		Type:     sqlType,
+		Nullable: (nullable || f.Nullable) && !f.NotNull,
+		Key:      f.Key,
+		Auto:     f.Auto,
+		Default:  f.Default,
+	}
+	if f.SqlType != "" {
+		col.Type = f.SqlType
+	}
+	col.Type = sizedType(col.Type, f.Size)
+	return col
+}
+
+// CreateTableSql generates a CREATE TABLE statement for the given flavor.
+//
+// Keys are collected into a single PRIMARY KEY clause. Auto-increment keys are
+// rendered in the flavor's own idiom: SERIAL on Postgres, AUTO_INCREMENT on
+// MySQL, and INTEGER PRIMARY KEY AUTOINCREMENT on SQLite.
+func CreateTableSql(flavor, table string, cols []ColumnDef) string {
+	lines := make([]string, 0, len(cols)+1)
+	keys := []string{}
+	for _, c := range cols {
+		if c.Key {
+			keys = append(keys, c.Name)
+		}
+	}
+
+	// SQLite only supports AUTOINCREMENT on a lone INTEGER PRIMARY KEY.
+	inlineKey := isSqlite(flavor) && len(keys) == 1
+
+	for _, c := range cols {
+		def := c.Name + " " + c.Type
+		switch {
+		case c.Auto && flavor == "postgres":
+			def = c.Name + " SERIAL"
+			if c.Type == "BIGINT" {
+				def = c.Name + " BIGSERIAL"
+			}
+		case c.Auto && inlineKey && c.Key:
+			def = c.Name + " INTEGER PRIMARY KEY AUTOINCREMENT"
+		case c.Auto && flavor == "mysql":
+			def += " AUTO_INCREMENT"
+		}
+		if c.Default != "" {
+			def += " DEFAULT " + c.Default
+		}
+		if !c.Nullable && !(inlineKey && c.Key && c.Auto) {
+			def += " NOT NULL"
+		}
+		lines = append(lines, "\t"+def)
+	}
+
+	if len(keys) > 0 && !(inlineKey && autoKey(cols)) {
+		lines = append(lines, fmt.Sprintf("\tPRIMARY KEY (%s)", strings.Join(keys, ", ")))
+	}
+
+	return fmt.Sprintf("CREATE TABLE %s (\n%s\n);", table, strings.Join(lines, ",\n"))
+}
+
+func autoKey(cols []ColumnDef) bool {
+	for _, c := range cols {
+		if c.Key && c.Auto {
+			return true
+		}
+	}
+	return false
+}
+
+// sizedType limits a TEXT type to size characters.
+func sizedType(typ string, size int) string {
+	if typ != "TEXT" || size <= 0 {
+		return typ
+	}
+	return fmt.Sprintf("VARCHAR(%d)", size)
+}
@@ -0,0 +1,218 @@
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/Masterminds/structable"
+)
+
+// ErrLockTimeout is returned by Lock.Acquire when another owner holds the
+// lock for longer than the Timeout.
+var ErrLockTimeout = errors.New("timed out waiting for the migration lock")
+
+// ErrLockLost is returned when a lock that was held has expired and been
+// taken over by another owner.
+var ErrLockLost = errors.New("the migration lock was taken over by another owner")
+
+// Lock serializes schema changes across replicas that start at the same
+// time, so that only one of them applies the ALTERs.
+//
+// The lock is a row in a lock table, which is created if it does not exist:
+//
+//	lock := migrate.NewLock(db, "postgres")
+//	err := lock.Do(func() error {
+//		_, err := migrate.Apply(NewUser(db, "postgres"), nil)
+//		return err
+//	})
+//
+// A lock is a lease: it expires TTL after it was last acquired or refreshed.
+// Once it has expired, for example because its owner crashed, another owner
+// takes it over. Do refreshes the lock while its function runs; callers of
+// Acquire must call Refresh themselves.
+//
+// Because the lock is a plain row, it works the same on every flavor, and
+// can be inspected and removed by hand. The db should not be a transaction,
+// or the lock would not be visible to other replicas until it is released.
+type Lock struct {
+	// Table is the lock table. It defaults to structable_migration_lock.
+	Table string
+	// Name identifies the lock, so that independent migrations can use
+	// separate locks. It defaults to "migrate".
+	Name string
+	// Owner identifies the holder of the lock. It defaults to the host name
+	// and process ID.
+	Owner string
+	// TTL is how long the lock is held without a Refresh. It defaults to a
+	// minute.
+	TTL time.Duration
+	// Timeout is how long Acquire waits for the lock. It defaults to five
+	// minutes.
+	Timeout time.Duration
+	// Poll is how often Acquire checks the lock while it waits. It defaults
+	// to a second.
+	Poll time.Duration
+
+	db      structable.Runner
+	builder squirrel.StatementBuilderType
+	now     func() time.Time
+}
+
+// NewLock creates a Lock with the default settings.
+func NewLock(db structable.Runner, flavor string) *Lock {
+	host, _ := os.Hostname()
+	b := squirrel.StatementBuilder.RunWith(db)
+	if flavor == "postgres" {
+		b = b.PlaceholderFormat(squirrel.Dollar)
+	}
+	return &Lock{
+		Table:   "structable_migration_lock",
+		Name:    "migrate",
+		Owner:   fmt.Sprintf("%s:%d", host, os.Getpid()),
+		TTL:     time.Minute,
+		Timeout: 5 * time.Minute,
+		Poll:    time.Second,
+		db:      db,
+		builder: b,
+		now:     time.Now,
+	}
+}
+
+// Acquire takes the lock, waiting up to Timeout while another owner holds
+// it. An expired lock is taken over.
+//
+// Acquiring a lock that the same Owner already holds refreshes it.
+func (l *Lock) Acquire() error {
+	create := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (name VARCHAR(64) PRIMARY KEY, owner VARCHAR(255) NOT NULL, expires_at BIGINT NOT NULL)", l.Table)
+	if _, err := l.db.Exec(create); err != nil {
+		return err
+	}
+
+	deadline := l.now().Add(l.Timeout)
+	for {
+		ok, err := l.try()
+		if err != nil || ok {
+			return err
+		}
+		if !l.now().Before(deadline) {
+			return fmt.Errorf("%w %s after %s", ErrLockTimeout, l.Name, l.Timeout)
+		}
+		time.Sleep(l.Poll)
+	}
+}
+
+// try makes one attempt to take the lock.
+func (l *Lock) try() (bool, error) {
+	now := l.now()
+	expires := now.Add(l.TTL).UnixNano()
+	ins := l.builder.Insert(l.Table).Columns("name", "owner", "expires_at").Values(l.Name, l.Owner, expires)
+	if _, insErr := ins.Exec(); insErr == nil {
+		return true, nil
+	} else if _, err := l.holder(); err == sql.ErrNoRows {
+		// The insert did not fail because the lock is held.
+		return false, insErr
+	} else if err != nil {
+		return false, err
+	}
+
+	// Take over an expired lock, or refresh one we hold already.
+	up := l.builder.Update(l.Table).
+		Set("owner", l.Owner).
+		Set("expires_at", expires).
+		Where(squirrel.Eq{"name": l.Name}).
+		Where(squirrel.Or{squirrel.Lt{"expires_at": now.UnixNano()}, squirrel.Eq{"owner": l.Owner}})
+	return affected(up.Exec())
+}
+
+// Holder returns the current owner of the lock, or "" if it is not held.
+//
+// An expired lock is still reported until it is taken over or released.
+func (l *Lock) Holder() (string, error) {
+	owner, err := l.holder()
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return owner, err
+}
+
+func (l *Lock) holder() (string, error) {
+	var owner string
+	q := l.builder.Select("owner").From(l.Table).Where(squirrel.Eq{"name": l.Name})
+	err := q.QueryRow().Scan(&owner)
+	return owner, err
+}
+
+// Refresh extends the lock by TTL. It returns ErrLockLost if the lock is no
+// longer held by this Owner.
+func (l *Lock) Refresh() error {
+	up := l.builder.Update(l.Table).
+		Set("expires_at", l.now().Add(l.TTL).UnixNano()).
+		Where(squirrel.Eq{"name": l.Name, "owner": l.Owner})
+	ok, err := affected(up.Exec())
+	if err == nil && !ok {
+		err = ErrLockLost
+	}
+	return err
+}
+
+// Release gives up the lock. Releasing a lock that is not held by this Owner
+// does nothing.
+func (l *Lock) Release() error {
+	_, err := l.builder.Delete(l.Table).Where(squirrel.Eq{"name": l.Name, "owner": l.Owner}).Exec()
+	return err
+}
+
+// Do acquires the lock, runs fn, and releases the lock.
+//
+// While fn runs, the lock is refreshed every third of its TTL. If the lock
+// is lost anyway, Do returns ErrLockLost after fn returns.
+func (l *Lock) Do(fn func() error) error {
+	if err := l.Acquire(); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	lost := make(chan error, 1)
+	go func() {
+		t := time.NewTicker(l.TTL / 3)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+				if err := l.Refresh(); err == ErrLockLost {
+					lost <- err
+					return
+				}
+			}
+		}
+	}()
+
+	err := fn()
+	close(done)
+	if rerr := l.Release(); err == nil {
+		err = rerr
+	}
+	select {
+	case lerr := <-lost:
+		if err == nil {
+			err = lerr
+		}
+	default:
+	}
+	return err
+}
+
+// affected reports whether a statement changed any rows.
+func affected(res sql.Result, err error) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
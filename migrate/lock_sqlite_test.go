@@ -0,0 +1,70 @@
+//go:build sqlite
+// +build sqlite
+
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/structable"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestLock(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+
+	clock := time.Unix(1000, 0)
+	now := func() time.Time { return clock }
+	newLock := func(owner string) *Lock {
+		l := NewLock(structable.NewRunner(db), "sqlite3")
+		l.Owner, l.Timeout, l.Poll, l.now = owner, 0, time.Millisecond, now
+		return l
+	}
+	a, b := newLock("a"), newLock("b")
+
+	if err := a.Acquire(); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Acquire(); err != nil {
+		t.Errorf("Expected the owner to reacquire its lock, got %s", err)
+	}
+	if err := b.Acquire(); !errors.Is(err, ErrLockTimeout) {
+		t.Errorf("Expected ErrLockTimeout, got %v", err)
+	}
+
+	// Once the lease expires, b takes the lock over.
+	clock = clock.Add(2 * a.TTL)
+	if err := b.Acquire(); err != nil {
+		t.Fatalf("Expected b to take over, got %s", err)
+	}
+	if owner, _ := b.Holder(); owner != "b" {
+		t.Errorf("Expected b to hold the lock, got %q", owner)
+	}
+	if err := a.Refresh(); err != ErrLockLost {
+		t.Errorf("Expected ErrLockLost, got %v", err)
+	}
+	if err := a.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if owner, _ := b.Holder(); owner != "b" {
+		t.Errorf("Expected a's release to leave b's lock alone, got %q", owner)
+	}
+
+	if err := b.Release(); err != nil {
+		t.Fatal(err)
+	}
+	ran := false
+	if err := a.Do(func() error { ran = true; return nil }); err != nil || !ran {
+		t.Errorf("Expected Do to run, got %t, %v", ran, err)
+	}
+	if owner, _ := a.Holder(); owner != "" {
+		t.Errorf("Expected Do to release the lock, got %q", owner)
+	}
+}
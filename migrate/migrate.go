@@ -0,0 +1,426 @@
+/*
+Package migrate compares a bound Record with the live database schema.
+
+Structable itself does not manage schemas. This package is an opt-in helper
+for teams that keep their schema in Go structs: it introspects the table that
+a Recorder is bound to, compares it to the struct, and produces the ALTER
+TABLE statements needed to bring the table in line with the struct.
+
+Only additive and nullability changes are computed. Columns that exist in the
+database but not on the struct are left alone, because Structable ignores
+unmapped columns anyway. Dropping and renaming columns is left to the user.
+
+Usage:
+
+	u := NewUser(db, "postgres")
+	changes, err := migrate.Diff(u)
+	for _, c := range changes {
+		fmt.Println(c.SQL)
+	}
+
+	// Or apply them, confirming each statement.
+	_, err = migrate.Apply(u, func(c migrate.Change) bool {
+		log.Printf("Applying %s", c.SQL)
+		return true
+	})
+
+VerifySchema checks the other direction: that every field's Go type can hold
+the type of its live column.
+
+When several replicas run migrations at startup, wrap them in a Lock so that
+only one replica applies them at a time.
+
+RefreshMetadata brings a running Recorder up to date after the table changes,
+so that fields tagged TOLERATE_MISSING are used once their columns exist.
+
+Nullability is derived from the Go type. Pointer fields and the sql.Null*
+types are considered nullable. All other fields are considered NOT NULL. The
+NOT_NULL tag option makes any field NOT NULL, DEFAULT(value) sets the default of
+an added column, and SIZE(n) adds TEXT columns as VARCHAR(n). Column types are
+derived from the Go type, unless the field declares one with TYPE=.
+*/
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/structable"
+)
+
+// ErrNoTable indicates that the bound table does not exist in the database.
+//
+// Creating tables is outside of the scope of this package. It is the same
+// error as structable.ErrNoTable.
+var ErrNoTable = structable.ErrNoTable
+
+// ChangeKind describes the type of schema change.
+type ChangeKind int
+
+const (
+	// AddColumn adds a column that is on the struct but not in the table.
+	AddColumn ChangeKind = iota
+	// SetNotNull marks a nullable column NOT NULL.
+	SetNotNull
+	// DropNotNull allows NULL values on a NOT NULL column.
+	DropNotNull
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case AddColumn:
+		return "add column"
+	case SetNotNull:
+		return "set not null"
+	case DropNotNull:
+		return "drop not null"
+	}
+	return "unknown"
+}
+
+// Change is a single difference between the struct and the table.
+type Change struct {
+	Kind   ChangeKind
+	Table  string
+	Column string
+	// SQL is the statement that applies this change.
+	SQL string
+}
+
+// ConfirmFunc is called before each change is applied.
+//
+// If it returns false, the change is skipped.
+type ConfirmFunc func(Change) bool
+
+// Column describes a column in the live database.
+type Column struct {
+	Name string
+	// Type is the database's own name for the column type.
+	Type     string
+	Nullable bool
+}
+
+// field describes a column as declared on the struct.
+type field struct {
+	name         string
+	column       string
+	typ          reflect.Type
+	nullable     bool
+	tolerate     bool
+	sqlType      string
+	defaultValue string
+	size         int
+}
+
+// Inspect reads the columns of the Recorder's table from the database, with
+// structable.DescribeTable.
+//
+// If the table does not exist (or has no columns), an empty list is returned.
+func Inspect(rec structable.Recorder) ([]Column, error) {
+	ts, err := structable.DescribeTable(rec.DB(), rec.Driver(), rec.TableName())
+	if errors.Is(err, structable.ErrNoTable) {
+		return []Column{}, nil
+	}
+	if err != nil {
+		return []Column{}, err
+	}
+	cols := make([]Column, len(ts.Columns))
+	for i, c := range ts.Columns {
+		cols[i] = Column{Name: c.Name, Type: c.Type, Nullable: c.Nullable}
+	}
+	return cols, nil
+}
+
+// Diff compares the Recorder's bound Record with the live table.
+//
+// It returns the list of changes necessary to make the table match the
+// struct. An empty list means the table is up to date.
+func Diff(rec structable.Recorder) ([]Change, error) {
+	live, err := Inspect(rec)
+	if err != nil {
+		return []Change{}, err
+	}
+	if len(live) == 0 {
+		return []Change{}, ErrNoTable
+	}
+	fields, err := structFields(rec.Interface(), fieldTag(rec))
+	if err != nil {
+		return []Change{}, err
+	}
+	return diff(rec.Driver(), rec.TableName(), fields, live), nil
+}
+
+// Apply computes the changes for a Recorder and executes them.
+//
+// Each change is passed to confirm before it is executed. If confirm is nil,
+// every change is applied. The returned list contains the changes that were
+// actually applied. Execution stops at the first error.
+func Apply(rec structable.Recorder, confirm ConfirmFunc) ([]Change, error) {
+	applied := []Change{}
+
+	changes, err := Diff(rec)
+	if err != nil {
+		return applied, err
+	}
+
+	for _, c := range changes {
+		if confirm != nil && !confirm(c) {
+			continue
+		}
+		if _, err := rec.DB().Exec(c.SQL); err != nil {
+			return applied, fmt.Errorf("could not %s %s.%s: %s", c.Kind, c.Table, c.Column, err)
+		}
+		applied = append(applied, c)
+	}
+	return applied, nil
+}
+
+// VerifySchema checks that each field on the Recorder's bound Record can hold
+// the type of its column in the live table.
+//
+// Incompatible fields are reported together in a *structable.TypeError. Fields
+// whose columns do not exist are not reported; see Diff. This is the runtime
+// counterpart of the TYPE= tag option, and is useful at startup:
+//
+//	if err := migrate.VerifySchema(NewUser(db, "postgres")); err != nil {
+//		log.Fatal(err)
+//	}
+func VerifySchema(rec structable.Recorder) error {
+	live, err := Inspect(rec)
+	if err != nil {
+		return err
+	}
+	if len(live) == 0 {
+		return ErrNoTable
+	}
+	fields, err := structFields(rec.Interface(), fieldTag(rec))
+	if err != nil {
+		return err
+	}
+	return verify(rec.TableName(), fields, live)
+}
+
+// verify does the actual type check. It does not touch the database.
+func verify(table string, fields []*field, live []Column) error {
+	existing := make(map[string]Column, len(live))
+	for _, c := range live {
+		existing[strings.ToLower(c.Name)] = c
+	}
+
+	terr := &structable.TypeError{Table: table}
+	for _, f := range fields {
+		c, ok := existing[strings.ToLower(f.column)]
+		if !ok || structable.CompatibleType(f.typ, c.Type) {
+			continue
+		}
+		terr.Mismatches = append(terr.Mismatches, structable.TypeMismatch{
+			Field:   f.name,
+			Column:  f.column,
+			GoType:  f.typ,
+			SQLType: c.Type,
+		})
+	}
+	if len(terr.Mismatches) > 0 {
+		return terr
+	}
+	return nil
+}
+
+// diff does the actual comparison. It does not touch the database.
+func diff(flavor, table string, fields []*field, live []Column) []Change {
+	existing := make(map[string]Column, len(live))
+	for _, c := range live {
+		existing[strings.ToLower(c.Name)] = c
+	}
+
+	changes := []Change{}
+	for _, f := range fields {
+		c, ok := existing[strings.ToLower(f.column)]
+		if !ok {
+			changes = append(changes, Change{
+				Kind:   AddColumn,
+				Table:  table,
+				Column: f.column,
+				SQL:    addColumnSql(flavor, table, f),
+			})
+			continue
+		}
+
+		// SQLite cannot alter a column's constraints in place.
+		if isSqlite(flavor) || c.Nullable == f.nullable {
+			continue
+		}
+
+		kind := SetNotNull
+		if f.nullable {
+			kind = DropNotNull
+		}
+		changes = append(changes, Change{
+			Kind:   kind,
+			Table:  table,
+			Column: f.column,
+			SQL:    nullabilitySql(flavor, table, c, f.nullable),
+		})
+	}
+	return changes
+}
+
+func addColumnSql(flavor, table string, f *field) string {
+	typ := f.sqlType
+	if typ == "" {
+		typ = sizedType(SqlType(flavor, f.typ), f.size)
+	}
+	def := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, f.column, typ)
+	if f.defaultValue != "" {
+		def += " DEFAULT " + f.defaultValue
+	}
+	if f.nullable {
+		return def
+	}
+	def += " NOT NULL"
+	// A NOT NULL column cannot be added to a populated table without a default.
+	if z := zeroLiteral(f.typ); z != "" && f.defaultValue == "" {
+		def += " DEFAULT " + z
+	}
+	return def
+}
+
+func nullabilitySql(flavor, table string, c Column, nullable bool) string {
+	if flavor == "mysql" {
+		null := "NOT NULL"
+		if nullable {
+			null = "NULL"
+		}
+		return fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s %s", table, c.Name, c.Type, null)
+	}
+
+	op := "SET NOT NULL"
+	if nullable {
+		op = "DROP NOT NULL"
+	}
+	return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s", table, c.Name, op)
+}
+
+func isSqlite(flavor string) bool {
+	return flavor == "sqlite3" || flavor == "sqlite"
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	bytesType   = reflect.TypeOf([]byte{})
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+// SqlType returns a reasonable SQL column type for a Go type.
+//
+// Like schema2struct, the goal is a safe mapping rather than an exact one.
+// Pointers are dereferenced. Unknown types map to TEXT.
+func SqlType(flavor string, t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		switch flavor {
+		case "postgres":
+			return "TIMESTAMP WITH TIME ZONE"
+		default:
+			return "DATETIME"
+		}
+	case bytesType:
+		if flavor == "postgres" {
+			return "BYTEA"
+		}
+		return "BLOB"
+	}
+
+	// sql.NullString and friends wrap the real value in their first field.
+	if reflect.PtrTo(t).Implements(scannerType) && t.Kind() == reflect.Struct && t.NumField() > 0 {
+		return SqlType(flavor, t.Field(0).Type)
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "BOOLEAN"
+	case reflect.Int8, reflect.Int16, reflect.Uint8:
+		return "SMALLINT"
+	case reflect.Int32, reflect.Uint16:
+		return "INTEGER"
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		if isSqlite(flavor) {
+			return "INTEGER"
+		}
+		return "BIGINT"
+	case reflect.Float32:
+		return "REAL"
+	case reflect.Float64:
+		switch flavor {
+		case "postgres":
+			return "DOUBLE PRECISION"
+		case "mysql":
+			return "DOUBLE"
+		}
+		return "REAL"
+	}
+	return "TEXT"
+}
+
+// zeroLiteral returns the SQL literal for the zero value of a type, or ""
+// if there is no portable literal.
+func zeroLiteral(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "FALSE"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "0"
+	case reflect.String:
+		return "''"
+	}
+	return ""
+}
+
+// structFields reads the stbl tags off of a Record with lookup, or with
+// structable.LookupTag if lookup is nil, and parses them with
+// structable.ParseTag.
+func structFields(rec structable.Record, lookup func(reflect.StructField) (string, bool)) ([]*field, error) {
+	if lookup == nil {
+		lookup = func(sf reflect.StructField) (string, bool) {
+			return structable.LookupTag(sf)
+		}
+	}
+	t := reflect.Indirect(reflect.ValueOf(rec)).Type()
+	if t.Kind() != reflect.Struct {
+		return []*field{}, fmt.Errorf("expected a struct, got %s", t.Kind())
+	}
+
+	fields := make([]*field, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := lookup(sf)
+		if !ok {
+			continue
+		}
+		nullable := sf.Type.Kind() == reflect.Ptr ||
+			(sf.Type.Kind() == reflect.Struct && sf.Type != timeType && reflect.PtrTo(sf.Type).Implements(scannerType))
+		info := structable.ParseTag(tag)
+		def := FieldColumnDef(info, "", nullable)
+		fields = append(fields, &field{
+			name:         sf.Name,
+			column:       def.Name,
+			typ:          sf.Type,
+			nullable:     def.Nullable,
+			tolerate:     info.TolerateMissing,
+			sqlType:      def.Type,
+			defaultValue: def.Default,
+			size:         info.Size,
+		})
+	}
+	return fields, nil
+}
@@ -0,0 +1,203 @@
+package migrate
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/structable"
+)
+
+type Stool struct {
+	Id       int            `stbl:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Legs     int            `stbl:"number_of_legs"`
+	Material string         `stbl:"material"`
+	Color    *string        `stbl:"color"`
+	Maker    sql.NullString `stbl:"maker"`
+	Built    time.Time      `stbl:"built"`
+	Ignored  string
+}
+
+func TestStructFields(t *testing.T) {
+	fields, err := structFields(&Stool{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 6 {
+		t.Fatalf("Expected 6 fields, got %d", len(fields))
+	}
+
+	nullable := map[string]bool{}
+	for _, f := range fields {
+		nullable[f.column] = f.nullable
+	}
+	for col, expect := range map[string]bool{"id": false, "color": true, "maker": true, "built": false} {
+		if nullable[col] != expect {
+			t.Errorf("Expected %s nullable=%t", col, expect)
+		}
+	}
+
+	if _, err := structFields(new(int), nil); err == nil {
+		t.Error("Expected non-struct to fail")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	fields, _ := structFields(&Stool{}, nil)
+	live := []Column{
+		{Name: "id", Type: "integer"},
+		{Name: "number_of_legs", Type: "integer", Nullable: true},
+		{Name: "MATERIAL", Type: "text"},
+		{Name: "color", Type: "text", Nullable: true},
+		{Name: "built", Type: "timestamp"},
+		{Name: "extra", Type: "text"},
+	}
+
+	changes := diff("postgres", "stools", fields, live)
+	expect := []Change{
+		{Kind: SetNotNull, Table: "stools", Column: "number_of_legs",
+			SQL: "ALTER TABLE stools ALTER COLUMN number_of_legs SET NOT NULL"},
+		{Kind: AddColumn, Table: "stools", Column: "maker",
+			SQL: "ALTER TABLE stools ADD COLUMN maker TEXT"},
+	}
+	if !reflect.DeepEqual(changes, expect) {
+		t.Errorf("Unexpected changes:\n%+v\nexpected\n%+v", changes, expect)
+	}
+
+	changes = diff("mysql", "stools", fields, live)
+	if len(changes) != 2 || changes[0].SQL != "ALTER TABLE stools MODIFY COLUMN number_of_legs integer NOT NULL" {
+		t.Errorf("Unexpected MySQL changes: %+v", changes)
+	}
+
+	// SQLite only gets additive changes.
+	changes = diff("sqlite3", "stools", fields, live)
+	if len(changes) != 1 || changes[0].Kind != AddColumn {
+		t.Errorf("Unexpected SQLite changes: %+v", changes)
+	}
+}
+
+func TestAddColumnSql(t *testing.T) {
+	f := &field{column: "legs", typ: reflect.TypeOf(0)}
+	expect := "ALTER TABLE stools ADD COLUMN legs BIGINT NOT NULL DEFAULT 0"
+	if got := addColumnSql("postgres", "stools", f); got != expect {
+		t.Errorf("Expected %q, got %q", expect, got)
+	}
+}
+
+func TestSqlType(t *testing.T) {
+	tests := []struct {
+		flavor string
+		val    interface{}
+		expect string
+	}{
+		{"postgres", 1, "BIGINT"},
+		{"sqlite3", 1, "INTEGER"},
+		{"postgres", int32(1), "INTEGER"},
+		{"mysql", 1.0, "DOUBLE"},
+		{"postgres", "", "TEXT"},
+		{"postgres", []byte{}, "BYTEA"},
+		{"mysql", []byte{}, "BLOB"},
+		{"postgres", time.Time{}, "TIMESTAMP WITH TIME ZONE"},
+		{"postgres", new(bool), "BOOLEAN"},
+		{"postgres", sql.NullInt64{}, "BIGINT"},
+	}
+	for _, tt := range tests {
+		if got := SqlType(tt.flavor, reflect.TypeOf(tt.val)); got != tt.expect {
+			t.Errorf("%s %T: expected %s, got %s", tt.flavor, tt.val, tt.expect, got)
+		}
+	}
+}
+
+func TestCreateTableSql(t *testing.T) {
+	cols := []ColumnDef{
+		{Name: "id", Type: "BIGINT", Key: true, Auto: true},
+		{Name: "name", Type: "TEXT"},
+		{Name: "color", Type: "TEXT", Nullable: true},
+	}
+
+	expect := "CREATE TABLE stools (\n\tid BIGSERIAL NOT NULL,\n\tname TEXT NOT NULL,\n\tcolor TEXT,\n\tPRIMARY KEY (id)\n);"
+	if got := CreateTableSql("postgres", "stools", cols); got != expect {
+		t.Errorf("Expected\n%s\ngot\n%s", expect, got)
+	}
+
+	expect = "CREATE TABLE stools (\n\tid BIGINT AUTO_INCREMENT NOT NULL,\n\tname TEXT NOT NULL,\n\tcolor TEXT,\n\tPRIMARY KEY (id)\n);"
+	if got := CreateTableSql("mysql", "stools", cols); got != expect {
+		t.Errorf("Expected\n%s\ngot\n%s", expect, got)
+	}
+
+	expect = "CREATE TABLE stools (\n\tid INTEGER PRIMARY KEY AUTOINCREMENT,\n\tname TEXT NOT NULL,\n\tcolor TEXT\n);"
+	if got := CreateTableSql("sqlite3", "stools", cols); got != expect {
+		t.Errorf("Expected\n%s\ngot\n%s", expect, got)
+	}
+}
+
+func TestTagColumnDef(t *testing.T) {
+	col := TagColumnDef("title,NOT_NULL,SIZE(64),DEFAULT('a,b')", "TEXT", true)
+	expect := ColumnDef{Name: "title", Type: "VARCHAR(64)", Default: "'a,b'"}
+	if col != expect {
+		t.Errorf("Expected %+v, got %+v", expect, col)
+	}
+
+	expectSql := "CREATE TABLE drafts (\n\ttitle VARCHAR(64) DEFAULT 'a,b' NOT NULL\n);"
+	if got := CreateTableSql("postgres", "drafts", []ColumnDef{col}); got != expectSql {
+		t.Errorf("Expected\n%s\ngot\n%s", expectSql, got)
+	}
+
+	type draft struct {
+		Note  *string `stbl:"note,NOT_NULL,SIZE(8),DEFAULT('x')"`
+		Price float64 `stbl:"price,TYPE=NUMERIC(10,2)"`
+	}
+	fields, _ := structFields(&draft{}, nil)
+	expectSql = "ALTER TABLE drafts ADD COLUMN note VARCHAR(8) DEFAULT 'x' NOT NULL"
+	if got := addColumnSql("postgres", "drafts", fields[0]); got != expectSql {
+		t.Errorf("Expected %q, got %q", expectSql, got)
+	}
+	expectSql = "ALTER TABLE drafts ADD COLUMN price NUMERIC(10,2) NOT NULL DEFAULT 0"
+	if got := addColumnSql("postgres", "drafts", fields[1]); got != expectSql {
+		t.Errorf("Expected %q, got %q", expectSql, got)
+	}
+}
+
+func TestTagColumnDefType(t *testing.T) {
+	col := TagColumnDef("price,TYPE=NUMERIC(10,2),NOT_NULL", "DOUBLE PRECISION", true)
+	expect := ColumnDef{Name: "price", Type: "NUMERIC(10,2)"}
+	if col != expect {
+		t.Errorf("Expected %+v, got %+v", expect, col)
+	}
+	expectSql := "CREATE TABLE items (\n\tprice NUMERIC(10,2) NOT NULL\n);"
+	if got := CreateTableSql("postgres", "items", []ColumnDef{col}); got != expectSql {
+		t.Errorf("Expected\n%s\ngot\n%s", expectSql, got)
+	}
+
+	// A SIZE still limits a declared TEXT type.
+	col = TagColumnDef("title,TYPE=TEXT,SIZE(32)", "BLOB", true)
+	if col.Type != "VARCHAR(32)" {
+		t.Errorf("Expected VARCHAR(32), got %s", col.Type)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	fields, _ := structFields(&Stool{}, nil)
+	live := []Column{
+		{Name: "id", Type: "bigint"},
+		{Name: "number_of_legs", Type: "text"},
+		{Name: "material", Type: "character varying"},
+		{Name: "color", Type: "integer"},
+		{Name: "maker", Type: "integer"},
+		{Name: "built", Type: "timestamp without time zone"},
+	}
+
+	err := verify("stools", fields, live)
+	terr, ok := err.(*structable.TypeError)
+	if !ok {
+		t.Fatalf("Expected a *structable.TypeError, got %v", err)
+	}
+	if len(terr.Mismatches) != 2 || terr.Mismatches[0].Field != "Legs" || terr.Mismatches[1].Field != "Color" {
+		t.Errorf("Unexpected mismatches: %+v", terr.Mismatches)
+	}
+
+	if err := verify("stools", fields, live[:1]); err != nil {
+		t.Errorf("Expected missing columns to be ignored, got %s", err)
+	}
+}
@@ -0,0 +1,105 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/Masterminds/structable"
+)
+
+// ErrMissingColumn indicates that a column of the Record does not exist in
+// the table, and is not tagged TOLERATE_MISSING.
+var ErrMissingColumn = errors.New("column does not exist")
+
+// RefreshMetadata re-reads the live schema of the Recorder's table, and
+// brings the Recorder up to date with it.
+//
+// It is meant for long-running services whose tables change under them. New
+// fields are tagged TOLERATE_MISSING, and deployed before the ALTER TABLE
+// that adds their columns:
+//
+//	type User struct {
+//		Id       int    `stbl:"id,PRIMARY_KEY,SERIAL"`
+//		Nickname string `stbl:"nickname,TOLERATE_MISSING"`
+//	}
+//
+// RefreshMetadata verifies the schema as VerifySchema does, and also fails
+// with ErrMissingColumn if any other column is missing. Tolerated columns that
+// are missing are left out of the Recorder's statements until a later
+// refresh finds them (see DbRecorder.SetMissingColumns). Call it at startup,
+// and again after each schema change, for example from a signal handler or a
+// periodic check.
+//
+// Prepared statements may still refer to the old table, so the statement
+// cache of the Recorder's DB is cleared, if it has a Clear method (as
+// squirrel.StmtCache does).
+//
+// The Recorder must be, or wrap, a *structable.DbRecorder.
+func RefreshMetadata(rec structable.Recorder) error {
+	dr := dbRecorder(rec)
+	if dr == nil {
+		return fmt.Errorf("cannot refresh %T: not a DbRecorder", rec)
+	}
+	live, err := Inspect(rec)
+	if err != nil {
+		return err
+	}
+	if len(live) == 0 {
+		return ErrNoTable
+	}
+	fields, err := structFields(rec.Interface(), fieldTag(rec))
+	if err != nil {
+		return err
+	}
+	if err := verify(rec.TableName(), fields, live); err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool, len(live))
+	for _, c := range live {
+		existing[strings.ToLower(c.Name)] = true
+	}
+	missing := []string{}
+	for _, f := range fields {
+		if existing[strings.ToLower(f.column)] {
+			continue
+		}
+		if !f.tolerate {
+			return fmt.Errorf("%w: %s.%s", ErrMissingColumn, rec.TableName(), f.column)
+		}
+		missing = append(missing, f.column)
+	}
+	if err := dr.SetMissingColumns(missing...); err != nil {
+		return err
+	}
+
+	if c, ok := rec.DB().(interface{ Clear() error }); ok {
+		return c.Clear()
+	}
+	return nil
+}
+
+// dbRecorder unwraps a Recorder to its *structable.DbRecorder, or returns nil.
+func dbRecorder(rec structable.Recorder) *structable.DbRecorder {
+	for {
+		switch r := rec.(type) {
+		case *structable.DbRecorder:
+			return r
+		case interface{ Unwrap() structable.Recorder }:
+			rec = r.Unwrap()
+		default:
+			return nil
+		}
+	}
+}
+
+// fieldTag returns the function that reads a field's stbl tag the way a
+// Recorder does, or nil for the package defaults.
+func fieldTag(rec structable.Recorder) func(reflect.StructField) (string, bool) {
+	if d := dbRecorder(rec); d != nil {
+		return d.FieldTag
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+//go:build sqlite
+// +build sqlite
+
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/Masterminds/structable"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type member struct {
+	Id       int64  `stbl:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Name     string `stbl:"name"`
+	Nickname string `stbl:"nickname,TOLERATE_MISSING"`
+}
+
+func TestRefreshMetadata(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("CREATE TABLE members (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &member{Name: "Ada", Nickname: "A"}
+	r := structable.New(squirrel.NewStmtCache(db), "sqlite3")
+	r.Bind("members", m)
+
+	if err := RefreshMetadata(r); err != nil {
+		t.Fatalf("Failed refresh: %s", err)
+	}
+	if err := r.Insert(); err != nil {
+		t.Fatalf("Expected the missing column to be skipped, got %s", err)
+	}
+
+	if _, err := db.Exec("ALTER TABLE members ADD COLUMN nickname TEXT"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RefreshMetadata(r); err != nil {
+		t.Fatalf("Failed refresh: %s", err)
+	}
+	if len(r.MissingColumns()) != 0 {
+		t.Errorf("Expected the new column to be found, got %v", r.MissingColumns())
+	}
+	if err := r.Update(); err != nil {
+		t.Fatalf("Failed Update: %s", err)
+	}
+	var nick string
+	if err := db.QueryRow("SELECT nickname FROM members WHERE id = ?", m.Id).Scan(&nick); err != nil || nick != "A" {
+		t.Errorf("Expected the nickname to be stored, got %q, %v", nick, err)
+	}
+
+	strict := structable.New(structable.NewRunner(db), "sqlite3")
+	strict.Bind("members", &person{})
+	if err := RefreshMetadata(strict); !errors.Is(err, ErrMissingColumn) {
+		t.Errorf("Expected ErrMissingColumn, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package structable
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// SetMissingColumns tells the DbRecorder which of its columns do not exist in
+// the table, so that it leaves them out of every statement.
+//
+// Only columns tagged TOLERATE_MISSING, which are not primary keys, may be
+// missing. Their fields keep
+// their values, but are not loaded or stored. Each call replaces the missing
+// columns of the previous one, so calling it with no columns puts them all
+// back:
+//
+//	// Before the ALTER TABLE has run everywhere.
+//	err := r.SetMissingColumns("nickname")
+//
+// This is usually called by migrate.RefreshMetadata, which compares the
+// Record with the live table.
+func (s *DbRecorder) SetMissingColumns(cols ...string) error {
+	current := make(map[*field]bool, len(s.fields)+len(s.missing))
+	for _, f := range s.fields {
+		current[f] = true
+	}
+	for _, f := range s.missing {
+		current[f] = true
+	}
+	missing := make(map[string]bool, len(cols))
+	for _, c := range cols {
+		missing[c] = true
+	}
+
+	// Keep the fields in struct order, whatever was missing before.
+	m, _ := fieldCache.Load(s.fieldKey(reflect.Indirect(reflect.ValueOf(s.record)).Type()))
+	fields := []*field{}
+	gone := []*field{}
+	for _, f := range m.(*fieldMeta).fields {
+		switch {
+		case !current[f]:
+		case missing[f.column]:
+			if !f.tolerateMissing || f.isKey {
+				return fmt.Errorf("column %s on table %s is missing, and is not TOLERATE_MISSING", f.column, s.table)
+			}
+			gone = append(gone, f)
+			delete(missing, f.column)
+		default:
+			fields = append(fields, f)
+		}
+	}
+	for c := range missing {
+		return fmt.Errorf("unknown column %q on table %s", c, s.table)
+	}
+	s.fields, s.missing = fields, gone
+	return nil
+}
+
+// MissingColumns returns the columns set with SetMissingColumns.
+func (s *DbRecorder) MissingColumns() []string {
+	cols := make([]string, len(s.missing))
+	for i, f := range s.missing {
+		cols[i] = f.column
+	}
+	return cols
+}
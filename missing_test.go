@@ -0,0 +1,45 @@
+package structable
+
+import (
+	"reflect"
+	"testing"
+)
+
+type profileV2 struct {
+	Id       int    `stbl:"id,PRIMARY_KEY"`
+	Name     string `stbl:"name"`
+	Nickname string `stbl:"nickname,TOLERATE_MISSING"`
+}
+
+func TestSetMissingColumns(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "mysql")
+	r.Bind("profiles", &profileV2{Id: 1, Name: "matt", Nickname: "m"})
+
+	if err := r.SetMissingColumns("nickname"); err != nil {
+		t.Fatal(err)
+	}
+	if cols := r.Columns(true); !reflect.DeepEqual(cols, []string{"id", "name"}) {
+		t.Errorf("Expected nickname to be left out, got %v", cols)
+	}
+	if missing := r.MissingColumns(); !reflect.DeepEqual(missing, []string{"nickname"}) {
+		t.Errorf("Unexpected missing columns %v", missing)
+	}
+	r.Update()
+	if expect := "UPDATE profiles SET name = ? WHERE id = ?"; db.LastExecSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastExecSql)
+	}
+
+	if err := r.SetMissingColumns(); err != nil {
+		t.Fatal(err)
+	}
+	if cols := r.Columns(true); !reflect.DeepEqual(cols, []string{"id", "name", "nickname"}) {
+		t.Errorf("Expected nickname to be back, got %v", cols)
+	}
+
+	for _, c := range []string{"name", "id", "nope"} {
+		if err := r.SetMissingColumns(c); err == nil {
+			t.Errorf("Expected %s not to be allowed to be missing", c)
+		}
+	}
+}
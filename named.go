@@ -0,0 +1,118 @@
+package structable
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// Named returns a predicate whose arguments are given by name, as :name.
+//
+//	err := r.LoadWhere(structable.Named(
+//		"email = :email AND created_at > :since",
+//		map[string]interface{}{"email": e, "since": t},
+//	))
+//
+// The predicate can be used anywhere a squirrel predicate can: LoadWhere,
+// ExistsWhere, and the Where of a WhereFunc. Names are replaced with ?
+// placeholders, which squirrel then converts to the flavor's placeholder
+// format. A name may be used more than once. If an argument is a slice (other
+// than []byte), its name becomes a list of placeholders, for IN clauses:
+//
+//	structable.Named("id IN (:ids)", map[string]interface{}{"ids": ids})
+//
+// Names inside quoted strings, and Postgres :: casts, are left alone. A name
+// without an argument is an error. The predicate should not also use ?
+// placeholders.
+func Named(pred string, args map[string]interface{}) squirrel.Sqlizer {
+	return namedPred{pred: pred, args: args}
+}
+
+// NamedArgs is like Named, but takes the arguments as sql.NamedArgs:
+//
+//	structable.NamedArgs("email = :email", sql.Named("email", e))
+func NamedArgs(pred string, args ...sql.NamedArg) squirrel.Sqlizer {
+	m := make(map[string]interface{}, len(args))
+	for _, a := range args {
+		m[a.Name] = a.Value
+	}
+	return Named(pred, m)
+}
+
+// LoadWhereNamed loads a record by a predicate with named arguments.
+//
+// It is shorthand for LoadWhere(Named(pred, args)).
+func (s *DbRecorder) LoadWhereNamed(pred string, args map[string]interface{}) error {
+	return s.LoadWhere(Named(pred, args))
+}
+
+type namedPred struct {
+	pred string
+	args map[string]interface{}
+}
+
+func (n namedPred) ToSql() (string, []interface{}, error) {
+	var b strings.Builder
+	args := []interface{}{}
+	p := n.pred
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		switch {
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(p[i+1:], c)
+			if end < 0 {
+				return "", nil, fmt.Errorf("unterminated quote in %q", p)
+			}
+			b.WriteString(p[i : i+end+2])
+			i += end + 1
+		case c == ':' && i+1 < len(p) && p[i+1] == ':':
+			b.WriteString("::")
+			i++
+		case c == ':' && i+1 < len(p) && isNameStart(p[i+1]):
+			j := i + 1
+			for j < len(p) && isNamePart(p[j]) {
+				j++
+			}
+			name := p[i+1 : j]
+			v, ok := n.args[name]
+			if !ok {
+				return "", nil, fmt.Errorf("no argument named %q in %q", name, p)
+			}
+			marks, vals := namedValues(v)
+			if len(vals) == 0 {
+				return "", nil, fmt.Errorf("argument %q is an empty list", name)
+			}
+			b.WriteString(marks)
+			args = append(args, vals...)
+			i = j - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), args, nil
+}
+
+// namedValues returns the placeholders and values for one named argument.
+// Slices other than []byte are expanded.
+func namedValues(v interface{}) (string, []interface{}) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return "?", []interface{}{v}
+	}
+	vals := make([]interface{}, rv.Len())
+	for i := range vals {
+		vals[i] = rv.Index(i).Interface()
+	}
+	return strings.TrimSuffix(strings.Repeat("?, ", len(vals)), ", "), vals
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isNamePart(c byte) bool {
+	return isNameStart(c) || c >= '0' && c <= '9'
+}
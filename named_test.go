@@ -0,0 +1,56 @@
+package structable
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestNamed(t *testing.T) {
+	tests := []struct {
+		pred   string
+		args   map[string]interface{}
+		expect string
+		vals   []interface{}
+	}{
+		{"a = :a AND b = :b", map[string]interface{}{"a": 1, "b": "x"}, "a = ? AND b = ?", []interface{}{1, "x"}},
+		{"a = :a OR c = :a", map[string]interface{}{"a": 1}, "a = ? OR c = ?", []interface{}{1, 1}},
+		{"id IN (:ids)", map[string]interface{}{"ids": []int{1, 2}}, "id IN (?, ?)", []interface{}{1, 2}},
+		{"data = :d", map[string]interface{}{"d": []byte("x")}, "data = ?", []interface{}{[]byte("x")}},
+		{"a = ':a' AND b::text = :b", map[string]interface{}{"b": 2}, "a = ':a' AND b::text = ?", []interface{}{2}},
+	}
+	for _, tt := range tests {
+		sql, vals, err := Named(tt.pred, tt.args).ToSql()
+		if err != nil {
+			t.Errorf("%s: %s", tt.pred, err)
+			continue
+		}
+		if sql != tt.expect || !reflect.DeepEqual(vals, tt.vals) {
+			t.Errorf("%s: expected %s %v, got %s %v", tt.pred, tt.expect, tt.vals, sql, vals)
+		}
+	}
+
+	for _, pred := range []string{"a = :missing", "a = 'open", "id IN (:ids)"} {
+		if _, _, err := Named(pred, map[string]interface{}{"ids": []int{}}).ToSql(); err == nil {
+			t.Errorf("%s: expected an error", pred)
+		}
+	}
+}
+
+func TestLoadWhereNamed(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "postgres")
+	r.Bind("test_table", newStool())
+
+	r.LoadWhereNamed("material = :m AND number_of_legs > :legs", map[string]interface{}{"m": "wood", "legs": 3})
+	expect := "SELECT id, id_two, number_of_legs, material, color FROM test_table WHERE material = $1 AND number_of_legs > $2 LIMIT 1"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+
+	r.ExistsWhere(NamedArgs("material = :m", sql.Named("m", "wood")))
+	expect = "SELECT EXISTS(SELECT 1 FROM test_table WHERE material = $1)"
+	if db.LastQueryRowSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQueryRowSql)
+	}
+}
@@ -0,0 +1,177 @@
+package structable
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// NamingStrategy names the column of a field whose tag omits the column name,
+// as in `stbl:",PRIMARY_KEY"` or `stbl:""`.
+//
+// Fields are cached per NamingStrategy, so a NamingStrategy must be
+// comparable, such as a struct or a pointer, and should be created once.
+type NamingStrategy interface {
+	// ColumnName returns the column name for a struct field name.
+	ColumnName(field string) string
+}
+
+// SnakeCase names columns in snake case: UserID becomes user_id. It is the
+// default NamingStrategy.
+var SnakeCase NamingStrategy = snakeCaseNaming{}
+
+// Verbatim names columns exactly like their fields: UserID stays UserID.
+var Verbatim NamingStrategy = verbatimNaming{}
+
+// naming is the NamingStrategy of DbRecorders that do not set their own.
+var naming = SnakeCase
+
+// SetNamingStrategy sets the NamingStrategy of every DbRecorder that does not
+// set its own. It should be called before any Record is bound. A nil
+// NamingStrategy restores the default, SnakeCase.
+func SetNamingStrategy(ns NamingStrategy) {
+	if ns == nil {
+		ns = SnakeCase
+	}
+	naming = ns
+}
+
+// SetNamingStrategy sets the NamingStrategy that names this DbRecorder's
+// columns, overriding the package's. It takes effect on the next Bind.
+func (s *DbRecorder) SetNamingStrategy(ns NamingStrategy) *DbRecorder {
+	s.naming = ns
+	return s
+}
+
+// NamingStrategy returns the NamingStrategy that names this DbRecorder's
+// columns.
+func (s *DbRecorder) NamingStrategy() NamingStrategy {
+	if s.naming != nil {
+		return s.naming
+	}
+	return naming
+}
+
+// WithOverrides returns a NamingStrategy that names the fields in names
+// explicitly, and every other field with ns:
+//
+//	ns := structable.WithOverrides(structable.SnakeCase, map[string]string{
+//		"URL": "url",
+//	})
+func WithOverrides(ns NamingStrategy, names map[string]string) NamingStrategy {
+	return &overrides{ns: ns, names: names}
+}
+
+type overrides struct {
+	ns    NamingStrategy
+	names map[string]string
+}
+
+func (o *overrides) ColumnName(field string) string {
+	if c, ok := o.names[field]; ok {
+		return c
+	}
+	return o.ns.ColumnName(field)
+}
+
+type snakeCaseNaming struct{}
+
+func (snakeCaseNaming) ColumnName(field string) string {
+	return snakeCase(field)
+}
+
+type verbatimNaming struct{}
+
+func (verbatimNaming) ColumnName(field string) string {
+	return field
+}
+
+// TableNamingStrategy is implemented by NamingStrategies that also name
+// tables, for BindAuto.
+type TableNamingStrategy interface {
+	// TableName returns the table name for a struct type name.
+	TableName(structName string) string
+}
+
+// BindAuto binds the DbRecorder to a Record, naming the table after the
+// Record's struct type.
+//
+// The struct name is converted by the NamingStrategy, and the last word is
+// made plural, so User binds to users and LineItem to line_items. A
+// NamingStrategy that is a TableNamingStrategy names tables itself. A Record
+// that is a TableNamer still names its own table.
+//
+// Explicit table names, given to Bind, remain the recommended way; BindAuto
+// is for code bases that follow the convention throughout.
+//
+// If the Record is not a pointer to a named struct type, there is no name to
+// go by, and the error is returned by the next operation, as for Bind.
+func (s *DbRecorder) BindAuto(ar Record) Recorder {
+	t, err := recordType(ar)
+	if err == nil && t.Name() == "" {
+		err = fmt.Errorf("%w: BindAuto needs a named struct type, got %T", ErrInvalidRecord, ar)
+	}
+	if err != nil {
+		s.Bind("", ar)
+		s.bindErr = err
+		return s
+	}
+	name := t.Name()
+	ns := s.NamingStrategy()
+	if tn, ok := ns.(TableNamingStrategy); ok {
+		return s.Bind(tn.TableName(name), ar)
+	}
+	return s.Bind(pluralize(ns.ColumnName(name)), ar)
+}
+
+// irregularPlurals are the plurals that pluralize does not derive by rule.
+var irregularPlurals = map[string]string{
+	"person": "people",
+	"child":  "children",
+	"man":    "men",
+	"woman":  "women",
+	"mouse":  "mice",
+	"datum":  "data",
+	"index":  "indices",
+}
+
+// pluralize makes the last word of a name plural, keeping its case.
+func pluralize(name string) string {
+	start := strings.LastIndexAny(name, "_.") + 1
+	word := name[start:]
+	lower := strings.ToLower(word)
+	if p, ok := irregularPlurals[lower]; ok {
+		if lower != word {
+			// Keep a leading capital, as in Person and People.
+			p = strings.ToUpper(p[:1]) + p[1:]
+		}
+		return name[:start] + p
+	}
+
+	switch {
+	case lower == "":
+		return name
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "z"),
+		strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		return name + "es"
+	case strings.HasSuffix(lower, "y") && len(lower) > 1 && !strings.ContainsAny(lower[len(lower)-2:len(lower)-1], "aeiou"):
+		return name[:len(name)-1] + "ies"
+	}
+	return name + "s"
+}
+
+// snakeCase converts a Go field name to snake case: UserID becomes user_id.
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,106 @@
+package structable
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type conventional struct {
+	UserID    int    `stbl:",PRIMARY_KEY,SERIAL"`
+	FirstName string `stbl:""`
+	HomeURL   string `stbl:" ,SIZE(255)"`
+	Email     string `stbl:"email_address"`
+	Skipped   string
+}
+
+func TestNamingStrategy(t *testing.T) {
+	tests := map[string]string{
+		"UserID":     "user_id",
+		"FirstName":  "first_name",
+		"HTTPServer": "http_server",
+		"ID":         "id",
+		"Version2":   "version2",
+	}
+	for field, expect := range tests {
+		if got := SnakeCase.ColumnName(field); got != expect {
+			t.Errorf("%s: expected %s, got %s", field, expect, got)
+		}
+	}
+
+	r := New(&DBStub{}, "mysql")
+	r.Bind("users", &conventional{})
+	expect := []string{"user_id", "first_name", "home_url", "email_address"}
+	if cols := r.Columns(true); !reflect.DeepEqual(cols, expect) {
+		t.Errorf("Expected %v, got %v", expect, cols)
+	}
+	if f, _ := r.fieldForColumn("home_url"); f.size != 255 {
+		t.Errorf("Expected the options to be kept, got %+v", f)
+	}
+
+	ns := WithOverrides(Verbatim, map[string]string{"HomeURL": "url"})
+	r = New(&DBStub{}, "mysql").SetNamingStrategy(ns)
+	r.Bind("users", &conventional{})
+	expect = []string{"UserID", "FirstName", "url", "email_address"}
+	if cols := r.Columns(true); !reflect.DeepEqual(cols, expect) {
+		t.Errorf("Expected %v, got %v", expect, cols)
+	}
+}
+
+type LineItem struct {
+	Id int `stbl:",PRIMARY_KEY"`
+}
+
+type Person struct {
+	Id int `stbl:",PRIMARY_KEY"`
+}
+
+type Goose struct {
+	Id int `stbl:",PRIMARY_KEY"`
+}
+
+func (g *Goose) TableName() string { return "geese" }
+
+func TestBindAuto(t *testing.T) {
+	tests := map[string]string{
+		"user":      "users",
+		"line_item": "line_items",
+		"box":       "boxes",
+		"category":  "categories",
+		"day":       "days",
+		"branch":    "branches",
+		"person":    "people",
+		"Person":    "People",
+		"sales.tax": "sales.taxes",
+	}
+	for name, expect := range tests {
+		if got := pluralize(name); got != expect {
+			t.Errorf("%s: expected %s, got %s", name, expect, got)
+		}
+	}
+
+	r := New(&DBStub{}, "mysql")
+	r.BindAuto(&LineItem{})
+	if name := r.TableName(); name != "line_items" {
+		t.Errorf("Expected line_items, got %s", name)
+	}
+	r = New(&DBStub{}, "mysql").SetNamingStrategy(Verbatim)
+	r.BindAuto(&Person{})
+	if name := r.TableName(); name != "People" {
+		t.Errorf("Expected People, got %s", name)
+	}
+	r.BindAuto(&Goose{})
+	if name := r.TableName(); name != "geese" {
+		t.Errorf("Expected the TableNamer to win, got %s", name)
+	}
+
+	for _, rec := range []Record{nil, (*LineItem)(nil), LineItem{}, &struct {
+		Id int `stbl:"id"`
+	}{}} {
+		r := New(&DBStub{}, "mysql")
+		r.BindAuto(rec)
+		if err := r.Insert(); !errors.Is(err, ErrInvalidRecord) {
+			t.Errorf("Expected BindAuto(%T) to fail with ErrInvalidRecord, got %v", rec, err)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package structable
+
+import (
+	"database/sql/driver"
+	"reflect"
+)
+
+// writeValue returns the value that is written to f's column for the field
+// value v.
+//
+// Zero values of NULLABLE fields are written as NULL. The sql.Null* types are
+// written as the value they hold, or NULL, rather than as structs, so that
+// every driver, Tracer, and cache sees plain values.
+func writeValue(f *field, v interface{}) interface{} {
+	switch {
+	case f.nullable && isZero(v):
+		return nil
+	case f.isExternal:
+		return externalKey(v)
+	case f.isCompressed:
+		return compressValue(v)
+	}
+	return sqlValue(v)
+}
+
+// sqlValue returns the driver value of a database/sql type, such as
+// sql.NullString, and any other value as it is.
+func sqlValue(v interface{}) interface{} {
+	vr, ok := v.(driver.Valuer)
+	if !ok {
+		return v
+	}
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.PkgPath() != "database/sql" {
+		return v
+	}
+	// The database/sql Valuers never fail.
+	dv, err := vr.Value()
+	if err != nil {
+		return v
+	}
+	return dv
+}
+
+// isZero reports whether v is nil, or the zero value of its type.
+func isZero(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	return !rv.IsValid() || rv.IsZero()
+}
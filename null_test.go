@@ -0,0 +1,53 @@
+package structable
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type contact struct {
+	Id    int            `stbl:"id,PRIMARY_KEY"`
+	Phone sql.NullString `stbl:"phone"`
+	Age   sql.NullInt64  `stbl:"age"`
+	Seen  *sql.NullTime  `stbl:"seen"`
+	Email string         `stbl:"email,NULLABLE"`
+}
+
+func TestNullValues(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "mysql")
+	seen := time.Unix(1, 0)
+	c := &contact{
+		Id:    1,
+		Phone: sql.NullString{String: "555", Valid: true},
+		Seen:  &sql.NullTime{Time: seen, Valid: true},
+	}
+	r.Bind("contacts", c)
+
+	if err := r.Insert(); err != nil {
+		t.Fatal(err)
+	}
+	expect := []interface{}{1, "555", nil, seen, nil}
+	if !reflect.DeepEqual(db.LastExecArgs, expect) {
+		t.Errorf("Expected %#v, got %#v", expect, db.LastExecArgs)
+	}
+
+	c.Email = "a@example.com"
+	if err := r.Update(); err != nil {
+		t.Fatal(err)
+	}
+	expect = []interface{}{nil, "a@example.com", "555", seen, 1}
+	if !reflect.DeepEqual(db.LastExecArgs, expect) {
+		t.Errorf("Expected %#v, got %#v", expect, db.LastExecArgs)
+	}
+
+	c.Email = "x"
+	if err := r.scan(fillRow{nil}, false); err != nil {
+		t.Fatal(err)
+	}
+	if c.Email != "" || c.Phone.Valid {
+		t.Errorf("Expected NULL to be loaded as the zero value, got %+v", c)
+	}
+}
@@ -0,0 +1,235 @@
+/*
+Package otel adds OpenTelemetry tracing to Structable Recorders.
+
+WithTracing wraps a Recorder so that each operation creates a span named
+after the operation (structable.Load, structable.Insert, and so on). Spans
+carry the table name, the database system, and, for loads and lists, the
+number of rows that were loaded.
+
+	u := new(User)
+	u.Recorder = otel.WithTracing(structable.New(db, "postgres").Bind("users", u)).
+		WithContext(r.Context())
+
+The functions of structable that read many rows take a Recorder, so they
+cannot be traced by wrapping it. The tracing Recorder has methods for them
+instead:
+
+	users, err := otel.WithTracing(structable.New(db, "postgres").Bind("users", new(User))).
+		WithContext(r.Context()).
+		List(structable.WithWhereEq("active", true))
+
+Spans are created with the global TracerProvider unless another one is
+supplied with WithTracerProvider.
+*/
+package otel
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Masterminds/structable"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/Masterminds/structable/otel"
+
+// Attribute keys set on every span.
+const (
+	TableKey  = attribute.Key("db.sql.table")
+	SystemKey = attribute.Key("db.system")
+	RowsKey   = attribute.Key("structable.rows")
+)
+
+// Option configures a tracing Recorder.
+type Option func(*Recorder)
+
+// WithTracerProvider sets the TracerProvider used to create spans.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(r *Recorder) {
+		r.tracer = tp.Tracer(instrumentationName)
+	}
+}
+
+// Recorder is a structable.Recorder that traces its operations.
+type Recorder struct {
+	structable.Recorder
+	tracer trace.Tracer
+	ctx    context.Context
+}
+
+// WithTracing wraps a Recorder with OpenTelemetry tracing.
+func WithTracing(rec structable.Recorder, opts ...Option) *Recorder {
+	r := &Recorder{
+		Recorder: rec,
+		tracer:   otel.GetTracerProvider().Tracer(instrumentationName),
+		ctx:      context.Background(),
+	}
+	for _, o := range opts {
+		o(r)
+	}
+	return r
+}
+
+// WithContext returns a copy of the Recorder whose spans are children of ctx.
+func (r *Recorder) WithContext(ctx context.Context) *Recorder {
+	c := *r
+	c.ctx = ctx
+	return &c
+}
+
+// Bind binds the underlying Recorder, and returns the tracing Recorder.
+func (r *Recorder) Bind(table string, rec structable.Record) structable.Recorder {
+	r.Recorder = r.Recorder.Bind(table, rec)
+	return r
+}
+
+// Unwrap returns the underlying Recorder.
+func (r *Recorder) Unwrap() structable.Recorder {
+	return r.Recorder
+}
+
+// Load loads the record, inside of a structable.Load span.
+func (r *Recorder) Load() error {
+	span := r.start("Load")
+	err := r.Recorder.Load()
+	r.end(span, loadRows(err), err)
+	return err
+}
+
+// LoadWhere loads the record, inside of a structable.LoadWhere span.
+func (r *Recorder) LoadWhere(pred interface{}, args ...interface{}) error {
+	span := r.start("LoadWhere")
+	err := r.Recorder.LoadWhere(pred, args...)
+	r.end(span, loadRows(err), err)
+	return err
+}
+
+// Reload reloads the record, inside of a structable.Reload span.
+func (r *Recorder) Reload() error {
+	span := r.start("Reload")
+	err := r.Recorder.Reload()
+	r.end(span, loadRows(err), err)
+	return err
+}
+
+// LoadByKey loads the record by its key, inside of a structable.LoadByKey
+// span.
+func (r *Recorder) LoadByKey(values ...interface{}) error {
+	span := r.start("LoadByKey")
+	err := r.Recorder.LoadByKey(values...)
+	r.end(span, loadRows(err), err)
+	return err
+}
+
+// Exists checks for the record, inside of a structable.Exists span.
+func (r *Recorder) Exists() (bool, error) {
+	span := r.start("Exists")
+	ok, err := r.Recorder.Exists()
+	r.end(span, -1, err)
+	return ok, err
+}
+
+// ExistsWhere checks for records, inside of a structable.ExistsWhere span.
+func (r *Recorder) ExistsWhere(pred interface{}, args ...interface{}) (bool, error) {
+	span := r.start("ExistsWhere")
+	ok, err := r.Recorder.ExistsWhere(pred, args...)
+	r.end(span, -1, err)
+	return ok, err
+}
+
+// Insert inserts the record, inside of a structable.Insert span.
+func (r *Recorder) Insert() error {
+	span := r.start("Insert")
+	err := r.Recorder.Insert()
+	r.end(span, -1, err)
+	return err
+}
+
+// Update updates the record, inside of a structable.Update span.
+func (r *Recorder) Update() error {
+	span := r.start("Update")
+	err := r.Recorder.Update()
+	r.end(span, -1, err)
+	return err
+}
+
+// Delete deletes the record, inside of a structable.Delete span.
+func (r *Recorder) Delete() error {
+	span := r.start("Delete")
+	err := r.Recorder.Delete()
+	r.end(span, -1, err)
+	return err
+}
+
+// List lists records as by structable.List, inside of a structable.List
+// span.
+func (r *Recorder) List(opts ...structable.WhereFunc) ([]structable.Recorder, error) {
+	span := r.start("List")
+	items, err := structable.List(r, opts...)
+	r.end(span, len(items), err)
+	return items, err
+}
+
+// ListWhere lists records as by structable.ListWhere, inside of a
+// structable.ListWhere span.
+func (r *Recorder) ListWhere(fn structable.WhereFunc) ([]structable.Recorder, error) {
+	span := r.start("ListWhere")
+	items, err := structable.ListWhere(r, fn)
+	r.end(span, len(items), err)
+	return items, err
+}
+
+// Paginate fetches a page of records as by structable.Paginate, inside of a
+// structable.Paginate span. The row count is the number of items on the
+// page.
+func (r *Recorder) Paginate(page structable.PageRequest) (structable.PageResult, error) {
+	span := r.start("Paginate")
+	res, err := structable.Paginate(r, page)
+	r.end(span, len(res.Items), err)
+	return res, err
+}
+
+func (r *Recorder) start(op string) trace.Span {
+	_, span := r.tracer.Start(r.ctx, "structable."+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			TableKey.String(r.TableName()),
+			SystemKey.String(system(r.Driver())),
+		))
+	return span
+}
+
+// end finishes a span. A negative row count is not recorded.
+func (r *Recorder) end(span trace.Span, rows int, err error) {
+	if rows >= 0 {
+		span.SetAttributes(RowsKey.Int(rows))
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// loadRows is the number of rows that a single-record load returned.
+func loadRows(err error) int {
+	if err != nil {
+		return 0
+	}
+	return 1
+}
+
+// system maps a Structable flavor to an OpenTelemetry db.system value.
+func system(flavor string) string {
+	switch flavor {
+	case "postgres":
+		return "postgresql"
+	case "sqlite3":
+		return "sqlite"
+	}
+	return flavor
+}
@@ -0,0 +1,68 @@
+//go:build sqlite
+// +build sqlite
+
+package otel
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/Masterminds/structable"
+	_ "github.com/mattn/go-sqlite3"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestListRows(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE stools (id INTEGER PRIMARY KEY AUTOINCREMENT, number_of_legs INTEGER)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO stools (number_of_legs) VALUES (3), (4), (4)`); err != nil {
+		t.Fatal(err)
+	}
+
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	r := WithTracing(structable.New(structable.NewRunner(db), "sqlite3"), WithTracerProvider(tp))
+	r.Bind("stools", &stool{})
+
+	items, err := r.List(structable.WithWhereEq("number_of_legs", 4))
+	if err != nil || len(items) != 2 {
+		t.Fatalf("Expected 2 stools, got %d: %v", len(items), err)
+	}
+	if _, ok := items[0].Interface().(*stool); !ok {
+		t.Errorf("Expected stools, got %T", items[0].Interface())
+	}
+	if _, err := r.ListWhere(structable.WithWhereEq("number_of_legs", 5)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Paginate(structable.PageRequest{Size: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []struct {
+		name, rows string
+	}{
+		{"structable.List", "2"},
+		{"structable.ListWhere", "0"},
+		{"structable.Paginate", "2"},
+	}
+	spans := sr.Ended()
+	if len(spans) != len(expect) {
+		t.Fatalf("Expected %d spans, got %d", len(expect), len(spans))
+	}
+	for i, e := range expect {
+		attrs := map[string]string{}
+		for _, a := range spans[i].Attributes() {
+			attrs[string(a.Key)] = a.Value.Emit()
+		}
+		if spans[i].Name() != e.name || attrs["structable.rows"] != e.rows || attrs["db.sql.table"] != "stools" {
+			t.Errorf("Unexpected span %s: %v", spans[i].Name(), attrs)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package otel
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/Masterminds/structable"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type stool struct {
+	Id   int `stbl:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Legs int `stbl:"number_of_legs"`
+}
+
+// dbStub fails every Exec, and returns empty rows otherwise.
+type dbStub struct{}
+
+func (dbStub) Prepare(string) (*sql.Stmt, error) { return nil, nil }
+func (dbStub) Begin() (*sql.Tx, error)           { return nil, nil }
+func (dbStub) Exec(string, ...interface{}) (sql.Result, error) {
+	return nil, errors.New("intentional failure")
+}
+func (dbStub) Query(string, ...interface{}) (*sql.Rows, error) { return nil, nil }
+func (dbStub) QueryRow(string, ...interface{}) squirrel.RowScanner {
+	return &squirrel.Row{RowScanner: rowStub{}}
+}
+
+type rowStub struct{}
+
+func (rowStub) Scan(...interface{}) error { return nil }
+
+func TestWithTracing(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	r := WithTracing(structable.New(dbStub{}, "postgres"), WithTracerProvider(tp))
+	r.Bind("stools", &stool{Id: 1})
+
+	if err := r.Load(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := r.Delete(); err == nil {
+		t.Fatal("Expected delete to fail")
+	}
+
+	spans := sr.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+
+	load := spans[0]
+	if load.Name() != "structable.Load" {
+		t.Errorf("Unexpected span name %s", load.Name())
+	}
+	attrs := map[string]string{}
+	for _, a := range load.Attributes() {
+		attrs[string(a.Key)] = a.Value.Emit()
+	}
+	if attrs["db.sql.table"] != "stools" || attrs["db.system"] != "postgresql" || attrs["structable.rows"] != "1" {
+		t.Errorf("Unexpected attributes: %v", attrs)
+	}
+
+	del := spans[1]
+	if del.Name() != "structable.Delete" || del.Status().Description != "intentional failure" {
+		t.Errorf("Expected failed Delete span, got %s: %v", del.Name(), del.Status())
+	}
+}
+
+func TestEndNoRows(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	inner := structable.New(dbStub{}, "postgres")
+	r := WithTracing(inner, WithTracerProvider(tp))
+	if r.Unwrap() != structable.Recorder(inner) {
+		t.Error("Expected Unwrap to return the traced Recorder")
+	}
+
+	r.end(r.start("Load"), 0, fmt.Errorf("loading stool: %w", sql.ErrNoRows))
+	if s := sr.Ended()[0]; len(s.Events()) != 0 || s.Status().Description != "" {
+		t.Errorf("Expected a wrapped sql.ErrNoRows not to be an error, got %v", s.Status())
+	}
+}
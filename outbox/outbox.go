@@ -0,0 +1,220 @@
+/*
+Package outbox publishes events reliably, with the transactional outbox
+pattern.
+
+An event is not sent to a message broker directly, since the send could
+succeed while the transaction that caused it rolls back, or the other way
+around. Instead, WithOutbox inserts the event into an outbox table, in the
+same transaction as the writes it describes:
+
+	err := structable.Transact(structable.NewRunner(db), "postgres", func(tx structable.Runner) error {
+		if err := NewOrder(tx, "postgres").Bind("orders", order).Insert(); err != nil {
+			return err
+		}
+		return outbox.WithOutbox(tx, "postgres", outbox.Event{
+			Topic:   "order.created",
+			Key:     strconv.FormatInt(order.Id, 10),
+			Payload: order,
+		})
+	})
+
+A Poller then reads the events that have not been dispatched yet, in the order
+they were added, hands each to a Dispatch function that sends it to the
+broker, and marks it as dispatched:
+
+	p := &outbox.Poller{DB: structable.NewRunner(db), Flavor: "postgres", Dispatch: publish}
+	go p.Run(ctx)
+
+Delivery is at least once: if the Poller stops after an event was sent, but
+before it was marked, the event is sent again. Consumers should use the event
+ID to skip duplicates. Run one Poller per outbox table, or several Pollers
+may send the same events.
+
+The outbox table looks like this (adjust the types for the database):
+
+	CREATE TABLE outbox (
+		id            SERIAL PRIMARY KEY,
+		topic         VARCHAR(255) NOT NULL,
+		event_key     VARCHAR(255) NOT NULL,
+		payload       BYTEA NOT NULL,
+		created_at    TIMESTAMP NOT NULL,
+		dispatched_at TIMESTAMP,
+		attempts      INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX outbox_pending ON outbox (id) WHERE dispatched_at IS NULL;
+*/
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/structable"
+)
+
+// DefaultTable is the outbox table used when none is set.
+const DefaultTable = "outbox"
+
+// Record is a row of the outbox table.
+type Record struct {
+	Id int64 `stbl:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	// Topic is what the event is about, such as the broker topic to send it
+	// to.
+	Topic string `stbl:"topic"`
+	// Key orders or partitions the events of a topic, such as the ID of the
+	// record the event is about.
+	Key     string `stbl:"event_key"`
+	Payload []byte `stbl:"payload"`
+	// CreatedAt is when the event was added, and DispatchedAt is when it was
+	// sent, or nil if it was not sent yet.
+	CreatedAt    time.Time  `stbl:"created_at"`
+	DispatchedAt *time.Time `stbl:"dispatched_at,NULLABLE"`
+	// Attempts counts the failed sends.
+	Attempts int `stbl:"attempts"`
+}
+
+// Event is an event to add to the outbox.
+type Event struct {
+	Topic string
+	Key   string
+	// Payload is stored as is if it is a []byte, and encoded as JSON
+	// otherwise.
+	Payload interface{}
+}
+
+// Outbox adds events to an outbox table.
+type Outbox struct {
+	// Table is the outbox table. It defaults to DefaultTable.
+	Table string
+	// Now returns the time an event is added. It defaults to time.Now.
+	Now func() time.Time
+}
+
+func table(t string) string {
+	if t == "" {
+		return DefaultTable
+	}
+	return t
+}
+
+func now(fn func() time.Time) time.Time {
+	if fn == nil {
+		return time.Now()
+	}
+	return fn()
+}
+
+// Add inserts an event into the outbox table, and returns its Record.
+//
+// The tx should be the transaction of the writes that the event describes,
+// such as the Runner given to the function of structable.Transact.
+func (o Outbox) Add(tx structable.Runner, flavor string, e Event) (*Record, error) {
+	payload, ok := e.Payload.([]byte)
+	if !ok {
+		var err error
+		if payload, err = json.Marshal(e.Payload); err != nil {
+			return nil, fmt.Errorf("encoding %s event: %w", e.Topic, err)
+		}
+	}
+	rec := &Record{Topic: e.Topic, Key: e.Key, Payload: payload, CreatedAt: now(o.Now)}
+	if err := structable.New(tx, flavor).Bind(table(o.Table), rec).Insert(); err != nil {
+		return nil, fmt.Errorf("adding %s event to the outbox: %w", e.Topic, err)
+	}
+	return rec, nil
+}
+
+// WithOutbox adds an event to the DefaultTable, on tx. See Outbox.Add.
+func WithOutbox(tx structable.Runner, flavor string, e Event) error {
+	_, err := Outbox{}.Add(tx, flavor, e)
+	return err
+}
+
+// Poller dispatches the events of an outbox table.
+type Poller struct {
+	DB     structable.Runner
+	Flavor string
+	// Table is the outbox table. It defaults to DefaultTable.
+	Table string
+	// Dispatch sends an event. If it returns an error, the event's Attempts
+	// are counted up, and it is tried again by the next Poll.
+	Dispatch func(ctx context.Context, r *Record) error
+	// Batch is the most events read by one Poll. It defaults to 100.
+	Batch uint64
+	// Interval is how long Run waits when there are no more events. It
+	// defaults to one second.
+	Interval time.Duration
+	// OnError is called by Run with the errors of Poll. They are dropped if it
+	// is nil.
+	OnError func(error)
+	// Now returns the time an event is dispatched. It defaults to time.Now.
+	Now func() time.Time
+}
+
+func (p *Poller) batch() uint64 {
+	if p.Batch == 0 {
+		return 100
+	}
+	return p.Batch
+}
+
+// Poll dispatches up to Batch events that were not dispatched yet, oldest
+// first, and returns how many were dispatched.
+//
+// Poll stops at the first event that fails to dispatch, so that events are
+// not sent out of order, and returns the error.
+func (p *Poller) Poll(ctx context.Context) (int, error) {
+	batch := p.batch()
+	proto := structable.New(p.DB, p.Flavor).Bind(table(p.Table), &Record{})
+	items, err := structable.ListWhere(proto, structable.Compose(
+		structable.WithWhere("dispatched_at IS NULL"),
+		structable.WithOrderBy("id"),
+		structable.WithLimit(batch),
+	))
+	if err != nil {
+		return 0, fmt.Errorf("reading the outbox: %w", err)
+	}
+
+	for i, item := range items {
+		if err := ctx.Err(); err != nil {
+			return i, err
+		}
+		r := item.(*structable.DbRecorder)
+		rec := r.Record().(*Record)
+		if err := p.Dispatch(ctx, rec); err != nil {
+			derr := fmt.Errorf("dispatching event %d (%s): %w", rec.Id, rec.Topic, err)
+			if err := r.ApplyChanges(map[string]interface{}{"attempts": rec.Attempts + 1}, "attempts"); err != nil {
+				return i, fmt.Errorf("%s (counting the attempt failed: %s)", derr, err)
+			}
+			return i, derr
+		}
+		if err := r.ApplyChanges(map[string]interface{}{"dispatched_at": now(p.Now)}, "dispatched_at"); err != nil {
+			return i, fmt.Errorf("marking event %d as dispatched: %w", rec.Id, err)
+		}
+	}
+	return len(items), nil
+}
+
+// Run polls until ctx is done, and returns ctx.Err(). It polls again right
+// away while full batches are dispatched, and waits for Interval otherwise.
+func (p *Poller) Run(ctx context.Context) error {
+	interval := p.Interval
+	if interval == 0 {
+		interval = time.Second
+	}
+	for {
+		n, err := p.Poll(ctx)
+		if err != nil && p.OnError != nil && ctx.Err() == nil {
+			p.OnError(err)
+		}
+		if err == nil && uint64(n) == p.batch() {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
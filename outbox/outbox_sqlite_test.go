@@ -0,0 +1,82 @@
+//go:build sqlite
+// +build sqlite
+
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/Masterminds/structable"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestPoller(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	_, err = db.Exec(`CREATE TABLE outbox (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, topic TEXT, event_key TEXT, payload BLOB,
+		created_at TIMESTAMP, dispatched_at TIMESTAMP, attempts INTEGER
+	)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runner := structable.NewRunner(db)
+	err = structable.Transact(runner, "sqlite3", func(tx structable.Runner) error {
+		for _, topic := range []string{"a", "b", "c"} {
+			if err := WithOutbox(tx, "sqlite3", Event{Topic: topic, Payload: []byte(topic)}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to add events: %s", err)
+	}
+
+	sent := []string{}
+	down := errors.New("broker down")
+	p := &Poller{DB: runner, Flavor: "sqlite3", Dispatch: func(ctx context.Context, r *Record) error {
+		if r.Topic == "b" && r.Attempts == 0 {
+			return down
+		}
+		sent = append(sent, string(r.Payload))
+		return nil
+	}}
+
+	n, err := p.Poll(context.Background())
+	if n != 1 || !errors.Is(err, down) {
+		t.Fatalf("Expected the poll to stop at b, got %d, %v", n, err)
+	}
+	n, err = p.Poll(context.Background())
+	if n != 2 || err != nil {
+		t.Fatalf("Expected b and c to be sent, got %d, %v", n, err)
+	}
+	if n, err := p.Poll(context.Background()); n != 0 || err != nil {
+		t.Errorf("Expected nothing left to send, got %d, %v", n, err)
+	}
+	if len(sent) != 3 || sent[0] != "a" || sent[1] != "b" || sent[2] != "c" {
+		t.Errorf("Expected the events in order, got %v", sent)
+	}
+
+	var attempts, pending int
+	if err := db.QueryRow("SELECT attempts FROM outbox WHERE topic = 'b'").Scan(&attempts); err != nil || attempts != 1 {
+		t.Errorf("Expected one failed attempt for b, got %d, %v", attempts, err)
+	}
+	if err := db.QueryRow("SELECT COUNT(*) FROM outbox WHERE dispatched_at IS NULL").Scan(&pending); err != nil || pending != 0 {
+		t.Errorf("Expected every event to be marked, got %d pending, %v", pending, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := p.Run(ctx); err != context.Canceled {
+		t.Errorf("Expected Run to stop with the context, got %v", err)
+	}
+}
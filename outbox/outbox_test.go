@@ -0,0 +1,39 @@
+package outbox
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/structable/stest"
+)
+
+func TestAdd(t *testing.T) {
+	db := &stest.DB{}
+	at := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	o := Outbox{Table: "events", Now: func() time.Time { return at }}
+
+	rec, err := o.Add(db, "mysql", Event{Topic: "order.created", Key: "7", Payload: map[string]int{"id": 7}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stest.AssertQueries(t, db, "INSERT INTO events (topic,event_key,payload,created_at,attempts) VALUES (?,?,?,?,?)")
+	var payload map[string]int
+	if err := json.Unmarshal(rec.Payload, &payload); err != nil || payload["id"] != 7 {
+		t.Errorf("Expected a JSON payload, got %s", rec.Payload)
+	}
+	if rec.Id != 1 || !rec.CreatedAt.Equal(at) {
+		t.Errorf("Expected the inserted Record, got %+v", rec)
+	}
+
+	rec, err = o.Add(db, "mysql", Event{Topic: "raw", Payload: []byte("as is")})
+	if err != nil || string(rec.Payload) != "as is" {
+		t.Errorf("Expected a []byte payload to be stored as is, got %q, %v", rec.Payload, err)
+	}
+
+	db.Reset()
+	if err := WithOutbox(db, "postgres", Event{Topic: "t"}); err != nil {
+		t.Fatal(err)
+	}
+	stest.AssertQueries(t, db, "INSERT INTO outbox (topic,event_key,payload,created_at,attempts) VALUES ($1,$2,$3,$4,$5) RETURNING id,topic,event_key,payload,created_at,dispatched_at,attempts")
+}
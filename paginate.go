@@ -0,0 +1,271 @@
+package structable
+
+import (
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// ErrBadPageToken is returned by Paginate when a page token cannot be decoded.
+var ErrBadPageToken = errors.New("invalid page token")
+
+// PageRequest describes one page of a Paginate call.
+type PageRequest struct {
+	// Size is the maximum number of items on the page. It must be greater
+	// than zero.
+	Size uint64
+	// Token is the NextToken of the previous page. Leave it empty to get the
+	// first page.
+	Token string
+	// OrderBy is the column that pages are ordered by. The primary key is
+	// always used to break ties. If OrderBy is empty, pages are ordered by the
+	// primary key alone.
+	OrderBy string
+	// Desc orders pages in descending order.
+	Desc bool
+	// Keyset selects keyset (seek) pagination instead of OFFSET pagination.
+	//
+	// Keyset pagination finds the next page with a WHERE clause on the last
+	// item of the previous page, so it stays fast on deep pages and does not
+	// skip or repeat items when rows are inserted between requests. It
+	// requires a single-column primary key, and the OrderBy column should be
+	// NOT NULL.
+	Keyset bool
+	// Where optionally filters the items. It is applied to both the page
+	// query and the count query.
+	Where WhereFunc
+	// SkipTotal skips the count query. PageResult.Total is then zero.
+	SkipTotal bool
+}
+
+// PageResult is one page of results.
+type PageResult struct {
+	// Items are the Recorders on this page.
+	Items []Recorder
+	// Total is the number of items on all pages.
+	Total uint64
+	// NextToken fetches the following page. It is empty on the last page.
+	NextToken string
+}
+
+// Paginate lists one page of Recorders, along with a total count and a token
+// for the next page.
+//
+//	page, err := structable.Paginate(r, structable.PageRequest{
+//		Size:    20,
+//		Token:   req.URL.Query().Get("page"),
+//		OrderBy: "created_at",
+//		Desc:    true,
+//		Keyset:  true,
+//	})
+//
+// Tokens are opaque strings that are safe to put in URLs. A token is only
+// meaningful to a PageRequest with the same OrderBy, Desc, and Keyset.
+func Paginate(d Recorder, page PageRequest) (PageResult, error) {
+	res := PageResult{Items: []Recorder{}}
+	if page.Size == 0 {
+		return res, errors.New("page size must be greater than zero")
+	}
+	dr := protoRecorder(d)
+
+	keys := dr.Key()
+	if page.Keyset && len(keys) != 1 {
+		return res, fmt.Errorf("table %s must have exactly one primary key for keyset pagination", dr.table)
+	}
+	if page.OrderBy != "" {
+		if err := checkColumns(dr, page.OrderBy); err != nil {
+			return res, err
+		}
+	}
+	where := page.Where
+	if where == nil {
+		where = Compose()
+	}
+
+	// Count the items on all pages.
+	var err error
+	if !page.SkipTotal {
+		if res.Total, err = CountWhere(dr, where); err != nil {
+			return res, err
+		}
+	}
+
+	cursor, err := decodePageToken(page.Token)
+	if err != nil {
+		return res, err
+	}
+
+	dir := " ASC"
+	if page.Desc {
+		dir = " DESC"
+	}
+	order := []string{}
+	if page.OrderBy != "" && (len(keys) != 1 || page.OrderBy != keys[0]) {
+		order = append(order, page.OrderBy+dir)
+	}
+	for _, k := range keys {
+		order = append(order, k+dir)
+	}
+
+	var offset uint64
+	fn := func(desc Describer, q squirrel.SelectBuilder) (squirrel.SelectBuilder, error) {
+		q, err := where(desc, q)
+		if err != nil {
+			return q, err
+		}
+		q = q.OrderBy(order...).Limit(page.Size + 1)
+
+		switch {
+		case cursor == nil:
+		case page.Keyset:
+			return keysetWhere(q, page, keys[0], cursor)
+		default:
+			o, ok := cursor[0].(int64)
+			if len(cursor) != 1 || !ok || o < 0 {
+				return q, ErrBadPageToken
+			}
+			offset = uint64(o)
+			q = q.Offset(offset)
+		}
+		return q, nil
+	}
+	if res.Items, err = ListWhere(dr, fn); err != nil {
+		return res, err
+	}
+
+	if uint64(len(res.Items)) <= page.Size {
+		return res, nil
+	}
+	res.Items = res.Items[:page.Size]
+	if !page.Keyset {
+		res.NextToken, err = encodePageToken([]interface{}{int64(offset + page.Size)})
+		return res, err
+	}
+
+	last := res.Items[page.Size-1].(*DbRecorder)
+	next := []interface{}{}
+	if page.OrderBy != "" && page.OrderBy != keys[0] {
+		next = append(next, last.columnValue(page.OrderBy))
+	}
+	next = append(next, last.columnValue(keys[0]))
+	res.NextToken, err = encodePageToken(next)
+	return res, err
+}
+
+// keysetWhere restricts a query to the items after the cursor.
+func keysetWhere(q squirrel.SelectBuilder, page PageRequest, key string, cursor []interface{}) (squirrel.SelectBuilder, error) {
+	op := ">"
+	if page.Desc {
+		op = "<"
+	}
+	if page.OrderBy == "" || page.OrderBy == key {
+		if len(cursor) != 1 {
+			return q, ErrBadPageToken
+		}
+		return q.Where(fmt.Sprintf("%s %s ?", key, op), cursor[0]), nil
+	}
+	if len(cursor) != 2 {
+		return q, ErrBadPageToken
+	}
+	pred := fmt.Sprintf("(%[1]s %[3]s ? OR (%[1]s = ? AND %[2]s %[3]s ?))", page.OrderBy, key, op)
+	return q.Where(pred, cursor[0], cursor[0], cursor[1]), nil
+}
+
+// columnValue returns the driver value of the field mapped to a column.
+func (s *DbRecorder) columnValue(column string) interface{} {
+	ar := reflect.Indirect(reflect.ValueOf(s.record))
+	for _, f := range s.fields {
+		if f.column == column {
+			v, err := driver.DefaultParameterConverter.ConvertValue(ar.FieldByName(f.name).Interface())
+			if err != nil {
+				return nil
+			}
+			return v
+		}
+	}
+	return nil
+}
+
+// encodePageToken encodes driver values into a page token.
+//
+// Each value is tagged with its type, so that it decodes to the same type.
+func encodePageToken(vals []interface{}) (string, error) {
+	parts := make([]string, len(vals))
+	for i, v := range vals {
+		switch v := v.(type) {
+		case nil:
+			parts[i] = "n:"
+		case int64:
+			parts[i] = "i:" + strconv.FormatInt(v, 10)
+		case float64:
+			parts[i] = "f:" + strconv.FormatFloat(v, 'g', -1, 64)
+		case bool:
+			parts[i] = "b:" + strconv.FormatBool(v)
+		case string:
+			parts[i] = "s:" + v
+		case []byte:
+			parts[i] = "x:" + hex.EncodeToString(v)
+		case time.Time:
+			parts[i] = "t:" + v.Format(time.RFC3339Nano)
+		default:
+			return "", fmt.Errorf("cannot use %T in a page token", v)
+		}
+	}
+	data, err := json.Marshal(parts)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodePageToken decodes a page token. An empty token decodes to nil.
+func decodePageToken(token string) ([]interface{}, error) {
+	if token == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrBadPageToken
+	}
+	parts := []string{}
+	if err := json.Unmarshal(data, &parts); err != nil || len(parts) == 0 {
+		return nil, ErrBadPageToken
+	}
+
+	vals := make([]interface{}, len(parts))
+	for i, p := range parts {
+		if len(p) < 2 || p[1] != ':' {
+			return nil, ErrBadPageToken
+		}
+		var err error
+		switch s := p[2:]; p[0] {
+		case 'n':
+		case 'i':
+			vals[i], err = strconv.ParseInt(s, 10, 64)
+		case 'f':
+			vals[i], err = strconv.ParseFloat(s, 64)
+		case 'b':
+			vals[i], err = strconv.ParseBool(s)
+		case 's':
+			vals[i] = s
+		case 'x':
+			vals[i], err = hex.DecodeString(s)
+		case 't':
+			vals[i], err = time.Parse(time.RFC3339Nano, s)
+		default:
+			err = ErrBadPageToken
+		}
+		if err != nil {
+			return nil, ErrBadPageToken
+		}
+	}
+	return vals, nil
+}
@@ -0,0 +1,68 @@
+package structable
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPaginate(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "postgres").Bind("categories", &category{})
+
+	token, _ := encodePageToken([]interface{}{int64(40)})
+	if _, err := Paginate(r, PageRequest{Size: 20, Token: token, OrderBy: "name", Desc: true}); err != nil {
+		t.Fatal(err)
+	}
+	expect := "SELECT COUNT(*) FROM categories"
+	if db.LastQueryRowSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQueryRowSql)
+	}
+	expect = "SELECT id, parent_id, name FROM categories ORDER BY name DESC, id DESC LIMIT 21 OFFSET 40"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+
+	token, _ = encodePageToken([]interface{}{"Go", int64(7)})
+	if _, err := Paginate(r, PageRequest{Size: 20, Token: token, OrderBy: "name", Keyset: true}); err != nil {
+		t.Fatal(err)
+	}
+	expect = "SELECT id, parent_id, name FROM categories WHERE (name > $1 OR (name = $2 AND id > $3)) ORDER BY name ASC, id ASC LIMIT 21"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+	if !reflect.DeepEqual(db.LastQueryArgs, []interface{}{"Go", "Go", int64(7)}) {
+		t.Errorf("Unexpected args %v", db.LastQueryArgs)
+	}
+
+	if _, err := Paginate(r, PageRequest{Size: 20, Token: "garbage"}); err != ErrBadPageToken {
+		t.Errorf("Expected ErrBadPageToken, got %v", err)
+	}
+	if _, err := Paginate(r, PageRequest{Size: 20, OrderBy: "nope"}); err == nil {
+		t.Error("Expected unknown column to fail")
+	}
+	if _, err := Paginate(r, PageRequest{}); err == nil {
+		t.Error("Expected zero size to fail")
+	}
+}
+
+func TestPageToken(t *testing.T) {
+	when := time.Date(2015, time.June, 23, 1, 2, 3, 4, time.UTC)
+	vals := []interface{}{nil, int64(-3), 1.5, true, "a:b", []byte{0, 1}, when}
+
+	token, err := encodePageToken(vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := decodePageToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, vals) {
+		t.Errorf("Expected %v, got %v", vals, got)
+	}
+
+	if _, err := encodePageToken([]interface{}{struct{}{}}); err == nil {
+		t.Error("Expected unsupported type to fail")
+	}
+}
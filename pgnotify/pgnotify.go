@@ -0,0 +1,214 @@
+/*
+Package pgnotify streams changes made by Structable Recorders through
+Postgres LISTEN/NOTIFY.
+
+A Recorder wraps another Recorder. After each Insert, Update, and Delete, it
+sends a notification on a channel named after the table, with the operation
+and the primary key of the changed row as a JSON payload:
+
+	{"op":"update","table":"users","keys":{"id":7}}
+
+	u.Recorder = pgnotify.New(structable.New(tx, "postgres")).Bind("users", u)
+
+Postgres delivers notifications sent in a transaction when it commits, and
+drops them if it rolls back, so listeners only hear about committed changes.
+On other flavors, the Recorder sends nothing.
+
+A Listener turns the notifications received by a *pq.Listener back into
+Records, loaded by their keys:
+
+	pl := pq.NewListener(dsn, time.Second, time.Minute, nil)
+	pl.Listen("users")
+	l := &pgnotify.Listener{DB: structable.NewRunner(db), Flavor: "postgres", Proto: &User{}}
+	err := l.Run(ctx, pl.Notify, func(c pgnotify.Change) error {
+		cache.Forget(c.Keys)
+		return nil
+	})
+
+Notifications are not stored: changes made while no listener is connected
+are missed. Use them for cache invalidation and live updates, not for events
+that must not be lost (see the outbox package for those).
+*/
+package pgnotify
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/Masterminds/structable"
+	"github.com/lib/pq"
+)
+
+// Change is the payload of a notification.
+type Change struct {
+	Op    string                 `json:"op"`
+	Table string                 `json:"table"`
+	Keys  map[string]interface{} `json:"keys"`
+	// Record is the changed Record, loaded by a Listener. It is nil for
+	// deletes, and for rows that were deleted before they could be loaded.
+	Record structable.Record `json:"-"`
+}
+
+// Recorder is a structable.Recorder that notifies listeners of its changes.
+type Recorder struct {
+	structable.Recorder
+	channel func(table string) string
+}
+
+// New wraps a Recorder, so that its changes are sent on the channel named
+// after its table.
+func New(rec structable.Recorder) *Recorder {
+	return &Recorder{Recorder: rec}
+}
+
+// Middleware returns a RecorderMiddleware that notifies listeners of changes,
+// for use with structable.Wrap.
+func Middleware() structable.RecorderMiddleware {
+	return func(next structable.Recorder) structable.Recorder {
+		return New(next)
+	}
+}
+
+// WithChannel returns a copy of the Recorder that sends on the channel that
+// fn names for a table, instead of the table name.
+func (r *Recorder) WithChannel(fn func(table string) string) *Recorder {
+	c := *r
+	c.channel = fn
+	return &c
+}
+
+// Bind binds the underlying Recorder, and returns the notifying Recorder.
+func (r *Recorder) Bind(table string, rec structable.Record) structable.Recorder {
+	r.Recorder = r.Recorder.Bind(table, rec)
+	return r
+}
+
+// Unwrap returns the underlying Recorder.
+func (r *Recorder) Unwrap() structable.Recorder {
+	return r.Recorder
+}
+
+// Insert inserts the record, and sends a notification.
+func (r *Recorder) Insert() error {
+	if err := r.Recorder.Insert(); err != nil {
+		return err
+	}
+	return r.notify(structable.OpInsert)
+}
+
+// Update updates the record, and sends a notification.
+func (r *Recorder) Update() error {
+	if err := r.Recorder.Update(); err != nil {
+		return err
+	}
+	return r.notify(structable.OpUpdate)
+}
+
+// Delete deletes the record, and sends a notification.
+func (r *Recorder) Delete() error {
+	if err := r.Recorder.Delete(); err != nil {
+		return err
+	}
+	return r.notify(structable.OpDelete)
+}
+
+// notify sends a Change with pg_notify, which takes the channel as a
+// parameter, unlike NOTIFY.
+func (r *Recorder) notify(op string) error {
+	if r.Driver() != "postgres" {
+		return nil
+	}
+	table := r.TableName()
+	payload, err := json.Marshal(Change{Op: op, Table: table, Keys: r.WhereIds()})
+	if err != nil {
+		return err
+	}
+	channel := table
+	if r.channel != nil {
+		channel = r.channel(table)
+	}
+	q := r.Builder().Select().Column("pg_notify(?, ?)", channel, string(payload))
+	query, args, err := q.ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err := r.DB().Exec(query, args...); err != nil {
+		return fmt.Errorf("notifying %s of a change to %s: %w", channel, table, err)
+	}
+	return nil
+}
+
+// Listener turns notifications sent by Recorders into Changes.
+type Listener struct {
+	// DB and Flavor are used to load the changed Records.
+	DB     structable.Runner
+	Flavor string
+	// Proto is a Record of the type stored in the table. It is only used for
+	// its type.
+	Proto structable.Record
+	// KeysOnly skips loading the Records, so Change.Record is always nil.
+	KeysOnly bool
+	// OnReconnect is called when the *pq.Listener reconnects, since
+	// notifications may have been missed while it was disconnected.
+	OnReconnect func()
+}
+
+// Decode decodes the payload of a notification, and loads the changed Record
+// unless it was deleted.
+func (l *Listener) Decode(payload string) (Change, error) {
+	var c Change
+	if err := json.Unmarshal([]byte(payload), &c); err != nil {
+		return c, fmt.Errorf("decoding notification: %w", err)
+	}
+	if l.KeysOnly || c.Op == structable.OpDelete {
+		return c, nil
+	}
+
+	rec := reflect.New(reflect.Indirect(reflect.ValueOf(l.Proto)).Type()).Interface()
+	r := structable.New(l.DB, l.Flavor)
+	r.Bind(c.Table, rec)
+	if err := r.SetValues(c.Keys); err != nil {
+		return c, fmt.Errorf("decoding the keys of %s: %w", c.Table, err)
+	}
+	if err := r.Load(); err != nil {
+		if err == sql.ErrNoRows {
+			return c, nil
+		}
+		return c, fmt.Errorf("loading %s %v: %w", c.Table, c.Keys, err)
+	}
+	c.Record = rec
+	return c, nil
+}
+
+// Run decodes the notifications from a *pq.Listener's Notify channel, and
+// passes each Change to fn, until ctx is done, the channel is closed, or fn or
+// Decode returns an error.
+func (l *Listener) Run(ctx context.Context, notes <-chan *pq.Notification, fn func(Change) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case n, ok := <-notes:
+			if !ok {
+				return nil
+			}
+			// pq sends nil after it reconnects.
+			if n == nil {
+				if l.OnReconnect != nil {
+					l.OnReconnect()
+				}
+				continue
+			}
+			c, err := l.Decode(n.Extra)
+			if err != nil {
+				return err
+			}
+			if err := fn(c); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package pgnotify
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Masterminds/structable"
+	"github.com/Masterminds/structable/stest"
+	"github.com/lib/pq"
+)
+
+type user struct {
+	Id   int    `stbl:"id,PRIMARY_KEY,SERIAL"`
+	Name string `stbl:"name"`
+}
+
+func TestRecorder(t *testing.T) {
+	db := &stest.DB{}
+	u := &user{Id: 7, Name: "matt"}
+	r := New(structable.New(db, "postgres")).Bind("users", u)
+
+	if err := r.Update(); err != nil {
+		t.Fatal(err)
+	}
+	stest.AssertQueries(t, db,
+		"UPDATE users SET name = $1 WHERE id = $2",
+		"SELECT pg_notify($1, $2)")
+	args := db.Last().Args
+	if args[0] != "users" || args[1] != `{"op":"update","table":"users","keys":{"id":7}}` {
+		t.Errorf("Unexpected notification %v", args)
+	}
+
+	db.Reset()
+	w := New(structable.New(db, "postgres")).WithChannel(func(t string) string { return "changes_" + t })
+	w.Bind("users", u)
+	if err := w.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if args := db.Last().Args; args[0] != "changes_users" {
+		t.Errorf("Expected the named channel, got %v", args)
+	}
+
+	db.Reset()
+	if err := New(structable.New(db, "mysql")).Bind("users", u).Delete(); err != nil {
+		t.Fatal(err)
+	}
+	stest.AssertQueries(t, db, "DELETE FROM users WHERE id = ?")
+}
+
+func TestListener(t *testing.T) {
+	db := &stest.DB{}
+	l := &Listener{DB: db, Flavor: "postgres", Proto: &user{}}
+	reconnects := 0
+	l.OnReconnect = func() { reconnects++ }
+
+	notes := make(chan *pq.Notification, 3)
+	notes <- &pq.Notification{Channel: "users", Extra: `{"op":"update","table":"users","keys":{"id":7}}`}
+	notes <- nil
+	notes <- &pq.Notification{Channel: "users", Extra: `{"op":"delete","table":"users","keys":{"id":8}}`}
+	close(notes)
+
+	changes := []Change{}
+	err := l.Run(context.Background(), notes, func(c Change) error {
+		changes = append(changes, c)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || reconnects != 1 {
+		t.Fatalf("Expected 2 changes and a reconnect, got %v and %d", changes, reconnects)
+	}
+	if u, ok := changes[0].Record.(*user); !ok || u.Id != 7 {
+		t.Errorf("Expected the updated user to be loaded, got %#v", changes[0].Record)
+	}
+	stest.AssertQueries(t, db, "SELECT name FROM users WHERE id = $1")
+	if changes[1].Op != structable.OpDelete || changes[1].Record != nil || changes[1].Keys["id"] != 8.0 {
+		t.Errorf("Expected the delete's keys only, got %+v", changes[1])
+	}
+
+	if _, err := l.Decode("nope"); err == nil {
+		t.Error("Expected a bad payload to fail")
+	}
+}
@@ -0,0 +1,56 @@
+/*
+Package plantest guards tests against query plan regressions.
+
+AssertIndexed runs a Recorder operation against a seeded test database, and
+fails the test if any statement it ran reads a large table in full. This
+catches predicate changes that silently stop using an index:
+
+	func TestLoadByEmailUsesIndex(t *testing.T) {
+		db := seededDB(t) // with enough rows for the planner to prefer an index
+		u := NewUser(db, "postgres")
+		u.Email = "matt@example.com"
+		plantest.AssertIndexed(t, u.Recorder.(*structable.DbRecorder), 1000, func() error {
+			return u.LoadByUnique("email")
+		})
+	}
+
+Plans come from DbRecorder.Explain. Planners skip indexes on small tables,
+so tables with no more than threshold rows may be scanned in full.
+*/
+package plantest
+
+import (
+	"testing"
+
+	"github.com/Masterminds/structable"
+)
+
+// AssertIndexed fails t if a statement run by op scans a table with more than
+// threshold rows in full. It also fails t if op fails.
+func AssertIndexed(t testing.TB, r *structable.DbRecorder, threshold uint64, op func() error) {
+	t.Helper()
+	plans, err := r.Explain(op)
+	if err != nil {
+		t.Errorf("plantest: %s", err)
+		return
+	}
+	for _, p := range plans {
+		for _, table := range p.FullScans() {
+			n, err := count(r, table)
+			if err != nil {
+				t.Errorf("plantest: counting rows of %s: %s", table, err)
+				continue
+			}
+			if n > threshold {
+				t.Errorf("plantest: full scan of %s (%d rows) in:\n%s", table, n, p)
+			}
+		}
+	}
+}
+
+// count returns the number of rows in a table.
+func count(r *structable.DbRecorder, table string) (uint64, error) {
+	var n uint64
+	err := r.DB().QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n)
+	return n, err
+}
@@ -0,0 +1,72 @@
+//go:build sqlite
+// +build sqlite
+
+package plantest
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/Masterminds/structable"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type person struct {
+	Id    int64  `stbl:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Email string `stbl:"email"`
+	Name  string `stbl:"name"`
+}
+
+// fakeT records failures instead of failing the test.
+type fakeT struct {
+	testing.TB
+	errs []string
+}
+
+func (f *fakeT) Helper() {}
+func (f *fakeT) Errorf(format string, args ...interface{}) {
+	f.errs = append(f.errs, fmt.Sprintf(format, args...))
+}
+
+func TestAssertIndexed(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt := `
+	CREATE TABLE people (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT, name TEXT);
+	CREATE INDEX people_email ON people (email);
+	WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 100)
+	INSERT INTO people (email, name) SELECT 'p' || i || '@example.com', 'P' || i FROM n;
+	`
+	if _, err := db.Exec(stmt); err != nil {
+		t.Fatal(err)
+	}
+	r := structable.New(structable.NewRunner(db), "sqlite3")
+	r.Bind("people", &person{})
+
+	ft := &fakeT{}
+	AssertIndexed(ft, r, 10, func() error {
+		return r.LoadWhere("email = ?", "p7@example.com")
+	})
+	if len(ft.errs) != 0 {
+		t.Errorf("Expected the email lookup to use its index, got %v", ft.errs)
+	}
+
+	AssertIndexed(ft, r, 10, func() error {
+		return r.LoadWhere("name = ?", "P7")
+	})
+	if len(ft.errs) != 1 {
+		t.Fatalf("Expected the name lookup to be a full scan, got %v", ft.errs)
+	}
+	t.Log(ft.errs[0])
+
+	ft = &fakeT{}
+	AssertIndexed(ft, r, 1000, func() error {
+		return r.LoadWhere("name = ?", "P7")
+	})
+	if len(ft.errs) != 0 {
+		t.Errorf("Expected a full scan below the threshold to pass, got %v", ft.errs)
+	}
+}
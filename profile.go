@@ -0,0 +1,37 @@
+package structable
+
+import (
+	"context"
+	"runtime/pprof"
+	rtrace "runtime/trace"
+)
+
+// SetProfileLabels sets whether statements are annotated for profiling.
+//
+// When it is on, each statement runs with the pprof labels
+// structable.table and structable.op, so that CPU profiles attribute the
+// time to a table and operation:
+//
+//	go tool pprof -tagfocus=structable.table=users cpu.pprof
+//
+// Each statement also runs in a runtime/trace region named after its
+// operation, such as structable.load, which shows up in execution traces.
+// Labels are added to those of the context set with SetContext.
+func (s *DbRecorder) SetProfileLabels(on bool) *DbRecorder {
+	s.profileLabels = on
+	return s
+}
+
+// profile runs fn, with pprof labels and a trace region if SetProfileLabels
+// is on. The context passed to fn carries the labels.
+func (s *DbRecorder) profile(op string, fn func(context.Context)) {
+	if !s.profileLabels {
+		fn(s.Context())
+		return
+	}
+	labels := pprof.Labels("structable.table", s.table, "structable.op", op)
+	pprof.Do(s.Context(), labels, func(ctx context.Context) {
+		defer rtrace.StartRegion(ctx, "structable."+op).End()
+		fn(ctx)
+	})
+}
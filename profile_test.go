@@ -0,0 +1,35 @@
+package structable
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+)
+
+func TestProfileLabels(t *testing.T) {
+	r := New(&DBStub{}, "mysql")
+	r.Bind("test_table", newStool())
+
+	r.profile(OpLoad, func(ctx context.Context) {
+		if _, ok := pprof.Label(ctx, "structable.table"); ok {
+			t.Error("Expected no labels by default")
+		}
+	})
+
+	r.SetProfileLabels(true)
+	r.profile(OpLoad, func(ctx context.Context) {
+		if v, _ := pprof.Label(ctx, "structable.table"); v != "test_table" {
+			t.Errorf("Expected the table label, got %q", v)
+		}
+		if v, _ := pprof.Label(ctx, "structable.op"); v != OpLoad {
+			t.Errorf("Expected the op label, got %q", v)
+		}
+	})
+
+	if err := r.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Update(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,59 @@
+package structable
+
+import (
+	"github.com/Masterminds/squirrel"
+)
+
+// QueryInto runs a raw SQL query, and scans its first row into the bound
+// Record.
+//
+// Result columns are matched to the Record's fields by name, so the query may
+// return its columns in any order, and may leave some out or add others:
+//
+//	err := u.QueryInto(`SELECT u.* FROM users u
+//		JOIN sessions s ON s.user_id = u.id
+//		WHERE s.token = $1`, token)
+//
+// Columns that do not match a field are ignored, and fields without a column
+// are left untouched. The query is passed to the database verbatim, so it must
+// use the driver's placeholders. If there are no rows, sql.ErrNoRows is
+// returned.
+func (s *DbRecorder) QueryInto(query string, args ...interface{}) error {
+	rows, err := s.query(OpQuery, squirrel.Expr(query, args...))
+	if err != nil {
+		return err
+	}
+	return s.scanFirst(rows)
+}
+
+// QueryAllInto runs a raw SQL query, and scans each row into a new Record of
+// the bound type.
+//
+// Columns are matched to fields as in QueryInto. The returned Recorders are
+// bound to the new Records. If MaxRows is set, rows past the cap are handled
+// as they are by ListWhere.
+func (s *DbRecorder) QueryAllInto(query string, args ...interface{}) ([]Recorder, error) {
+	buf := []Recorder{}
+	rows, err := s.query(OpQuery, squirrel.Expr(query, args...))
+	if err != nil || rows == nil {
+		return buf, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return buf, err
+	}
+	cs := s.columnScanner(cols)
+	for rows.Next() {
+		if s.maxRows > 0 && uint64(len(buf)) == s.maxRows {
+			return buf, s.overflow()
+		}
+		r := s.Clone(nil)
+		if err := cs.scan(r, rows); err != nil {
+			return buf, err
+		}
+		buf = append(buf, r)
+	}
+	return buf, rows.Err()
+}
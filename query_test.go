@@ -0,0 +1,24 @@
+package structable
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestQueryInto(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "postgres")
+	r.Bind("accounts", &account{})
+
+	query := "SELECT a.* FROM accounts a JOIN profiles p ON p.account_id = a.id WHERE p.bio = $1"
+	if err := r.QueryInto(query, "gopher"); err != sql.ErrNoRows {
+		t.Errorf("Expected sql.ErrNoRows from the stub, got %v", err)
+	}
+	if db.LastQuerySql != query || len(db.LastQueryArgs) != 1 || db.LastQueryArgs[0] != "gopher" {
+		t.Errorf("Expected the query verbatim, got %q with %v", db.LastQuerySql, db.LastQueryArgs)
+	}
+
+	if recs, err := r.QueryAllInto(query, "gopher"); err != nil || len(recs) != 0 {
+		t.Errorf("Expected no records from the stub, got %d, %v", len(recs), err)
+	}
+}
@@ -0,0 +1,34 @@
+package structable
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrInvalidRecord is the BindError of a DbRecorder that was bound to
+// something other than a non-nil pointer to a struct.
+var ErrInvalidRecord = errors.New("a Record must be a non-nil pointer to a struct")
+
+// recordType returns the struct type that a Record points to, or
+// ErrInvalidRecord.
+func recordType(rec Record) (reflect.Type, error) {
+	v := reflect.ValueOf(rec)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w, got %T", ErrInvalidRecord, rec)
+	}
+	return v.Elem().Type(), nil
+}
+
+// checkExported returns an error if the Record has a tagged field that is
+// not exported. Such fields cannot be read or set, so they are not bound.
+func (s *DbRecorder) checkExported() error {
+	t := reflect.Indirect(reflect.ValueOf(s.record)).Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if _, ok := s.FieldTag(f); ok && f.PkgPath != "" {
+			return fmt.Errorf("field %s on table %s is tagged, but is not exported", f.Name, s.table)
+		}
+	}
+	return nil
+}
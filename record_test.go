@@ -0,0 +1,162 @@
+package structable
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type unexported struct {
+	Id   int    `stbl:"id,PRIMARY_KEY"`
+	name string `stbl:"name"`
+}
+
+type oddFields struct {
+	Id  interface{}    `stbl:"id,PRIMARY_KEY,SERIAL"`
+	Any interface{}    `stbl:"any"`
+	M   map[string]int `stbl:"m"`
+	PP  **int          `stbl:"pp"`
+	F   func()         `stbl:"f"`
+}
+
+func TestBindInvalid(t *testing.T) {
+	i := 1
+	var nilRec *oddFields
+	odd := &oddFields{}
+	for _, rec := range []Record{nil, &i, i, oddFields{}, &odd, nilRec, map[string]int{}} {
+		r := exercise(t, rec)
+		if err := r.BindError(); !errors.Is(err, ErrInvalidRecord) {
+			t.Errorf("%T: expected ErrInvalidRecord, got %v", rec, err)
+		}
+		if err := r.Load(); !errors.Is(err, ErrInvalidRecord) {
+			t.Errorf("%T: expected Load to fail with ErrInvalidRecord, got %v", rec, err)
+		}
+	}
+
+	r := exercise(t, &unexported{})
+	if err := r.BindError(); err == nil {
+		t.Error("Expected an error for an unexported field")
+	}
+	if cols := r.Columns(true); !reflect.DeepEqual(cols, []string{"id"}) {
+		t.Errorf("Expected the unexported field to be left out, got %v", cols)
+	}
+
+	exercise(t, odd)
+	if odd.Id != int64(1) {
+		t.Errorf("Expected an interface{} SERIAL to be set, got %#v", odd.Id)
+	}
+}
+
+// fieldTypes are the types that FuzzBind builds structs from.
+var fieldTypes = []reflect.Type{
+	reflect.TypeOf(0),
+	reflect.TypeOf(new(int)),
+	reflect.TypeOf(new(*int)),
+	reflect.TypeOf(uint8(0)),
+	reflect.TypeOf(""),
+	reflect.TypeOf(new(string)),
+	reflect.TypeOf([]byte{}),
+	reflect.TypeOf(1.5),
+	reflect.TypeOf(false),
+	reflect.TypeOf(time.Time{}),
+	reflect.TypeOf(sql.NullString{}),
+	reflect.TypeOf((*interface{})(nil)).Elem(),
+	reflect.TypeOf(map[string]int{}),
+	reflect.TypeOf([]int{}),
+	reflect.TypeOf(struct{ A int }{}),
+	reflect.TypeOf(new(struct{ A int })),
+	reflect.TypeOf(Blob{}),
+	reflect.TypeOf(func() {}),
+	reflect.TypeOf(make(chan int)),
+}
+
+// fieldOptions are the tag options that FuzzBind adds to fields.
+var fieldOptions = []string{
+	"PRIMARY_KEY", "SERIAL", "UNIQUE", "NUMERIC", "NOT_NULL", "DEFAULT(0)",
+	"SIZE(2)", "TYPE=INTEGER", "COMPRESSED", "EXTERNAL", "TENANT",
+	"TOLERATE_MISSING", "RESTRICTED=admin", "READONLY", "OMIT_UPDATE", "NULLABLE",
+}
+
+// FuzzBind binds structs of arbitrary shapes, and checks that no operation
+// panics. Each byte of the input describes one field: its type, and whether
+// it has each tag option.
+func FuzzBind(f *testing.F) {
+	f.Add([]byte{0x00, 0x04, 0x05})
+	f.Add([]byte{0x20, 0x31, 0x42, 0x53, 0x64, 0x75, 0x86, 0x97, 0xa8, 0xb9, 0xca, 0xdb, 0xec, 0xfd})
+	f.Add([]byte{0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10, 0x11, 0x12})
+	f.Add([]byte{0xff, 0xfe, 0x33, 0x21})
+	f.Fuzz(func(t *testing.T, shape []byte) {
+		if len(shape) > 32 {
+			shape = shape[:32]
+		}
+		fields := make([]reflect.StructField, len(shape))
+		for i, b := range shape {
+			tag := fmt.Sprintf("f%d", i)
+			for j, opt := range fieldOptions {
+				if (int(b)>>uint(j%8))&1 == 1 && (int(b)+j)%3 == 0 {
+					tag += "," + opt
+				}
+			}
+			fields[i] = reflect.StructField{
+				Name: fmt.Sprintf("F%d", i),
+				Type: fieldTypes[int(b)%len(fieldTypes)],
+				Tag:  reflect.StructTag(fmt.Sprintf("stbl:%q", tag)),
+			}
+		}
+		exercise(t, reflect.New(reflect.StructOf(fields)).Interface())
+	})
+}
+
+// exercise binds rec, and runs every operation on it, failing the test if one
+// of them panics.
+func exercise(t *testing.T, rec Record) *DbRecorder {
+	t.Helper()
+	var r *DbRecorder
+	for _, flavor := range []string{"mysql", "postgres"} {
+		r = New(&DBStub{}, flavor)
+		ops := []func(){
+			func() { r.Bind("things", rec) },
+			func() { r.Insert() },
+			func() { r.Update() },
+			func() { r.Load() },
+			func() { r.LoadWhere("1 = 1") },
+			func() { r.Exists() },
+			func() { r.Delete() },
+			func() { r.FieldReferences(true) },
+			func() { r.WhereIds() },
+			func() { r.Columns(true) },
+			func() { r.scan(fillRow{int64(1)}, true) },
+			func() { r.Clone(nil).scan(fillRow{int64(1)}, true) },
+			func() { List(r) },
+			func() { ListWhere(r, nil) },
+		}
+		for i, op := range ops {
+			func() {
+				defer func() {
+					if p := recover(); p != nil {
+						t.Fatalf("%s: operation %d on %T panicked: %v", flavor, i, rec, p)
+					}
+				}()
+				op()
+			}()
+		}
+	}
+	return r
+}
+
+// fillRow scans the same value into every destination.
+type fillRow struct {
+	v interface{}
+}
+
+func (r fillRow) Scan(dest ...interface{}) error {
+	for _, d := range dest {
+		if err := convertAssign(d, r.v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
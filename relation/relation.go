@@ -0,0 +1,122 @@
+/*
+Package relation provides explicit loaders for related Records.
+
+Structable deliberately does not manage relations between tables. This
+package does not change that: nothing is loaded automatically, and nothing is
+cached. It only writes the obvious queries, so that the same few lines of
+WhereFunc do not need to be repeated for every relation.
+
+	// SELECT ... FROM comments WHERE post_id = ?
+	recs, err := relation.HasMany(post, NewComment(db), "post_id")
+	comments := []*Comment{}
+	err = relation.Collect(recs, &comments)
+
+	// SELECT ... FROM posts WHERE id = ?, with the comment's post_id.
+	p := NewPost(db)
+	err = relation.BelongsTo(comment, p, "post_id")
+
+Related tables must have a single-column primary key.
+*/
+package relation
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/Masterminds/structable"
+)
+
+// HasMany lists the Records in child's table whose fkColumn holds parent's
+// primary key.
+//
+// The child Recorder is only used to describe the table and Record type, as
+// with structable.List. Options are applied after the foreign key condition.
+func HasMany(parent, child structable.Recorder, fkColumn string, opts ...structable.WhereFunc) ([]structable.Recorder, error) {
+	key, err := primaryKey(parent)
+	if err != nil {
+		return []structable.Recorder{}, err
+	}
+	where := structable.WithWhereEq(fkColumn, parent.WhereIds()[key])
+	return structable.List(child, append([]structable.WhereFunc{where}, opts...)...)
+}
+
+// BelongsTo loads into parent the Record that child's fkColumn refers to.
+//
+// If the foreign key field is a nil pointer, sql.ErrNoRows is returned without
+// querying the database.
+func BelongsTo(child, parent structable.Recorder, fkColumn string) error {
+	key, err := primaryKey(parent)
+	if err != nil {
+		return err
+	}
+	fk, err := columnValue(child, fkColumn)
+	if err != nil {
+		return err
+	}
+	if fk == nil {
+		return sql.ErrNoRows
+	}
+	return parent.LoadWhere(squirrel.Eq{key: fk})
+}
+
+// Collect appends the Record of each Recorder to the slice that dest points
+// to, so that related Records can be used with their own types:
+//
+//	comments := []*Comment{}
+//	err := relation.Collect(recs, &comments)
+//
+// The slice's element type must match the Records' type.
+func Collect(recs []structable.Recorder, dest interface{}) error {
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Ptr || dv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("expected a pointer to a slice, got %T", dest)
+	}
+	sv := dv.Elem()
+	et := sv.Type().Elem()
+	for _, r := range recs {
+		v := reflect.ValueOf(r.Interface())
+		if !v.Type().AssignableTo(et) {
+			return fmt.Errorf("cannot collect %s into %s", v.Type(), sv.Type())
+		}
+		sv = reflect.Append(sv, v)
+	}
+	dv.Elem().Set(sv)
+	return nil
+}
+
+// primaryKey returns the name of a Recorder's only primary key column.
+func primaryKey(r structable.Recorder) (string, error) {
+	ids := r.WhereIds()
+	if len(ids) != 1 {
+		return "", fmt.Errorf("table %s must have exactly one primary key", r.TableName())
+	}
+	for k := range ids {
+		return k, nil
+	}
+	return "", nil
+}
+
+// columnValue returns the value of the field mapped to a column, or nil if
+// the field is a nil pointer.
+func columnValue(r structable.Recorder, column string) (interface{}, error) {
+	v := reflect.Indirect(reflect.ValueOf(r.Interface()))
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag, ok := structable.LookupTag(t.Field(i))
+		if !ok || strings.TrimSpace(structable.SplitTag(tag)[0]) != column {
+			continue
+		}
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				return nil, nil
+			}
+			fv = fv.Elem()
+		}
+		return fv.Interface(), nil
+	}
+	return nil, fmt.Errorf("unknown column %q on table %s", column, r.TableName())
+}
@@ -0,0 +1,98 @@
+package relation
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/Masterminds/structable"
+)
+
+type post struct {
+	Id    int    `stbl:"id,PRIMARY_KEY,SERIAL"`
+	Title string `stbl:"title"`
+}
+
+type comment struct {
+	Id     int    `stbl:"id,PRIMARY_KEY,SERIAL"`
+	PostId *int   `stbl:"post_id"`
+	Body   string `stbl:"body"`
+}
+
+// dbStub records the last query.
+type dbStub struct {
+	query string
+	args  []interface{}
+}
+
+func (s *dbStub) Exec(string, ...interface{}) (sql.Result, error) { return nil, nil }
+func (s *dbStub) Query(q string, args ...interface{}) (*sql.Rows, error) {
+	s.query, s.args = q, args
+	return nil, nil
+}
+func (s *dbStub) QueryRow(q string, args ...interface{}) squirrel.RowScanner {
+	s.query, s.args = q, args
+	return rowStub{}
+}
+
+type rowStub struct{}
+
+func (rowStub) Scan(...interface{}) error { return nil }
+
+func TestHasMany(t *testing.T) {
+	db := &dbStub{}
+	p := structable.New(db, "postgres").Bind("posts", &post{Id: 7})
+	c := structable.New(db, "postgres").Bind("comments", &comment{})
+
+	if _, err := HasMany(p, c, "post_id", structable.WithOrderBy("id")); err != nil {
+		t.Fatal(err)
+	}
+	expect := "SELECT id, post_id, body FROM comments WHERE post_id = $1 ORDER BY id"
+	if db.query != expect || db.args[0] != 7 {
+		t.Errorf("Expected %q with 7, got %q with %v", expect, db.query, db.args)
+	}
+	if _, err := HasMany(p, c, "nope"); err == nil {
+		t.Error("Expected unknown column to fail")
+	}
+}
+
+func TestBelongsTo(t *testing.T) {
+	db := &dbStub{}
+	id := 7
+	c := structable.New(db, "postgres").Bind("comments", &comment{PostId: &id})
+	p := structable.New(db, "postgres").Bind("posts", &post{})
+
+	// The stub returns no rows.
+	if err := BelongsTo(c, p, "post_id"); err != sql.ErrNoRows {
+		t.Errorf("Expected sql.ErrNoRows, got %v", err)
+	}
+	expect := "SELECT id, title FROM posts WHERE id = $1 LIMIT 1"
+	if db.query != expect || db.args[0] != 7 {
+		t.Errorf("Expected %q with 7, got %q with %v", expect, db.query, db.args)
+	}
+
+	db.query = ""
+	c.Interface().(*comment).PostId = nil
+	if err := BelongsTo(c, p, "post_id"); err != sql.ErrNoRows || db.query != "" {
+		t.Errorf("Expected sql.ErrNoRows without a query, got %v", err)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	recs := []structable.Recorder{
+		structable.New(nil, "postgres").Bind("posts", &post{Id: 1}),
+		structable.New(nil, "postgres").Bind("posts", &post{Id: 2}),
+	}
+	posts := []*post{}
+	if err := Collect(recs, &posts); err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 2 || posts[1].Id != 2 {
+		t.Errorf("Unexpected posts %v", posts)
+	}
+
+	comments := []*comment{}
+	if err := Collect(recs, &comments); err == nil {
+		t.Error("Expected mismatched types to fail")
+	}
+}
@@ -0,0 +1,47 @@
+package structable
+
+import "github.com/Masterminds/squirrel"
+
+// Reload loads the bound Record again from the database, by its primary key.
+//
+// Every column other than the primary key is overwritten, so changes made by
+// triggers, defaults, or other writers are reflected in the Record. The key
+// fields are left as they are. If the row no longer exists, sql.ErrNoRows is
+// returned.
+func (s *DbRecorder) Reload() error {
+	return s.Load()
+}
+
+// SetRefreshAfterWrite sets whether Insert and Update reload the Record after
+// writing it.
+//
+// Columns that the database fills in, with a DEFAULT, a trigger, or a
+// generated expression, are otherwise stale in the Record until it is loaded
+// again:
+//
+//	r.SetRefreshAfterWrite(true)
+//	err := r.Update() // r.Record().UpdatedAt is now set by the trigger.
+//
+// On postgres, the written row is read back with RETURNING in the same
+// statement. Insert always does this on postgres, so the setting only changes
+// Update there. On other flavors, the Record is reloaded with a second
+// statement, after the write, as by Reload.
+//
+// With this on, Update returns sql.ErrNoRows if no row has the Record's
+// primary key.
+func (s *DbRecorder) SetRefreshAfterWrite(on bool) *DbRecorder {
+	s.refreshAfterWrite = on
+	return s
+}
+
+// updateRefresh runs an update, and then scans the updated row back into the
+// Record.
+func (s *DbRecorder) updateRefresh(q squirrel.UpdateBuilder) error {
+	if s.flavor == "postgres" {
+		return s.scan(s.queryRow(OpUpdate, q), false)
+	}
+	if _, err := s.exec(OpUpdate, q); err != nil {
+		return err
+	}
+	return s.Reload()
+}
@@ -0,0 +1,62 @@
+package structable
+
+import "testing"
+
+func TestReload(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "mysql").Bind("test_table", newStool())
+
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	expect := "SELECT number_of_legs, material, color FROM test_table WHERE id = ? AND id_two = ?"
+	if db.LastQueryRowSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQueryRowSql)
+	}
+}
+
+func TestRefreshAfterWrite(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "mysql").SetRefreshAfterWrite(true)
+	r.Bind("test_table", newStool())
+
+	if err := r.Update(); err != nil {
+		t.Fatal(err)
+	}
+	expect := "UPDATE test_table SET material = ?, number_of_legs = ? WHERE id = ? AND id_two = ?"
+	if db.LastExecSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastExecSql)
+	}
+	expect = "SELECT number_of_legs, material, color FROM test_table WHERE id = ? AND id_two = ?"
+	if db.LastQueryRowSql != expect {
+		t.Errorf("Expected a reload, got %q", db.LastQueryRowSql)
+	}
+
+	db.LastQueryRowSql = ""
+	if err := r.Insert(); err != nil {
+		t.Fatal(err)
+	}
+	if db.LastQueryRowSql != expect {
+		t.Errorf("Expected a reload after insert, got %q", db.LastQueryRowSql)
+	}
+
+	db = &DBStub{}
+	r = New(db, "postgres").SetRefreshAfterWrite(true)
+	r.Bind("test_table", newStool())
+	if err := r.Update(); err != nil {
+		t.Fatal(err)
+	}
+	expect = "UPDATE test_table SET material = $1, number_of_legs = $2 WHERE id = $3 AND id_two = $4 RETURNING number_of_legs,material,color"
+	if db.LastQueryRowSql != expect || db.LastExecSql != "" {
+		t.Errorf("Expected %q, got %q", expect, db.LastQueryRowSql)
+	}
+
+	db = &DBStub{}
+	r = New(db, "mysql").Bind("test_table", newStool()).(*DbRecorder)
+	if err := r.Update(); err != nil {
+		t.Fatal(err)
+	}
+	if db.LastQueryRowSql != "" {
+		t.Errorf("Expected no reload by default, got %q", db.LastQueryRowSql)
+	}
+}
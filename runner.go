@@ -0,0 +1,160 @@
+package structable
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// Runner is the database handle that a DbRecorder runs statements on.
+//
+// Runner is deliberately smaller than squirrel.DBProxyBeginner: it does not
+// require Prepare or Begin. Any squirrel.DBProxyBeginner (like the statement
+// cache returned by squirrel.NewStmtCacheProxy) is a Runner. A plain *sql.DB
+// or *sql.Tx can be made into a Runner with NewRunner.
+type Runner interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) squirrel.RowScanner
+}
+
+// StdRunner describes the query methods of *sql.DB, *sql.Tx, and *sql.Conn.
+type StdRunner interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// NewRunner adapts a *sql.DB, *sql.Tx, or similar to the Runner interface.
+//
+// Statements are sent to the database directly, without being prepared and
+// cached first. This is useful with connection poolers that do not support
+// prepared statements, such as pgbouncer in transaction mode.
+//
+//	db, _ := sql.Open("postgres", dsn)
+//	r := structable.New(structable.NewRunner(db), "postgres")
+func NewRunner(db StdRunner) Runner {
+	return &stdRunner{db}
+}
+
+type stdRunner struct {
+	db StdRunner
+}
+
+func (r *stdRunner) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return r.db.Exec(query, args...)
+}
+
+func (r *stdRunner) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return r.db.Query(query, args...)
+}
+
+func (r *stdRunner) QueryRow(query string, args ...interface{}) squirrel.RowScanner {
+	return r.db.QueryRow(query, args...)
+}
+
+// The context methods of a stdRunner are those of *sql.DB, *sql.Tx, and
+// *sql.Conn, if the handle has them.
+
+func (r *stdRunner) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if db, ok := r.db.(interface {
+		ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	}); ok {
+		return db.ExecContext(ctx, query, args...)
+	}
+	return r.db.Exec(query, args...)
+}
+
+func (r *stdRunner) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if db, ok := r.db.(interface {
+		QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+	}); ok {
+		return db.QueryContext(ctx, query, args...)
+	}
+	return r.db.Query(query, args...)
+}
+
+func (r *stdRunner) QueryRowContext(ctx context.Context, query string, args ...interface{}) squirrel.RowScanner {
+	if db, ok := r.db.(interface {
+		QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+	}); ok {
+		return db.QueryRowContext(ctx, query, args...)
+	}
+	return r.db.QueryRow(query, args...)
+}
+
+// execContext runs a statement with ctx if db has an ExecContext method, and
+// without it otherwise. So do queryContext and queryRowContext.
+func execContext(ctx context.Context, db Runner, query string, args ...interface{}) (sql.Result, error) {
+	if c, ok := db.(squirrel.ExecerContext); ok {
+		return c.ExecContext(ctx, query, args...)
+	}
+	return db.Exec(query, args...)
+}
+
+func queryContext(ctx context.Context, db Runner, query string, args ...interface{}) (*sql.Rows, error) {
+	if c, ok := db.(squirrel.QueryerContext); ok {
+		return c.QueryContext(ctx, query, args...)
+	}
+	return db.Query(query, args...)
+}
+
+func queryRowContext(ctx context.Context, db Runner, query string, args ...interface{}) squirrel.RowScanner {
+	if c, ok := db.(squirrel.QueryRowerContext); ok {
+		return c.QueryRowContext(ctx, query, args...)
+	}
+	return db.QueryRow(query, args...)
+}
+
+// AsRunner adapts any squirrel.BaseRunner to the Runner interface.
+//
+// A Runner, such as a squirrel.DBProxyBeginner, is returned as it is. A
+// StdRunner, such as a *sql.DB or *sql.Tx, is wrapped with NewRunner. Any
+// other BaseRunner, which can only Exec and Query, gets a QueryRow that runs
+// Query and reads the first row. New and Init call AsRunner, so they accept
+// all of these.
+func AsRunner(db squirrel.BaseRunner) Runner {
+	switch db := db.(type) {
+	case nil:
+		return nil
+	case Runner:
+		return db
+	case StdRunner:
+		return NewRunner(db)
+	}
+	return &baseRunner{db}
+}
+
+type baseRunner struct {
+	squirrel.BaseRunner
+}
+
+func (r *baseRunner) QueryRow(query string, args ...interface{}) squirrel.RowScanner {
+	rows, err := r.Query(query, args...)
+	return &firstRow{rows: rows, err: err}
+}
+
+// firstRow scans the first of a set of rows, like a *sql.Row. The rows are
+// closed by Scan.
+type firstRow struct {
+	rows *sql.Rows
+	err  error
+}
+
+func (r *firstRow) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	defer r.rows.Close()
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	if err := r.rows.Scan(dest...); err != nil {
+		return err
+	}
+	return r.rows.Close()
+}
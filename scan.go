@@ -0,0 +1,413 @@
+package structable
+
+import (
+	"database/sql"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// ScanError indicates that a database value could not be stored in a field.
+//
+// ScanErrors are only produced by recorders in lenient mode, for fields that
+// are scanned exactly, and for COMPRESSED fields. See DbRecorder.SetLenient
+// and DbRecorder.SetExactNumeric.
+type ScanError struct {
+	// Column is the name of the database column.
+	Column string
+	// Field is the name of the struct field.
+	Field string
+	// Err is the underlying conversion error.
+	Err error
+}
+
+func (e *ScanError) Error() string {
+	return fmt.Sprintf("could not scan column %s into field %s: %s", e.Column, e.Field, e.Err)
+}
+
+// Unwrap returns the underlying conversion error.
+func (e *ScanError) Unwrap() error {
+	return e.Err
+}
+
+// SetLenient toggles lenient scanning.
+//
+// By default, values are scanned directly into the Record's fields, and any
+// conversion error is returned exactly as the database driver reports it. For
+// wide structs, those errors can be hard to attribute to a particular field.
+//
+// In lenient mode, each row is scanned into intermediate values which are then
+// converted field by field. Conversion failures are returned as a *ScanError
+// naming both the column and the field. Lenient mode also tolerates a few
+// mismatches that the driver would reject: NULL is stored as the field's zero
+// value, and numbers stored as text are parsed.
+func (s *DbRecorder) SetLenient(lenient bool) *DbRecorder {
+	s.lenient = lenient
+	return s
+}
+
+// fieldList returns the fields that correspond to FieldReferences(withKeys).
+func (s *DbRecorder) fieldList(withKeys bool) []*field {
+	fields := make([]*field, 0, len(s.fields))
+	for _, f := range s.fields {
+		if !withKeys && f.isKey {
+			continue
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// SetExactNumeric toggles exact scanning of numeric fields.
+//
+// Drivers usually return NUMERIC and DECIMAL values as text, and scanning text
+// into a float64 silently rounds it. With exact scanning, a value is only
+// stored in a float or integer field if the field can hold it exactly;
+// otherwise a *ScanError is returned. String fields receive the text as-is,
+// and sql.Scanner fields (such as decimal types) receive the driver value.
+//
+// Exact scanning can also be enabled for individual fields with the NUMERIC
+// tag option:
+//
+//	Price string `stbl:"price,NUMERIC"`
+//
+// SetExactNumeric enables it for every float and integer field.
+func (s *DbRecorder) SetExactNumeric(exact bool) *DbRecorder {
+	s.exactNumeric = exact
+	return s
+}
+
+// isNumeric reports whether a field is scanned with convertNumeric.
+func (s *DbRecorder) isNumeric(f *field, ref interface{}) bool {
+	if f.isNumeric {
+		return true
+	}
+	if !s.exactNumeric {
+		return false
+	}
+	switch reflect.TypeOf(ref).Elem().Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// scan scans a single row into the fields of the bound Record that
+// correspond to FieldReferences(withKeys).
+func (s *DbRecorder) scan(row squirrel.RowScanner, withKeys bool) error {
+	return s.scanInto(row, s.fieldList(withKeys))
+}
+
+// scanInto scans a single row into the given fields of the bound Record.
+func (s *DbRecorder) scanInto(row squirrel.RowScanner, fields []*field) error {
+	if _, ok := row.(dryRow); ok {
+		return nil
+	}
+	dest, finish := s.scanDest(fields)
+	if err := row.Scan(dest...); err != nil {
+		return err
+	}
+	return finish()
+}
+
+// scanFirst scans the first of rows into the bound Record by column name, and
+// closes rows. If there are no rows, sql.ErrNoRows is returned.
+func (s *DbRecorder) scanFirst(rows *sql.Rows) error {
+	if rows == nil {
+		return sql.ErrNoRows
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	return s.scanColumns(rows, cols)
+}
+
+// scanColumns scans a row whose result columns are named cols. See
+// columnScanner.
+func (s *DbRecorder) scanColumns(row squirrel.RowScanner, cols []string) error {
+	return s.columnScanner(cols).scan(s, row)
+}
+
+// columnScanner scans rows with a fixed set of result columns into Records
+// of one type. The columns are matched to fields once, and the scan
+// destinations are reused from row to row.
+//
+// Each column is stored in the field mapped to the same name, and columns
+// without a field are discarded. If a name appears more than once, the first
+// column wins.
+type columnScanner struct {
+	fields []*field
+	// pos is the column index of each field.
+	pos  []int
+	dest []interface{}
+	// plain is true if no field needs to be converted by hand, so that rows
+	// can be scanned straight into the Record.
+	plain   bool
+	discard interface{}
+}
+
+// columnScanner prepares a columnScanner for s's Record type.
+func (s *DbRecorder) columnScanner(cols []string) *columnScanner {
+	c := &columnScanner{
+		fields: make([]*field, 0, len(cols)),
+		pos:    make([]int, 0, len(cols)),
+		dest:   make([]interface{}, len(cols)),
+		plain:  !s.lenient && !s.exactNumeric,
+	}
+	seen := make(map[*field]bool, len(cols))
+	for i, col := range cols {
+		for _, f := range s.fields {
+			if f.column == col && !seen[f] {
+				c.fields = append(c.fields, f)
+				c.pos = append(c.pos, i)
+				seen[f] = true
+				if f.isNumeric || f.isCompressed || f.isExternal || f.nullable {
+					c.plain = false
+				}
+				break
+			}
+		}
+	}
+	return c
+}
+
+// scan scans one row into the Record bound to s, which must be of the type
+// that the columnScanner was prepared for.
+func (c *columnScanner) scan(s *DbRecorder, row squirrel.RowScanner) error {
+	for i := range c.dest {
+		c.dest[i] = &c.discard
+	}
+	if c.plain {
+		for j, f := range c.fields {
+			c.dest[c.pos[j]] = s.fieldRef(f)
+		}
+		return row.Scan(c.dest...)
+	}
+
+	d, finish := s.scanDest(c.fields)
+	for j, i := range c.pos {
+		c.dest[i] = d[j]
+	}
+	if err := row.Scan(c.dest...); err != nil {
+		return err
+	}
+	return finish()
+}
+
+// scanDest returns the scan destinations for the given fields of the bound
+// Record, and a function that must be called once the row has been scanned
+// into them. Fields that are converted by hand are scanned into intermediate
+// values, which finish stores in the Record.
+func (s *DbRecorder) scanDest(fields []*field) ([]interface{}, func() error) {
+	refs := make([]interface{}, len(fields))
+	for i, f := range fields {
+		refs[i] = s.fieldRef(f)
+	}
+
+	dest := make([]interface{}, len(refs))
+	numeric := make([]bool, len(refs))
+	converted := false
+	for i, f := range fields {
+		numeric[i] = s.isNumeric(f, refs[i])
+		if s.lenient || numeric[i] || f.isCompressed || f.isExternal || f.nullable {
+			dest[i] = new(interface{})
+			converted = true
+		} else {
+			dest[i] = refs[i]
+		}
+	}
+	if !converted {
+		return refs, func() error { return nil }
+	}
+
+	return dest, func() error {
+		ar := reflect.Indirect(reflect.ValueOf(s.record))
+		for i, f := range fields {
+			if !s.lenient && !numeric[i] && !f.isCompressed && !f.isExternal && !f.nullable {
+				continue
+			}
+			v := *(dest[i].(*interface{}))
+			if f.isCompressed {
+				var err error
+				if v, err = decompressValue(v); err != nil {
+					return &ScanError{Column: f.column, Field: f.name, Err: err}
+				}
+			}
+			if fv := ar.FieldByName(f.name); v == nil && (fv.Kind() == reflect.Ptr || f.nullable) {
+				fv.Set(reflect.Zero(fv.Type()))
+				continue
+			}
+			var err error
+			switch {
+			case f.isExternal:
+				err = s.loadExternal(f, refs[i], v)
+			case numeric[i]:
+				err = convertNumeric(refs[i], v)
+			default:
+				err = convertAssign(refs[i], v)
+			}
+			if err != nil {
+				return &ScanError{Column: f.column, Field: f.name, Err: err}
+			}
+		}
+		return nil
+	}
+}
+
+// convertNumeric stores a NUMERIC driver value in dest, which is a pointer,
+// returning an error rather than losing precision.
+func convertNumeric(dest, src interface{}) error {
+	if sc, ok := dest.(sql.Scanner); ok {
+		return sc.Scan(src)
+	}
+
+	dv := reflect.Indirect(reflect.ValueOf(dest))
+	var str string
+	switch v := src.(type) {
+	case nil:
+		return fmt.Errorf("cannot store NULL in %s", dv.Type())
+	case []byte:
+		str = string(v)
+	case string:
+		str = v
+	case int64:
+		str = strconv.FormatInt(v, 10)
+	case float64:
+		str = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Errorf("unsupported conversion from %T to %s", src, dv.Type())
+	}
+
+	if dv.Kind() == reflect.String {
+		dv.SetString(str)
+		return nil
+	}
+
+	want, ok := new(big.Rat).SetString(str)
+	if !ok {
+		return fmt.Errorf("%q is not a number", str)
+	}
+	switch dv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if !want.IsInt() || !want.Num().IsInt64() || dv.OverflowInt(want.Num().Int64()) {
+			return fmt.Errorf("%s cannot be stored exactly in %s", str, dv.Type())
+		}
+		dv.SetInt(want.Num().Int64())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if !want.IsInt() || !want.Num().IsUint64() || dv.OverflowUint(want.Num().Uint64()) {
+			return fmt.Errorf("%s cannot be stored exactly in %s", str, dv.Type())
+		}
+		dv.SetUint(want.Num().Uint64())
+	case reflect.Float32, reflect.Float64:
+		// A float holds the value if its shortest representation is the same
+		// number.
+		bits := dv.Type().Bits()
+		f, err := strconv.ParseFloat(str, bits)
+		if err != nil {
+			return fmt.Errorf("%s cannot be stored exactly in %s", str, dv.Type())
+		}
+		got, _ := new(big.Rat).SetString(strconv.FormatFloat(f, 'f', -1, bits))
+		if got == nil || got.Cmp(want) != 0 {
+			return fmt.Errorf("%s cannot be stored exactly in %s", str, dv.Type())
+		}
+		dv.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported conversion from %T to %s", src, dv.Type())
+	}
+	return nil
+}
+
+// convertAssign stores a driver value in dest, which is a pointer.
+//
+// This covers the types that database drivers return (see driver.Value), plus
+// sql.Scanner implementations.
+func convertAssign(dest, src interface{}) error {
+	if sc, ok := dest.(sql.Scanner); ok {
+		return sc.Scan(src)
+	}
+
+	dv := reflect.Indirect(reflect.ValueOf(dest))
+	if src == nil {
+		dv.Set(reflect.Zero(dv.Type()))
+		return nil
+	}
+
+	sv := reflect.ValueOf(src)
+	if sv.Type().AssignableTo(dv.Type()) {
+		switch b := src.(type) {
+		case []byte:
+			// Drivers may reuse the underlying buffer.
+			dv.Set(reflect.ValueOf(append([]byte(nil), b...)))
+		default:
+			dv.Set(sv)
+		}
+		return nil
+	}
+
+	var str string
+	switch v := src.(type) {
+	case string:
+		str = v
+	case []byte:
+		str = string(v)
+	case time.Time:
+		if dv.Kind() != reflect.String {
+			return fmt.Errorf("unsupported conversion from %T to %s", src, dv.Type())
+		}
+		str = v.Format(time.RFC3339Nano)
+	default:
+		str = fmt.Sprint(v)
+	}
+
+	switch dv.Kind() {
+	case reflect.String:
+		dv.SetString(str)
+	case reflect.Slice:
+		if dv.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("unsupported conversion from %T to %s", src, dv.Type())
+		}
+		dv.SetBytes([]byte(str))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(str)
+		if err != nil {
+			return err
+		}
+		dv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(str, 10, dv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(str, 10, dv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(str, dv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dv.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported conversion from %T to %s", src, dv.Type())
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package structable
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// valueRow is a RowScanner that returns fixed driver values.
+type valueRow struct {
+	vals []interface{}
+}
+
+func (r *valueRow) Scan(dest ...interface{}) error {
+	if len(dest) != len(r.vals) {
+		return errors.New("wrong number of destinations")
+	}
+	for i, d := range dest {
+		*(d.(*interface{})) = r.vals[i]
+	}
+	return nil
+}
+
+func TestLenientScan(t *testing.T) {
+	stool := newStool()
+	rec := New(&DBStub{}, "mysql").SetLenient(true)
+	rec.Bind("test_table", stool)
+
+	row := &valueRow{vals: []interface{}{int64(5), int64(6), []byte("4"), "Wood", nil}}
+	if err := rec.scan(row, true); err != nil {
+		t.Fatalf("Failed lenient scan: %s", err)
+	}
+	if stool.Id != 5 || stool.Id2 != 6 || stool.Legs != 4 || stool.Material != "Wood" {
+		t.Errorf("Unexpected values: %+v", stool)
+	}
+	if stool.Color != nil {
+		t.Errorf("Expected NULL to leave a nil pointer, got %q", *stool.Color)
+	}
+
+	row = &valueRow{vals: []interface{}{int64(5), int64(6), "four", "Wood", "Blue"}}
+	err := rec.scan(row, true)
+	serr, ok := err.(*ScanError)
+	if !ok {
+		t.Fatalf("Expected a *ScanError, got %v", err)
+	}
+	if serr.Column != "number_of_legs" || serr.Field != "Legs" {
+		t.Errorf("Wrong attribution: %s", serr)
+	}
+	if _, ok := serr.Unwrap().(*strconv.NumError); !ok {
+		t.Errorf("Expected underlying strconv error, got %T", serr.Unwrap())
+	}
+}
+
+func TestConvertAssign(t *testing.T) {
+	var (
+		i  int32
+		u  uint8
+		f  float64
+		b  bool
+		s  string
+		bs []byte
+		tm time.Time
+	)
+	now := time.Now()
+
+	tests := []struct {
+		dest, src interface{}
+	}{
+		{&i, int64(12)},
+		{&i, []byte("12")},
+		{&u, "200"},
+		{&f, "1.5"},
+		{&b, int64(1)},
+		{&s, int64(7)},
+		{&bs, "bytes"},
+		{&tm, now},
+	}
+	for _, tt := range tests {
+		if err := convertAssign(tt.dest, tt.src); err != nil {
+			t.Errorf("Failed to convert %T to %T: %s", tt.src, tt.dest, err)
+		}
+	}
+	if i != 12 || u != 200 || f != 1.5 || !b || s != "7" || string(bs) != "bytes" || !tm.Equal(now) {
+		t.Errorf("Unexpected conversions: %d %d %f %t %q %q %s", i, u, f, b, s, bs, tm)
+	}
+
+	if err := convertAssign(&u, int64(300)); err == nil {
+		t.Error("Expected overflow to fail")
+	}
+	if err := convertAssign(&tm, "yesterday"); err == nil {
+		t.Error("Expected string to time.Time to fail")
+	}
+}
+
+type price struct {
+	Id     int     `stbl:"id,PRIMARY_KEY"`
+	Amount string  `stbl:"amount,NUMERIC"`
+	Rate   float64 `stbl:"rate"`
+	Qty    int8    `stbl:"qty"`
+}
+
+func TestExactNumericScan(t *testing.T) {
+	p := &price{}
+	rec := New(&DBStub{}, "postgres").SetExactNumeric(true)
+	rec.Bind("prices", p)
+
+	row := &valueRow{vals: []interface{}{int64(1), []byte("12345678901234567890.12"), []byte("0.10"), []byte("7")}}
+	if err := rec.scan(row, true); err != nil {
+		t.Fatalf("Failed exact scan: %s", err)
+	}
+	if p.Amount != "12345678901234567890.12" || p.Rate != 0.1 || p.Qty != 7 {
+		t.Errorf("Unexpected values: %+v", p)
+	}
+
+	tests := []struct {
+		rate, qty interface{}
+		column    string
+	}{
+		{[]byte("12345678901234567890.12"), []byte("7"), "rate"},
+		{[]byte("0.5"), []byte("7.5"), "qty"},
+		{[]byte("0.5"), []byte("300"), "qty"},
+		{[]byte("0.5"), nil, "qty"},
+	}
+	for _, tt := range tests {
+		row = &valueRow{vals: []interface{}{int64(1), "1", tt.rate, tt.qty}}
+		err := rec.scan(row, true)
+		serr, ok := err.(*ScanError)
+		if !ok || serr.Column != tt.column {
+			t.Errorf("Expected a *ScanError for %s, got %v", tt.column, err)
+		}
+	}
+
+	// Without SetExactNumeric, only the NUMERIC field is checked.
+	rec.SetExactNumeric(false)
+	row = &valueRow{vals: []interface{}{int64(1), "1", []byte("12345678901234567890.12"), []byte("7")}}
+	if err := rec.scan(&driverRow{row}, true); err != nil {
+		t.Fatalf("Failed scan: %s", err)
+	}
+	if p.Rate != 12345678901234567890.12 {
+		t.Errorf("Expected rounded rate, got %v", p.Rate)
+	}
+}
+
+// driverRow converts values the way database/sql would, for destinations
+// that are not *interface{}.
+type driverRow struct {
+	*valueRow
+}
+
+func (r *driverRow) Scan(dest ...interface{}) error {
+	for i, d := range dest {
+		if p, ok := d.(*interface{}); ok {
+			*p = r.vals[i]
+		} else if err := convertAssign(d, r.vals[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/Masterminds/structable"
+)
+
+// inspector reads table definitions from a particular kind of database.
+type inspector interface {
+	// tables lists the tables in the schema.
+	tables(*sql.DB) ([]string, error)
+	// columns describes the columns of one table, in table order.
+	columns(*sql.DB, string) ([]*column, error)
+}
+
+func newInspector(driver, schema string) (inspector, error) {
+	switch driver {
+	case "postgres":
+		return &pgInspector{schema: schema}, nil
+	case "mysql":
+		return &mysqlInspector{}, nil
+	case "sqlite3":
+		return &sqliteInspector{}, nil
+	}
+	return nil, fmt.Errorf("unsupported driver %q", driver)
+}
+
+// pgInspector lists the tables of a schema on Postgres.
+type pgInspector struct {
+	schema string
+}
+
+func (p *pgInspector) tables(db *sql.DB) ([]string, error) {
+	return stringList(db, `SELECT table_name FROM information_schema.tables
+		WHERE table_schema = $1 AND table_type = 'BASE TABLE' ORDER BY table_name`, p.schema)
+}
+
+func (p *pgInspector) columns(db *sql.DB, tbl string) ([]*column, error) {
+	return describe(db, "postgres", p.schema+"."+tbl)
+}
+
+// mysqlInspector lists the tables of the current database on MySQL.
+type mysqlInspector struct{}
+
+func (m *mysqlInspector) tables(db *sql.DB) ([]string, error) {
+	return stringList(db, `SELECT table_name FROM information_schema.tables
+		WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE' ORDER BY table_name`)
+}
+
+func (m *mysqlInspector) columns(db *sql.DB, tbl string) ([]*column, error) {
+	return describe(db, "mysql", tbl)
+}
+
+// sqliteInspector uses sqlite_master.
+type sqliteInspector struct{}
+
+func (s *sqliteInspector) tables(db *sql.DB) ([]string, error) {
+	return stringList(db, `SELECT name FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`)
+}
+
+func (s *sqliteInspector) columns(db *sql.DB, tbl string) ([]*column, error) {
+	return describe(db, "sqlite3", tbl)
+}
+
+// describe reads the columns of a table with structable.DescribeTable.
+func describe(db *sql.DB, driver, tbl string) ([]*column, error) {
+	ts, err := structable.DescribeTable(structable.NewRunner(db), driver, tbl)
+	if err != nil {
+		return nil, err
+	}
+	cols := make([]*column, len(ts.Columns))
+	for i, c := range ts.Columns {
+		cols[i] = &column{Name: c.Name, DataType: c.Type, Nullable: c.Nullable, Key: c.Key, Auto: c.Auto}
+	}
+	return cols, nil
+}
+
+// stringList runs a query that returns a single string column.
+func stringList(db *sql.DB, q string, args ...interface{}) ([]string, error) {
+	rows, err := db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []string{}
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	return res, rows.Err()
+}
@@ -1,47 +1,75 @@
+// Command schema2struct reads a database schema and generates Structable structs.
+//
+// Install it with:
+//
+//	go install github.com/Masterminds/structable/schema2struct@latest
+//
+// and run it from a go:generate directive:
+//
+//	//go:generate schema2struct -d postgres -c "dbname=app sslmode=disable" -f schemata.go
 package main
 
 import (
+	"bytes"
 	"database/sql"
+	"flag"
 	"fmt"
+	"go/format"
 	"io"
 	"os"
+	"sort"
 	"strings"
 	"text/template"
-
-	"github.com/Masterminds/squirrel"
-	"github.com/codegangsta/cli"
+	"unicode"
+	"unicode/utf8"
 
 	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
 )
 
-const version = "DEV"
+var version = "DEV"
 
-// Usage : exported const Usage
+// Usage describes the command.
 const Usage = `Read a schema and generate Structable structs.
 
-This utility generates Structable structs be reading your database table and
-generating the appropriate code.
-`
+This utility generates Structable structs by reading your database tables and
+generating the appropriate code. MySQL, PostgreSQL, and SQLite are supported.
 
-const fileHeader = `package %s
+Usage:
 
-// This file is automatically generated by schema2struct.
+	schema2struct [flags]
 
-import (
-	"time"
+Flags:
+`
 
-	"github.com/Masterminds/squirrel"
-	"github.com/Masterminds/structable"
-	_ "github.com/go-sql-driver/mysql"
-	_ "github.com/lib/pq"
-)
+const fileHeader = `// Code generated by schema2struct. DO NOT EDIT.
 
+package {{.Package}}
+{{if .Imports}}
+import (
+{{range .Imports}}	"{{.}}"
+{{end}})
+{{end}}
+{{if .Constructors}}
 // QueryFunc modifies a SelectBuilder prior to execution.
 //
 // The SelectBuilder is modified in place. An error is returned under any
 // conditions where the query should not be executed.
 type QueryFunc func(q squirrel.SelectBuilder) (squirrel.SelectBuilder, error)
+{{end}}
+`
+
+const plainTemplate = `// {{.StructName}} maps to database table {{.TableName}}
+type {{.StructName}} struct {
+	{{range .Fields}}{{.}}
+	{{end}}
+}
+
+// TableName returns the name of the table that {{.StructName}} maps to.
+func (o *{{.StructName}}) TableName() string {
+	return "{{.TableName}}"
+}
 
 `
 
@@ -83,7 +111,7 @@ func List{{.StructName}}(db squirrel.DBProxyBeginner, flavor string, limit, offs
 // The QueryFunc should not modify the list of fields returned or the table name,
 // as the intent is to construct a complete {{.StructName}} from each result.
 // More sophisticated queries should be written directly.
-func Query{{.StructName}}(db squirrel.DBProxyBeginner, flavor string, fn QueryFunc) ([]*{{.StructName}}, error){
+func Query{{.StructName}}(db squirrel.DBProxyBeginner, flavor string, fn QueryFunc) ([]*{{.StructName}}, error) {
 	var tn string = "{{.TableName}}"
 
 	// We need a prototype structable to learn about the table structure.
@@ -115,7 +143,7 @@ func Query{{.StructName}}(db squirrel.DBProxyBeginner, flavor string, fn QueryFu
 
 // Len{{.StructName}} returns the number of {{.StructName}} objects in the database.
 func Len{{.StructName}}(db squirrel.DBProxyBeginner, flavor string) (int, error) {
-	fn := func(q squirrel.SelectBuilder) (squirrel.SelectBuilder, error) {return q, nil}
+	fn := func(q squirrel.SelectBuilder) (squirrel.SelectBuilder, error) { return q, nil }
 	return QueryLen{{.StructName}}(db, flavor, fn)
 }
 
@@ -144,74 +172,54 @@ type structDesc struct {
 	Fields     []string
 }
 
+type options struct {
+	driver, connection, tables, file, pkg, schema string
+	constructors, showVersion                     bool
+}
+
 func main() {
-	app := cli.NewApp()
-	app.Name = "schema2struct"
-	app.Version = "version"
-	app.Usage = Usage
-	app.Action = importTables
-	app.Flags = []cli.Flag{
-		cli.StringFlag{
-			Name:  "driver,d",
-			Value: "postgres",
-			Usage: "The name of the SQL driver to use.",
-		},
-		cli.StringFlag{
-			Name:  "connection,c",
-			Value: "user=$USER dbname=$USER sslmode=disable",
-			Usage: "The database connection string. Environment variables are expanded.",
-		},
-		cli.StringFlag{
-			Name:  "tables,t",
-			Value: "",
-			Usage: "The list of tables to generate, comma separated. If none specified, the entire schema is used.",
-		},
-		cli.StringFlag{
-			Name:  "file,f",
-			Value: "",
-			Usage: "The file to send the output.",
-		},
-		cli.StringFlag{
-			Name:   "package,p",
-			Value:  "main",
-			Usage:  "The name of the destination package.",
-			EnvVar: "GOPACKAGE",
-		},
+	o := options{}
+	flag.StringVar(&o.driver, "d", "postgres", "The name of the SQL driver to use: postgres, mysql, or sqlite3.")
+	flag.StringVar(&o.connection, "c", "user=$USER dbname=$USER sslmode=disable", "The database connection string. Environment variables are expanded.")
+	flag.StringVar(&o.tables, "t", "", "The list of tables to generate, comma separated. If none specified, the entire schema is used.")
+	flag.StringVar(&o.file, "f", "", "The file to send the output. Defaults to STDOUT.")
+	flag.StringVar(&o.pkg, "p", envDefault("GOPACKAGE", "main"), "The name of the destination package.")
+	flag.StringVar(&o.schema, "s", "public", "The schema to read (Postgres only).")
+	flag.BoolVar(&o.constructors, "constructors", true, "Generate constructor, List, and Query functions for each struct.")
+	flag.BoolVar(&o.showVersion, "version", false, "Print the version and exit.")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, Usage)
+		flag.PrintDefaults()
 	}
+	flag.Parse()
 
-	app.Run(os.Args)
-}
-
-func driver(c *cli.Context) string {
-	return c.String("driver")
-}
-func conn(c *cli.Context) string {
-	return os.ExpandEnv(c.String("connection"))
-}
+	if o.showVersion {
+		fmt.Println(version)
+		return
+	}
 
-// dest gets the destination output writer.
-func dest(c *cli.Context) io.Writer {
-	if out := c.String("file"); out != "" {
-		f, err := os.Create(out)
-		if err != nil {
-			panic(f)
-		}
-		return f
+	if err := importTables(o); err != nil {
+		fmt.Fprintf(os.Stderr, "schema2struct: %s\n", err)
+		os.Exit(1)
 	}
-	return os.Stdout
 }
 
-func tableList(c *cli.Context) []string {
-	z := c.String("tables")
-	if z != "" {
-		return strings.Split(z, ",")
+func envDefault(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
 	}
-	return []string{}
+	return def
 }
 
-func cxdie(c *cli.Context, err error) {
-	fmt.Fprintf(os.Stderr, "Failed to connect to %s (type %s): %s", conn(c), driver(c), err)
-	os.Exit(1)
+// tableList splits the comma-separated -t flag.
+func tableList(z string) []string {
+	res := []string{}
+	for _, t := range strings.Split(z, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			res = append(res, t)
+		}
+	}
+	return res
 }
 
 var funcMap = map[string]interface{}{
@@ -220,213 +228,130 @@ var funcMap = map[string]interface{}{
 	},
 }
 
-func importTables(c *cli.Context) {
-	ttt := template.Must(template.New("st").Funcs(funcMap).Parse(structTemplate))
-	cxn, err := sql.Open(driver(c), conn(c))
+func importTables(o options) error {
+	in, err := newInspector(o.driver, o.schema)
 	if err != nil {
-		cxdie(c, err)
+		return err
+	}
+
+	conn := os.ExpandEnv(o.connection)
+	cxn, err := sql.Open(o.driver, conn)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s (type %s): %s", conn, o.driver, err)
 	}
 	// Many drivers defer connections until the first statement. We test
 	// that here.
 	if err := cxn.Ping(); err != nil {
-		cxdie(c, err)
+		return fmt.Errorf("failed to connect to %s (type %s): %s", conn, o.driver, err)
 	}
 	defer cxn.Close()
 
-	// Set up Squirrel
-	stmts := squirrel.NewStmtCacher(cxn)
-	bldr := squirrel.StatementBuilder.RunWith(stmts)
-	if driver(c) == "postgres" {
-		bldr = bldr.PlaceholderFormat(squirrel.Dollar)
-	}
-
-	// Set up destination
-	out := dest(c)
-	fmt.Fprintf(out, fileHeader, c.String("package"))
-
-	tables := tableList(c)
-
+	tables := tableList(o.tables)
 	if len(tables) == 0 {
-		tables, err = publicTables(bldr)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Cannot fetch list of tables: %s\n", err)
-			os.Exit(2)
+		if tables, err = in.tables(cxn); err != nil {
+			return fmt.Errorf("cannot fetch list of tables: %s", err)
 		}
 	}
 
+	descs := make([]*structDesc, 0, len(tables))
+	imports := map[string]bool{}
+	if o.constructors {
+		imports["github.com/Masterminds/squirrel"] = true
+		imports["github.com/Masterminds/structable"] = true
+	}
 	for _, t := range tables {
-		f, err := importTable(t, bldr, driver(c))
+		cols, err := in.columns(cxn, t)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to import table %s: %s", t, err)
+			return fmt.Errorf("failed to import table %s: %s", t, err)
 		}
-
-		//fmt.Fprintf(out, "%s %s %s\n", f.StructName, f.TableName, f.Fields)
-		ttt.Execute(out, f)
+		sd := &structDesc{
+			StructName: goName(t),
+			TableName:  t,
+			Fields:     make([]string, len(cols)),
+		}
+		for i, c := range cols {
+			gt := goType(c)
+			if strings.Contains(gt, "time.") {
+				imports["time"] = true
+			}
+			sd.Fields[i] = structField(c, t, gt)
+		}
+		descs = append(descs, sd)
 	}
-}
-
-type column struct {
-	Name, DataType string
-	Max            int64
-}
 
-func publicTables(b squirrel.StatementBuilderType) ([]string, error) {
-	rows, err := b.Select("table_name").From("INFORMATION_SCHEMA.TABLES").
-		Where("table_schema = 'public'").Query()
-
-	res := []string{}
+	src, err := render(o.pkg, o.constructors, imports, descs)
 	if err != nil {
-		return res, err
+		return err
 	}
 
-	for rows.Next() {
-		var s string
-		rows.Scan(&s)
-		res = append(res, s)
+	out := io.Writer(os.Stdout)
+	if o.file != "" {
+		f, err := os.Create(o.file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
 	}
-
-	return res, nil
+	_, err = out.Write(src)
+	return err
 }
 
-// importTable reads a table definition and writes a corresponding struct.
-// SELECT table_name, column_name, data_type, character_maximum_length
-//   FROM INFORMATION_SCHEMA.COLUMNS WHERE table_name = 'goose_db_version'
-func importTable(tbl string, b squirrel.StatementBuilderType, driver string) (*structDesc, error) {
-
-	pks, err := primaryKeyField(tbl, b)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error getting primary keys: %s", err)
+// render executes the templates and formats the resulting source.
+func render(pkg string, constructors bool, imports map[string]bool, descs []*structDesc) ([]byte, error) {
+	body := plainTemplate
+	if constructors {
+		body = structTemplate
 	}
+	hdr := template.Must(template.New("hdr").Parse(fileHeader))
+	ttt := template.Must(template.New("st").Funcs(funcMap).Parse(body))
 
-	q := b.Select("column_name, data_type, character_maximum_length").
-		From("INFORMATION_SCHEMA.COLUMNS").
-		Where("table_name = ?", tbl)
-
-	rows, err := q.Query()
+	imps := make([]string, 0, len(imports))
+	for i := range imports {
+		imps = append(imps, i)
+	}
+	sort.Strings(imps)
+
+	var buf bytes.Buffer
+	err := hdr.Execute(&buf, map[string]interface{}{
+		"Package":      pkg,
+		"Imports":      imps,
+		"Constructors": constructors,
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	ff := []string{}
-	for rows.Next() {
-		c := &column{}
-		var length sql.NullInt64
-		if err := rows.Scan(&c.Name, &c.DataType, &length); err != nil {
+	for _, d := range descs {
+		if err := ttt.Execute(&buf, d); err != nil {
 			return nil, err
 		}
-		c.Max = length.Int64
-		switch driver {
-		case "mysql":
-			ff = append(ff, structFieldMySQL(c, pks, tbl, b))
-		case "postgres":
-			ff = append(ff, structField(c, pks, tbl, b))
-		}
-	}
-	sd := &structDesc{
-		StructName: goName(tbl),
-		TableName:  tbl,
-		Fields:     ff,
-	}
-
-	return sd, nil
-}
-
-func primaryKeyField(tbl string, b squirrel.StatementBuilderType) ([]string, error) {
-	q := b.Select("column_name").
-		From("INFORMATION_SCHEMA.KEY_COLUMN_USAGE AS c").
-		LeftJoin("INFORMATION_SCHEMA.TABLE_CONSTRAINTS AS t USING(constraint_name)").
-		Where("t.table_name = ? AND t.constraint_type = 'PRIMARY KEY'", tbl).
-		OrderBy("ordinal_position")
-
-	rows, err := q.Query()
-	if err != nil {
-		return []string{}, err
-	}
-
-	res := []string{}
-	for rows.Next() {
-		var s string
-		rows.Scan(&s)
-		res = append(res, s)
-	}
-	return res, nil
-}
-
-func autoincrementKey(tbl, pk string, b squirrel.StatementBuilderType) bool {
-	q := b.Select("COUNT(*)").
-		From("INFORMATION_SCHEMA.COLUMNS").
-		Where("TABLE_NAME = ? AND COLUMN_NAME = ? AND EXTRA = 'auto_increment'", tbl, pk)
-	var num int
-	if err := q.Scan(&num); err != nil {
-		panic(err)
 	}
-	return num > 0
+	return format.Source(buf.Bytes())
 }
 
-func sequentialKey(tbl, pk string, b squirrel.StatementBuilderType) bool {
-	tlen := 58
-
-	stbl := tbl
-	if len(tbl) > 29 {
-		stbl = tbl[0:29]
-	}
-
-	left := tlen - len(stbl)
-	spk := pk
-	if len(pk) > left {
-		spk = pk[0:left]
-	}
-	seq := fmt.Sprintf("%s_%s_seq", stbl, spk)
-
-	q := b.Select("COUNT(*)").
-		From("INFORMATION_SCHEMA.SEQUENCES").
-		Where("sequence_name = ?", seq)
-
-	var num int
-	if err := q.Scan(&num); err != nil {
-		panic(err)
-	}
-	return num > 0
+// column describes a column, regardless of the database it came from.
+type column struct {
+	Name, DataType string
+	Nullable       bool
+	Key, Auto      bool
 }
 
-func structFieldMySQL(c *column, pks []string, tbl string, b squirrel.StatementBuilderType) string {
+func structField(c *column, tbl, gt string) string {
 	tpl := "%s %s `stbl:\"%s\"`"
 	gn := destutter(goName(c.Name), goName(tbl))
-	tt := goType(c.DataType)
 
 	tag := c.Name
-	for _, p := range pks {
-		if c.Name == p {
-			tag += ",PRIMARY_KEY"
-			if autoincrementKey(tbl, c.Name, b) {
-				tag += ",AUTO_INCREMENT"
-			}
+	if c.Key {
+		tag += ",PRIMARY_KEY"
+		if c.Auto {
+			tag += ",AUTO_INCREMENT"
 		}
 	}
 
-	return fmt.Sprintf(tpl, gn, tt, tag)
+	return fmt.Sprintf(tpl, gn, gt, tag)
 }
 
-func structField(c *column, pks []string, tbl string, b squirrel.StatementBuilderType) string {
-	tpl := "%s %s `stbl:\"%s\"`"
-	gn := destutter(goName(c.Name), goName(tbl))
-	tt := goType(c.DataType)
-
-	tag := c.Name
-	for _, p := range pks {
-		if c.Name == p {
-			tag += ",PRIMARY_KEY"
-			if sequentialKey(tbl, c.Name, b) {
-				tag += ",SERIAL"
-			}
-		}
-	}
-
-	return fmt.Sprintf(tpl, gn, tt, tag)
-}
-
-// goType takes a SQL type and returns a string containin the name of a Go type.
+// goType takes a SQL type and returns a string containing the name of a Go type.
 //
 // The goal is not to provide an exact match for every type, but to provide a
 // safe Go representation of a SQL type.
@@ -434,48 +359,76 @@ func structField(c *column, pks []string, tbl string, b squirrel.StatementBuilde
 // For some floating point SQL types, for example, we store them as strings
 // so as not to lose precision while also not adding new types.
 //
-// The default type is string.
-func goType(sqlType string) string {
-	switch sqlType {
-	case "smallint", "smallserial":
+// Nullable columns become pointers. The default type is string.
+func goType(c *column) string {
+	t := baseType(c.DataType)
+	if c.Nullable && !c.Key && t != "[]byte" {
+		return "*" + t
+	}
+	return t
+}
+
+func baseType(sqlType string) string {
+	st := strings.ToLower(sqlType)
+	unsigned := strings.HasSuffix(st, " unsigned")
+	// Strip sizes and modifiers: varchar(255), int(11) unsigned
+	if i := strings.IndexAny(st, "( "); i > 0 && !strings.HasPrefix(st, "double precision") &&
+		!strings.HasPrefix(st, "character varying") && !strings.HasPrefix(st, "timestamp") {
+		st = st[:i]
+	}
+
+	if unsigned {
+		switch st {
+		case "tinyint", "smallint":
+			return "uint16"
+		case "integer", "int", "mediumint":
+			return "uint32"
+		case "bigint":
+			return "uint64"
+		}
+	}
+
+	switch {
+	case st == "tinyint", st == "smallint", st == "smallserial", st == "int2":
 		return "int16"
-	case "integer", "serial":
+	case st == "integer", st == "int", st == "mediumint", st == "serial", st == "int4":
 		return "int32"
-	case "bigint", "bigserial":
-		return "int"
-	case "real":
+	case st == "bigint", st == "bigserial", st == "int8":
+		return "int64"
+	case st == "real", st == "float", st == "float4":
 		return "float32"
-	case "double precision":
+	case st == "double precision", st == "double", st == "float8":
 		return "float64"
 	// Because we need to preserve base-10 precision.
-	case "money":
-		return "string"
-	case "text", "varchar", "char", "character", "character varying", "uuid":
+	case st == "money", st == "numeric", st == "decimal":
 		return "string"
-	case "bytea":
+	case st == "bytea", st == "blob", st == "binary", st == "varbinary",
+		st == "tinyblob", st == "mediumblob", st == "longblob":
 		return "[]byte"
-	case "boolean":
+	case st == "boolean", st == "bool", st == "bit":
 		return "bool"
-	case "timezone", "timezonetz", "date", "time":
+	case st == "date", st == "datetime", strings.HasPrefix(st, "timestamp"):
 		return "time.Time"
-	case "interval":
-		return "time.Duration"
 	}
 	return "string"
 }
 
 // Convert a SQL name to a Go name.
 func goName(sqlName string) string {
-	// This can definitely be done better.
-	goName := strings.Replace(sqlName, "_", " ", -1)
-	goName = strings.Replace(goName, ".", " ", -1)
-	goName = strings.Title(goName)
-	goName = strings.Replace(goName, " ", "", -1)
-
-	return goName
+	words := strings.FieldsFunc(sqlName, func(r rune) bool {
+		return r == '_' || r == '.' || r == ' '
+	})
+	for i, w := range words {
+		r, n := utf8.DecodeRuneInString(w)
+		words[i] = string(unicode.ToUpper(r)) + w[n:]
+	}
+	return strings.Join(words, "")
 }
 
 // destutter removes a stutter prefix.
 func destutter(str, prefix string) string {
+	if str == prefix {
+		return str
+	}
 	return strings.TrimPrefix(str, prefix)
 }
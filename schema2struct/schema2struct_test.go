@@ -0,0 +1,67 @@
+package main
+
+import "testing"
+
+func TestBaseType(t *testing.T) {
+	tests := []struct {
+		sqlType, expect string
+	}{
+		{"smallint", "int16"},
+		{"tinyint(1)", "int16"},
+		{"int(11)", "int32"},
+		{"INTEGER", "int32"},
+		{"bigserial", "int64"},
+		{"int(10) unsigned", "uint32"},
+		{"tinyint unsigned", "uint16"},
+		{"bigint(20) unsigned", "uint64"},
+		{"real", "float32"},
+		{"double precision", "float64"},
+		{"numeric(10,2)", "string"},
+		{"varchar(255)", "string"},
+		{"character varying", "string"},
+		{"bytea", "[]byte"},
+		{"longblob", "[]byte"},
+		{"boolean", "bool"},
+		{"timestamp with time zone", "time.Time"},
+		{"datetime", "time.Time"},
+		{"uuid", "string"},
+	}
+	for _, tt := range tests {
+		if got := baseType(tt.sqlType); got != tt.expect {
+			t.Errorf("%s: expected %s, got %s", tt.sqlType, tt.expect, got)
+		}
+	}
+}
+
+func TestGoType(t *testing.T) {
+	tests := []struct {
+		col    column
+		expect string
+	}{
+		{column{DataType: "integer"}, "int32"},
+		{column{DataType: "integer", Nullable: true}, "*int32"},
+		{column{DataType: "integer", Nullable: true, Key: true}, "int32"},
+		{column{DataType: "bytea", Nullable: true}, "[]byte"},
+		{column{DataType: "text", Nullable: true}, "*string"},
+	}
+	for _, tt := range tests {
+		if got := goType(&tt.col); got != tt.expect {
+			t.Errorf("%+v: expected %s, got %s", tt.col, tt.expect, got)
+		}
+	}
+}
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"user_id":       "UserId",
+		"public.users":  "PublicUsers",
+		"name":          "Name",
+		"dt_release_2":  "DtRelease2",
+		"already_Upper": "AlreadyUpper",
+	}
+	for in, expect := range tests {
+		if got := goName(in); got != expect {
+			t.Errorf("%s: expected %s, got %s", in, expect, got)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+/*
+Package seed fills a database with the rows that an application or its tests
+need, such as lookup tables, an admin account, or demo data.
+
+A Seeder holds groups of Records, which are written in the order the groups
+were added, so that a group can refer to rows of the groups before it:
+
+	s := seed.New(structable.NewRunner(db), "postgres")
+	s.Match("users", "email")
+	s.Group("roles").Add("roles", &Role{Id: 1, Name: "admin"}, &Role{Id: 2, Name: "member"})
+	s.Group("admin").Add("users", &User{Email: "admin@example.com", RoleId: 1})
+	s.Group("demo", "dev", "test").AddFunc("posts", demoPosts)
+	res, err := s.Run(os.Getenv("APP_ENV"))
+
+Seeding is idempotent, so it can run on every start or before every test. Each
+Record is upserted: if a row with the same key exists, it is updated to the
+Record's values, and otherwise the Record is inserted. The key is the primary
+key, or the columns given to Match for tables whose primary key is generated.
+
+Tags select the groups for an environment: Run runs the groups without tags,
+and the groups that have any of the tags it is given.
+*/
+package seed
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/Masterminds/structable"
+)
+
+// Seeder writes groups of Records.
+type Seeder struct {
+	db     structable.Runner
+	flavor string
+	groups []*Group
+	match  map[string][]string
+}
+
+// New creates a Seeder that writes to db.
+func New(db structable.Runner, flavor string) *Seeder {
+	return &Seeder{db: db, flavor: flavor, match: map[string][]string{}}
+}
+
+// Match sets the columns that identify the rows of a table, instead of its
+// primary key. Use it for tables with an AUTO_INCREMENT key, whose Records
+// cannot give their key in advance.
+func (s *Seeder) Match(table string, cols ...string) *Seeder {
+	s.match[table] = cols
+	return s
+}
+
+// Group returns the group with a name, adding it with the given tags if there
+// is none. Groups run in the order they were added.
+func (s *Seeder) Group(name string, tags ...string) *Group {
+	for _, g := range s.groups {
+		if g.name == name {
+			return g
+		}
+	}
+	g := &Group{name: name, tags: tags}
+	s.groups = append(s.groups, g)
+	return g
+}
+
+// Group is an ordered list of Records to seed.
+type Group struct {
+	name  string
+	tags  []string
+	items []item
+}
+
+// item is a Record, or a factory of Records, for a table.
+type item struct {
+	table   string
+	rec     structable.Record
+	factory func(tx structable.Runner) ([]structable.Record, error)
+}
+
+// Add adds Records to seed into a table.
+func (g *Group) Add(table string, recs ...structable.Record) *Group {
+	for _, rec := range recs {
+		g.items = append(g.items, item{table: table, rec: rec})
+	}
+	return g
+}
+
+// AddFunc adds a factory of Records to seed into a table. The factory is
+// called when the group runs, with the Runner that the group writes on, so it
+// can look up the rows written by earlier groups.
+func (g *Group) AddFunc(table string, fn func(tx structable.Runner) ([]structable.Record, error)) *Group {
+	g.items = append(g.items, item{table: table, factory: fn})
+	return g
+}
+
+// runs reports whether the group runs for the given tags.
+func (g *Group) runs(tags []string) bool {
+	if len(g.tags) == 0 {
+		return true
+	}
+	for _, t := range g.tags {
+		for _, want := range tags {
+			if t == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Result counts the rows written by Run.
+type Result struct {
+	Inserted int
+	Updated  int
+}
+
+// Run seeds the groups that have no tags, or any of the given tags.
+//
+// Each group is written in a transaction, as by structable.Transact, and is
+// rolled back if any of its Records fails. The groups before it are kept. If
+// the db cannot begin a transaction, such as when it is already a *sql.Tx,
+// the groups are written on it directly.
+func (s *Seeder) Run(tags ...string) (Result, error) {
+	var res Result
+	for _, g := range s.groups {
+		if !g.runs(tags) {
+			continue
+		}
+		var gres Result
+		err := structable.Transact(s.db, s.flavor, func(tx structable.Runner) error {
+			gres = Result{}
+			return s.runGroup(tx, g, &gres)
+		})
+		if err == structable.ErrNoTransactions {
+			gres = Result{}
+			err = s.runGroup(s.db, g, &gres)
+		}
+		if err != nil {
+			return res, fmt.Errorf("seeding group %s: %w", g.name, err)
+		}
+		res.Inserted += gres.Inserted
+		res.Updated += gres.Updated
+	}
+	return res, nil
+}
+
+// runGroup upserts the Records of a group, and then resets the sequences of
+// the tables whose keys it inserted.
+func (s *Seeder) runGroup(tx structable.Runner, g *Group, res *Result) error {
+	keyed := map[string]*structable.DbRecorder{}
+	var tables []string
+	for _, it := range g.items {
+		recs := []structable.Record{it.rec}
+		if it.factory != nil {
+			var err error
+			if recs, err = it.factory(tx); err != nil {
+				return fmt.Errorf("building %s records: %w", it.table, err)
+			}
+		}
+		for _, rec := range recs {
+			r := structable.New(tx, s.flavor)
+			r.Bind(it.table, rec)
+			inserted, err := s.upsert(it.table, r)
+			if err != nil {
+				return fmt.Errorf("seeding %s %v: %w", it.table, r.WhereIds(), err)
+			}
+			if inserted {
+				res.Inserted++
+			} else {
+				res.Updated++
+			}
+			if inserted && len(s.match[it.table]) == 0 && keyed[it.table] == nil {
+				keyed[it.table] = r
+				tables = append(tables, it.table)
+			}
+		}
+	}
+	for _, t := range tables {
+		if err := keyed[t].ResetSequences(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upsert updates the row with the Record's key, or inserts the Record if
+// there is none, and reports whether it was inserted.
+func (s *Seeder) upsert(table string, r *structable.DbRecorder) (bool, error) {
+	if cols := s.match[table]; len(cols) > 0 {
+		vals := r.Values()
+		where := make(map[string]interface{}, len(cols))
+		for _, c := range cols {
+			if _, ok := vals[c]; !ok {
+				return false, fmt.Errorf("unknown match column %q", c)
+			}
+			where[c] = vals[c]
+		}
+		found := r.Clone(nil)
+		err := found.LoadWhereMap(where)
+		if errors.Is(err, sql.ErrNoRows) {
+			return true, r.Insert()
+		}
+		if err != nil {
+			return false, err
+		}
+		if err := r.SetValues(found.WhereIds()); err != nil {
+			return false, err
+		}
+		return false, r.Update()
+	}
+
+	ids := r.WhereIds()
+	if len(ids) == 0 {
+		return false, fmt.Errorf("table %s has no primary key; use Match", table)
+	}
+	zero := true
+	for _, v := range ids {
+		if v != nil && !reflect.ValueOf(v).IsZero() {
+			zero = false
+		}
+	}
+	if zero {
+		return false, fmt.Errorf("%w; set it, or use Match", structable.ErrMissingKey)
+	}
+	ok, err := r.Exists()
+	if err != nil {
+		return false, err
+	}
+	if ok {
+		return false, r.Update()
+	}
+	return true, r.InsertKeyed()
+}
@@ -0,0 +1,59 @@
+//go:build sqlite
+// +build sqlite
+
+package seed
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/Masterminds/structable"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestSeed(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	_, err = db.Exec(`
+		CREATE TABLE roles (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT);
+		CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT UNIQUE, role_id INTEGER);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newSeeder := func(adminRole int) *Seeder {
+		s := New(structable.NewRunner(db), "sqlite3").Match("users", "email")
+		s.Group("roles").Add("roles", &role{Id: 1, Name: "admin"}, &role{Id: 2, Name: "member"})
+		s.Group("users").Add("users", &user{Email: "admin@example.com", RoleId: adminRole})
+		s.Group("demo", "dev").AddFunc("users", func(tx structable.Runner) ([]structable.Record, error) {
+			r := &role{}
+			if err := structable.New(tx, "sqlite3").Bind("roles", r).LoadWhere("name = ?", "member"); err != nil {
+				return nil, err
+			}
+			return []structable.Record{&user{Email: "demo@example.com", RoleId: r.Id}}, nil
+		})
+		return s
+	}
+
+	res, err := newSeeder(1).Run("dev")
+	if err != nil || res.Inserted != 4 || res.Updated != 0 {
+		t.Fatalf("Expected 4 inserts, got %+v, %v", res, err)
+	}
+	res, err = newSeeder(2).Run("dev")
+	if err != nil || res.Inserted != 0 || res.Updated != 4 {
+		t.Fatalf("Expected 4 updates, got %+v, %v", res, err)
+	}
+
+	var users string
+	if err := db.QueryRow("SELECT group_concat(id || ' ' || email || ' ' || role_id, ',') FROM users ORDER BY id").Scan(&users); err != nil {
+		t.Fatal(err)
+	}
+	if users != "1 admin@example.com 2,2 demo@example.com 2" {
+		t.Errorf("Expected the users to be seeded once, got %q", users)
+	}
+}
@@ -0,0 +1,67 @@
+package seed
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Masterminds/structable"
+	"github.com/Masterminds/structable/stest"
+)
+
+type role struct {
+	Id   int    `stbl:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Name string `stbl:"name"`
+}
+
+type user struct {
+	Id     int    `stbl:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Email  string `stbl:"email"`
+	RoleId int    `stbl:"role_id"`
+}
+
+func TestRunTags(t *testing.T) {
+	db := &stest.DB{}
+	// A Runner without Begin, so that the groups are written directly.
+	s := New(struct{ structable.Runner }{db}, "mysql")
+	s.Group("roles").Add("roles", &role{Id: 1, Name: "admin"})
+	s.Group("demo", "dev").Add("roles", &role{Id: 2, Name: "demo"})
+
+	res, err := s.Run("prod")
+	if err != nil || res.Inserted != 1 || res.Updated != 0 {
+		t.Fatalf("Expected one insert, got %+v, %v", res, err)
+	}
+	stest.AssertQueries(t, db,
+		"SELECT EXISTS(SELECT 1 FROM roles WHERE id = ?)",
+		"INSERT INTO roles (id,name) VALUES (?,?)",
+	)
+
+	db.Reset()
+	if res, err := s.Run("dev"); err != nil || res.Inserted != 2 {
+		t.Errorf("Expected the dev group to run, got %+v, %v", res, err)
+	}
+	if s.Group("demo") != s.Group("demo") || len(s.groups) != 2 {
+		t.Error("Expected Group to return the existing group")
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	db := &stest.DB{}
+	s := New(struct{ structable.Runner }{db}, "mysql")
+	s.Group("users").Add("users", &user{Email: "admin@example.com"})
+	if _, err := s.Run(); !errors.Is(err, structable.ErrMissingKey) {
+		t.Errorf("Expected a Record without a key to fail, got %v", err)
+	}
+
+	s = New(struct{ structable.Runner }{db}, "mysql").Match("users", "mail")
+	s.Group("users").Add("users", &user{Email: "admin@example.com"})
+	if _, err := s.Run(); err == nil {
+		t.Error("Expected an unknown match column to fail")
+	}
+
+	boom := errors.New("boom")
+	s = New(struct{ structable.Runner }{db}, "mysql")
+	s.Group("users").AddFunc("users", func(structable.Runner) ([]structable.Record, error) { return nil, boom })
+	if _, err := s.Run(); !errors.Is(err, boom) {
+		t.Errorf("Expected the factory error, got %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package structable
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+)
+
+// SessionSetup is a list of statements that are run on every new connection.
+//
+// Settings like the Postgres search_path or the MySQL sql_mode are scoped to
+// a session. Since database/sql pools connections, setting them once with
+// db.Exec only affects whichever connection happened to run the statement.
+// A SessionSetup is instead run by the connector each time the pool opens a
+// connection, so every Recorder sees the same schema and strictness settings
+// no matter how the pool is configured.
+//
+//	db, err := structable.OpenSession("postgres", dsn,
+//		structable.SearchPath("app", "public"))
+//	u := NewUser(squirrel.NewStmtCacheProxy(db), "postgres")
+type SessionSetup []string
+
+// SearchPath returns a Postgres SessionSetup that sets the schema search path.
+//
+// Schema names are used verbatim. DO NOT TRUST USER-SUPPLIED VALUES.
+func SearchPath(schemas ...string) SessionSetup {
+	return SessionSetup{"SET search_path TO " + strings.Join(schemas, ", ")}
+}
+
+// SqlMode returns a MySQL SessionSetup that sets the session's sql_mode.
+func SqlMode(modes ...string) SessionSetup {
+	return SessionSetup{fmt.Sprintf("SET SESSION sql_mode = '%s'", strings.Join(modes, ","))}
+}
+
+// StrictMySQL is a MySQL SessionSetup for strict, standards-leaning behavior.
+var StrictMySQL = SqlMode("STRICT_ALL_TABLES", "NO_ZERO_DATE", "NO_ZERO_IN_DATE",
+	"ERROR_FOR_DIVISION_BY_ZERO", "NO_ENGINE_SUBSTITUTION", "ONLY_FULL_GROUP_BY")
+
+// ForeignKeys is a SQLite SessionSetup that enables foreign key enforcement.
+var ForeignKeys = SessionSetup{"PRAGMA foreign_keys = ON"}
+
+// OpenSession opens a database whose connections all run the given setup.
+//
+// The arguments are the same as for sql.Open. Multiple setups are run in order.
+func OpenSession(driverName, dsn string, setup ...SessionSetup) (*sql.DB, error) {
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	d := db.Driver()
+	db.Close()
+
+	var c driver.Connector
+	if dc, ok := d.(driver.DriverContext); ok {
+		if c, err = dc.OpenConnector(dsn); err != nil {
+			return nil, err
+		}
+	} else {
+		c = &dsnConnector{dsn: dsn, driver: d}
+	}
+
+	all := SessionSetup{}
+	for _, s := range setup {
+		all = append(all, s...)
+	}
+	return sql.OpenDB(NewSessionConnector(c, all)), nil
+}
+
+// NewSessionConnector wraps a connector so that every new connection runs setup.
+//
+// If any setup statement fails, the connection is closed and the error is
+// returned to the pool.
+func NewSessionConnector(c driver.Connector, setup SessionSetup) driver.Connector {
+	return &sessionConnector{Connector: c, setup: setup}
+}
+
+type sessionConnector struct {
+	driver.Connector
+	setup SessionSetup
+}
+
+func (s *sessionConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := s.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, stmt := range s.setup {
+		if err := execConn(ctx, conn, stmt); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("session setup %q failed: %s", stmt, err)
+		}
+	}
+	return conn, nil
+}
+
+// execConn runs a statement directly on a driver connection.
+func execConn(ctx context.Context, conn driver.Conn, stmt string) error {
+	if ex, ok := conn.(driver.ExecerContext); ok {
+		_, err := ex.ExecContext(ctx, stmt, nil)
+		if err != driver.ErrSkip {
+			return err
+		}
+	}
+	st, err := conn.Prepare(stmt)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	_, err = st.Exec(nil)
+	return err
+}
+
+// dsnConnector is a connector for drivers that do not implement DriverContext.
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (d *dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return d.driver.Open(d.dsn)
+}
+
+func (d *dsnConnector) Driver() driver.Driver {
+	return d.driver
+}
@@ -0,0 +1,84 @@
+package structable
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// sessionDriver records the statements run on each connection.
+type sessionDriver struct {
+	mu    sync.Mutex
+	execs []string
+}
+
+func (d *sessionDriver) Open(string) (driver.Conn, error) {
+	return &sessionConn{d: d}, nil
+}
+
+func (d *sessionDriver) log(s string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.execs = append(d.execs, s)
+}
+
+type sessionConn struct {
+	d *sessionDriver
+}
+
+func (c *sessionConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *sessionConn) Close() error                        { return nil }
+func (c *sessionConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+func (c *sessionConn) ExecContext(ctx context.Context, q string, args []driver.NamedValue) (driver.Result, error) {
+	if strings.Contains(q, "fail") {
+		return nil, errors.New("intentional failure")
+	}
+	c.d.log(q)
+	return driver.RowsAffected(0), nil
+}
+
+var testSessionDriver = &sessionDriver{}
+
+func init() {
+	sql.Register("structable-session-test", testSessionDriver)
+}
+
+func TestOpenSession(t *testing.T) {
+	db, err := OpenSession("structable-session-test", "", SearchPath("app", "public"), ForeignKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := db.Ping(); err != nil {
+		t.Fatalf("Failed to connect: %s", err)
+	}
+
+	expect := []string{"SET search_path TO app, public", "PRAGMA foreign_keys = ON"}
+	got := testSessionDriver.execs
+	if len(got) != len(expect) {
+		t.Fatalf("Expected %v, got %v", expect, got)
+	}
+	for i := range expect {
+		if got[i] != expect[i] {
+			t.Errorf("Expected %q, got %q", expect[i], got[i])
+		}
+	}
+
+	bad, _ := OpenSession("structable-session-test", "", SessionSetup{"fail"})
+	defer bad.Close()
+	if err := bad.Ping(); err == nil {
+		t.Error("Expected failed setup to fail the connection")
+	}
+}
+
+func TestSqlMode(t *testing.T) {
+	expect := "SET SESSION sql_mode = 'STRICT_ALL_TABLES,NO_ZERO_DATE'"
+	if got := SqlMode("STRICT_ALL_TABLES", "NO_ZERO_DATE")[0]; got != expect {
+		t.Errorf("Expected %q, got %q", expect, got)
+	}
+}
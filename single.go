@@ -0,0 +1,83 @@
+package structable
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// ErrMultipleRows is returned by LoadWhere in strict mode when more than one
+// row matches the predicate. See SetStrictSingle.
+var ErrMultipleRows = errors.New("more than one record matches the predicate")
+
+// SetStrictSingle sets whether LoadWhere fails when its predicate matches
+// more than one row.
+//
+// By default, LoadWhere reads the first matching row, and ignores the rest.
+// A predicate that was meant to be unique, but is not, then loads an
+// arbitrary record. With strict mode on, LoadWhere reads up to two rows, and
+// returns ErrMultipleRows, leaving the Record unchanged, if there are two:
+//
+//	r.SetStrictSingle(true)
+//	err := r.LoadWhere("email = ?", email)
+//	if errors.Is(err, structable.ErrMultipleRows) {
+//		// The data has duplicate emails.
+//	}
+//
+// This applies to everything that loads through LoadWhere, such as
+// LoadByExample and FindOrCreate.
+func (s *DbRecorder) SetStrictSingle(on bool) *DbRecorder {
+	s.strictSingle = on
+	return s
+}
+
+// limitRows limits a select to n rows, in the flavor's dialect.
+//
+// MSSQL has no LIMIT, and takes TOP after SELECT instead. Oracle takes FETCH
+// FIRST at the end of the statement, which must come before any locking
+// clause, so limitRows must be called before lockSelect.
+func (s *DbRecorder) limitRows(q squirrel.SelectBuilder, n uint64) squirrel.SelectBuilder {
+	switch s.flavor {
+	case "mssql":
+		return q.Options(fmt.Sprintf("TOP %d", n))
+	case "oracle":
+		return q.Suffix(fmt.Sprintf("FETCH FIRST %d ROWS ONLY", n))
+	}
+	return q.Limit(n)
+}
+
+// loadSingle runs the select of LoadWhere in strict mode.
+func (s *DbRecorder) loadSingle(q squirrel.SelectBuilder) error {
+	rows, err := s.query(OpLoadWhere, s.lockSelect(s.limitRows(q, 2)))
+	if err != nil {
+		return err
+	}
+	if rows == nil {
+		return sql.ErrNoRows
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	fresh := s.Clone(nil)
+	if err := fresh.scanColumns(rows, cols); err != nil {
+		return err
+	}
+	if rows.Next() {
+		return fmt.Errorf("%w: table %s", ErrMultipleRows, s.table)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return copyFields(s, fresh)
+}
@@ -0,0 +1,37 @@
+package structable
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestLoadWhereLimit(t *testing.T) {
+	for flavor, expect := range map[string]string{
+		"mysql":  "SELECT id, id_two, number_of_legs, material, color FROM test_table WHERE material = ? LIMIT 1",
+		"mssql":  "SELECT TOP 1 id, id_two, number_of_legs, material, color FROM test_table WHERE material = ?",
+		"oracle": "SELECT id, id_two, number_of_legs, material, color FROM test_table WHERE material = ? FETCH FIRST 1 ROWS ONLY",
+	} {
+		db := &DBStub{}
+		r := New(db, flavor).Bind("test_table", newStool())
+		if err := r.LoadWhere("material = ?", "wood"); err != sql.ErrNoRows {
+			t.Fatalf("%s: expected sql.ErrNoRows from the stub, got %v", flavor, err)
+		}
+		if db.LastQuerySql != expect {
+			t.Errorf("%s: expected %q, got %q", flavor, expect, db.LastQuerySql)
+		}
+	}
+}
+
+func TestStrictSingle(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "mysql").SetStrictSingle(true)
+	r.Bind("test_table", newStool())
+
+	if err := r.LoadWhere("material = ?", "wood"); err != sql.ErrNoRows {
+		t.Fatalf("Expected sql.ErrNoRows from the stub, got %v", err)
+	}
+	expect := "SELECT id, id_two, number_of_legs, material, color FROM test_table WHERE material = ? LIMIT 2"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+}
@@ -0,0 +1,270 @@
+package structable
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// Spec is a reusable, composable filter: a business rule such as "active
+// users" or "created after t", expressed as a predicate.
+//
+//	func Active() structable.Spec {
+//		return structable.And(structable.Eq("status", "active"), structable.IsNull("deleted_at"))
+//	}
+//
+//	func CreatedAfter(t time.Time) structable.Spec {
+//		return structable.Gt("created_at", t)
+//	}
+//
+//	users, err := structable.List(r, structable.WithSpec(structable.And(Active(), CreatedAfter(t))))
+//	n, err := r.DeleteSpec(structable.Not(Active()))
+//
+// A Spec gets the Describer of the table it is applied to, so it can check
+// its columns; the leaf Specs in this package reject columns that are not on
+// the bound Record. Specs are accepted by WithSpec (for List and ListWhere),
+// ExistsSpec, and DeleteSpec.
+type Spec interface {
+	ToPredicate(d Describer) (squirrel.Sqlizer, error)
+}
+
+// SpecFunc adapts an ordinary function to the Spec interface.
+type SpecFunc func(d Describer) (squirrel.Sqlizer, error)
+
+// ToPredicate calls f(d).
+func (f SpecFunc) ToPredicate(d Describer) (squirrel.Sqlizer, error) {
+	return f(d)
+}
+
+// And is satisfied when every spec is. An empty And is always satisfied.
+func And(specs ...Spec) Spec {
+	return SpecFunc(func(d Describer) (squirrel.Sqlizer, error) {
+		and := squirrel.And{}
+		for _, s := range specs {
+			p, err := s.ToPredicate(d)
+			if err != nil {
+				return nil, err
+			}
+			and = append(and, p)
+		}
+		return and, nil
+	})
+}
+
+// Or is satisfied when any spec is. An empty Or is never satisfied.
+func Or(specs ...Spec) Spec {
+	return SpecFunc(func(d Describer) (squirrel.Sqlizer, error) {
+		or := squirrel.Or{}
+		for _, s := range specs {
+			p, err := s.ToPredicate(d)
+			if err != nil {
+				return nil, err
+			}
+			or = append(or, p)
+		}
+		return or, nil
+	})
+}
+
+// Not is satisfied when spec is not.
+//
+// As always in SQL, a comparison with NULL is neither true nor false, so
+// Not(Eq("color", "red")) does not match rows where color is NULL.
+func Not(spec Spec) Spec {
+	return SpecFunc(func(d Describer) (squirrel.Sqlizer, error) {
+		p, err := spec.ToPredicate(d)
+		if err != nil {
+			return nil, err
+		}
+		return notPred{p}, nil
+	})
+}
+
+type notPred struct {
+	pred squirrel.Sqlizer
+}
+
+func (n notPred) ToSql() (string, []interface{}, error) {
+	sql, args, err := n.pred.ToSql()
+	if err != nil {
+		return "", nil, err
+	}
+	return "NOT (" + sql + ")", args, nil
+}
+
+// Eq is satisfied when the column equals value. If value is a slice, it is
+// satisfied when the column is one of its values.
+func Eq(column string, value interface{}) Spec {
+	return columnSpec(column, squirrel.Eq{column: value})
+}
+
+// NotEq is satisfied when the column does not equal value. If value is a
+// slice, it is satisfied when the column is none of its values.
+func NotEq(column string, value interface{}) Spec {
+	return columnSpec(column, squirrel.NotEq{column: value})
+}
+
+// Gt is satisfied when the column is greater than value.
+func Gt(column string, value interface{}) Spec {
+	return columnSpec(column, squirrel.Gt{column: value})
+}
+
+// GtOrEq is satisfied when the column is greater than or equal to value.
+func GtOrEq(column string, value interface{}) Spec {
+	return columnSpec(column, squirrel.GtOrEq{column: value})
+}
+
+// Lt is satisfied when the column is less than value.
+func Lt(column string, value interface{}) Spec {
+	return columnSpec(column, squirrel.Lt{column: value})
+}
+
+// LtOrEq is satisfied when the column is less than or equal to value.
+func LtOrEq(column string, value interface{}) Spec {
+	return columnSpec(column, squirrel.LtOrEq{column: value})
+}
+
+// Like is satisfied when the column matches a LIKE pattern.
+func Like(column, pattern string) Spec {
+	return columnSpec(column, squirrel.Like{column: pattern})
+}
+
+// ILike is satisfied when the column matches a LIKE pattern, ignoring case.
+//
+// Postgres uses ILIKE. Other flavors compare LOWER(column) with LOWER(pattern).
+func ILike(column, pattern string) Spec {
+	return SpecFunc(func(d Describer) (squirrel.Sqlizer, error) {
+		if err := checkColumns(d, column); err != nil {
+			return nil, err
+		}
+		if d.Driver() == "postgres" {
+			return squirrel.Expr(column+" ILIKE ?", pattern), nil
+		}
+		return squirrel.Expr("LOWER("+column+") LIKE LOWER(?)", pattern), nil
+	})
+}
+
+// In is satisfied when the column is one of values, which must be a slice or
+// an array. An empty In is never satisfied.
+//
+//	structable.In("id", ids)
+func In(column string, values interface{}) Spec {
+	return SpecFunc(func(d Describer) (squirrel.Sqlizer, error) {
+		if err := checkColumns(d, column); err != nil {
+			return nil, err
+		}
+		if k := reflect.ValueOf(values).Kind(); k != reflect.Slice && k != reflect.Array {
+			return nil, fmt.Errorf("In(%s) needs a slice of values, got %T", column, values)
+		}
+		return squirrel.Eq{column: values}, nil
+	})
+}
+
+// Between is satisfied when the column is between low and high, inclusive.
+func Between(column string, low, high interface{}) Spec {
+	return columnSpec(column, squirrel.Expr(column+" BETWEEN ? AND ?", low, high))
+}
+
+// IsNull is satisfied when the column is NULL.
+func IsNull(column string) Spec {
+	return columnSpec(column, squirrel.Eq{column: nil})
+}
+
+// Where is satisfied when a raw predicate is. It is given as for LoadWhere.
+// Columns in a raw predicate are not checked.
+func Where(pred interface{}, args ...interface{}) Spec {
+	return SpecFunc(func(d Describer) (squirrel.Sqlizer, error) {
+		switch p := pred.(type) {
+		case string:
+			return squirrel.Expr(p, args...), nil
+		case map[string]interface{}:
+			return squirrel.Eq(p), nil
+		case squirrel.Sqlizer:
+			return p, nil
+		}
+		return nil, fmt.Errorf("unsupported predicate type %T", pred)
+	})
+}
+
+// columnSpec returns a Spec for a predicate on one column, which must be on
+// the bound Record.
+func columnSpec(column string, pred squirrel.Sqlizer) Spec {
+	return SpecFunc(func(d Describer) (squirrel.Sqlizer, error) {
+		if err := checkColumns(d, column); err != nil {
+			return nil, err
+		}
+		return pred, nil
+	})
+}
+
+// WithSpec returns a WhereFunc that restricts a list to the rows that satisfy
+// spec.
+func WithSpec(spec Spec) WhereFunc {
+	return func(desc Describer, q squirrel.SelectBuilder) (squirrel.SelectBuilder, error) {
+		p, err := spec.ToPredicate(desc)
+		if err != nil {
+			return q, err
+		}
+		return q.Where(p), nil
+	}
+}
+
+// ExistsSpec returns true if at least one record satisfies spec.
+func (s *DbRecorder) ExistsSpec(spec Spec) (bool, error) {
+	p, err := spec.ToPredicate(s)
+	if err != nil {
+		return false, err
+	}
+	return s.ExistsWhere(p)
+}
+
+// DeleteSpec deletes every record that satisfies spec, and returns the number
+// of records deleted.
+func (s *DbRecorder) DeleteSpec(spec Spec) (int64, error) {
+	p, err := spec.ToPredicate(s)
+	if err != nil {
+		return 0, err
+	}
+	return s.DeleteWhere(p)
+}
+
+// LoadWhereMap loads the first record whose columns equal the values in a map,
+// as LoadWhere does with a squirrel.Eq:
+//
+//	err := r.LoadWhereMap(map[string]interface{}{"email": email, "active": true})
+//
+// Unlike a map given to LoadWhere, every key must be one of the columns on the
+// bound Record, so a misspelled or user-supplied column name is an error, not
+// part of the SQL. A slice value matches any of its values. An empty map is an
+// error, rather than loading an arbitrary record.
+func (s *DbRecorder) LoadWhereMap(cols map[string]interface{}) error {
+	pred, err := s.mapWhere(cols)
+	if err != nil {
+		return err
+	}
+	return s.LoadWhere(pred)
+}
+
+// ExistsWhereMap returns true if a record's columns equal the values in a map.
+// The map is checked as by LoadWhereMap.
+func (s *DbRecorder) ExistsWhereMap(cols map[string]interface{}) (bool, error) {
+	pred, err := s.mapWhere(cols)
+	if err != nil {
+		return false, err
+	}
+	return s.ExistsWhere(pred)
+}
+
+// mapWhere checks the columns of a map, and makes it a predicate.
+func (s *DbRecorder) mapWhere(cols map[string]interface{}) (squirrel.Eq, error) {
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("no columns to match on table %s", s.table)
+	}
+	for c := range cols {
+		if err := checkColumns(s, c); err != nil {
+			return nil, err
+		}
+	}
+	return squirrel.Eq(cols), nil
+}
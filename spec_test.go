@@ -0,0 +1,102 @@
+package structable
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSpec(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "mysql")
+	r.Bind("test_table", newStool())
+
+	wooden := Eq("material", "wood")
+	sturdy := Or(GtOrEq("number_of_legs", 4), Where("id_two = ?", 2))
+
+	tests := []struct {
+		spec   Spec
+		expect string
+		args   []interface{}
+	}{
+		{wooden, "material = ?", []interface{}{"wood"}},
+		{And(wooden, sturdy), "(material = ? AND (number_of_legs >= ? OR id_two = ?))", []interface{}{"wood", 4, 2}},
+		{Not(And(wooden, IsNull("color"))), "NOT ((material = ? AND color IS NULL))", []interface{}{"wood"}},
+		{And(NotEq("id", []int{1, 2}), Lt("number_of_legs", 5), Like("material", "w%")),
+			"(id NOT IN (?,?) AND number_of_legs < ? AND material LIKE ?)", []interface{}{1, 2, 5, "w%"}},
+		{And(In("id", []int{1, 2}), Between("number_of_legs", 3, 5), ILike("material", "W%")),
+			"(id IN (?,?) AND number_of_legs BETWEEN ? AND ? AND LOWER(material) LIKE LOWER(?))", []interface{}{1, 2, 3, 5, "W%"}},
+	}
+	for _, tt := range tests {
+		if _, err := List(r, WithSpec(tt.spec)); err != nil {
+			t.Fatal(err)
+		}
+		expect := "SELECT id, id_two, number_of_legs, material, color FROM test_table WHERE " + tt.expect
+		if db.LastQuerySql != expect || !reflect.DeepEqual(db.LastQueryArgs, tt.args) {
+			t.Errorf("Expected %q %v, got %q %v", expect, tt.args, db.LastQuerySql, db.LastQueryArgs)
+		}
+	}
+
+	if _, err := r.ExistsSpec(wooden); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "SELECT EXISTS(SELECT 1 FROM test_table WHERE material = ?)"; db.LastQueryRowSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQueryRowSql)
+	}
+	if n, err := r.DeleteSpec(Not(sturdy)); err != nil || n != 1 {
+		t.Fatalf("Expected 1 row deleted, got %d, %v", n, err)
+	}
+	if expect := "DELETE FROM test_table WHERE NOT ((number_of_legs >= ? OR id_two = ?))"; db.LastExecSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastExecSql)
+	}
+
+	if _, err := List(r, WithSpec(And(wooden, Eq("nope", 1)))); err == nil {
+		t.Error("Expected an unknown column to fail")
+	}
+	if _, err := r.DeleteSpec(Where(42)); err == nil {
+		t.Error("Expected an unsupported predicate to fail")
+	}
+	if _, err := List(r, WithSpec(In("id", 1))); err == nil {
+		t.Error("Expected In with a single value to fail")
+	}
+	for _, spec := range []Spec{In("nope", []int{1}), Between("nope", 1, 2), ILike("nope", "a")} {
+		if _, err := List(r, WithSpec(spec)); err == nil {
+			t.Error("Expected an unknown column to fail")
+		}
+	}
+
+	pg := New(db, "postgres").Bind("test_table", newStool())
+	if _, err := List(pg, WithSpec(ILike("material", "W%"))); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "SELECT id, id_two, number_of_legs, material, color FROM test_table WHERE material ILIKE $1"; db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+}
+
+func TestWhereMap(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "postgres")
+	r.Bind("test_table", newStool())
+
+	// The stub returns no rows.
+	r.LoadWhereMap(map[string]interface{}{"material": "wood", "id": []int{1, 2}})
+	expect := "SELECT id, id_two, number_of_legs, material, color FROM test_table WHERE id IN ($1,$2) AND material = $3 LIMIT 1"
+	if db.LastQuerySql != expect || !reflect.DeepEqual(db.LastQueryArgs, []interface{}{1, 2, "wood"}) {
+		t.Errorf("Expected %q, got %q %v", expect, db.LastQuerySql, db.LastQueryArgs)
+	}
+
+	if _, err := r.ExistsWhereMap(map[string]interface{}{"color": nil}); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "SELECT EXISTS(SELECT 1 FROM test_table WHERE color IS NULL)"; db.LastQueryRowSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQueryRowSql)
+	}
+
+	db.LastQuerySql = ""
+	if err := r.LoadWhereMap(map[string]interface{}{"1=1; --": 1}); err == nil || db.LastQuerySql != "" {
+		t.Errorf("Expected an unknown column to fail, got %v", err)
+	}
+	if _, err := r.ExistsWhereMap(nil); err == nil {
+		t.Error("Expected an empty map to fail")
+	}
+}
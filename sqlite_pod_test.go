@@ -1,10 +1,16 @@
+//go:build sqlite
 // +build sqlite
 
 package structable
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
+	"errors"
 	"log"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -247,3 +253,1694 @@ func getLanguagesDb() *sql.DB {
 	}
 	return db
 }
+
+func TestPlainStructListMaxRows(t *testing.T) {
+
+	db := getLanguagesDb()
+
+	for _, name := range []string{"Go", "Scala", "Rust"} {
+		if _, err := db.Exec("INSERT INTO languages (name, version, dt_release) VALUES (?, '1.0', '2015-06-23')", name); err != nil {
+			t.Fatalf("Sqlite Exec failed: %s", err)
+		}
+	}
+
+	r := New(squirrel.NewStmtCacheProxy(db), "mysql").SetMaxRows(2, nil)
+	r.Bind("languages", &Language{})
+
+	items, err := ListWhere(r, func(d Describer, q squirrel.SelectBuilder) (squirrel.SelectBuilder, error) {
+		return q.OrderBy("id"), nil
+	})
+	if !errors.Is(err, ErrTooManyRows) {
+		t.Fatalf("Expected ErrTooManyRows, got %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(items))
+	}
+	if name := items[1].Interface().(*Language).Name; name != "Scala" {
+		t.Errorf("Expected Scala, got %s", name)
+	}
+
+	truncated := ""
+	r.SetMaxRows(2, func(table string, max uint64) error {
+		truncated = table
+		return nil
+	})
+	if items, err = List(r, WithLimit(10), WithOffset(0)); err != nil {
+		t.Fatalf("Failed List: %s", err)
+	}
+	if len(items) != 2 || truncated != "languages" {
+		t.Errorf("Expected truncation to 2 items, got %d", len(items))
+	}
+}
+
+func TestEmbeddedRecorderListMaxRows(t *testing.T) {
+
+	db := getLanguagesDb()
+
+	for _, name := range []string{"Go", "Scala", "Rust"} {
+		if _, err := db.Exec("INSERT INTO languages (name, version, dt_release) VALUES (?, '1.0', '2015-06-23')", name); err != nil {
+			t.Fatalf("Sqlite Exec failed: %s", err)
+		}
+	}
+
+	l := &Language{}
+	r := New(NewRunner(db), "sqlite3").SetMaxRows(2, nil)
+	l.Recorder = r.Bind("languages", l)
+
+	items, err := List(l)
+	if !errors.Is(err, ErrTooManyRows) {
+		t.Fatalf("Expected ErrTooManyRows, got %v", err)
+	}
+	if len(items) != 2 {
+		t.Errorf("Expected 2 items, got %d", len(items))
+	}
+}
+
+func TestPlainStructNoStmtCache(t *testing.T) {
+
+	db := getLanguagesDb()
+
+	l := &Language{
+		Name:      "Go",
+		Version:   "1.5",
+		DtRelease: time.Date(2015, time.August, 19, 0, 0, 0, 0, time.UTC)}
+	l.Recorder = New(NewRunner(db), "mysql").Bind("languages", l)
+
+	if err := l.Insert(); err != nil {
+		t.Fatalf("Failed Insert: %s", err)
+	}
+
+	again := &Language{Id: l.Id}
+	again.Recorder = New(NewRunner(db), "mysql").Bind("languages", again)
+	if err := again.Load(); err != nil {
+		t.Fatalf("Failed Load: %s", err)
+	}
+	if !l.equals(again) {
+		t.Fatal("Loaded and inserted objects should be equivalent")
+	}
+}
+
+func TestPlainStructBaseRunner(t *testing.T) {
+
+	db := getLanguagesDb()
+
+	// A *sql.DB is taken as it is, and a BaseRunner gets a QueryRow.
+	l := &Language{
+		Name:      "Go",
+		Version:   "stable",
+		DtRelease: time.Date(2015, time.August, 19, 0, 0, 0, 0, time.UTC)}
+	l.Recorder = New(db, "mysql").Bind("languages", l)
+	if err := l.Insert(); err != nil {
+		t.Fatalf("Failed Insert: %s", err)
+	}
+
+	base := struct{ squirrel.BaseRunner }{db}
+	again := &Language{Id: l.Id}
+	again.Recorder = New(base, "mysql").Bind("languages", again)
+	if err := again.Load(); err != nil {
+		t.Fatalf("Failed Load: %s", err)
+	}
+	if !l.equals(again) {
+		t.Fatal("Loaded and inserted objects should be equivalent")
+	}
+
+	missing := &Language{Id: l.Id + 1}
+	missing.Recorder = New(base, "mysql").Bind("languages", missing)
+	if err := missing.Load(); err != sql.ErrNoRows {
+		t.Errorf("Expected sql.ErrNoRows, got %v", err)
+	}
+}
+
+func TestPlainStructLatestPerGroup(t *testing.T) {
+
+	db := getLanguagesDb()
+
+	releases := [][]string{
+		{"Go", "1.4", "2014-12-10"},
+		{"Go", "1.5", "2015-08-19"},
+		{"Scala", "2.11.7", "2015-06-23"},
+		{"Scala", "2.10.5", "2015-03-05"},
+	}
+	for _, r := range releases {
+		if _, err := db.Exec("INSERT INTO languages (name, version, dt_release) VALUES (?, ?, ?)", r[0], r[1], r[2]); err != nil {
+			t.Fatalf("Sqlite Exec failed: %s", err)
+		}
+	}
+
+	// sqlite3 uses ROW_NUMBER(), and mysql uses the correlated subquery.
+	for _, flavor := range []string{"sqlite3", "mysql"} {
+		r := New(NewRunner(db), flavor).Bind("languages", &Language{})
+		items, err := LatestPerGroup(r, "name", "dt_release")
+		if err != nil {
+			t.Fatalf("%s: Failed LatestPerGroup: %s", flavor, err)
+		}
+		if len(items) != 2 {
+			t.Fatalf("%s: Expected 2 items, got %d", flavor, len(items))
+		}
+		for _, item := range items {
+			l := item.Interface().(*Language)
+			if (l.Name == "Go" && l.Version != "1.5") || (l.Name == "Scala" && l.Version != "2.11.7") {
+				t.Errorf("%s: Unexpected latest %s %s", flavor, l.Name, l.Version)
+			}
+		}
+	}
+}
+
+type OrgRelease struct {
+	Id      int64  `stbl:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	OrgId   int    `stbl:"org_id,TENANT"`
+	Name    string `stbl:"name"`
+	Version string `stbl:"version"`
+}
+
+func TestPlainStructLatestPerGroupTenant(t *testing.T) {
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Couldn't Open database: %s", err)
+	}
+	_, err = db.Exec(`
+	CREATE TABLE releases (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		org_id INTEGER,
+		name STRING,
+		version STRING
+	);
+	INSERT INTO releases (org_id, name, version) VALUES
+		(1, 'Go', '1.4'),
+		(1, 'Go', '1.5'),
+		(2, 'Go', '1.6');
+	`)
+	if err != nil {
+		t.Fatalf("Sqlite Exec failed: %s", err)
+	}
+
+	// The newest Go release belongs to another tenant, and must not hide
+	// this tenant's newest one.
+	for _, flavor := range []string{"sqlite3", "mysql"} {
+		r := New(NewRunner(db), flavor).WithTenant(1)
+		r.Bind("releases", &OrgRelease{})
+		items, err := LatestPerGroup(r, "name", "id")
+		if err != nil {
+			t.Fatalf("%s: Failed LatestPerGroup: %s", flavor, err)
+		}
+		if len(items) != 1 {
+			t.Fatalf("%s: Expected 1 item, got %d", flavor, len(items))
+		}
+		if v := items[0].Interface().(*OrgRelease).Version; v != "1.5" {
+			t.Errorf("%s: Expected 1.5, got %s", flavor, v)
+		}
+	}
+}
+
+type Category struct {
+	Recorder
+
+	Id       int64  `stbl:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	ParentId int64  `stbl:"parent_id"`
+	Name     string `stbl:"name"`
+}
+
+func TestPlainStructTree(t *testing.T) {
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Couldn't Open database: %s", err)
+	}
+	_, err = db.Exec(`
+	CREATE TABLE categories (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		parent_id INTEGER,
+		name STRING
+	);
+	INSERT INTO categories (id, parent_id, name) VALUES
+		(1, 0, 'Languages'),
+		(2, 1, 'Compiled'),
+		(3, 2, 'Go'),
+		(4, 2, 'Rust'),
+		(5, 1, 'Interpreted');
+	`)
+	if err != nil {
+		t.Fatalf("Sqlite Exec failed: %s", err)
+	}
+
+	c := &Category{Id: 1}
+	c.Recorder = New(NewRunner(db), "sqlite3").Bind("categories", c)
+	nodes, err := LoadDescendants(c, "parent_id")
+	if err != nil {
+		t.Fatalf("Failed LoadDescendants: %s", err)
+	}
+	if len(nodes) != 4 {
+		t.Fatalf("Expected 4 descendants, got %d", len(nodes))
+	}
+	if last := nodes[3]; last.Depth != 2 || last.Interface().(*Category).ParentId != 2 {
+		t.Errorf("Expected a grandchild last, got depth %d", last.Depth)
+	}
+
+	c = &Category{Id: 3}
+	c.Recorder = New(NewRunner(db), "sqlite3").Bind("categories", c)
+	if nodes, err = LoadAncestors(c, "parent_id"); err != nil {
+		t.Fatalf("Failed LoadAncestors: %s", err)
+	}
+	names := []string{}
+	for _, n := range nodes {
+		names = append(names, n.Interface().(*Category).Name)
+	}
+	if len(names) != 2 || names[0] != "Compiled" || names[1] != "Languages" {
+		t.Errorf("Unexpected ancestors %v", names)
+	}
+}
+
+func TestPlainStructClosureTable(t *testing.T) {
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Couldn't Open database: %s", err)
+	}
+	_, err = db.Exec(`
+	CREATE TABLE categories (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		parent_id INTEGER,
+		name STRING
+	);
+	CREATE TABLE category_tree (
+		ancestor INTEGER NOT NULL,
+		descendant INTEGER NOT NULL,
+		depth INTEGER NOT NULL,
+		PRIMARY KEY (ancestor, descendant)
+	);
+	`)
+	if err != nil {
+		t.Fatalf("Sqlite Exec failed: %s", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("Failed Begin: %s", err)
+	}
+	tree := NewClosureTable("category_tree")
+	insert := func(name string, parent interface{}) *Category {
+		c := &Category{Name: name}
+		c.Recorder = New(NewRunner(tx), "sqlite3").Bind("categories", c)
+		if err := tree.Insert(c, parent); err != nil {
+			t.Fatalf("Failed Insert of %s: %s", name, err)
+		}
+		return c
+	}
+	names := func(parent *Category, fn func(interface{}) WhereFunc) []string {
+		items, err := ListWhere(New(NewRunner(tx), "sqlite3").Bind("categories", &Category{}), fn(parent.Id))
+		if err != nil {
+			t.Fatalf("Failed ListWhere: %s", err)
+		}
+		buf := []string{}
+		for _, item := range items {
+			buf = append(buf, item.Interface().(*Category).Name)
+		}
+		return buf
+	}
+
+	langs := insert("Languages", nil)
+	compiled := insert("Compiled", langs.Id)
+	golang := insert("Go", compiled.Id)
+	scripting := insert("Scripting", nil)
+
+	if got := names(golang, tree.Ancestors); len(got) != 2 || got[0] != "Compiled" || got[1] != "Languages" {
+		t.Errorf("Unexpected ancestors %v", got)
+	}
+
+	if err := tree.Move(compiled, scripting.Id); err != nil {
+		t.Fatalf("Failed Move: %s", err)
+	}
+	if got := names(golang, tree.Ancestors); len(got) != 2 || got[1] != "Scripting" {
+		t.Errorf("Unexpected ancestors after Move %v", got)
+	}
+	if got := names(langs, tree.Descendants); len(got) != 0 {
+		t.Errorf("Expected no descendants after Move, got %v", got)
+	}
+	if err := tree.Move(scripting, golang.Id); err == nil {
+		t.Error("Expected Move beneath a descendant to fail")
+	}
+
+	if err := tree.Delete(compiled); err != nil {
+		t.Fatalf("Failed Delete: %s", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Failed Commit: %s", err)
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM categories").Scan(&count); err != nil || count != 2 {
+		t.Errorf("Expected 2 categories after Delete, got %d (%v)", count, err)
+	}
+	if err := db.QueryRow("SELECT COUNT(*) FROM category_tree").Scan(&count); err != nil || count != 2 {
+		t.Errorf("Expected 2 links after Delete, got %d (%v)", count, err)
+	}
+}
+
+func TestPlainStructClosureTableRollback(t *testing.T) {
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Couldn't Open database: %s", err)
+	}
+	db.SetMaxOpenConns(1)
+	// There is no closure table, so linking fails after the insert.
+	_, err = db.Exec(`
+	CREATE TABLE categories (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		parent_id INTEGER,
+		name STRING
+	);
+	`)
+	if err != nil {
+		t.Fatalf("Sqlite Exec failed: %s", err)
+	}
+	tree := NewClosureTable("category_tree")
+	count := func(q interface {
+		QueryRow(string, ...interface{}) *sql.Row
+	}) int {
+		var n int
+		if err := q.QueryRow("SELECT COUNT(*) FROM categories").Scan(&n); err != nil {
+			t.Fatalf("Failed count: %s", err)
+		}
+		return n
+	}
+
+	c := &Category{Name: "Languages"}
+	c.Recorder = New(NewRunner(db), "sqlite3").Bind("categories", c)
+	if err := tree.Insert(c, nil); err == nil {
+		t.Fatal("Expected Insert to fail")
+	}
+	if n := count(db); n != 0 {
+		t.Errorf("Expected the insert to be rolled back, got %d categories", n)
+	}
+
+	// In a transaction, only the failed Insert is rolled back.
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("Failed Begin: %s", err)
+	}
+	if _, err := tx.Exec("INSERT INTO categories (name) VALUES ('Kept')"); err != nil {
+		t.Fatalf("Sqlite Exec failed: %s", err)
+	}
+	c = &Category{Name: "Languages"}
+	c.Recorder = New(NewRunner(tx), "sqlite3").Bind("categories", c)
+	if err := tree.Insert(c, nil); err == nil {
+		t.Fatal("Expected Insert to fail")
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Failed Commit: %s", err)
+	}
+	if n := count(db); n != 1 {
+		t.Errorf("Expected only the failed insert to be rolled back, got %d categories", n)
+	}
+}
+
+func TestPlainStructPaginate(t *testing.T) {
+
+	db := getLanguagesDb()
+
+	names := []string{"Go", "Rust", "Scala", "Ada", "Lisp"}
+	for _, name := range names {
+		if _, err := db.Exec("INSERT INTO languages (name, version, dt_release) VALUES (?, '1.0', '2015-06-23')", name); err != nil {
+			t.Fatalf("Sqlite Exec failed: %s", err)
+		}
+	}
+
+	for _, keyset := range []bool{false, true} {
+		r := New(NewRunner(db), "sqlite3").Bind("languages", &Language{})
+		req := PageRequest{Size: 2, OrderBy: "name", Keyset: keyset}
+		got := []string{}
+		for pages := 0; ; pages++ {
+			if pages > 3 {
+				t.Fatalf("keyset=%t: Too many pages", keyset)
+			}
+			page, err := Paginate(r, req)
+			if err != nil {
+				t.Fatalf("keyset=%t: Failed Paginate: %s", keyset, err)
+			}
+			if page.Total != 5 {
+				t.Errorf("keyset=%t: Expected total 5, got %d", keyset, page.Total)
+			}
+			for _, item := range page.Items {
+				got = append(got, item.Interface().(*Language).Name)
+			}
+			if page.NextToken == "" {
+				break
+			}
+			req.Token = page.NextToken
+		}
+		if strings.Join(got, ",") != "Ada,Go,Lisp,Rust,Scala" {
+			t.Errorf("keyset=%t: Unexpected order %v", keyset, got)
+		}
+	}
+}
+
+type Sample struct {
+	Recorder
+
+	Id         int64     `stbl:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	RecordedAt time.Time `stbl:"recorded_at"`
+	Hour       time.Time `stbl:"recorded_hour"`
+	Latency    float64   `stbl:"latency"`
+}
+
+func TestPlainStructTimeSeries(t *testing.T) {
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Couldn't Open database: %s", err)
+	}
+	_, err = db.Exec(`
+	CREATE TABLE samples (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		recorded_at TIMESTAMP,
+		recorded_hour TIMESTAMP,
+		latency REAL
+	);
+	`)
+	if err != nil {
+		t.Fatalf("Sqlite Exec failed: %s", err)
+	}
+
+	ts := NewTimeSeries("recorded_at", "recorded_hour", time.Hour)
+	start := time.Date(2016, time.May, 4, 13, 0, 0, 0, time.UTC)
+	for i, latency := range []float64{10, 20, 30, 40} {
+		s := &Sample{RecordedAt: start.Add(time.Duration(i) * 40 * time.Minute), Latency: latency}
+		s.Recorder = New(NewRunner(db), "sqlite3").Bind("samples", s)
+		if err := ts.Append(s); err != nil {
+			t.Fatalf("Failed Append: %s", err)
+		}
+	}
+
+	r := New(NewRunner(db), "sqlite3").Bind("samples", &Sample{})
+	items, err := ts.ListRange(r, start.Add(30*time.Minute), start.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("Failed ListRange: %s", err)
+	}
+	if len(items) != 2 || items[0].Interface().(*Sample).Latency != 20 {
+		t.Errorf("Expected the 2nd and 3rd samples, got %d", len(items))
+	}
+
+	rows, err := ts.Downsample(r, start, start.Add(3*time.Hour), Aggregate{"AVG", "latency"}, Aggregate{"COUNT", "id"})
+	if err != nil {
+		t.Fatalf("Failed Downsample: %s", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("Expected 3 buckets, got %d", len(rows))
+	}
+	if !rows[0].Bucket.Equal(start) || rows[0].Values[0] != 15 || rows[0].Values[1] != 2 {
+		t.Errorf("Unexpected first bucket %v", rows[0])
+	}
+}
+
+func TestPlainStructLoadColumns(t *testing.T) {
+
+	db := getLanguagesDb()
+
+	if _, err := db.Exec("INSERT INTO languages (id, name, version, dt_release) VALUES (1, 'Go', '1.5', '2015-08-19')"); err != nil {
+		t.Fatalf("Sqlite Exec failed: %s", err)
+	}
+
+	l := &Language{Id: 1, Version: "unchanged"}
+	r := New(NewRunner(db), "sqlite3")
+	l.Recorder = r.Bind("languages", l)
+	if err := r.LoadColumns("name"); err != nil {
+		t.Fatalf("Failed LoadColumns: %s", err)
+	}
+	if l.Name != "Go" || l.Version != "unchanged" {
+		t.Errorf("Unexpected values %s %s", l.Name, l.Version)
+	}
+
+	items, err := List(r, WithColumns("id", "version"))
+	if err != nil {
+		t.Fatalf("Failed List: %s", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(items))
+	}
+	if item := items[0].Interface().(*Language); item.Version != "1.5" || item.Name != "" {
+		t.Errorf("Unexpected values %s %s", item.Name, item.Version)
+	}
+}
+
+func TestPlainStructAggregates(t *testing.T) {
+
+	db := getLanguagesDb()
+
+	for _, name := range []string{"Go", "Go", "Rust"} {
+		if _, err := db.Exec("INSERT INTO languages (name, version, dt_release) VALUES (?, '1.0', '2015-06-23')", name); err != nil {
+			t.Fatalf("Sqlite Exec failed: %s", err)
+		}
+	}
+	r := New(NewRunner(db), "sqlite3").Bind("languages", &Language{})
+
+	groups, err := GroupCount(r, "name", nil)
+	if err != nil {
+		t.Fatalf("Failed GroupCount: %s", err)
+	}
+	if len(groups) != 2 || groups[0].Value != "Go" || groups[0].Count != 2 {
+		t.Errorf("Unexpected groups %v", groups)
+	}
+
+	sum, err := SumWhere(r, "id", WithWhereEq("name", "Go"))
+	if err != nil || sum != 3 {
+		t.Errorf("Expected sum 3, got %v (%v)", sum, err)
+	}
+	var max *int64
+	if err := MaxWhere(r, &max, "id", WithWhereEq("name", "COBOL")); err != nil || max != nil {
+		t.Errorf("Expected NULL max, got %v (%v)", max, err)
+	}
+}
+
+func TestPlainStructLoadAllByKeys(t *testing.T) {
+
+	db := getLanguagesDb()
+
+	for _, name := range []string{"Go", "Rust", "Scala"} {
+		if _, err := db.Exec("INSERT INTO languages (name, version, dt_release) VALUES (?, '1.0', '2015-06-23')", name); err != nil {
+			t.Fatalf("Sqlite Exec failed: %s", err)
+		}
+	}
+
+	l := &Language{}
+	l.Recorder = New(NewRunner(db), "sqlite3").Bind("languages", l)
+	recs, err := LoadAllByKeys(l, []interface{}{3, 9, 1})
+	merr, ok := err.(*MissingKeysError)
+	if !ok || len(merr.Keys) != 1 || merr.Keys[0] != 9 {
+		t.Fatalf("Expected key 9 to be missing, got %v", err)
+	}
+	if len(recs) != 2 || recs[0].Interface().(*Language).Name != "Scala" || recs[1].Interface().(*Language).Name != "Go" {
+		t.Errorf("Expected Scala and Go in key order, got %d records", len(recs))
+	}
+}
+
+type uniqueLanguage struct {
+	Id      int64  `stbl:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Name    string `stbl:"name,UNIQUE"`
+	Version string `stbl:"version"`
+}
+
+func TestPlainStructLoadByUnique(t *testing.T) {
+
+	db := getLanguagesDb()
+
+	for _, name := range []string{"Go", "Rust", "Rust"} {
+		if _, err := db.Exec("INSERT INTO languages (name, version) VALUES (?, 'v1')", name); err != nil {
+			t.Fatalf("Sqlite Exec failed: %s", err)
+		}
+	}
+
+	l := &uniqueLanguage{Name: "Go"}
+	r := New(squirrel.NewStmtCacheProxy(db), "sqlite3").Bind("languages", l).(*DbRecorder)
+	if err := r.LoadByUnique("name"); err != nil {
+		t.Fatalf("Failed LoadByUnique: %s", err)
+	}
+	if l.Id != 1 || l.Version != "v1" {
+		t.Errorf("Expected Go to load, got %+v", l)
+	}
+	if ok, err := r.ExistsByUnique("name"); !ok || err != nil {
+		t.Errorf("Expected Go to exist, got %t, %v", ok, err)
+	}
+
+	l.Name = "Scala"
+	if err := r.LoadByUnique("name"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if l.Id != 1 {
+		t.Errorf("Expected a failed load to leave the record alone, got %+v", l)
+	}
+
+	l.Name = "Rust"
+	if err := r.LoadByUnique("name"); !errors.Is(err, ErrAmbiguous) {
+		t.Errorf("Expected ErrAmbiguous, got %v", err)
+	}
+	if ok, err := r.ExistsByUnique("name"); !ok || !errors.Is(err, ErrAmbiguous) {
+		t.Errorf("Expected an ambiguous match, got %t, %v", ok, err)
+	}
+}
+
+type languageTag struct {
+	Id         int64  `stbl:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	LanguageId int64  `stbl:"language_id"`
+	Tag        string `stbl:"tag"`
+}
+
+func TestPlainStructJoin(t *testing.T) {
+
+	db := getLanguagesDb()
+
+	stmt := `
+	CREATE TABLE language_tags (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		language_id INTEGER,
+		tag STRING
+	);
+	INSERT INTO languages (name, version) VALUES ('Go', 'v1'), ('Rust', 'v1');
+	INSERT INTO language_tags (language_id, tag) VALUES (1, 'gc'), (2, 'borrowck'), (1, 'goroutines');
+	`
+	if _, err := db.Exec(stmt); err != nil {
+		t.Fatalf("Sqlite Exec failed: %s", err)
+	}
+
+	l := new(Language)
+	l.Recorder = New(squirrel.NewStmtCacheProxy(db), "sqlite3").Bind("languages", l)
+	tag := new(languageTag)
+	tr := New(squirrel.NewStmtCacheProxy(db), "sqlite3").Bind("language_tags", tag)
+
+	j := Join(l, tr).On("language_tags.language_id = languages.id")
+	if err := j.LoadWhere("language_tags.tag = ?", "borrowck"); err != nil {
+		t.Fatalf("Failed join LoadWhere: %s", err)
+	}
+	if l.Name != "Rust" || tag.LanguageId != 2 {
+		t.Errorf("Expected Rust and its tag, got %q and %+v", l.Name, tag)
+	}
+
+	rows, err := j.ListWhere(func(d Describer, q squirrel.SelectBuilder) (squirrel.SelectBuilder, error) {
+		return q.Where("languages.name = ?", "Go"), nil
+	})
+	if err != nil {
+		t.Fatalf("Failed join ListWhere: %s", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(rows))
+	}
+	for _, row := range rows {
+		if row[0].Interface().(*Language).Name != "Go" || row[1].Interface().(*languageTag).LanguageId != 1 {
+			t.Errorf("Unexpected row %+v, %+v", row[0].Interface(), row[1].Interface())
+		}
+	}
+}
+
+func TestPlainStructQueryInto(t *testing.T) {
+
+	db := getLanguagesDb()
+
+	for _, name := range []string{"Go", "Rust"} {
+		if _, err := db.Exec("INSERT INTO languages (name, version) VALUES (?, 'v1')", name); err != nil {
+			t.Fatalf("Sqlite Exec failed: %s", err)
+		}
+	}
+
+	l := new(Language)
+	r := New(squirrel.NewStmtCacheProxy(db), "sqlite3")
+	r.Bind("languages", l)
+
+	// Columns come back out of order, with an extra one, and without version.
+	err := r.QueryInto("SELECT upper(name) AS shout, name, id FROM languages WHERE name = ?", "Rust")
+	if err != nil {
+		t.Fatalf("Failed QueryInto: %s", err)
+	}
+	if l.Id != 2 || l.Name != "Rust" || l.Version != "" {
+		t.Errorf("Expected Rust without a version, got %+v", l)
+	}
+
+	if err := r.QueryInto("SELECT * FROM languages WHERE name = ?", "Scala"); err != sql.ErrNoRows {
+		t.Errorf("Expected sql.ErrNoRows, got %v", err)
+	}
+
+	recs, err := r.QueryAllInto("SELECT version, name FROM languages ORDER BY name DESC")
+	if err != nil {
+		t.Fatalf("Failed QueryAllInto: %s", err)
+	}
+	if len(recs) != 2 || recs[0].Interface().(*Language).Name != "Rust" || recs[1].Interface().(*Language).Version != "v1" {
+		t.Errorf("Expected Rust then Go, got %d records", len(recs))
+	}
+}
+
+func TestPlainStructListByColumnName(t *testing.T) {
+
+	db := getLanguagesDb()
+
+	stmt := `
+	CREATE TABLE language_tags (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		language_id INTEGER,
+		tag STRING
+	);
+	INSERT INTO languages (name, version) VALUES ('Go', 'v1'), ('Rust', 'v2');
+	INSERT INTO language_tags (language_id, tag) VALUES (2, 'borrowck');
+	`
+	if _, err := db.Exec(stmt); err != nil {
+		t.Fatalf("Sqlite Exec failed: %s", err)
+	}
+
+	l := New(squirrel.NewStmtCacheProxy(db), "sqlite3").Bind("languages", new(Language))
+
+	// Reverse the select list, and add a column from a joined table.
+	items, err := ListWhere(l, func(d Describer, q squirrel.SelectBuilder) (squirrel.SelectBuilder, error) {
+		return q.RemoveColumns().
+			Columns("language_tags.tag", "languages.dt_release", "languages.version", "languages.name", "languages.id").
+			Join("language_tags ON language_tags.language_id = languages.id"), nil
+	})
+	if err != nil {
+		t.Fatalf("Failed ListWhere: %s", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(items))
+	}
+	if got := items[0].Interface().(*Language); got.Id != 2 || got.Name != "Rust" || got.Version != "v2" {
+		t.Errorf("Expected Rust v2, got %+v", got)
+	}
+}
+
+type compressedLanguage struct {
+	Id   int64  `stbl:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Name string `stbl:"name"`
+	Spec string `stbl:"spec,COMPRESSED"`
+}
+
+func TestPlainStructCompressed(t *testing.T) {
+
+	db := getLanguagesDb()
+	if _, err := db.Exec("ALTER TABLE languages ADD COLUMN spec BLOB"); err != nil {
+		t.Fatalf("Sqlite Exec failed: %s", err)
+	}
+	if _, err := db.Exec("INSERT INTO languages (name, spec) VALUES ('Legacy', 'plain text')"); err != nil {
+		t.Fatalf("Sqlite Exec failed: %s", err)
+	}
+
+	spec := strings.Repeat("The Go Programming Language Specification. ", 50)
+	l := &compressedLanguage{Name: "Go", Spec: spec}
+	r := New(squirrel.NewStmtCacheProxy(db), "sqlite3")
+	r.Bind("languages", l)
+	if err := r.Insert(); err != nil {
+		t.Fatalf("Failed Insert: %s", err)
+	}
+
+	var size int
+	if err := db.QueryRow("SELECT length(spec) FROM languages WHERE name = 'Go'").Scan(&size); err != nil {
+		t.Fatalf("Sqlite QueryRow failed: %s", err)
+	}
+	if size >= len(spec) {
+		t.Errorf("Expected the spec to be stored compressed, got %d bytes", size)
+	}
+
+	items, err := List(r, WithOrderBy("id"))
+	if err != nil {
+		t.Fatalf("Failed List: %s", err)
+	}
+	if len(items) != 2 || items[0].Interface().(*compressedLanguage).Spec != "plain text" || items[1].Interface().(*compressedLanguage).Spec != spec {
+		t.Errorf("Expected the legacy and compressed specs back, got %d items", len(items))
+	}
+}
+
+type attachedLanguage struct {
+	Id   int64  `stbl:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Name string `stbl:"name"`
+	Spec string `stbl:"spec_hash"`
+}
+
+func TestPlainStructBlobTable(t *testing.T) {
+
+	db := getLanguagesDb()
+	stmt := `
+	ALTER TABLE languages ADD COLUMN spec_hash TEXT;
+	CREATE TABLE blobs (hash TEXT PRIMARY KEY, data BLOB NOT NULL, refs INTEGER NOT NULL);
+	`
+	if _, err := db.Exec(stmt); err != nil {
+		t.Fatalf("Sqlite Exec failed: %s", err)
+	}
+	blobs := NewBlobTable("blobs")
+	refs := func() map[string]int {
+		m := map[string]int{}
+		rows, err := db.Query("SELECT hash, refs FROM blobs")
+		if err != nil {
+			t.Fatalf("Sqlite Query failed: %s", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var h string
+			var n int
+			rows.Scan(&h, &n)
+			m[h] = n
+		}
+		return m
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := []byte("the spec")
+	recs := make([]*DbRecorder, 2)
+	for i, name := range []string{"Go", "Go+"} {
+		recs[i] = New(NewRunner(tx), "sqlite3")
+		recs[i].Bind("languages", &attachedLanguage{Name: name})
+		if err := blobs.Insert(recs[i], "spec_hash", spec); err != nil {
+			t.Fatalf("Failed blob Insert: %s", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	for i := range recs {
+		recs[i].Init(NewRunner(db), "sqlite3")
+	}
+
+	h := BlobHash(spec)
+	if m := refs(); len(m) != 1 || m[h] != 2 {
+		t.Errorf("Expected one blob with 2 refs, got %v", m)
+	}
+	if data, err := blobs.Load(recs[1], "spec_hash"); err != nil || string(data) != "the spec" {
+		t.Errorf("Expected the spec back, got %q, %v", data, err)
+	}
+
+	if err := blobs.Update(recs[0], "spec_hash", []byte("new spec")); err != nil {
+		t.Fatalf("Failed blob Update: %s", err)
+	}
+	if m := refs(); len(m) != 2 || m[h] != 1 || m[BlobHash([]byte("new spec"))] != 1 {
+		t.Errorf("Expected two blobs with 1 ref each, got %v", m)
+	}
+
+	if err := blobs.Delete(recs[1], "spec_hash"); err != nil {
+		t.Fatalf("Failed blob Delete: %s", err)
+	}
+	if m := refs(); len(m) != 1 || m[h] != 0 {
+		t.Errorf("Expected the old blob to be gone, got %v", m)
+	}
+}
+
+func BenchmarkListWhere(b *testing.B) {
+
+	db := getLanguagesDb()
+	stmt := `
+	WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 100000)
+	INSERT INTO languages (name, version, dt_release) SELECT 'lang' || i, 'v' || i, '2015-06-23' FROM n;
+	`
+	if _, err := db.Exec(stmt); err != nil {
+		b.Fatalf("Sqlite Exec failed: %s", err)
+	}
+	r := New(NewRunner(db), "sqlite3")
+	r.Bind("languages", new(Language))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		items, err := ListWhere(r, func(d Describer, q squirrel.SelectBuilder) (squirrel.SelectBuilder, error) {
+			return q, nil
+		})
+		if err != nil || len(items) != 100000 {
+			b.Fatalf("Expected 100000 items, got %d, %v", len(items), err)
+		}
+	}
+}
+
+func TestPlainStructListEmbeddedRecorder(t *testing.T) {
+
+	db := getLanguagesDb()
+	for _, name := range []string{"Go", "Scala"} {
+		if _, err := db.Exec("INSERT INTO languages (name, version, dt_release) VALUES (?, 'v1', '2015-06-23')", name); err != nil {
+			t.Fatalf("Sqlite Exec failed: %s", err)
+		}
+	}
+
+	l := new(Language)
+	l.Recorder = New(NewRunner(db), "sqlite3").Bind("languages", l)
+
+	items, err := List(l, WithOrderBy("id"))
+	if err != nil {
+		t.Fatalf("Failed List: %s", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(items))
+	}
+	if name := items[1].Record().(*Language).Name; name != "Scala" {
+		t.Errorf("Expected Scala, got %s", name)
+	}
+}
+
+func TestPlainStructListWrapped(t *testing.T) {
+
+	db := getLanguagesDb()
+	for _, name := range []string{"Go", "Scala", "Rust"} {
+		if _, err := db.Exec("INSERT INTO languages (name, version, dt_release) VALUES (?, 'v1', '2015-06-23')", name); err != nil {
+			t.Fatalf("Sqlite Exec failed: %s", err)
+		}
+	}
+
+	r := New(NewRunner(db), "sqlite3").SetMaxRows(2, func(string, uint64) error { return nil })
+	r.Bind("languages", new(Language))
+
+	log := []string{}
+	items, err := List(Wrap(r, logging("audit", &log)), WithOrderBy("id"))
+	if err != nil {
+		t.Fatalf("Failed List: %s", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("Expected MaxRows to cap the list at 2, got %d", len(items))
+	}
+
+	items[1].Record().(*Language).Name = "Scala 3"
+	if err := items[1].Insert(); err != nil {
+		t.Fatalf("Failed Insert: %s", err)
+	}
+	if len(log) != 1 {
+		t.Errorf("Expected listed Recorders to be wrapped, got %v", log)
+	}
+}
+
+func TestPlainStructListCache(t *testing.T) {
+
+	db := getLanguagesDb()
+	for _, name := range []string{"Go", "Scala"} {
+		if _, err := db.Exec("INSERT INTO languages (name, version, dt_release) VALUES (?, 'v1', '2015-06-23')", name); err != nil {
+			t.Fatalf("Sqlite Exec failed: %s", err)
+		}
+	}
+
+	queries := 0
+	r := New(NewRunner(db), "sqlite3").SetListCache(NewListCache(time.Minute))
+	r.SetTracer(TracerFunc(func(context.Context, string, []interface{}, time.Duration, error) {
+		queries++
+	}))
+	r.Bind("languages", new(Language))
+
+	for i := 0; i < 2; i++ {
+		items, err := List(r, WithOrderBy("id"))
+		if err != nil || len(items) != 2 {
+			t.Fatalf("Expected 2 items, got %d, %v", len(items), err)
+		}
+	}
+	if queries != 1 {
+		t.Errorf("Expected the second List to be cached, got %d queries", queries)
+	}
+
+	l := r.Clone(&Language{Name: "Rust", Version: "v1"})
+	if err := l.Insert(); err != nil {
+		t.Fatalf("Failed Insert: %s", err)
+	}
+	items, err := List(r, WithOrderBy("id"))
+	if err != nil || len(items) != 3 {
+		t.Errorf("Expected the insert to invalidate the list, got %d items, %v", len(items), err)
+	}
+}
+
+func TestPlainStructTenant(t *testing.T) {
+
+	db := getLanguagesDb()
+	stmt := `
+	CREATE TABLE invoices (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		org_id INTEGER NOT NULL,
+		total INTEGER
+	);
+	INSERT INTO invoices (org_id, total) VALUES (1, 10), (2, 20);
+	`
+	if _, err := db.Exec(stmt); err != nil {
+		t.Fatalf("Sqlite Exec failed: %s", err)
+	}
+
+	r := New(NewRunner(db), "sqlite3").WithTenant(2)
+	r.Bind("invoices", &invoice{Id: 1})
+	if err := r.Load(); err != sql.ErrNoRows {
+		t.Errorf("Expected another tenant's row to be hidden, got %v", err)
+	}
+	if err := r.Delete(); err != nil {
+		t.Fatalf("Failed Delete: %s", err)
+	}
+
+	items, err := List(r)
+	if err != nil {
+		t.Fatalf("Failed List: %s", err)
+	}
+	if len(items) != 1 || items[0].Record().(*invoice).Total != 20 {
+		t.Errorf("Expected only the tenant's invoice, got %d items", len(items))
+	}
+
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM invoices").Scan(&n); err != nil || n != 2 {
+		t.Errorf("Expected the other tenant's invoice to survive, got %d, %v", n, err)
+	}
+}
+
+func TestPlainStructDefault(t *testing.T) {
+
+	db := getLanguagesDb()
+	stmt := `
+	CREATE TABLE drafts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		title VARCHAR(8) NOT NULL,
+		status TEXT NOT NULL DEFAULT 'a,b',
+		note TEXT NOT NULL
+	);
+	`
+	if _, err := db.Exec(stmt); err != nil {
+		t.Fatalf("Sqlite Exec failed: %s", err)
+	}
+
+	note := "n"
+	d := &draft{Title: "t", Note: &note}
+	r := New(NewRunner(db), "sqlite3")
+	r.Bind("drafts", d)
+	if err := r.Insert(); err != nil {
+		t.Fatalf("Failed Insert: %s", err)
+	}
+	if err := r.Load(); err != nil {
+		t.Fatalf("Failed Load: %s", err)
+	}
+	if d.Status != "a,b" {
+		t.Errorf("Expected the database default, got %q", d.Status)
+	}
+}
+
+func TestPlainStructReadOnly(t *testing.T) {
+
+	db := getLanguagesDb()
+	stmt := `
+	CREATE TABLE docs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		body TEXT,
+		tokens TEXT GENERATED ALWAYS AS (upper(body)) VIRTUAL,
+		created TEXT,
+		touched TEXT
+	);
+	`
+	if _, err := db.Exec(stmt); err != nil {
+		t.Fatalf("Sqlite Exec failed: %s", err)
+	}
+
+	d := &searchDoc{Body: "hello", Created: "c"}
+	r := New(NewRunner(db), "sqlite3")
+	r.Bind("docs", d)
+	if err := r.Insert(); err != nil {
+		t.Fatalf("Failed Insert: %s", err)
+	}
+	d.Body = "world"
+	if err := r.Update(); err != nil {
+		t.Fatalf("Failed Update: %s", err)
+	}
+	if err := r.Load(); err != nil {
+		t.Fatalf("Failed Load: %s", err)
+	}
+	if d.Tokens != "WORLD" {
+		t.Errorf("Expected the generated column to be loaded, got %q", d.Tokens)
+	}
+}
+
+func TestPlainStructNullable(t *testing.T) {
+
+	db := getLanguagesDb()
+	stmt := `
+	CREATE TABLE contacts (
+		id INTEGER PRIMARY KEY,
+		phone TEXT,
+		age INTEGER,
+		seen DATETIME,
+		email TEXT
+	);
+	`
+	if _, err := db.Exec(stmt); err != nil {
+		t.Fatalf("Sqlite Exec failed: %s", err)
+	}
+
+	c := &contact{Id: 1, Age: sql.NullInt64{Int64: 42, Valid: true}}
+	r := New(NewRunner(db), "sqlite3")
+	r.Bind("contacts", c)
+	if err := r.Insert(); err != nil {
+		t.Fatalf("Failed Insert: %s", err)
+	}
+
+	var nulls int
+	if err := db.QueryRow("SELECT COUNT(*) FROM contacts WHERE phone IS NULL AND email IS NULL").Scan(&nulls); err != nil || nulls != 1 {
+		t.Errorf("Expected NULLs to be stored, got %d, %v", nulls, err)
+	}
+
+	c.Email, c.Age = "x", sql.NullInt64{}
+	if err := r.Load(); err != nil {
+		t.Fatalf("Failed Load: %s", err)
+	}
+	if c.Email != "" || c.Age.Int64 != 42 || c.Phone.Valid {
+		t.Errorf("Unexpected contact %+v", c)
+	}
+}
+
+func TestPlainStructUpdateDeleteAll(t *testing.T) {
+
+	db := getLanguagesDb()
+
+	for _, name := range []string{"Go", "Rust", "Scala"} {
+		if _, err := db.Exec("INSERT INTO languages (name, version, dt_release) VALUES (?, '1.0', '2015-06-23')", name); err != nil {
+			t.Fatalf("Sqlite Exec failed: %s", err)
+		}
+	}
+
+	l := &Language{}
+	l.Recorder = New(NewRunner(db), "sqlite3").Bind("languages", l)
+	n, err := UpdateAll(l, []interface{}{1, 3}, map[string]interface{}{"version": "2.0"})
+	if err != nil || n != 2 {
+		t.Fatalf("Expected 2 updated, got %d, %v", n, err)
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM languages WHERE version = '2.0'").Scan(&count); err != nil || count != 2 {
+		t.Errorf("Expected 2 updated rows, got %d, %v", count, err)
+	}
+
+	n, err = DeleteAll(l, []interface{}{2, 3, 9})
+	if err != nil || n != 2 {
+		t.Fatalf("Expected 2 deleted, got %d, %v", n, err)
+	}
+	if err := db.QueryRow("SELECT COUNT(*) FROM languages").Scan(&count); err != nil || count != 1 {
+		t.Errorf("Expected 1 row left, got %d, %v", count, err)
+	}
+}
+
+func TestPlainStructRefreshAfterWrite(t *testing.T) {
+
+	db := getLanguagesDb()
+	trigger := `CREATE TRIGGER languages_version AFTER UPDATE OF name ON languages
+	BEGIN
+		UPDATE languages SET version = version || '+' WHERE id = NEW.id;
+	END`
+	if _, err := db.Exec(trigger); err != nil {
+		t.Fatalf("Sqlite Exec failed: %s", err)
+	}
+
+	l := &Language{Name: "Go", Version: "1.4"}
+	l.Recorder = New(NewRunner(db), "sqlite3").SetRefreshAfterWrite(true).Bind("languages", l)
+	if err := l.Insert(); err != nil {
+		t.Fatalf("Failed Insert: %s", err)
+	}
+
+	l.Name = "Golang"
+	if err := l.Update(); err != nil {
+		t.Fatalf("Failed Update: %s", err)
+	}
+	if l.Version != "1.4+" {
+		t.Errorf("Expected the trigger's version to be reloaded, got %q", l.Version)
+	}
+
+	l.Id = 99
+	if err := l.Update(); err != sql.ErrNoRows {
+		t.Errorf("Expected sql.ErrNoRows for a missing row, got %v", err)
+	}
+}
+
+func TestPlainStructInsertKeyed(t *testing.T) {
+
+	db := getLanguagesDb()
+
+	l := &Language{Id: 42, Name: "Go", Version: "1.4"}
+	l.Recorder = New(NewRunner(db), "sqlite3").Bind("languages", l)
+	if err := l.Recorder.(*DbRecorder).InsertKeyed(); err != nil {
+		t.Fatalf("Failed InsertKeyed: %s", err)
+	}
+
+	lsql := new(Language)
+	if err := lsql.loadFromSql(42, db); err != nil || lsql.Name != "Go" {
+		t.Fatalf("Expected Go with ID 42, got %q, %v", lsql.Name, err)
+	}
+
+	next := &Language{Name: "Rust"}
+	next.Recorder = New(NewRunner(db), "sqlite3").Bind("languages", next)
+	if err := next.Insert(); err != nil {
+		t.Fatalf("Failed Insert: %s", err)
+	}
+	if next.Id != 43 {
+		t.Errorf("Expected the next ID to follow the explicit one, got %d", next.Id)
+	}
+}
+
+func TestPlainStructFirstLast(t *testing.T) {
+
+	db := getLanguagesDb()
+
+	for _, name := range []string{"Go", "Rust", "Scala"} {
+		if _, err := db.Exec("INSERT INTO languages (name, version, dt_release) VALUES (?, '1.0', '2015-06-23')", name); err != nil {
+			t.Fatalf("Sqlite Exec failed: %s", err)
+		}
+	}
+
+	l := &Language{}
+	l.Recorder = New(NewRunner(db), "sqlite3").Bind("languages", l)
+	if err := First(l); err != nil || l.Id != 1 || l.Name != "Go" {
+		t.Errorf("Expected Go first, got %d %q, %v", l.Id, l.Name, err)
+	}
+	if err := Last(l); err != nil || l.Id != 3 || l.Name != "Scala" {
+		t.Errorf("Expected Scala last, got %d %q, %v", l.Id, l.Name, err)
+	}
+	if err := Last(l, "name DESC"); err != nil || l.Name != "Go" {
+		t.Errorf("Expected Go last by descending name, got %q, %v", l.Name, err)
+	}
+	if l.Recorder == nil {
+		t.Error("Expected the embedded Recorder to be kept")
+	}
+}
+
+func TestPlainStructFindOrCreate(t *testing.T) {
+
+	db := getLanguagesDb()
+	if _, err := db.Exec("CREATE UNIQUE INDEX languages_name ON languages (name)"); err != nil {
+		t.Fatalf("Sqlite Exec failed: %s", err)
+	}
+
+	l := &Language{Name: "Go", Version: "1.4"}
+	l.Recorder = New(NewRunner(db), "sqlite3").Bind("languages", l)
+	created, err := l.Recorder.(*DbRecorder).FindOrCreate("name = ?", "Go")
+	if err != nil || !created || l.Id != 1 {
+		t.Fatalf("Expected Go to be created, got %t, %v", created, err)
+	}
+
+	found := &Language{Name: "Go", Version: "2.0"}
+	found.Recorder = New(NewRunner(db), "sqlite3").Bind("languages", found)
+	created, err = found.Recorder.(*DbRecorder).FindOrCreate("name = ?", "Go")
+	if err != nil || created || found.Id != 1 || found.Version != "1.4" {
+		t.Errorf("Expected Go to be found, got %t %q, %v", created, found.Version, err)
+	}
+
+	// A row inserted after the lookup is loaded instead of duplicated.
+	raced := &Language{Name: "Go", Version: "3.0"}
+	rr := New(NewRunner(db), "sqlite3").Bind("languages", raced).(*DbRecorder)
+	created, err = rr.insertIgnore()
+	if err != nil || created {
+		t.Errorf("Expected the conflicting insert to be ignored, got %t, %v", created, err)
+	}
+}
+
+func TestPlainStructListByExample(t *testing.T) {
+
+	db := getLanguagesDb()
+
+	for _, l := range [][2]string{{"Go", "1.4"}, {"Rust", "1.0"}, {"Scala", "1.0"}} {
+		if _, err := db.Exec("INSERT INTO languages (name, version, dt_release) VALUES (?, ?, '2015-06-23')", l[0], l[1]); err != nil {
+			t.Fatalf("Sqlite Exec failed: %s", err)
+		}
+	}
+
+	l := &Language{Version: "1.0"}
+	l.Recorder = New(NewRunner(db), "sqlite3").Bind("languages", l)
+	found, err := ListByExample(l, WithOrderBy("name DESC"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 || found[0].Interface().(*Language).Name != "Scala" {
+		t.Errorf("Expected Scala and Rust, got %d records", len(found))
+	}
+
+	l.Name = "Rust"
+	if err := l.Recorder.(*DbRecorder).LoadByExample(); err != nil || l.Id != 2 {
+		t.Errorf("Expected Rust to load, got %d, %v", l.Id, err)
+	}
+}
+
+func TestPlainStructSpecHelpers(t *testing.T) {
+
+	db := getLanguagesDb()
+
+	for _, name := range []string{"Go", "Rust", "Scala"} {
+		if _, err := db.Exec("INSERT INTO languages (name, version, dt_release) VALUES (?, '1.0', '2015-06-23')", name); err != nil {
+			t.Fatalf("Sqlite Exec failed: %s", err)
+		}
+	}
+
+	l := &Language{}
+	l.Recorder = New(NewRunner(db), "sqlite3").Bind("languages", l)
+	found, err := List(l, WithSpec(And(In("id", []int64{1, 2, 3}), Between("id", 2, 3), ILike("name", "s%"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].Interface().(*Language).Name != "Scala" {
+		t.Errorf("Expected only Scala, got %d records", len(found))
+	}
+}
+
+func TestPlainStructTransact(t *testing.T) {
+
+	db := getLanguagesDb()
+	// Every connection to :memory: is a separate database.
+	db.SetMaxOpenConns(1)
+
+	insert := func(tx Runner, name string) error {
+		return New(tx, "sqlite3").Bind("languages", &Language{Name: name}).Insert()
+	}
+	boom := errors.New("boom")
+	err := Transact(NewRunner(db), "sqlite3", func(tx Runner) error {
+		if err := insert(tx, "Go"); err != nil {
+			return err
+		}
+		err := Transact(tx, "sqlite3", func(tx Runner) error {
+			if err := insert(tx, "Scala"); err != nil {
+				return err
+			}
+			return boom
+		})
+		if err != boom {
+			t.Errorf("Expected the inner error, got %v", err)
+		}
+		return Transact(tx, "sqlite3", func(tx Runner) error {
+			return insert(tx, "Rust")
+		})
+	})
+	if err != nil {
+		t.Fatalf("Failed Transact: %s", err)
+	}
+
+	err = Transact(NewRunner(db), "sqlite3", func(tx Runner) error {
+		if err := insert(tx, "Cobol"); err != nil {
+			return err
+		}
+		return boom
+	})
+	if err != boom {
+		t.Errorf("Expected the outer error, got %v", err)
+	}
+
+	var names string
+	if err := db.QueryRow("SELECT group_concat(name, ',') FROM languages ORDER BY id").Scan(&names); err != nil {
+		t.Fatal(err)
+	}
+	if names != "Go,Rust" {
+		t.Errorf("Expected only the committed inserts, got %q", names)
+	}
+}
+
+func TestPlainStructStrictSingle(t *testing.T) {
+
+	db := getLanguagesDb()
+	l := &Language{}
+	r := New(NewRunner(db), "sqlite3")
+	r.Bind("languages", l)
+	for _, v := range []string{"1.0", "1.1"} {
+		if err := r.Clone(&Language{Name: "Go", Version: v}).Insert(); err != nil {
+			t.Fatalf("Failed Insert: %s", err)
+		}
+	}
+
+	if err := r.LoadWhere("name = ?", "Go"); err != nil {
+		t.Fatalf("Failed LoadWhere: %s", err)
+	}
+
+	l = &Language{}
+	r.SetStrictSingle(true).Bind("languages", l)
+	if err := r.LoadWhere("name = ?", "Go"); !errors.Is(err, ErrMultipleRows) {
+		t.Errorf("Expected ErrMultipleRows, got %v", err)
+	}
+	if l.Id != 0 {
+		t.Errorf("Expected the Record to be unchanged, got %+v", l)
+	}
+	if err := r.LoadWhere("version = ?", "1.1"); err != nil {
+		t.Fatalf("Failed LoadWhere: %s", err)
+	}
+	if l.Id != 2 || l.Name != "Go" {
+		t.Errorf("Expected the single match to be loaded, got %+v", l)
+	}
+}
+
+func TestPlainStructUnitOfWork(t *testing.T) {
+
+	db := getLanguagesDb()
+	db.SetMaxOpenConns(1)
+
+	u := NewUnitOfWork(NewRunner(db), "sqlite3")
+	goLang := &Language{Name: "Go", Version: "1.4"}
+	rust := &Language{Name: "Rust", Version: "nightly"}
+	u.Insert(u.Bind("languages", goLang))
+	u.Insert(u.Bind("languages", rust))
+	if err := u.Flush(); err != nil {
+		t.Fatalf("Failed Flush: %s", err)
+	}
+	if goLang.Id != 1 || rust.Id != 2 {
+		t.Errorf("Expected the inserts to set IDs, got %d and %d", goLang.Id, rust.Id)
+	}
+
+	l := &Language{Id: 1}
+	r := u.Bind("languages", l)
+	if err := r.Load(); err != nil {
+		t.Fatalf("Failed Load: %s", err)
+	}
+	l.Version = "1.5"
+	u.Update(r)
+	u.Delete(u.Bind("languages", &Language{Id: 2}))
+	u.Update(u.Bind("languages", &Language{Name: "Nope"}))
+	if err := u.Flush(); !errors.Is(err, ErrMissingKey) {
+		t.Fatalf("Expected the last update to fail, got %v", err)
+	}
+
+	var names string
+	if err := db.QueryRow("SELECT group_concat(name || ' ' || version, ',') FROM languages ORDER BY id").Scan(&names); err != nil {
+		t.Fatal(err)
+	}
+	if names != "Go 1.4,Rust nightly" {
+		t.Errorf("Expected the failed flush to be rolled back, got %q", names)
+	}
+}
+
+func TestPlainStructUnitOfWorkIdentity(t *testing.T) {
+
+	db := getLanguagesDb()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("INSERT INTO languages (name, version) VALUES ('Go', '1.4')"); err != nil {
+		t.Fatal(err)
+	}
+
+	u := NewUnitOfWork(NewRunner(db), "sqlite3")
+	a, err := u.Load("languages", &Language{Id: 1})
+	if err != nil {
+		t.Fatalf("Failed Load: %s", err)
+	}
+	a.Record().(*Language).Version = "1.5"
+
+	b, err := u.Load("languages", &Language{Id: 1})
+	if err != nil {
+		t.Fatalf("Failed Load: %s", err)
+	}
+	if b.Record() != a.Record() || b.Record().(*Language).Version != "1.5" {
+		t.Errorf("Expected the same Record from both loads, got %+v", b.Record())
+	}
+	if _, err := u.Load("languages", &Language{Id: 2}); err != sql.ErrNoRows {
+		t.Errorf("Expected sql.ErrNoRows for a missing row, got %v", err)
+	}
+
+	u.Update(b)
+	if err := u.Flush(); err != nil {
+		t.Fatalf("Failed Flush: %s", err)
+	}
+	c, err := u.Load("languages", &Language{Id: 1})
+	if err != nil {
+		t.Fatalf("Failed Load: %s", err)
+	}
+	if c.Record() == a.Record() || c.Record().(*Language).Version != "1.5" {
+		t.Errorf("Expected a fresh Record after Flush, got %+v", c.Record())
+	}
+	if err := u.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPlainStructImport(t *testing.T) {
+
+	db := getLanguagesDb()
+	db.SetMaxOpenConns(1)
+	r := New(NewRunner(db), "sqlite3").Bind("languages", &Language{})
+
+	in := "id,Name,Version,dt_release\n7,Go,1.4,2009-11-10\n8,Rust,nightly,2015-05-15 00:00:00\n9,C,K&R,nope\n"
+	n, err := ImportCSV(r, strings.NewReader(in), ImportOptions{Keyed: true, BatchSize: 2})
+	if n != 2 || err == nil || !strings.Contains(err.Error(), "row 4") {
+		t.Fatalf("Expected the second batch to fail on row 4, got %d, %v", n, err)
+	}
+
+	in = `[{"name": "Python", "version": "3"}, {"name": "Perl", "version": "5"}]`
+	if n, err := ImportJSON(r, strings.NewReader(in), ImportOptions{}); err != nil || n != 2 {
+		t.Fatalf("Expected 2 rows, got %d, %v", n, err)
+	}
+
+	var names string
+	if err := db.QueryRow("SELECT group_concat(id || ' ' || name, ',') FROM languages ORDER BY id").Scan(&names); err != nil {
+		t.Fatal(err)
+	}
+	if names != "7 Go,8 Rust,9 Python,10 Perl" {
+		t.Errorf("Unexpected rows %q", names)
+	}
+
+	l := &Language{Id: 7}
+	if err := New(NewRunner(db), "sqlite3").Bind("languages", l).Load(); err != nil {
+		t.Fatal(err)
+	}
+	if l.DtRelease.Format("2006-01-02") != "2009-11-10" {
+		t.Errorf("Expected the release date to be imported, got %s", l.DtRelease)
+	}
+}
+
+func TestPlainStructExport(t *testing.T) {
+
+	db := getLanguagesDb()
+	if _, err := db.Exec(`INSERT INTO languages (name, version, dt_release) VALUES
+		('Go', '1.4', '2009-11-10 00:00:00'), ('Rust', 'nightly', '2015-05-15 00:00:00')`); err != nil {
+		t.Fatal(err)
+	}
+	r := New(NewRunner(db), "sqlite3").Bind("languages", &Language{})
+
+	var buf bytes.Buffer
+	n, err := ExportWhere(r, WithOrderBy("id"), &buf, FormatCSV)
+	if err != nil || n != 2 {
+		t.Fatalf("Expected 2 rows, got %d, %v", n, err)
+	}
+	expect := "id,name,version,dt_release\n1,Go,1.4,2009-11-10T00:00:00Z\n2,Rust,nightly,2015-05-15T00:00:00Z\n"
+	if buf.String() != expect {
+		t.Errorf("Expected %q, got %q", expect, buf.String())
+	}
+
+	buf.Reset()
+	if _, err := ExportWhere(r, Compose(WithColumns("name", "id"), WithWhereEq("name", "Rust")), &buf, FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "[\n{\"name\":\"Rust\",\"id\":2}\n]\n"; buf.String() != expect {
+		t.Errorf("Expected %q, got %q", expect, buf.String())
+	}
+
+	buf.Reset()
+	if _, err := ExportWhere(r, WithOrderBy("id"), &buf, FormatNDJSON); err != nil {
+		t.Fatal(err)
+	}
+	other := getLanguagesDb()
+	other.SetMaxOpenConns(1)
+	or := New(NewRunner(other), "sqlite3").Bind("languages", &Language{})
+	if n, err := ImportJSON(or, &buf, ImportOptions{Keyed: true}); err != nil || n != 2 {
+		t.Fatalf("Expected the export to import, got %d, %v", n, err)
+	}
+	l := &Language{Id: 2}
+	if err := New(NewRunner(other), "sqlite3").Bind("languages", l).Load(); err != nil {
+		t.Fatal(err)
+	}
+	if l.Name != "Rust" || l.DtRelease.Year() != 2015 {
+		t.Errorf("Unexpected imported record %+v", l)
+	}
+}
+
+func TestPlainStructDynamicRecord(t *testing.T) {
+
+	db := getLanguagesDb()
+	rec, err := NewDynamicRecord(
+		DynamicColumn{Name: "id", Key: true, Auto: true},
+		DynamicColumn{Name: "name"},
+		DynamicColumn{Name: "version"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := New(NewRunner(db), "sqlite3")
+	r.Bind("languages", rec)
+	rec.Set("name", "Go")
+	rec.Set("version", "stable")
+	if err := r.Insert(); err != nil {
+		t.Fatalf("Failed Insert: %s", err)
+	}
+	if rec.Get("id") != int64(1) {
+		t.Errorf("Expected the ID to be set, got %#v", rec.Get("id"))
+	}
+
+	rec.Set("version", "nightly")
+	if err := r.Update(); err != nil {
+		t.Fatalf("Failed Update: %s", err)
+	}
+
+	other, _ := DynamicOf(r.Clone(nil).Record())
+	other.Set("id", 1)
+	if err := New(NewRunner(db), "sqlite3").Bind("languages", other).Load(); err != nil {
+		t.Fatalf("Failed Load: %s", err)
+	}
+	if other.Get("name") != "Go" || other.Get("version") != "nightly" {
+		t.Errorf("Unexpected loaded values %#v", other.Map())
+	}
+
+	items, err := List(r)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("Expected one item, got %d, %v", len(items), err)
+	}
+	row, err := DynamicOf(items[0].Record())
+	if err != nil || row.Get("name") != "Go" {
+		t.Errorf("Unexpected listed record %v, %v", row, err)
+	}
+
+	if err := r.Delete(); err != nil {
+		t.Fatalf("Failed Delete: %s", err)
+	}
+}
+
+func TestPlainStructDescribeTable(t *testing.T) {
+
+	db := getLanguagesDb()
+	runner := NewRunner(db)
+	ts, err := DescribeTable(runner, "sqlite3", "languages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ts.Key, []string{"id"}) || len(ts.Columns) != 4 {
+		t.Fatalf("Unexpected schema %+v", ts)
+	}
+	id, _ := ts.Column("id")
+	if !id.Key || !id.Auto || id.Type != "INTEGER" {
+		t.Errorf("Unexpected id column %+v", id)
+	}
+	dt, _ := ts.Column("dt_release")
+	if dt.Key || !dt.Nullable || dt.Default == "" {
+		t.Errorf("Unexpected dt_release column %+v", dt)
+	}
+
+	if _, err := DescribeTable(runner, "sqlite3", "nope"); !errors.Is(err, ErrNoTable) {
+		t.Errorf("Expected ErrNoTable, got %v", err)
+	}
+
+	rec, err := ts.NewRecord()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Set("name", "Go")
+	rec.Set("version", "stable")
+	if err := New(runner, "sqlite3").Bind("languages", rec).Insert(); err != nil {
+		t.Fatalf("Failed Insert: %s", err)
+	}
+	if rec.Get("id") != int64(1) {
+		t.Errorf("Expected the ID to be set, got %#v", rec.Get("id"))
+	}
+}
+
+func TestPlainStructLoadByKey(t *testing.T) {
+
+	db := getLanguagesDb()
+	if _, err := db.Exec("INSERT INTO languages (name, version) VALUES ('Go', 'stable')"); err != nil {
+		t.Fatal(err)
+	}
+	l := &Language{}
+	r := New(NewRunner(db), "sqlite3")
+	r.Bind("languages", l)
+	if err := r.LoadByKey("1"); err != nil {
+		t.Fatalf("Failed LoadByKey: %s", err)
+	}
+	if l.Id != 1 || l.Name != "Go" {
+		t.Errorf("Unexpected record %+v", l)
+	}
+	if err := r.LoadByKey(2); err != sql.ErrNoRows {
+		t.Errorf("Expected sql.ErrNoRows, got %v", err)
+	}
+}
+
+func TestPlainStructCanceledContext(t *testing.T) {
+	db := getLanguagesDb()
+	if _, err := db.Exec("INSERT INTO languages (name, version) VALUES ('Go', 'stable')"); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	l := &Language{Id: 1}
+	r := New(NewRunner(db), "sqlite3").SetContext(ctx)
+	r.Bind("languages", l)
+	if err := r.Load(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected Load to be canceled, got %v", err)
+	}
+	if _, err := List(r); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected List to be canceled, got %v", err)
+	}
+
+	err := Transact(NewRunner(db), "sqlite3", func(tx Runner) error {
+		l.Name = "Golang"
+		return New(tx, "sqlite3").SetContext(ctx).Bind("languages", l).Update()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected Update to be canceled, got %v", err)
+	}
+
+	r.SetContext(context.Background())
+	if err := r.Load(); err != nil || l.Name != "Go" {
+		t.Errorf("Expected the row to be unchanged, got %+v: %v", l, err)
+	}
+}
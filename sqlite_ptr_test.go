@@ -1,3 +1,4 @@
+//go:build sqlite
 // +build sqlite
 
 package structable
@@ -193,7 +194,7 @@ func TestStructWithPointerUpdate(t *testing.T) {
 
 	msql.loadFromSql(lastId, db)
 	if !CompareStringPtr(msql.Genre, stringPtr("Crime Thriller")) {
-		t.Log("msql.Genre: %v\n", *msql.Genre)
+		t.Logf("msql.Genre: %v", *msql.Genre)
 		t.Fatal("Update should ignore nil pointers")
 	}
 }
@@ -0,0 +1,155 @@
+package structable
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// ErrDryRun is returned by statements that read from the database while a
+// DbRecorder is in dry-run mode. See SetDryRun.
+var ErrDryRun = errors.New("statement not run: dry run")
+
+// Statement is an SQL statement that was not run, because its DbRecorder was
+// in dry-run mode.
+type Statement struct {
+	// Op is the operation, such as OpLoad or OpInsert.
+	Op string
+	// Query and Args are the statement, as it would have been sent to the
+	// database.
+	Query string
+	Args  []interface{}
+}
+
+func (st Statement) String() string {
+	return st.Op + " " + st.Query
+}
+
+// LoadSQL returns the statement that Load would run.
+func (s *DbRecorder) LoadSQL() (string, []interface{}, error) {
+	return s.build(s.loadQuery())
+}
+
+// InsertSQL returns the statement that Insert would run.
+//
+// The Record is checked as by Insert, and its TENANT field is set, but
+// EXTERNAL fields are not stored. Expressions set with SetExpr are kept for
+// the next write.
+func (s *DbRecorder) InsertSQL() (string, []interface{}, error) {
+	if err := s.setTenant(); err != nil {
+		return "", nil, err
+	}
+	if err := s.validate(OpInsert); err != nil {
+		return "", nil, err
+	}
+	return s.build(s.insertQuery())
+}
+
+// UpdateSQL returns the statement that Update would run. As with InsertSQL,
+// the Record is checked, but EXTERNAL fields are not stored.
+//
+// If SetRefreshAfterWrite is on, other flavors than postgres also reload the
+// Record with a second statement, which is not returned.
+func (s *DbRecorder) UpdateSQL() (string, []interface{}, error) {
+	if err := s.checkKeys(); err != nil {
+		return "", nil, err
+	}
+	if err := s.setTenant(); err != nil {
+		return "", nil, err
+	}
+	if err := s.validate(OpUpdate); err != nil {
+		return "", nil, err
+	}
+	return s.build(s.updateQuery())
+}
+
+// DeleteSQL returns the statement that Delete would run.
+func (s *DbRecorder) DeleteSQL() (string, []interface{}, error) {
+	if err := s.checkKeys(); err != nil {
+		return "", nil, err
+	}
+	return s.build(s.deleteQuery())
+}
+
+// build renders a statement as it is sent to the database.
+func (s *DbRecorder) build(q squirrel.Sqlizer) (string, []interface{}, error) {
+	if err := s.ready(); err != nil {
+		return "", nil, err
+	}
+	query, args, err := q.ToSql()
+	if err != nil {
+		return "", nil, err
+	}
+	return s.comment(query), args, nil
+}
+
+// SetDryRun sets whether the DbRecorder records its statements instead of
+// running them. The recorded statements are returned by DryRunStatements.
+//
+//	r.SetDryRun(true)
+//	if err := migrate(r); err != nil {
+//		return err
+//	}
+//	for _, st := range r.DryRunStatements() {
+//		fmt.Println(st.Query, st.Args)
+//	}
+//
+// Statements that write report success, without changing the Record:
+// LastInsertId and RowsAffected are 0, and nothing is read back with
+// RETURNING. Statements that read, including the reload of
+// SetRefreshAfterWrite, are recorded and return ErrDryRun, since there is no
+// data to return. Tracers, Metrics, and History are not told about statements
+// that were not run.
+//
+// As with SetHistory, the statements are shared with copies of the
+// DbRecorder. Turning dry-run mode on again starts a new, empty list.
+func (s *DbRecorder) SetDryRun(on bool) *DbRecorder {
+	s.dryRun = nil
+	if on {
+		s.dryRun = &dryRun{}
+	}
+	return s
+}
+
+// DryRunStatements returns the statements that were recorded in dry-run mode,
+// oldest first, or nil if dry-run mode is off.
+func (s *DbRecorder) DryRunStatements() []Statement {
+	dr := s.dryRun
+	if dr == nil {
+		return nil
+	}
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+	return append([]Statement{}, dr.stmts...)
+}
+
+// dryRun holds the statements recorded in dry-run mode.
+type dryRun struct {
+	mu    sync.Mutex
+	stmts []Statement
+}
+
+func (dr *dryRun) record(op, query string, args []interface{}) {
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+	dr.stmts = append(dr.stmts, Statement{Op: op, Query: query, Args: args})
+}
+
+// dryResult is the sql.Result of a statement that was not run. setAutos leaves
+// the Record untouched when it is given a dryResult.
+type dryResult struct{}
+
+func (dryResult) LastInsertId() (int64, error) { return 0, nil }
+func (dryResult) RowsAffected() (int64, error) { return 0, nil }
+
+// dryRow is the row returned by a write that was not run. scanInto leaves the
+// Record untouched when it is given a dryRow.
+type dryRow struct{}
+
+func (dryRow) Scan(...interface{}) error { return nil }
+
+// isWrite reports whether op changes the database.
+func isWrite(op string) bool {
+	return op == OpInsert || op == OpUpdate || op == OpDelete
+}
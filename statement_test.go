@@ -0,0 +1,112 @@
+package structable
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStatementSQL(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "mysql").Bind("test_table", newStool()).(*DbRecorder)
+
+	for _, c := range []struct {
+		name   string
+		build  func() (string, []interface{}, error)
+		expect string
+		args   []interface{}
+	}{
+		{"load", r.LoadSQL, "SELECT number_of_legs, material, color FROM test_table WHERE id = ? AND id_two = ?", []interface{}{1, 2}},
+		{"insert", r.InsertSQL, "INSERT INTO test_table (id_two,number_of_legs,material) VALUES (?,?,?)", []interface{}{2, 3, "Stainless Steel"}},
+		{"update", r.UpdateSQL, "UPDATE test_table SET material = ?, number_of_legs = ? WHERE id = ? AND id_two = ?", []interface{}{"Stainless Steel", 3, 1, 2}},
+		{"delete", r.DeleteSQL, "DELETE FROM test_table WHERE id = ? AND id_two = ?", []interface{}{1, 2}},
+	} {
+		query, args, err := c.build()
+		if err != nil {
+			t.Fatalf("%s: %s", c.name, err)
+		}
+		if query != c.expect {
+			t.Errorf("%s: expected %q, got %q", c.name, c.expect, query)
+		}
+		if !reflect.DeepEqual(args, c.args) {
+			t.Errorf("%s: expected args %v, got %v", c.name, c.args, args)
+		}
+	}
+	if db.LastExecSql != "" || db.LastQueryRowSql != "" {
+		t.Errorf("Expected no statements to run, got %q and %q", db.LastExecSql, db.LastQueryRowSql)
+	}
+
+	pg := New(db, "postgres").Bind("test_table", newStool()).(*DbRecorder)
+	query, _, err := pg.InsertSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "INSERT INTO test_table (id_two,number_of_legs,material) VALUES ($1,$2,$3) RETURNING id,id_two,number_of_legs,material,color"
+	if query != expect {
+		t.Errorf("Expected %q, got %q", expect, query)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	db := &DBStub{}
+	stool := newStool()
+	r := New(db, "mysql").SetDryRun(true)
+	r.Bind("test_table", stool)
+
+	stool.Id = 7
+	if err := r.Insert(); err != nil {
+		t.Fatal(err)
+	}
+	if stool.Id != 7 {
+		t.Errorf("Expected the Record to be left alone, got id %d", stool.Id)
+	}
+	if err := r.Update(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Load(); err != ErrDryRun {
+		t.Errorf("Expected ErrDryRun from Load, got %v", err)
+	}
+	if _, err := List(r); err != ErrDryRun {
+		t.Errorf("Expected ErrDryRun from List, got %v", err)
+	}
+	if db.LastExecSql != "" || db.LastQueryRowSql != "" || db.LastQuerySql != "" {
+		t.Errorf("Expected no statements to run, got %q, %q, %q", db.LastExecSql, db.LastQueryRowSql, db.LastQuerySql)
+	}
+
+	stmts := r.DryRunStatements()
+	ops := []string{OpInsert, OpUpdate, OpDelete, OpLoad, OpList}
+	if len(stmts) != len(ops) {
+		t.Fatalf("Expected %d statements, got %v", len(ops), stmts)
+	}
+	for i, op := range ops {
+		if stmts[i].Op != op {
+			t.Errorf("Expected statement %d to be %s, got %s", i, op, stmts[i].Op)
+		}
+	}
+	expect := "INSERT INTO test_table (id_two,number_of_legs,material) VALUES (?,?,?)"
+	if stmts[0].Query != expect {
+		t.Errorf("Expected %q, got %q", expect, stmts[0].Query)
+	}
+
+	pg := New(db, "postgres").SetDryRun(true)
+	pg.Bind("test_table", stool)
+	if err := pg.Insert(); err != nil {
+		t.Fatal(err)
+	}
+	if stool.Material != "Stainless Steel" || db.LastQueryRowSql != "" {
+		t.Errorf("Expected nothing to be read back, got %+v", stool)
+	}
+
+	r.SetDryRun(false)
+	if r.DryRunStatements() != nil {
+		t.Error("Expected no statements with dry-run mode off")
+	}
+	if err := r.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if db.LastExecSql == "" {
+		t.Error("Expected the statement to run with dry-run mode off")
+	}
+}
@@ -0,0 +1,152 @@
+package stest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/structable"
+)
+
+// Fixtures inserts test data into a database, through Recorders.
+//
+// Each table that fixture files may contain is registered with the type of
+// Record that is stored in it. A fixture file maps table names to lists of
+// rows, each keyed by column name:
+//
+//	{
+//		"users": [
+//			{"id": 1, "email": "matt@example.com", "name": "Matt"}
+//		],
+//		"posts": [
+//			{"user_id": 1, "title": "Hello"}
+//		]
+//	}
+//
+// Tables are filled in the order they were registered, whatever their order in
+// the file, so that rows can refer to rows of tables registered before them.
+//
+// Values are converted to the field types as by DbRecorder.SetValues. A row
+// that gives every primary key column is inserted with InsertKeyed, so the
+// keys are kept; otherwise it is inserted with Insert, and its AUTO_INCREMENT
+// key is generated.
+type Fixtures struct {
+	db        structable.Runner
+	flavor    string
+	tables    []string
+	types     map[string]reflect.Type
+	unmarshal func([]byte, interface{}) error
+}
+
+// NewFixtures creates a Fixtures that inserts into db.
+func NewFixtures(db structable.Runner, flavor string) *Fixtures {
+	return &Fixtures{
+		db:        db,
+		flavor:    flavor,
+		types:     map[string]reflect.Type{},
+		unmarshal: json.Unmarshal,
+	}
+}
+
+// Register sets the type of Record that is stored in a table. The proto is
+// only used for its type.
+func (f *Fixtures) Register(table string, proto structable.Record) *Fixtures {
+	if _, ok := f.types[table]; !ok {
+		f.tables = append(f.tables, table)
+	}
+	f.types[table] = reflect.Indirect(reflect.ValueOf(proto)).Type()
+	return f
+}
+
+// SetUnmarshal sets the function that decodes fixture data. The default is
+// json.Unmarshal. For YAML, use the Unmarshal function of a YAML package:
+//
+//	f.SetUnmarshal(yaml.Unmarshal)
+//
+// The function must be able to decode into a
+// map[string][]map[string]interface{}.
+func (f *Fixtures) SetUnmarshal(fn func([]byte, interface{}) error) *Fixtures {
+	f.unmarshal = fn
+	return f
+}
+
+// Load decodes fixture data, and inserts its rows. It returns the inserted
+// Records by table, in the order of their rows.
+func (f *Fixtures) Load(data []byte) (map[string][]structable.Record, error) {
+	var rows map[string][]map[string]interface{}
+	if err := f.unmarshal(data, &rows); err != nil {
+		return nil, fmt.Errorf("decoding fixtures: %s", err)
+	}
+	for table := range rows {
+		if _, ok := f.types[table]; !ok {
+			return nil, fmt.Errorf("no Record is registered for table %s", table)
+		}
+	}
+
+	loaded := make(map[string][]structable.Record, len(rows))
+	for _, table := range f.tables {
+		keyed := false
+		for i, vals := range rows[table] {
+			rec := reflect.New(f.types[table]).Interface()
+			r := structable.New(f.db, f.flavor)
+			r.Bind(table, rec)
+			if err := r.SetValues(vals); err != nil {
+				return loaded, fmt.Errorf("row %d of table %s: %s", i, table, err)
+			}
+			if hasKeys(r, vals) {
+				keyed = true
+				if err := r.InsertKeyed(); err != nil {
+					return loaded, fmt.Errorf("row %d of table %s: %s", i, table, err)
+				}
+			} else if err := r.Insert(); err != nil {
+				return loaded, fmt.Errorf("row %d of table %s: %s", i, table, err)
+			}
+			loaded[table] = append(loaded[table], rec)
+		}
+		if keyed {
+			r := structable.New(f.db, f.flavor)
+			r.Bind(table, reflect.New(f.types[table]).Interface())
+			if err := r.ResetSequences(); err != nil {
+				return loaded, err
+			}
+		}
+	}
+	return loaded, nil
+}
+
+// LoadFile reads a fixture file, and inserts its rows, as by Load.
+func (f *Fixtures) LoadFile(path string) (map[string][]structable.Record, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	loaded, err := f.Load(data)
+	if err != nil {
+		return loaded, fmt.Errorf("%s: %s", filepath.Base(path), err)
+	}
+	return loaded, nil
+}
+
+// LoadFixtures loads a fixture file with f, and fails t if that fails.
+func LoadFixtures(t testing.TB, f *Fixtures, path string) map[string][]structable.Record {
+	t.Helper()
+	loaded, err := f.LoadFile(path)
+	if err != nil {
+		t.Fatalf("stest: %s", err)
+	}
+	return loaded
+}
+
+// hasKeys reports whether vals has a value for every primary key column.
+func hasKeys(r *structable.DbRecorder, vals map[string]interface{}) bool {
+	key := r.Key()
+	for _, k := range key {
+		if _, ok := vals[k]; !ok {
+			return false
+		}
+	}
+	return len(key) > 0
+}
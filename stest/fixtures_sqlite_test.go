@@ -0,0 +1,43 @@
+//go:build sqlite
+// +build sqlite
+
+package stest
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/Masterminds/structable"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestFixturesSqlite(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt := `
+	CREATE TABLE owners (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT);
+	CREATE TABLE pets (id INTEGER PRIMARY KEY AUTOINCREMENT, owner_id INTEGER REFERENCES owners (id), name TEXT);
+	`
+	if _, err := db.Exec(stmt); err != nil {
+		t.Fatal(err)
+	}
+	runner := structable.NewRunner(db)
+	f := NewFixtures(runner, "sqlite3").Register("owners", &owner{}).Register("pets", &pet{})
+	loaded := LoadFixtures(t, f, "testdata/fixtures.json")
+
+	if p := loaded["pets"][1].(*pet); p.Id != 8 {
+		t.Errorf("Expected the pet without an ID to follow the keyed one, got %+v", p)
+	}
+
+	p := &pet{Id: 8}
+	r := structable.New(runner, "sqlite3")
+	r.Bind("pets", p)
+	if err := r.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if p.OwnerId != 2 || p.Name != "Tom" {
+		t.Errorf("Expected Tom to be stored, got %+v", p)
+	}
+}
@@ -0,0 +1,64 @@
+package stest
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("stest.update", false, "rewrite golden SQL files instead of comparing with them")
+
+// normalize collapses runs of whitespace, so that SQL can be written across
+// lines in tests and golden files.
+func normalize(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// AssertSQL fails t if got and want differ, other than in whitespace.
+func AssertSQL(t testing.TB, got, want string) {
+	t.Helper()
+	if normalize(got) != normalize(want) {
+		t.Errorf("stest: expected SQL\n\t%s\ngot\n\t%s", normalize(want), normalize(got))
+	}
+}
+
+// AssertQueries fails t unless the statements run on db are want, in order.
+func AssertQueries(t testing.TB, db *DB, want ...string) {
+	t.Helper()
+	got := db.Queries()
+	if len(got) != len(want) {
+		t.Errorf("stest: expected %d statements, got %d:\n\t%s", len(want), len(got), strings.Join(got, "\n\t"))
+		return
+	}
+	for i := range got {
+		AssertSQL(t, got[i], want[i])
+	}
+}
+
+// AssertGolden fails t if got differs from the SQL in the golden file
+// testdata/name.sql, other than in whitespace.
+//
+// Run the tests with -stest.update to write got to the golden file instead:
+//
+//	go test ./... -stest.update
+func AssertGolden(t testing.TB, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".sql")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("stest: %s", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(got+"\n"), 0644); err != nil {
+			t.Fatalf("stest: %s", err)
+		}
+		return
+	}
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("stest: %s (run with -stest.update to create it)", err)
+	}
+	AssertSQL(t, got, string(want))
+}
@@ -0,0 +1,145 @@
+/*
+Package stest helps test code that uses structable, without a database.
+
+DB is a stub database handle. It records the statements that Recorders run on
+it, and returns canned results, so tests can check the SQL that an operation
+generates:
+
+	func TestRename(t *testing.T) {
+		db := &stest.DB{}
+		u := NewUser(db, "postgres")
+		u.Id, u.Name = 1, "Matt"
+		if err := u.Update(); err != nil {
+			t.Fatal(err)
+		}
+		stest.AssertQueries(t, db, "UPDATE users SET name = $1 WHERE id = $2")
+	}
+
+AssertSQL, AssertQueries, and AssertGolden compare statements with expected
+or golden SQL, ignoring differences in whitespace.
+
+Fixtures loads test data from JSON or YAML files into a real database,
+through Recorders, so that the rows go through the same tags and conversions
+as the application's own writes. A *sql.DB made with sqlmock can be used for
+either, wrapped with structable.NewRunner.
+*/
+package stest
+
+import (
+	"database/sql"
+	"sync"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// Statement is a statement that was run on a DB.
+type Statement struct {
+	Query string
+	Args  []interface{}
+}
+
+// DB is a stub database handle that records statements instead of running
+// them. It is a squirrel.DBProxyBeginner, so it can be used wherever a
+// structable.Runner is expected.
+//
+// Exec returns Result, or a Result with LastInsertId and RowsAffected of 1 if
+// Result is nil. Query returns no rows, and QueryRow returns a row whose Scan
+// leaves its arguments untouched. If Err is set, every method returns it
+// instead.
+//
+// A DB is safe for concurrent use.
+type DB struct {
+	Err    error
+	Result sql.Result
+
+	mu    sync.Mutex
+	stmts []Statement
+}
+
+// Statements returns the statements that were run, oldest first.
+func (db *DB) Statements() []Statement {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return append([]Statement{}, db.stmts...)
+}
+
+// Queries returns the SQL of the statements that were run, oldest first.
+func (db *DB) Queries() []string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	queries := make([]string, len(db.stmts))
+	for i, st := range db.stmts {
+		queries[i] = st.Query
+	}
+	return queries
+}
+
+// Last returns the statement that was run last, or an empty Statement.
+func (db *DB) Last() Statement {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if len(db.stmts) == 0 {
+		return Statement{}
+	}
+	return db.stmts[len(db.stmts)-1]
+}
+
+// Reset forgets the statements that were run.
+func (db *DB) Reset() {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.stmts = nil
+}
+
+func (db *DB) record(query string, args []interface{}) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.stmts = append(db.stmts, Statement{Query: query, Args: args})
+}
+
+// Prepare records nothing, and returns a nil statement.
+func (db *DB) Prepare(query string) (*sql.Stmt, error) {
+	return nil, db.Err
+}
+
+// Exec records a statement, and returns Result.
+func (db *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	db.record(query, args)
+	if db.Err != nil {
+		return nil, db.Err
+	}
+	if db.Result != nil {
+		return db.Result, nil
+	}
+	return result{}, nil
+}
+
+// Query records a statement, and returns no rows.
+func (db *DB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	db.record(query, args)
+	return nil, db.Err
+}
+
+// QueryRow records a statement, and returns a row that scans nothing.
+func (db *DB) QueryRow(query string, args ...interface{}) squirrel.RowScanner {
+	db.record(query, args)
+	return &squirrel.Row{RowScanner: row{db.Err}}
+}
+
+// Begin returns a nil transaction.
+func (db *DB) Begin() (*sql.Tx, error) {
+	return nil, db.Err
+}
+
+type result struct{}
+
+func (result) LastInsertId() (int64, error) { return 1, nil }
+func (result) RowsAffected() (int64, error) { return 1, nil }
+
+type row struct {
+	err error
+}
+
+func (r row) Scan(...interface{}) error {
+	return r.err
+}
@@ -0,0 +1,113 @@
+package stest
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Masterminds/structable"
+)
+
+type owner struct {
+	Id   int    `stbl:"id,PRIMARY_KEY,SERIAL"`
+	Name string `stbl:"name"`
+}
+
+type pet struct {
+	Id      int    `stbl:"id,PRIMARY_KEY,SERIAL"`
+	OwnerId int    `stbl:"owner_id"`
+	Name    string `stbl:"name"`
+}
+
+// fakeT records failures instead of failing the test.
+type fakeT struct {
+	testing.TB
+	errs []string
+}
+
+func (f *fakeT) Helper() {}
+func (f *fakeT) Errorf(format string, args ...interface{}) {
+	f.errs = append(f.errs, fmt.Sprintf(format, args...))
+}
+
+func TestDB(t *testing.T) {
+	db := &DB{}
+	r := structable.New(db, "postgres")
+	r.Bind("owners", &owner{Id: 1, Name: "Matt"})
+
+	if err := r.Update(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	AssertQueries(t, db,
+		"UPDATE owners SET name = $1 WHERE id = $2",
+		"DELETE FROM owners WHERE id = $1")
+	if last := db.Last(); len(last.Args) != 1 || last.Args[0] != 1 {
+		t.Errorf("Expected the delete's args, got %v", last.Args)
+	}
+
+	db.Reset()
+	db.Err = errors.New("down")
+	if err := r.Load(); err != db.Err {
+		t.Errorf("Expected Err from Load, got %v", err)
+	}
+	if len(db.Statements()) != 1 {
+		t.Errorf("Expected the failed statement to be recorded, got %v", db.Statements())
+	}
+}
+
+func TestAssertSQL(t *testing.T) {
+	AssertSQL(t, "SELECT id\n\tFROM owners", "SELECT id FROM owners")
+
+	ft := &fakeT{}
+	AssertSQL(ft, "SELECT id FROM owners", "SELECT name FROM owners")
+	if len(ft.errs) != 1 {
+		t.Errorf("Expected a mismatch, got %v", ft.errs)
+	}
+
+	ft = &fakeT{}
+	AssertQueries(ft, &DB{}, "SELECT 1")
+	if len(ft.errs) != 1 {
+		t.Errorf("Expected a missing statement, got %v", ft.errs)
+	}
+}
+
+func TestAssertGolden(t *testing.T) {
+	r := structable.New(&DB{}, "postgres")
+	r.Bind("owners", &owner{Id: 1, Name: "Matt"})
+	query, _, err := r.UpdateSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertGolden(t, "owner_update", query)
+}
+
+func TestFixtures(t *testing.T) {
+	db := &DB{}
+	f := NewFixtures(db, "mysql").Register("owners", &owner{}).Register("pets", &pet{})
+	loaded := LoadFixtures(t, f, "testdata/fixtures.json")
+
+	AssertQueries(t, db,
+		"INSERT INTO owners (id,name) VALUES (?,?)",
+		"INSERT INTO owners (id,name) VALUES (?,?)",
+		"INSERT INTO pets (id,owner_id,name) VALUES (?,?,?)",
+		"INSERT INTO pets (owner_id,name) VALUES (?,?)")
+	if len(loaded["owners"]) != 2 || len(loaded["pets"]) != 2 {
+		t.Fatalf("Expected every row to be returned, got %v", loaded)
+	}
+	if p := loaded["pets"][0].(*pet); p.Id != 7 || p.OwnerId != 1 || p.Name != "Rex" {
+		t.Errorf("Expected the first pet to be set from its row, got %+v", p)
+	}
+	if p := loaded["pets"][1].(*pet); p.Id != 1 {
+		t.Errorf("Expected the generated ID, got %+v", p)
+	}
+
+	if _, err := NewFixtures(db, "mysql").Load([]byte(`{"owners": []}`)); err == nil {
+		t.Error("Expected an unregistered table to fail")
+	}
+	if _, err := f.Load([]byte(`{"owners": [{"nope": 1}]}`)); err == nil {
+		t.Error("Expected an unknown column to fail")
+	}
+}
@@ -0,0 +1,3 @@
+UPDATE owners
+SET name = $1
+WHERE id = $2
@@ -0,0 +1,362 @@
+// Command struct2schema reads Go source and generates CREATE TABLE statements.
+//
+// It is the inverse of schema2struct: every struct with at least one field
+// tagged with `stbl` is rendered as a table definition for the chosen
+// database flavor.
+//
+// Install it with:
+//
+//	go install github.com/Masterminds/structable/struct2schema@latest
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Masterminds/structable"
+	"github.com/Masterminds/structable/migrate"
+)
+
+var version = "DEV"
+
+// Usage describes the command.
+const Usage = `Read Go source and generate SQL schemata for Structable structs.
+
+Each argument is a Go file or a directory of Go files. If none is given, the
+current directory is read.
+
+Usage:
+
+	struct2schema [flags] [path ...]
+
+Flags:
+`
+
+type options struct {
+	flavor, output, migrations, types, tags string
+	showVersion                             bool
+}
+
+// table is a struct that has been found in the source.
+type table struct {
+	Struct string
+	Name   string
+	Cols   []migrate.ColumnDef
+}
+
+func main() {
+	o := options{}
+	flag.StringVar(&o.flavor, "d", "postgres", "The database flavor: postgres, mysql, or sqlite3.")
+	flag.StringVar(&o.output, "f", "", "The file to send the output. Defaults to STDOUT.")
+	flag.StringVar(&o.migrations, "m", "", "Write one timestamped migration file per table into this directory instead.")
+	flag.StringVar(&o.types, "t", "", "The list of struct names to render, comma separated. Defaults to all.")
+	flag.StringVar(&o.tags, "k", "stbl", "The struct tags to read columns from, in order, comma separated. For example stbl,db,gorm.")
+	flag.BoolVar(&o.showVersion, "version", false, "Print the version and exit.")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, Usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if o.showVersion {
+		fmt.Println(version)
+		return
+	}
+
+	paths := flag.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	if err := run(o, paths); err != nil {
+		fmt.Fprintf(os.Stderr, "struct2schema: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(o options, paths []string) error {
+	structable.SetTagKey(strings.Split(o.tags, ",")...)
+	tables := []*table{}
+	for _, p := range paths {
+		t, err := parsePath(p, o.flavor)
+		if err != nil {
+			return err
+		}
+		tables = append(tables, t...)
+	}
+	tables = filter(tables, o.types)
+
+	if o.migrations != "" {
+		return writeMigrations(o.migrations, o.flavor, tables, time.Now())
+	}
+
+	out := io.Writer(os.Stdout)
+	if o.output != "" {
+		f, err := os.Create(o.output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	for _, t := range tables {
+		fmt.Fprintf(out, "-- %s\n%s\n\n", t.Struct, migrate.CreateTableSql(o.flavor, t.Name, t.Cols))
+	}
+	return nil
+}
+
+func filter(tables []*table, types string) []*table {
+	if types == "" {
+		return tables
+	}
+	want := map[string]bool{}
+	for _, t := range strings.Split(types, ",") {
+		want[strings.TrimSpace(t)] = true
+	}
+	res := []*table{}
+	for _, t := range tables {
+		if want[t.Struct] {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+func writeMigrations(dir, flavor string, tables []*table, now time.Time) error {
+	stamp := now.UTC().Format("20060102150405")
+	for _, t := range tables {
+		name := filepath.Join(dir, fmt.Sprintf("%s_create_%s.sql", stamp, t.Name))
+		ddl := migrate.CreateTableSql(flavor, t.Name, t.Cols) + "\n"
+		if err := os.WriteFile(name, []byte(ddl), 0644); err != nil {
+			return err
+		}
+		fmt.Println(name)
+	}
+	return nil
+}
+
+// parsePath parses a file or a directory.
+func parsePath(path, flavor string) ([]*table, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	files := []*ast.File{}
+	if fi.IsDir() {
+		notTest := func(fi os.FileInfo) bool { return !strings.HasSuffix(fi.Name(), "_test.go") }
+		pkgs, err := parser.ParseDir(fset, path, notTest, 0)
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, 0, len(pkgs))
+		for n := range pkgs {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			fnames := make([]string, 0, len(pkgs[n].Files))
+			for fn := range pkgs[n].Files {
+				fnames = append(fnames, fn)
+			}
+			sort.Strings(fnames)
+			for _, fn := range fnames {
+				files = append(files, pkgs[n].Files[fn])
+			}
+		}
+	} else {
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	return tablesFromFiles(files, flavor), nil
+}
+
+// tablesFromFiles finds every stbl-tagged struct in the files.
+func tablesFromFiles(files []*ast.File, flavor string) []*table {
+	names := tableNameMethods(files)
+	tables := []*table{}
+	for _, f := range files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			ts, ok := n.(*ast.TypeSpec)
+			if !ok {
+				return true
+			}
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok {
+				return true
+			}
+			t := structTable(ts.Name.Name, st, flavor)
+			if len(t.Cols) == 0 {
+				return true
+			}
+			if n, ok := names[ts.Name.Name]; ok {
+				t.Name = n
+			}
+			tables = append(tables, t)
+			return true
+		})
+	}
+	return tables
+}
+
+// tableNameMethods finds TableName() methods that return a string literal.
+func tableNameMethods(files []*ast.File) map[string]string {
+	names := map[string]string{}
+	for _, f := range files {
+		for _, d := range f.Decls {
+			fn, ok := d.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || fn.Name.Name != "TableName" || fn.Body == nil || len(fn.Body.List) != 1 {
+				continue
+			}
+			ret, ok := fn.Body.List[0].(*ast.ReturnStmt)
+			if !ok || len(ret.Results) != 1 {
+				continue
+			}
+			lit, ok := ret.Results[0].(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				continue
+			}
+			name, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				continue
+			}
+			names[receiverName(fn.Recv.List[0].Type)] = name
+		}
+	}
+	return names
+}
+
+func receiverName(e ast.Expr) string {
+	if s, ok := e.(*ast.StarExpr); ok {
+		e = s.X
+	}
+	if id, ok := e.(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
+
+func structTable(name string, st *ast.StructType, flavor string) *table {
+	t := &table{Struct: name, Name: snakeCase(name)}
+	for _, f := range st.Fields.List {
+		if f.Tag == nil {
+			continue
+		}
+		raw, err := strconv.Unquote(f.Tag.Value)
+		if err != nil {
+			continue
+		}
+		tag := reflect.StructTag(raw)
+
+		// schema2struct records the table name on a tablename tag.
+		if tn := tag.Get("tablename"); tn != "" {
+			t.Name = tn
+			continue
+		}
+
+		sf := reflect.StructField{Tag: tag}
+		if len(f.Names) > 0 {
+			sf.Name = f.Names[0].Name
+		}
+		stbl, ok := structable.LookupTag(sf)
+		if !ok {
+			continue
+		}
+		typ, nullable := goType(f.Type)
+		t.Cols = append(t.Cols, migrate.TagColumnDef(stbl, migrate.SqlType(flavor, typ), nullable))
+	}
+	return t
+}
+
+// knownTypes maps source type names to their reflect types.
+var knownTypes = map[string]reflect.Type{
+	"bool":            reflect.TypeOf(false),
+	"string":          reflect.TypeOf(""),
+	"int":             reflect.TypeOf(int(0)),
+	"int8":            reflect.TypeOf(int8(0)),
+	"int16":           reflect.TypeOf(int16(0)),
+	"int32":           reflect.TypeOf(int32(0)),
+	"int64":           reflect.TypeOf(int64(0)),
+	"uint":            reflect.TypeOf(uint(0)),
+	"uint8":           reflect.TypeOf(uint8(0)),
+	"uint16":          reflect.TypeOf(uint16(0)),
+	"uint32":          reflect.TypeOf(uint32(0)),
+	"uint64":          reflect.TypeOf(uint64(0)),
+	"float32":         reflect.TypeOf(float32(0)),
+	"float64":         reflect.TypeOf(float64(0)),
+	"[]byte":          reflect.TypeOf([]byte{}),
+	"time.Time":       reflect.TypeOf(time.Time{}),
+	"sql.NullString":  reflect.TypeOf(sql.NullString{}),
+	"sql.NullInt64":   reflect.TypeOf(sql.NullInt64{}),
+	"sql.NullInt32":   reflect.TypeOf(sql.NullInt32{}),
+	"sql.NullFloat64": reflect.TypeOf(sql.NullFloat64{}),
+	"sql.NullBool":    reflect.TypeOf(sql.NullBool{}),
+	"sql.NullTime":    reflect.TypeOf(sql.NullTime{}),
+}
+
+// goType resolves a field's type expression, and reports whether it is nullable.
+//
+// Types that cannot be resolved from source alone are treated as strings.
+func goType(e ast.Expr) (reflect.Type, bool) {
+	nullable := false
+	if s, ok := e.(*ast.StarExpr); ok {
+		nullable = true
+		e = s.X
+	}
+	name := typeName(e)
+	if strings.HasPrefix(name, "sql.Null") {
+		nullable = true
+	}
+	if t, ok := knownTypes[name]; ok {
+		return t, nullable
+	}
+	return knownTypes["string"], nullable
+}
+
+func typeName(e ast.Expr) string {
+	switch t := e.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		return typeName(t.X) + "." + t.Sel.Name
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return "[]" + typeName(t.Elt)
+		}
+	}
+	return ""
+}
+
+// snakeCase converts a Go name to a table name: LineItem becomes line_item.
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/Masterminds/structable/migrate"
+)
+
+const source = `package models
+
+import "database/sql"
+
+type Stool struct {
+	Id       int64          ` + "`stbl:\"id,PRIMARY_KEY,AUTO_INCREMENT\"`" + `
+	Legs     int            ` + "`stbl:\"number_of_legs\"`" + `
+	Material string         ` + "`stbl:\"material,SIZE(32)\"`" + `
+	Color    *string        ` + "`stbl:\"color\"`" + `
+	Maker    sql.NullString ` + "`stbl:\"maker\"`" + `
+	Note     string         ` + "`stbl:\"note,NULLABLE\"`" + `
+	Price    float64        ` + "`stbl:\"price,TYPE=NUMERIC(10,2)\"`" + `
+	internal string
+}
+
+func (s *Stool) TableName() string { return "stools" }
+
+type untagged struct {
+	Name string
+}
+`
+
+func parseSource(t *testing.T, flavor string) []*table {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "models.go", source, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tablesFromFiles([]*ast.File{f}, flavor)
+}
+
+func TestStructTable(t *testing.T) {
+	tests := map[string]string{
+		"postgres": "CREATE TABLE stools (\n" +
+			"\tid BIGSERIAL NOT NULL,\n" +
+			"\tnumber_of_legs BIGINT NOT NULL,\n" +
+			"\tmaterial VARCHAR(32) NOT NULL,\n" +
+			"\tcolor TEXT,\n" +
+			"\tmaker TEXT,\n" +
+			"\tnote TEXT,\n" +
+			"\tprice NUMERIC(10,2) NOT NULL,\n" +
+			"\tPRIMARY KEY (id)\n);",
+		"mysql": "CREATE TABLE stools (\n" +
+			"\tid BIGINT AUTO_INCREMENT NOT NULL,\n" +
+			"\tnumber_of_legs BIGINT NOT NULL,\n" +
+			"\tmaterial VARCHAR(32) NOT NULL,\n" +
+			"\tcolor TEXT,\n" +
+			"\tmaker TEXT,\n" +
+			"\tnote TEXT,\n" +
+			"\tprice NUMERIC(10,2) NOT NULL,\n" +
+			"\tPRIMARY KEY (id)\n);",
+		"sqlite3": "CREATE TABLE stools (\n" +
+			"\tid INTEGER PRIMARY KEY AUTOINCREMENT,\n" +
+			"\tnumber_of_legs INTEGER NOT NULL,\n" +
+			"\tmaterial VARCHAR(32) NOT NULL,\n" +
+			"\tcolor TEXT,\n" +
+			"\tmaker TEXT,\n" +
+			"\tnote TEXT,\n" +
+			"\tprice NUMERIC(10,2) NOT NULL\n);",
+	}
+	for flavor, expect := range tests {
+		tables := parseSource(t, flavor)
+		if len(tables) != 1 || tables[0].Struct != "Stool" || tables[0].Name != "stools" {
+			t.Fatalf("%s: unexpected tables %+v", flavor, tables)
+		}
+		if got := migrate.CreateTableSql(flavor, tables[0].Name, tables[0].Cols); got != expect {
+			t.Errorf("%s: expected\n%s\ngot\n%s", flavor, expect, got)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	tables := parseSource(t, "postgres")
+	if got := filter(tables, "Other"); len(got) != 0 {
+		t.Errorf("Expected no tables, got %d", len(got))
+	}
+	if got := filter(tables, " Stool ,Other"); len(got) != 1 {
+		t.Errorf("Expected one table, got %d", len(got))
+	}
+}
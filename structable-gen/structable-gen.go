@@ -0,0 +1,512 @@
+// Command structable-gen generates reflection-free methods for Structable
+// structs.
+//
+// For each struct, it writes the methods of structable.Generated, which a
+// DbRecorder calls instead of reading the struct with reflection. Run it with
+// go:generate:
+//
+//	//go:generate structable-gen -t User,Order
+//
+// Install it with:
+//
+//	go install github.com/Masterminds/structable/structable-gen@latest
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/structable"
+)
+
+var version = "DEV"
+
+// Usage describes the command.
+const Usage = `Generate reflection-free methods for Structable structs.
+
+Each argument is a Go file or a directory of Go files. If none is given, the
+current directory is read. The methods for each one are written to a file in
+the same directory.
+
+Usage:
+
+	structable-gen [flags] [path ...]
+
+Flags:
+`
+
+type options struct {
+	output, types, tags string
+	showVersion         bool
+}
+
+func main() {
+	o := options{}
+	flag.StringVar(&o.output, "o", "structable_gen.go", "The name of the file to write, in the directory of each path.")
+	flag.StringVar(&o.types, "t", "", "The list of struct names to generate methods for, comma separated. Defaults to all.")
+	flag.StringVar(&o.tags, "k", "stbl", "The struct tags to read columns from, in order, comma separated. For example stbl,db,gorm.")
+	flag.BoolVar(&o.showVersion, "version", false, "Print the version and exit.")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, Usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if o.showVersion {
+		fmt.Println(version)
+		return
+	}
+
+	paths := flag.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	if err := run(o, paths); err != nil {
+		fmt.Fprintf(os.Stderr, "structable-gen: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(o options, paths []string) error {
+	structable.SetTagKey(strings.Split(o.tags, ",")...)
+	want := map[string]bool{}
+	if o.types != "" {
+		for _, t := range strings.Split(o.types, ",") {
+			want[strings.TrimSpace(t)] = true
+		}
+	}
+
+	found := map[string]bool{}
+	for _, p := range paths {
+		dir, files, err := parsePath(p)
+		if err != nil {
+			return err
+		}
+		src, err := generate(files, want, found)
+		if err != nil {
+			return err
+		}
+		if src == nil {
+			continue
+		}
+		name := filepath.Join(dir, o.output)
+		if err := os.WriteFile(name, src, 0644); err != nil {
+			return err
+		}
+		fmt.Println(name)
+	}
+	for name := range want {
+		if !found[name] {
+			return fmt.Errorf("struct %s not found", name)
+		}
+	}
+	return nil
+}
+
+// parsePath parses a file, or the non-test files of a directory. It returns
+// the directory that the output belongs in.
+func parsePath(path string) (string, []*ast.File, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	fset := token.NewFileSet()
+	if !fi.IsDir() {
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return "", nil, err
+		}
+		return filepath.Dir(path), []*ast.File{f}, nil
+	}
+
+	notTest := func(fi os.FileInfo) bool { return !strings.HasSuffix(fi.Name(), "_test.go") }
+	pkgs, err := parser.ParseDir(fset, path, notTest, 0)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(pkgs) != 1 {
+		return "", nil, fmt.Errorf("%s: expected one package, found %d", path, len(pkgs))
+	}
+	files := []*ast.File{}
+	for _, pkg := range pkgs {
+		names := make([]string, 0, len(pkg.Files))
+		for n := range pkg.Files {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			files = append(files, pkg.Files[n])
+		}
+	}
+	return path, files, nil
+}
+
+// record is a struct that methods are generated for.
+type record struct {
+	Name   string
+	Fields []*recordField
+}
+
+// recordField is a field of a record that is mapped to a column.
+type recordField struct {
+	Name, Column string
+	Type         ast.Expr
+	ptr          bool
+
+	key, auto, omitInsert, omitUpdate, hasDefault, nullable bool
+}
+
+// generate writes the methods for the wanted structs in the files, or for all
+// of them if want is empty, and adds their names to found. It returns nil if
+// there are none.
+func generate(files []*ast.File, want, found map[string]bool) ([]byte, error) {
+	var recs []*record
+	imports := map[string]string{}
+	used := map[string]bool{}
+
+	for _, f := range files {
+		fileImports := importNames(f)
+		for _, d := range f.Decls {
+			gd, ok := d.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok || (len(want) > 0 && !want[ts.Name.Name]) {
+					continue
+				}
+				rec, err := parseRecord(ts.Name.Name, st)
+				if err != nil {
+					return nil, err
+				}
+				if rec == nil {
+					continue
+				}
+				for _, rf := range rec.Fields {
+					for _, pkg := range packagesOf(rf.Type) {
+						path, ok := fileImports[pkg]
+						if !ok {
+							return nil, fmt.Errorf("%s.%s: cannot find the import of %s", rec.Name, rf.Name, pkg)
+						}
+						imports[pkg] = path
+					}
+				}
+				found[ts.Name.Name] = true
+				recs = append(recs, rec)
+			}
+		}
+	}
+	if len(recs) == 0 {
+		return nil, nil
+	}
+
+	body := &bytes.Buffer{}
+	for _, r := range recs {
+		writeRecord(body, r, used)
+	}
+
+	out := &bytes.Buffer{}
+	fmt.Fprintf(out, "// Code generated by structable-gen. DO NOT EDIT.\n\npackage %s\n\n", files[0].Name.Name)
+	pkgs := make([]string, 0, len(used))
+	for pkg := range used {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	if len(pkgs) > 0 {
+		out.WriteString("import (\n")
+		for _, pkg := range pkgs {
+			path := imports[pkg]
+			if filepath.Base(path) == pkg {
+				fmt.Fprintf(out, "\t%q\n", path)
+			} else {
+				fmt.Fprintf(out, "\t%s %q\n", pkg, path)
+			}
+		}
+		out.WriteString(")\n\n")
+	}
+	out.Write(body.Bytes())
+	return format.Source(out.Bytes())
+}
+
+// importNames maps the package names used in a file to their import paths.
+func importNames(f *ast.File) map[string]string {
+	names := map[string]string{}
+	for _, spec := range f.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		name := filepath.Base(path)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		names[name] = path
+	}
+	return names
+}
+
+// packagesOf returns the packages that a type expression refers to.
+func packagesOf(e ast.Expr) []string {
+	var pkgs []string
+	ast.Inspect(e, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok {
+				pkgs = append(pkgs, id.Name)
+			}
+			return false
+		}
+		return true
+	})
+	return pkgs
+}
+
+// parseRecord reads the tagged fields of a struct, as Bind would. It returns
+// nil if the struct has none, or if it cannot be generated for.
+func parseRecord(name string, st *ast.StructType) (*record, error) {
+	rec := &record{Name: name}
+	for _, f := range st.Fields.List {
+		if f.Tag == nil {
+			continue
+		}
+		raw, err := strconv.Unquote(f.Tag.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid tag %s", name, f.Tag.Value)
+		}
+		tag := reflect.StructTag(raw)
+		if len(f.Names) == 0 {
+			if _, ok := structable.LookupTag(reflect.StructField{Tag: tag}); ok {
+				return nil, fmt.Errorf("%s: embedded fields cannot be mapped to columns", name)
+			}
+			continue
+		}
+
+		for _, id := range f.Names {
+			if !id.IsExported() {
+				continue
+			}
+			stbl, ok := structable.LookupTag(reflect.StructField{Name: id.Name, Tag: tag})
+			if !ok {
+				continue
+			}
+			rf, err := parseField(id.Name, stbl, f.Type)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %s", name, id.Name, err)
+			}
+			if rf == nil {
+				fmt.Fprintf(os.Stderr, "structable-gen: skipping %s, because %s is COMPRESSED or EXTERNAL\n", name, id.Name)
+				return nil, nil
+			}
+			rec.Fields = append(rec.Fields, rf)
+		}
+	}
+	if len(rec.Fields) == 0 {
+		return nil, nil
+	}
+	return rec, nil
+}
+
+// parseField reads the options of a field's tag. Unknown options are errors,
+// since Bind would silently ignore them.
+func parseField(name, tag string, typ ast.Expr) (*recordField, error) {
+	rf := &recordField{Name: name, Column: name, Type: typ}
+	_, rf.ptr = typ.(*ast.StarExpr)
+
+	parts := structable.SplitTag(tag)
+	if len(parts) > 0 {
+		rf.Column = parts[0]
+	}
+	for _, p := range parts[1:] {
+		p = strings.TrimSpace(p)
+		switch {
+		case p == "", strings.HasPrefix(p, "TYPE="), strings.HasPrefix(p, "RESTRICTED="):
+			continue
+		case strings.HasPrefix(p, "DEFAULT(") && strings.HasSuffix(p, ")"):
+			rf.hasDefault = true
+			continue
+		case strings.HasPrefix(p, "SIZE(") && strings.HasSuffix(p, ")"):
+			if _, err := strconv.Atoi(strings.TrimSpace(p[5 : len(p)-1])); err != nil {
+				return nil, fmt.Errorf("invalid option %s", p)
+			}
+			continue
+		}
+		switch p {
+		case "PRIMARY_KEY", "PRIMARY KEY":
+			rf.key = true
+		case "AUTO_INCREMENT", "SERIAL", "AUTO INCREMENT":
+			rf.auto = true
+		case "OMIT_INSERT":
+			rf.omitInsert = true
+		case "OMIT_UPDATE":
+			rf.omitUpdate = true
+		case "READONLY", "READ_ONLY":
+			rf.omitInsert, rf.omitUpdate = true, true
+		case "NULLABLE":
+			rf.nullable = true
+		case "NUMERIC", "UNIQUE", "TENANT", "TOLERATE_MISSING", "NOT_NULL", "NOT NULL":
+		case "COMPRESSED", "EXTERNAL":
+			return nil, nil
+		default:
+			return nil, fmt.Errorf("unknown option %s", p)
+		}
+	}
+	return rf, nil
+}
+
+// signature describes the fields as structable's fieldSignature does.
+func (r *record) signature() string {
+	parts := make([]string, len(r.Fields))
+	for i, f := range r.Fields {
+		p := []string{f.Name + ":" + f.Column}
+		for _, o := range []struct {
+			on   bool
+			name string
+		}{
+			{f.key, "PRIMARY_KEY"},
+			{f.auto, "AUTO_INCREMENT"},
+			{f.omitInsert, "OMIT_INSERT"},
+			{f.omitUpdate, "OMIT_UPDATE"},
+			{f.hasDefault, "DEFAULT"},
+			{f.nullable, "NULLABLE"},
+		} {
+			if o.on {
+				p = append(p, o.name)
+			}
+		}
+		parts[i] = strings.Join(p, " ")
+	}
+	return strings.Join(parts, ",")
+}
+
+// writeRecord writes the methods for one struct, and records the packages
+// that they use.
+func writeRecord(w *bytes.Buffer, r *record, used map[string]bool) {
+	use := func(e ast.Expr) string {
+		for _, pkg := range packagesOf(e) {
+			used[pkg] = true
+		}
+		return types.ExprString(e)
+	}
+
+	fmt.Fprintf(w, "// StructableSignature describes the fields that the methods of %s were\n// generated for.\n", r.Name)
+	fmt.Fprintf(w, "func (x *%s) StructableSignature() string {\n\treturn %q\n}\n\n", r.Name, r.signature())
+
+	var all, nokeys []string
+	for _, f := range r.Fields {
+		all = append(all, strconv.Quote(f.Column))
+		if !f.key {
+			nokeys = append(nokeys, strconv.Quote(f.Column))
+		}
+	}
+	fmt.Fprintf(w, "// StructableColumns returns the columns of %s.\n", r.Name)
+	fmt.Fprintf(w, "func (x *%s) StructableColumns(withKeys bool) []string {\n", r.Name)
+	fmt.Fprintf(w, "\tif withKeys {\n\t\treturn []string{%s}\n\t}\n", strings.Join(all, ", "))
+	fmt.Fprintf(w, "\treturn []string{%s}\n}\n\n", strings.Join(nokeys, ", "))
+
+	fmt.Fprintf(w, "// StructableFieldReferences returns references to the fields of %s.\n", r.Name)
+	fmt.Fprintf(w, "func (x *%s) StructableFieldReferences(withKeys bool) []interface{} {\n", r.Name)
+	fmt.Fprintf(w, "\trefs := make([]interface{}, 0, %d)\n", len(r.Fields))
+	for _, f := range r.Fields {
+		if f.key {
+			w.WriteString("\tif withKeys {\n")
+		}
+		if f.ptr {
+			fmt.Fprintf(w, "\tif x.%[1]s == nil {\n\t\tx.%[1]s = new(%[2]s)\n\t}\n", f.Name, use(f.Type.(*ast.StarExpr).X))
+			fmt.Fprintf(w, "\trefs = append(refs, x.%s)\n", f.Name)
+		} else {
+			fmt.Fprintf(w, "\trefs = append(refs, &x.%s)\n", f.Name)
+		}
+		if f.key {
+			w.WriteString("\t}\n")
+		}
+	}
+	w.WriteString("\treturn refs\n}\n\n")
+
+	fmt.Fprintf(w, "// StructableColValLists returns the columns and values of %s to write.\n", r.Name)
+	fmt.Fprintf(w, "func (x *%s) StructableColValLists(insert, withKeys, withAutos bool) ([]string, []interface{}) {\n", r.Name)
+	fmt.Fprintf(w, "\tcols := make([]string, 0, %[1]d)\n\tvals := make([]interface{}, 0, %[1]d)\n", len(r.Fields))
+	for _, f := range r.Fields {
+		if f.omitInsert && f.omitUpdate {
+			continue
+		}
+		var conds []string
+		if f.key {
+			conds = append(conds, "withKeys")
+		}
+		if f.auto {
+			conds = append(conds, "withAutos")
+		}
+		if f.omitInsert {
+			conds = append(conds, "!insert")
+		}
+		if f.omitUpdate {
+			conds = append(conds, "insert")
+		}
+		if f.ptr {
+			conds = append(conds, fmt.Sprintf("x.%s != nil", f.Name))
+		} else if f.hasDefault {
+			conds = append(conds, fmt.Sprintf("(!insert || x.%s != %s)", f.Name, zeroValue(f.Type, use)))
+		}
+
+		indent := "\t"
+		if len(conds) > 0 {
+			fmt.Fprintf(w, "\tif %s {\n", strings.Join(conds, " && "))
+			indent = "\t\t"
+		}
+		fmt.Fprintf(w, "%scols = append(cols, %q)\n", indent, f.Column)
+		if f.nullable && !f.ptr {
+			fmt.Fprintf(w, "%[1]sif x.%[2]s == %[3]s {\n%[1]s\tvals = append(vals, nil)\n%[1]s} else {\n%[1]s\tvals = append(vals, x.%[2]s)\n%[1]s}\n", indent, f.Name, zeroValue(f.Type, use))
+		} else {
+			fmt.Fprintf(w, "%svals = append(vals, x.%s)\n", indent, f.Name)
+		}
+		if len(conds) > 0 {
+			w.WriteString("\t}\n")
+		}
+	}
+	w.WriteString("\treturn cols, vals\n}\n\n")
+
+	fmt.Fprintf(w, "// StructableWhereIds returns the primary key of %s.\n", r.Name)
+	fmt.Fprintf(w, "func (x *%s) StructableWhereIds() map[string]interface{} {\n\treturn map[string]interface{}{\n", r.Name)
+	for _, f := range r.Fields {
+		if f.key {
+			fmt.Fprintf(w, "\t\t%q: x.%s,\n", f.Column, f.Name)
+		}
+	}
+	w.WriteString("\t}\n}\n\n")
+}
+
+// zeroValue returns an expression for the zero value of a type, for use in a
+// comparison. The type must be comparable.
+func zeroValue(e ast.Expr, use func(ast.Expr) string) string {
+	switch t := e.(type) {
+	case *ast.StarExpr, *ast.MapType, *ast.FuncType, *ast.ChanType, *ast.InterfaceType:
+		return "nil"
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return "nil"
+		}
+	case *ast.Ident:
+		switch t.Name {
+		case "string":
+			return `""`
+		case "bool":
+			return "false"
+		case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64",
+			"uintptr", "byte", "rune", "float32", "float64", "complex64", "complex128":
+			return "0"
+		}
+	}
+	return "*new(" + use(e) + ")"
+}
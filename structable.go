@@ -117,22 +117,73 @@ The Stbl Tag
 
 The `stbl` tag is of the form:
 
-	stbl:"field_name [,PRIMARY_KEY[,AUTO_INCREMENT]]"
+	stbl:"field_name [,PRIMARY_KEY[,AUTO_INCREMENT]][,UNIQUE][,TYPE=sql_type][,NUMERIC][,COMPRESSED][,EXTERNAL][,TENANT][,TOLERATE_MISSING][,RESTRICTED=role|role][,NOT_NULL][,DEFAULT(value)][,SIZE(n)][,READONLY|OMIT_INSERT|OMIT_UPDATE][,NULLABLE]"
 
 The field name is passed verbatim to the database. So `fieldName` will go to the database as `fieldName`.
 Structable is not at all opinionated about how you name your tables or fields. Some databases are, though, so
 you may need to be careful about your own naming conventions.
 
+If the tag omits the field name, as in `stbl:",PRIMARY_KEY"` or `stbl:""`, the column is named by
+the field name, converted to snake case: `UserID` goes to the database as `user_id`. See
+SetNamingStrategy.
+
 `PRIMARY_KEY` tells Structable that this field is (one of the pieces of) the primary key. Aliases: 'PRIMARY KEY'
 
 `AUTO_INCREMENT` tells Structable that this field is created by the database, and should never
 be assigned during an Insert(). Aliases: SERIAL, AUTO INCREMENT
 
+`UNIQUE` tells Structable that no two records share a value in this column, so it can be used
+to look up a single record. See DbRecorder.LoadByUnique and DbRecorder.ExistsByUnique.
+
+`TYPE=` declares the SQL type of the column, for example `TYPE=VARCHAR(64)`. Bind checks that the
+field's Go type can hold it. See DbRecorder.BindError.
+
+`NUMERIC` tells Structable to scan this field exactly, returning an error instead of rounding
+a NUMERIC or DECIMAL value that the field cannot hold. See DbRecorder.SetExactNumeric.
+
+`COMPRESSED` tells Structable to gzip a string or []byte field before it is written, and to
+decompress it when it is read. The column must hold binary data (BYTEA, BLOB). Compressed values
+start with a short header, and values without it are read as they are, so existing rows need no
+migration.
+
+`EXTERNAL` tells Structable to keep a string, []byte, or Blob field in a BlobStore (such as S3)
+instead of the database. The column stores only the key of the payload. See DbRecorder.SetBlobStore.
+
+`TENANT` marks the column that holds the tenant of each row. Statements are restricted
+to one tenant's rows. See DbRecorder.WithTenant.
+
+`TOLERATE_MISSING` marks a column that may not exist yet, such as a column that is added
+online after the code that uses it is deployed. See DbRecorder.SetMissingColumns.
+
+`RESTRICTED=` names the roles that may read the column, separated by |, for example
+`RESTRICTED=admin`. See DbRecorder.ColumnFilter.
+
+`NOT_NULL` declares that the column cannot hold NULL. Insert and Update return ErrNotNull
+instead of writing the field's zero value, unless the column has a DEFAULT. Aliases: 'NOT NULL'
+
+`DEFAULT(value)` declares the column's default, as an SQL literal or expression, for example
+`DEFAULT('draft')` or `DEFAULT(0)`. Insert leaves zero-valued DEFAULT columns out, so that the
+database fills them in.
+
+`SIZE(n)` declares the maximum length of a string or []byte column. Insert and Update return
+ErrTooLong for longer values. The migrate package creates such columns as VARCHAR(n).
+
+`READONLY` marks a column that the database maintains, such as a generated column or one set by
+a trigger. It is loaded, but never written by Insert or Update. `OMIT_INSERT` and `OMIT_UPDATE`
+leave the column out of only one of them. Aliases: READ_ONLY
+
+`NULLABLE` stores the zero value of a field as NULL, and loads NULL as the zero value, so that a
+plain string or int can map to a nullable column. Pointer fields and the sql.Null* types need no
+tag: a nil pointer or an invalid sql.NullString is NULL.
+
+Structs that are already tagged for sqlx (`db`) or gorm can be bound without stbl tags.
+See SetTagKey and DbRecorder.SetTagKeys.
+
 Limitations
 
 Things Structable doesn't do (by design)
 
-	- Guess table or column names. You must specify these.
+	- Guess table names, unless you ask for it with DbRecorder.BindAuto.
 	- Handle relations between tables.
 	- Manage the schema.
 	- Transform complex struct fields into simple ones (that is, serialize fields).
@@ -143,9 +194,13 @@ However, Squirrel can ease many of these tasks.
 package structable
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/Masterminds/squirrel"
 )
@@ -182,6 +237,33 @@ type field struct {
 	isKey bool
 	// Is an auto increment
 	isAuto bool
+	// Is scanned exactly, as a NUMERIC
+	isNumeric bool
+	// Is a unique column
+	isUnique bool
+	// Is stored compressed
+	isCompressed bool
+	// Is stored in a BlobStore
+	isExternal bool
+	// Roles that may see a RESTRICTED column, or nil
+	roles []string
+	// Holds the tenant of each row
+	isTenant bool
+	// May be missing from the table
+	tolerateMissing bool
+	// Cannot hold NULL
+	notNull bool
+	// Has a DEFAULT, given as an SQL literal in defaultValue
+	hasDefault   bool
+	defaultValue string
+	// Maximum length, or 0
+	size int
+	// Is never written by Insert, or by Update
+	omitInsert, omitUpdate bool
+	// Stores the zero value as NULL
+	nullable bool
+	// Declared SQL type, if any
+	sqlType string
 }
 
 // A Recorder is responsible for managing the persistence of a Record.
@@ -209,9 +291,6 @@ type Recorder interface {
 	Haecceity
 	Saver
 	Describer
-
-	// This returns the column names used for the primary key.
-	//Key() []string
 }
 
 type Loader interface {
@@ -225,6 +304,12 @@ type Loader interface {
 	Load() error
 	// Load by a WHERE-like clause. See Squirrel's Where(pred, args)
 	LoadWhere(interface{}, ...interface{}) error
+	// Reload loads the Record again by its PRIMARY_KEY(s), so that columns
+	// changed by the database are reflected in it.
+	Reload() error
+	// LoadByKey sets the PRIMARY_KEY(s) from a tuple of values, in the order
+	// of Key, and loads the Record by them.
+	LoadByKey(...interface{}) error
 }
 
 type Saver interface {
@@ -261,32 +346,51 @@ type Describer interface {
 	Columns(bool) []string
 	// FieldReferences gets references to the fields on this object.
 	FieldReferences(bool) []interface{}
+	// Fields describes the fields that are mapped to columns.
+	Fields() []FieldInfo
 	// WhereIds returns a map of ID fields to (current) ID values.
 	//
 	// This is useful to quickly generate where clauses.
 	WhereIds() map[string]interface{}
+	// Key returns the column names of the primary key.
+	Key() []string
+	// KeyFields returns the struct field names of the primary key, in the
+	// order of Key.
+	KeyFields() []string
+	// KeyValues returns the ID values as a tuple, in the order of Key.
+	KeyValues() []interface{}
+	// SetKey sets the ID fields from a tuple of values, in the order of Key.
+	SetKey(...interface{}) error
 
 	// TableName returns the table name.
 	TableName() string
+	// Record returns the bound Record.
+	Record() Record
 	// Builder returns the builder
 	Builder() *squirrel.StatementBuilderType
 	// DB returns a DB-like handle.
-	DB() squirrel.DBProxyBeginner
+	DB() Runner
 
 	Driver() string
 
-	Init(d squirrel.DBProxyBeginner, flavor string)
+	Init(d squirrel.BaseRunner, flavor string)
 }
 
 // List returns a list of objects of the given kind.
 //
-// This runs a Select of the given kind, and returns the results.
-func List(d Recorder, limit, offset uint64) ([]Recorder, error) {
-	fn := func(desc Describer, query squirrel.SelectBuilder) (squirrel.SelectBuilder, error) {
-		return query.Limit(limit).Offset(offset), nil
-	}
-
-	return ListWhere(d, fn)
+// This runs a Select of the given kind, and returns the results. Options are
+// applied to the select in order:
+//
+//	items, err := structable.List(r,
+//		structable.WithWhereEq("material", "wood"),
+//		structable.WithOrderBy("number_of_legs DESC"),
+//		structable.WithLimit(10),
+//		structable.WithOffset(20),
+//	)
+//
+// Any WhereFunc may be used as an option.
+func List(d Recorder, opts ...WhereFunc) ([]Recorder, error) {
+	return ListWhere(d, Compose(opts...))
 }
 
 // WhereFunc modifies a basic select operation to add conditions.
@@ -298,80 +402,187 @@ type WhereFunc func(desc Describer, query squirrel.SelectBuilder) (squirrel.Sele
 // ListWhere takes a Recorder and a query modifying function and executes a query.
 //
 // The WhereFunc will be given a SELECT d.Colsumns() FROM d.TableName() statement,
-// and may modify it. Note that while joining is supported, changing the column
-// list will have unpredictable side effects. It is advised that joins be done
-// using Squirrel instead.
+// and may modify it. Result columns are matched to fields by name, so the
+// WhereFunc may join other tables, or change the order of the columns.
+// Columns that do not match a field are ignored; if a join returns two columns
+// with the same name, the first one is used.
 //
-// This will return a list of Recorder objects, where the underlying type
-// of each matches the underlying type of the passed-in 'd' Recorder.
+// This will return a list of Recorder objects, each bound to a new Record of
+// the same type as d's Record. If d is a *DbRecorder, each is a copy of it, with
+// the same settings. If d was made by Wrap, each is a copy of the wrapped
+// *DbRecorder, wrapped with the same middleware. If d is a Record that embeds
+// its Recorder, each is a copy of the embedded *DbRecorder. Otherwise, each is
+// a new *DbRecorder with d's database and flavor.
 func ListWhere(d Recorder, fn WhereFunc) ([]Recorder, error) {
+	proto := protoRecorder(d)
 	var tn string = d.TableName()
-	var cols []string = d.Columns(false)
+	var cols []string = d.Columns(true)
 	buf := []Recorder{}
 
 	// Base query
-	q := d.Builder().Select(cols...).From(tn)
+	q := d.Builder().Select(cols...).From(tn).Where(proto.tenantWhere())
 
-	// Allow the fn to modify our query
+	// Fetch one extra row, so that we can tell when the cap is exceeded.
+	max := proto.maxRows
+	if max > 0 {
+		q = q.Limit(max + 1)
+	}
+
+	// Allow the fn to modify our query.
 	var err error
-	q, err = fn(d, q)
-	if err != nil {
-		return buf, err
+	if fn != nil {
+		if q, err = fn(d, q); err != nil {
+			return buf, err
+		}
 	}
 
-	rows, err := q.Query()
+	var key string
+	var gen uint64
+	if proto.lists != nil {
+		query, args, err := q.ToSql()
+		if err != nil {
+			return buf, err
+		}
+		key = listKey(query, args)
+		var cached []Record
+		var ok bool
+		if cached, gen, ok = proto.lists.get(tn, key); ok {
+			for _, rec := range cached {
+				buf = append(buf, wrapLike(d, proto.Clone(rec)))
+			}
+			return buf, nil
+		}
+	}
+
+	rows, err := proto.query(OpList, q)
 	if err != nil || rows == nil {
 		return buf, err
 	}
 	defer rows.Close()
 
-	v := reflect.Indirect(reflect.ValueOf(d))
-	t := v.Type()
+	// Results are scanned by column name, so the fn may reorder the select
+	// list, or add columns from joined tables.
+	names, err := rows.Columns()
+	if err != nil {
+		return buf, err
+	}
+	cs := proto.columnScanner(names)
+
 	for rows.Next() {
-		nv := reflect.New(t)
+		if max > 0 && uint64(len(buf)) == max {
+			return buf, proto.overflow()
+		}
 
-		// Bind an empty base object. Basically, we fetch the object out of
-		// the DbRecorder, and then construct an empty one.
-		rec := reflect.New(reflect.Indirect(reflect.ValueOf(d.(*DbRecorder).record)).Type())
-		nv.Interface().(Recorder).Bind(d.TableName(), rec.Interface())
+		s := proto.Clone(nil)
+		if err := cs.scan(s, rows); err != nil {
+			return buf, err
+		}
+		buf = append(buf, wrapLike(d, s))
+	}
+	if err := rows.Err(); err != nil {
+		return buf, err
+	}
 
-		s := nv.Interface().(Recorder)
-		s.Init(d.DB(), d.Driver())
-		dest := s.FieldReferences(true)
-		rows.Scan(dest...)
-		buf = append(buf, s)
+	if proto.lists != nil {
+		records := make([]Record, len(buf))
+		for i, r := range buf {
+			records[i] = r.Record()
+		}
+		proto.lists.put(tn, key, gen, records)
 	}
+	return buf, nil
+}
 
-	return buf, rows.Err()
+// runQuery runs a select for a Recorder, using the DbRecorder's tracing
+// when it is available.
+func runQuery(d Recorder, q squirrel.SelectBuilder) (*sql.Rows, error) {
+	if dr, ok := d.(*DbRecorder); ok {
+		return dr.query(OpList, q)
+	}
+	return q.Query()
 }
 
 // Implements the Recorder interface, and stores data in a DB.
+//
+// A DbRecorder is not safe for concurrent use: it reads and writes its bound
+// Record, and its settings may change at any time. To work with the same
+// table from several goroutines, give each goroutine its own DbRecorder with
+// Clone. The field metadata that Bind parses from the struct tags is never
+// changed after Bind, so clones share it safely.
 type DbRecorder struct {
 	builder *squirrel.StatementBuilderType
-	db      squirrel.DBProxyBeginner
+	db      Runner
+	ctx     context.Context
+	tracer  Tracer
+	metrics Metrics
+	history *history
 	table   string
 	fields  []*field
 	key     []*field
 	record  Record
 	flavor  string
+
+	lenient      bool
+	exactNumeric bool
+
+	maxRows    uint64
+	onOverflow OverflowFunc
+
+	blobs  BlobStore
+	lists  *ListCache
+	tenant interface{}
+
+	named       bool
+	tablePrefix string
+	quoteTables bool
+
+	missing []*field
+	tagKeys []string
+	naming  NamingStrategy
+
+	exprs map[string]squirrel.Sqlizer
+	lock  LockMode
+
+	comments      bool
+	profileLabels bool
+
+	refreshAfterWrite bool
+	resetSequences    bool
+	strictSingle      bool
+	allowZeroKeys     bool
+
+	// The number of fields that the Record's Generated methods cover, or 0.
+	gen int
+
+	dryRun *dryRun
+
+	bindErr error
 }
 
 func (d *DbRecorder) Interface() interface{} {
 	return d.record
 }
 
+// Record returns the bound Record.
+func (d *DbRecorder) Record() Record {
+	return d.record
+}
+
 // New creates a new DbRecorder.
 //
-// (The squirrel.DBProxy interface defines the functions normal for a database connection
-// or a prepared statement cache.)
-func New(db squirrel.DBProxyBeginner, flavor string) *DbRecorder {
+// The db is usually a squirrel.DBProxyBeginner, such as a prepared statement
+// cache created with squirrel.NewStmtCacheProxy. A *sql.DB or *sql.Tx runs
+// statements without preparing them. Any other squirrel.BaseRunner is adapted
+// as by AsRunner.
+func New(db squirrel.BaseRunner, flavor string) *DbRecorder {
 	d := new(DbRecorder)
 	d.Init(db, flavor)
 	return d
 }
 
-// Init initializes a DbRecorder
-func (d *DbRecorder) Init(db squirrel.DBProxyBeginner, flavor string) {
+// Init initializes a DbRecorder. The db is adapted as by AsRunner.
+func (d *DbRecorder) Init(base squirrel.BaseRunner, flavor string) {
+	db := AsRunner(base)
 	b := squirrel.StatementBuilder.RunWith(db)
 	if flavor == "postgres" {
 		b = b.PlaceholderFormat(squirrel.Dollar)
@@ -382,13 +593,66 @@ func (d *DbRecorder) Init(db squirrel.DBProxyBeginner, flavor string) {
 	d.flavor = flavor
 }
 
-// TableName returns the table name of this recorder.
+// Clone returns a new DbRecorder with the same database, table, and settings,
+// bound to rec.
+//
+// If rec is a pointer to the same struct type as the bound Record, the field
+// metadata is shared rather than parsed again, which makes Clone much cheaper
+// than New and Bind. If rec is nil, a new, empty Record of the bound type is
+// created:
+//
+//	tmpl := structable.New(db, "postgres").SetLenient(true)
+//	tmpl.Bind("users", &User{})
+//	for _, id := range ids {
+//		go func(id int) {
+//			r := tmpl.Clone(&User{Id: id})
+//			r.Load()
+//		}(id)
+//	}
+//
+// Clone reads the settings of s, so s must not be changed while it is being
+// cloned.
+func (s *DbRecorder) Clone(rec Record) *DbRecorder {
+	c := *s
+	if rec == nil {
+		t, err := recordType(s.record)
+		if err != nil {
+			return &c
+		}
+		rec = reflect.New(t).Interface()
+	}
+	if reflect.TypeOf(rec) != reflect.TypeOf(s.record) {
+		c.Bind(s.table, rec)
+		return &c
+	}
+	c.record = rec
+	return &c
+}
+
+// TableName returns the table name of this recorder, as it is used in SQL.
+//
+// This is the name given to Bind, unless the Record is a TableNamer. The
+// prefix set with SetTablePrefix is added, and the name is quoted if
+// SetQuoteTables is on.
 func (s *DbRecorder) TableName() string {
-	return s.table
+	name := s.table
+	if s.named {
+		if n := s.record.(TableNamer).TableName(); n != "" {
+			name = n
+		}
+	}
+	if s.tablePrefix != "" {
+		i := strings.LastIndex(name, ".")
+		name = name[:i+1] + s.tablePrefix + name[i+1:]
+	}
+	if s.quoteTables {
+		name = QuoteIdent(s.flavor, name)
+	}
+	return name
 }
 
-// DB returns the database (DBProxyBeginner) for this recorder.
-func (s *DbRecorder) DB() squirrel.DBProxyBeginner {
+// DB returns the database (Runner) for this recorder.
+func (s *DbRecorder) DB() Runner {
 	return s.db
 }
 
@@ -409,22 +673,54 @@ func (s *DbRecorder) Driver() string {
 //
 // The table name tells the recorder which database table to link this record
 // to. All storage operations will use that table.
+//
+// The Record must be a non-nil pointer to a struct, or a *DynamicRecord.
+// Bind does not panic on anything else: the problem is reported by
+// BindError, and returned by every statement the recorder runs.
 func (s *DbRecorder) Bind(tableName string, ar Record) Recorder {
 
 	// "To be is to be the value of a bound variable." - W. O. Quine
 
+	if d, ok := ar.(*DynamicRecord); ok {
+		ar = d.Record()
+	}
+
 	// Get the table name
 	s.table = tableName
+	s.record = ar
+	s.missing = nil
+
+	// Anything but a pointer to a struct has no fields to bind.
+	if _, err := recordType(ar); err != nil {
+		s.fields, s.key, s.named, s.gen = nil, nil, false, 0
+		s.bindErr = err
+		return Recorder(s)
+	}
 
 	// Get the fields
 	s.scanFields(ar)
+	s.named = isTableNamer(ar)
 
-	s.record = ar
+	// Check declared SQL types.
+	s.bindErr = s.checkExported()
+	if s.bindErr == nil {
+		s.bindErr = s.checkTypes()
+	}
+	if s.bindErr == nil {
+		s.bindErr = s.checkCompressed()
+	}
+	if s.bindErr == nil {
+		s.bindErr = s.checkExternal()
+	}
+	if s.bindErr == nil {
+		s.bindErr = s.checkTenants()
+	}
 
 	return Recorder(s)
 }
 
-// Key gets the string names of the fields used as primary key.
+// Key gets the column names of the fields used as primary key, in the order
+// the fields are declared.
 func (s *DbRecorder) Key() []string {
 	key := make([]string, len(s.key))
 
@@ -435,6 +731,16 @@ func (s *DbRecorder) Key() []string {
 	return key
 }
 
+// KeyFields gets the struct field names of the fields used as primary key, in
+// the order of Key.
+func (s *DbRecorder) KeyFields() []string {
+	names := make([]string, len(s.key))
+	for i, f := range s.key {
+		names[i] = f.name
+	}
+	return names
+}
+
 // Load selects the record from the database and loads the values into the bound Record.
 //
 // Load uses the table's PRIMARY KEY(s) as the sole criterion for matching a
@@ -443,13 +749,13 @@ func (s *DbRecorder) Key() []string {
 // This modifies the Record in-place. Other than the primary key fields, any
 // other field will be overwritten by the value retrieved from the database.
 func (s *DbRecorder) Load() error {
-	whereParts := s.WhereIds()
-	dest := s.FieldReferences(false)
-
-	q := s.builder.Select(s.colList(false, false)...).From(s.table).Where(whereParts)
-	err := q.QueryRow().Scan(dest...)
+	return s.scan(s.queryRow(OpLoad, s.loadQuery()), false)
+}
 
-	return err
+// loadQuery builds the statement that Load runs.
+func (s *DbRecorder) loadQuery() squirrel.SelectBuilder {
+	q := s.builder.Select(s.colList(false, false)...).From(s.TableName()).Where(s.WhereIds()).Where(s.tenantWhere())
+	return s.lockSelect(q)
 }
 
 // LoadWhere loads an object based on a WHERE clause.
@@ -462,13 +768,76 @@ func (s *DbRecorder) Load() error {
 //
 // This functions similarly to Load, but with the notable difference that
 // it loads the entire object (it does not skip keys used to do the lookup).
+// Result columns are matched to fields by name. If no record matches,
+// sql.ErrNoRows is returned.
+//
+// The select is limited to one row, in the flavor's dialect, so only the
+// first matching row is read. To be told when the predicate matches more
+// than one row, turn on SetStrictSingle.
+//
+// The predicate may be a string with ? placeholders and args, or any
+// squirrel.Sqlizer (squirrel.Eq, And, Or, Like, and so on, nested to any
+// depth), or a map, which is treated as squirrel.Eq. The same is true of
+// every other *Where method, and of WithWhere:
+//
+//	err := s.LoadWhere(squirrel.And{
+//		squirrel.Eq{"status": "active"},
+//		squirrel.Or{squirrel.Gt{"age": 18}, squirrel.Eq{"guardian": true}},
+//	})
 func (s *DbRecorder) LoadWhere(pred interface{}, args ...interface{}) error {
-	dest := s.FieldReferences(true)
+	q := s.builder.Select(s.colList(true, false)...).From(s.TableName()).Where(pred, args...).Where(s.tenantWhere())
+	if s.strictSingle {
+		return s.loadSingle(q)
+	}
+	rows, err := s.query(OpLoadWhere, s.lockSelect(s.limitRows(q, 1)))
+	if err != nil {
+		return err
+	}
+	return s.scanFirst(rows)
+}
 
-	q := s.builder.Select(s.colList(true, true)...).From(s.table).Where(pred, args...)
-	err := q.QueryRow().Scan(dest...)
+// LoadColumns loads only the given columns into the bound Record.
+//
+// Like Load, this uses the table's PRIMARY KEY(s) to find the record. Fields
+// for other columns are left untouched, so heavy columns (such as blobs or
+// large text) can be skipped:
+//
+//	err := doc.LoadColumns("title", "updated_at")
+//
+// Every column must be one of the columns on the bound Record.
+func (s *DbRecorder) LoadColumns(cols ...string) error {
+	fields, err := s.fieldsFor(cols)
+	if err != nil {
+		return err
+	}
+	q := s.builder.Select(cols...).From(s.TableName()).Where(s.WhereIds()).Where(s.tenantWhere())
+	return s.scanInto(s.queryRow(OpLoad, s.lockSelect(q)), fields)
+}
 
-	return err
+// fieldForColumn returns the field that is mapped to a column.
+func (s *DbRecorder) fieldForColumn(column string) (*field, error) {
+	for _, f := range s.fields {
+		if f.column == column {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown column %q on table %s", column, s.table)
+}
+
+// fieldsFor returns the fields that are mapped to the given columns.
+func (s *DbRecorder) fieldsFor(cols []string) ([]*field, error) {
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("no columns selected on table %s", s.table)
+	}
+	fields := make([]*field, len(cols))
+	for i, c := range cols {
+		f, err := s.fieldForColumn(c)
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = f
+	}
+	return fields, nil
 }
 
 // Exists returns `true` if and only if there is at least one record that matches the primary keys for this Record.
@@ -479,8 +848,8 @@ func (s *DbRecorder) Exists() (bool, error) {
 	has := false
 	whereParts := s.WhereIds()
 
-	q := s.builder.Select("COUNT(*) > 0").From(s.table).Where(whereParts)
-	err := q.QueryRow().Scan(&has)
+	q := squirrel.Select("1").From(s.TableName()).Where(whereParts).Where(s.tenantWhere())
+	err := s.queryRow(OpExists, s.existsQuery(q)).Scan(&has)
 
 	return has, err
 }
@@ -488,51 +857,110 @@ func (s *DbRecorder) Exists() (bool, error) {
 // ExistsWhere returns `true` if and only if there is at least one record that matches one (or multiple) conditions.
 //
 // Conditions are expressed in the form of predicates and expected values
-// that together build a WHERE clause. See Squirrel's Where(pred, args)
+// that together build a WHERE clause. See Squirrel's Where(pred, args), and
+// LoadWhere for the kinds of predicates.
 func (s *DbRecorder) ExistsWhere(pred interface{}, args ...interface{}) (bool, error) {
 	has := false
 
-	q := s.builder.Select("COUNT(*) > 0").From(s.table).Where(pred, args...)
-	err := q.QueryRow().Scan(&has)
+	q := squirrel.Select("1").From(s.TableName()).Where(pred, args...).Where(s.tenantWhere())
+	err := s.queryRow(OpExistsWhere, s.existsQuery(q)).Scan(&has)
 
 	return has, err
 }
 
+// existsQuery wraps a subquery in a statement that returns whether it has
+// any rows, in the flavor's dialect.
+//
+// Postgres, MySQL, and SQLite select EXISTS(...) directly. Other databases,
+// such as MSSQL and Oracle, only allow EXISTS in a condition, so it is
+// wrapped in a CASE. The database stops at the first matching row either way.
+func (s *DbRecorder) existsQuery(sub squirrel.SelectBuilder) squirrel.SelectBuilder {
+	switch s.flavor {
+	case "postgres", "mysql", "sqlite3", "sqlite":
+		return s.builder.Select().Column(squirrel.Expr("EXISTS(?)", sub))
+	}
+	q := s.builder.Select().Column(squirrel.Expr("CASE WHEN EXISTS(?) THEN 1 ELSE 0 END", sub))
+	if s.flavor == "oracle" {
+		q = q.Suffix("FROM DUAL")
+	}
+	return q
+}
+
 // Delete deletes the record from the underlying table.
 //
 // The fields on the present record will remain set, but not saved in the database.
+// As with Update, ErrMissingKey is returned if the primary key is not set.
 func (s *DbRecorder) Delete() error {
-	wheres := s.WhereIds()
-	q := s.builder.Delete(s.table).Where(wheres)
-	_, err := q.Exec()
+	if err := s.checkKeys(); err != nil {
+		return err
+	}
+	_, err := s.exec(OpDelete, s.deleteQuery())
 	return err
 }
 
+// deleteQuery builds the statement that Delete runs.
+func (s *DbRecorder) deleteQuery() squirrel.DeleteBuilder {
+	return s.builder.Delete(s.TableName()).Where(s.WhereIds()).Where(s.tenantWhere())
+}
+
+// DeleteWhere deletes every record that matches a predicate, and returns the
+// number of records deleted.
+//
+// The predicate is given as for LoadWhere. Since an empty predicate would
+// delete every row, a nil or empty one is an error.
+func (s *DbRecorder) DeleteWhere(pred interface{}, args ...interface{}) (int64, error) {
+	if pred == nil || pred == "" {
+		return 0, fmt.Errorf("refusing to delete from %s without a predicate", s.table)
+	}
+	q := s.builder.Delete(s.TableName()).Where(pred, args...).Where(s.tenantWhere())
+	res, err := s.exec(OpDelete, q)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 // Insert puts a new record into the database.
 //
 // This operation is particularly sensitive to DB differences in cases where AUTO_INCREMENT is set
 // on a member of the Record.
 func (s *DbRecorder) Insert() error {
+	defer s.clearExprs()
+	if err := s.setTenant(); err != nil {
+		return err
+	}
+	if err := s.validate(OpInsert); err != nil {
+		return err
+	}
+	if err := s.putExternal(s.fields); err != nil {
+		return err
+	}
 	switch s.flavor {
 	case "postgres":
 		return s.insertPg()
 	default:
-		return s.insertStd()
+		if err := s.insertStd(); err != nil || !s.refreshAfterWrite {
+			return err
+		}
+		return s.Reload()
 	}
 }
 
 // Insert and assume that LastInsertId() returns something.
 func (s *DbRecorder) insertStd() error {
-
-	cols, vals := s.colValLists(true, false)
-
-	q := s.builder.Insert(s.table).Columns(cols...).Values(vals...)
-
-	ret, err := q.Exec()
+	ret, err := s.exec(OpInsert, s.insertQuery())
 	if err != nil {
 		return err
 	}
+	return s.setAutos(ret)
+}
 
+// setAutos sets the AUTO_INCREMENT fields of the Record to the ID that the
+// insert generated.
+func (s *DbRecorder) setAutos(ret sql.Result) error {
+	if _, ok := ret.(dryResult); ok {
+		return nil
+	}
 	for _, f := range s.fields {
 		if f.isAuto {
 			ar := reflect.Indirect(reflect.ValueOf(s.record))
@@ -546,28 +974,31 @@ func (s *DbRecorder) insertStd() error {
 			if !field.CanSet() {
 				return fmt.Errorf("Could not set %s to returned value", f.name)
 			}
-			field.SetInt(id)
+			if err := convertAssign(field.Addr().Interface(), id); err != nil {
+				return fmt.Errorf("Could not set %s to returned value: %s", f.name, err)
+			}
 		}
 	}
 
-	return err
+	return nil
 }
 
 // insertPg runs a postgres-specific INSERT. Unlike the default (MySQL) driver,
 // this actually refreshes ALL of the fields on the Record object. We do this
 // because it is trivially easy in Postgres.
 func (s *DbRecorder) insertPg() error {
-	cols, vals := s.colValLists(true, false)
-	dest := s.FieldReferences(true)
-	q := s.builder.Insert(s.table).Columns(cols...).Values(vals...).
-		Suffix("RETURNING " + strings.Join(s.colList(true, false), ","))
+	return s.scan(s.queryRow(OpInsert, s.insertQuery()), true)
+}
 
-	sql, vals, err := q.ToSql()
-	if err != nil {
-		return err
+// insertQuery builds the statement that Insert runs. On postgres, it returns
+// every column.
+func (s *DbRecorder) insertQuery() squirrel.InsertBuilder {
+	cols, vals := s.colValLists(OpInsert, true, false)
+	q := s.builder.Insert(s.TableName()).Columns(cols...).Values(vals...)
+	if s.flavor == "postgres" {
+		q = q.Suffix("RETURNING " + strings.Join(s.colList(true, false), ","))
 	}
-
-	return s.db.QueryRow(sql, vals...).Scan(dest...)
+	return q
 }
 
 // Update updates the values on an existing entry.
@@ -575,20 +1006,48 @@ func (s *DbRecorder) insertPg() error {
 // This updates records where the Record's primary keys match the record in the
 // database. Essentially, it runs `UPDATE table SET names=values WHERE id=?`
 //
-// If no entry is found, update will NOT create (INSERT) a new record.
+// If no entry is found, update will NOT create (INSERT) a new record. If the
+// primary key is not set, ErrMissingKey is returned (see SetAllowZeroKeys).
 func (s *DbRecorder) Update() error {
-	whereParts := s.WhereIds()
-	updates := s.updateFields()
-	q := s.builder.Update(s.table).SetMap(updates).Where(whereParts)
-	_, err := q.Exec()
+	defer s.clearExprs()
+	if err := s.checkKeys(); err != nil {
+		return err
+	}
+	if err := s.setTenant(); err != nil {
+		return err
+	}
+	if err := s.validate(OpUpdate); err != nil {
+		return err
+	}
+	if err := s.putExternal(s.fields); err != nil {
+		return err
+	}
+	q := s.updateQuery()
+	if s.refreshAfterWrite {
+		return s.updateRefresh(q)
+	}
+	_, err := s.exec(OpUpdate, q)
 	return err
 }
 
+// updateQuery builds the statement that Update runs. On postgres, with
+// SetRefreshAfterWrite on, it returns the columns that are not keys.
+func (s *DbRecorder) updateQuery() squirrel.UpdateBuilder {
+	q := s.builder.Update(s.TableName()).SetMap(s.updateFields()).Where(s.WhereIds()).Where(s.tenantWhere())
+	if s.refreshAfterWrite && s.flavor == "postgres" {
+		q = q.Suffix("RETURNING " + strings.Join(s.colList(false, false), ","))
+	}
+	return q
+}
+
 // Columns returns the names of the columns on this table.
 //
 // If includeKeys is false, the columns that are marked as keys are omitted
 // from the returned list.
 func (s *DbRecorder) Columns(includeKeys bool) []string {
+	if g := s.generated(); g != nil {
+		return g.StructableColumns(includeKeys)
+	}
 	return s.colList(includeKeys, false)
 }
 
@@ -628,41 +1087,57 @@ func (s *DbRecorder) colList(withKeys bool, omitNil bool) []string {
 // This is used for processing SQL results:
 //
 //	dest := s.FieldReferences(false)
-//	q := s.builder.Select(s.Columns(false)...).From(s.table)
+//	q := s.builder.Select(s.Columns(false)...).From(s.TableName())
 //	err := q.QueryRow().Scan(dest...)
 func (s *DbRecorder) FieldReferences(withKeys bool) []interface{} {
+	if g := s.generated(); g != nil {
+		return g.StructableFieldReferences(withKeys)
+	}
 	refs := make([]interface{}, 0, len(s.fields))
 
-	ar := reflect.Indirect(reflect.ValueOf(s.record))
 	for _, field := range s.fields {
 		if !withKeys && field.isKey {
 			continue
 		}
-
-		fv := ar.FieldByName(field.name)
-		var ref reflect.Value
-		if fv.Kind() != reflect.Ptr {
-			// we want the address of field
-			ref = fv.Addr()
-		} else {
-			// we already have an address
-			ref = fv
-			if fv.IsNil() {
-				// allocate a new element of same type
-				fv.Set(reflect.New(fv.Type().Elem()))
-			}
-		}
-		refs = append(refs, ref.Interface())
+		refs = append(refs, s.fieldRef(field))
 	}
 
 	return refs
 }
 
+// fieldRef returns a reference to a field on the bound Record.
+//
+// Nil pointer fields are allocated, so that they can be scanned into.
+func (s *DbRecorder) fieldRef(f *field) interface{} {
+	fv := reflect.Indirect(reflect.ValueOf(s.record)).FieldByName(f.name)
+	if fv.Kind() != reflect.Ptr {
+		// we want the address of field
+		return fv.Addr().Interface()
+	}
+	// we already have an address
+	if fv.IsNil() {
+		// allocate a new element of same type
+		fv.Set(reflect.New(fv.Type().Elem()))
+	}
+	return fv.Interface()
+}
+
 // colValLists returns 2 lists, the column names and values.
 // If withKeys is false, columns and values of fields designated as primary keys
 // will not be included in those lists. Also, if withAutos is false, the returned
 // lists will not include fields designated as auto-increment.
-func (s *DbRecorder) colValLists(withKeys, withAutos bool) (columns []string, values []interface{}) {
+//
+// The op is OpInsert or OpUpdate. It leaves out OMIT_INSERT or OMIT_UPDATE
+// fields accordingly, and on insert, zero-valued DEFAULT fields.
+func (s *DbRecorder) colValLists(op string, withKeys, withAutos bool) (columns []string, values []interface{}) {
+	if g := s.generated(); g != nil && len(s.exprs) == 0 {
+		columns, values = g.StructableColValLists(op == OpInsert, withKeys, withAutos)
+		for i, v := range values {
+			values[i] = sqlValue(v)
+		}
+		return
+	}
+
 	ar := reflect.Indirect(reflect.ValueOf(s.record))
 
 	for _, field := range s.fields {
@@ -672,6 +1147,16 @@ func (s *DbRecorder) colValLists(withKeys, withAutos bool) (columns []string, va
 			continue
 		case !withAutos && field.isAuto:
 			continue
+		case op == OpInsert && field.omitInsert:
+			continue
+		case op == OpUpdate && field.omitUpdate:
+			continue
+		}
+
+		if e, ok := s.exprs[field.column]; ok {
+			values = append(values, e)
+			columns = append(columns, field.column)
+			continue
 		}
 
 		// Get the value of the field we are going to store.
@@ -688,8 +1173,12 @@ func (s *DbRecorder) colValLists(withKeys, withAutos bool) (columns []string, va
 			// get the value pointed to by the field
 			v = reflect.Indirect(f)
 		}
+		// On insert, let the database fill in zero-valued DEFAULT columns.
+		if op == OpInsert && field.hasDefault && v.IsZero() {
+			continue
+		}
 
-		values = append(values, v.Interface())
+		values = append(values, writeValue(field, v.Interface()))
 		columns = append(columns, field.column)
 	}
 
@@ -700,7 +1189,7 @@ func (s *DbRecorder) colValLists(withKeys, withAutos bool) (columns []string, va
 // This will NOT update PRIMARY_KEY fields.
 func (s *DbRecorder) updateFields() map[string]interface{} {
 	update := map[string]interface{}{}
-	cols, vals := s.colValLists(false, true)
+	cols, vals := s.colValLists(OpUpdate, false, true)
 	for i, col := range cols {
 		update[col] = vals[i]
 	}
@@ -710,6 +1199,9 @@ func (s *DbRecorder) updateFields() map[string]interface{} {
 // WhereIds gets a list of names and a list of values for all columns marked as primary
 // keys.
 func (s *DbRecorder) WhereIds() map[string]interface{} {
+	if g := s.generated(); g != nil {
+		return g.StructableWhereIds()
+	}
 	clause := make(map[string]interface{}, len(s.key))
 
 	ar := reflect.Indirect(reflect.ValueOf(s.record))
@@ -721,46 +1213,132 @@ func (s *DbRecorder) WhereIds() map[string]interface{} {
 	return clause
 }
 
+// fieldCache holds the parsed fields of each struct type that has been bound,
+// keyed by fieldKey. Fields are never changed once parsed, so every
+// DbRecorder bound to the same type with the same tag keys shares them.
+var fieldCache sync.Map
+
+// fieldKey identifies a struct type, as read through a list of tag keys and
+// a NamingStrategy.
+type fieldKey struct {
+	t      reflect.Type
+	keys   string
+	naming NamingStrategy
+}
+
+// fieldKey returns the fieldCache key of the bound Record's fields.
+func (s *DbRecorder) fieldKey(t reflect.Type) fieldKey {
+	return fieldKey{t: t, keys: strings.Join(s.TagKeys(), ","), naming: s.NamingStrategy()}
+}
+
+// fieldMeta is the parsed field metadata of one struct type.
+type fieldMeta struct {
+	fields, key []*field
+	// The type has Generated methods that match the fields.
+	generated bool
+}
+
 // scanFields extracts the tags from all of the fields on a struct.
+//
+// Tags are parsed once per struct type, and cached.
 func (s *DbRecorder) scanFields(ar Record) {
-	v := reflect.Indirect(reflect.ValueOf(ar))
-	t := v.Type()
+	t := reflect.Indirect(reflect.ValueOf(ar)).Type()
+	if m, ok := fieldCache.Load(s.fieldKey(t)); ok {
+		meta := m.(*fieldMeta)
+		s.fields, s.key = meta.fields, meta.key
+		s.gen = 0
+		if meta.generated {
+			s.gen = len(meta.fields)
+		}
+		return
+	}
+
 	count := t.NumField()
 	keys := make([]*field, 0, 2)
+	s.fields = make([]*field, 0, count)
+	s.key = keys
 
 	for i := 0; i < count; i++ {
 		f := t.Field(i)
-		// Skip fields with no tag.
-		if len(f.Tag) == 0 {
-			continue
-		}
-		sqtag := f.Tag.Get("stbl")
-		if len(sqtag) == 0 {
+		// Skip fields with no tag, and unexported fields, which cannot be
+		// read or set. See checkExported.
+		sqtag, ok := s.FieldTag(f)
+		if !ok || f.PkgPath != "" {
 			continue
 		}
 
-		parts := s.parseTag(f.Name, sqtag)
-		field := new(field)
-		field.name = f.Name
-		field.column = parts[0]
-		for _, part := range parts[1:] {
-			part = strings.TrimSpace(part)
-			switch part {
-			case "PRIMARY_KEY", "PRIMARY KEY":
-				field.isKey = true
-				keys = append(keys, field)
-			case "AUTO_INCREMENT", "SERIAL", "AUTO INCREMENT":
-				field.isAuto = true
-			}
+		field := parseField(f.Name, s.parseTag(f.Name, sqtag))
+		if field.isKey {
+			keys = append(keys, field)
 		}
 		s.fields = append(s.fields, field)
 		s.key = keys
 	}
+	meta := &fieldMeta{fields: s.fields, key: s.key, generated: generatedFits(t, s.fields)}
+	fieldCache.Store(s.fieldKey(t), meta)
+	s.gen = 0
+	if meta.generated {
+		s.gen = len(s.fields)
+	}
+}
+
+// parseField builds a field from the parts of its stbl tag: the column name,
+// and then the options.
+func parseField(name string, parts []string) *field {
+	field := &field{name: name, column: parts[0]}
+	for _, part := range parts[1:] {
+		part = strings.TrimSpace(part)
+		if strings.HasPrefix(part, "TYPE=") {
+			field.sqlType = strings.TrimSpace(strings.TrimPrefix(part, "TYPE="))
+			continue
+		}
+		if v, ok := tagArg(part, "DEFAULT"); ok {
+			field.hasDefault, field.defaultValue = true, v
+			continue
+		}
+		if v, ok := tagArg(part, "SIZE"); ok {
+			field.size, _ = strconv.Atoi(v)
+			continue
+		}
+		if strings.HasPrefix(part, "RESTRICTED=") {
+			field.roles = strings.Split(strings.TrimPrefix(part, "RESTRICTED="), "|")
+			continue
+		}
+		switch part {
+		case "PRIMARY_KEY", "PRIMARY KEY":
+			field.isKey = true
+		case "AUTO_INCREMENT", "SERIAL", "AUTO INCREMENT":
+			field.isAuto = true
+		case "NUMERIC":
+			field.isNumeric = true
+		case "UNIQUE":
+			field.isUnique = true
+		case "COMPRESSED":
+			field.isCompressed = true
+		case "EXTERNAL":
+			field.isExternal = true
+		case "TENANT":
+			field.isTenant = true
+		case "TOLERATE_MISSING":
+			field.tolerateMissing = true
+		case "NOT_NULL", "NOT NULL":
+			field.notNull = true
+		case "OMIT_INSERT":
+			field.omitInsert = true
+		case "OMIT_UPDATE":
+			field.omitUpdate = true
+		case "READONLY", "READ_ONLY":
+			field.omitInsert, field.omitUpdate = true, true
+		case "NULLABLE":
+			field.nullable = true
+		}
+	}
+	return field
 }
 
 // parseTag parses the contents of a stbl tag.
 func (s *DbRecorder) parseTag(fieldName, tag string) []string {
-	parts := strings.Split(tag, ",")
+	parts := SplitTag(tag)
 	if len(parts) == 0 {
 		return []string{fieldName}
 	}
@@ -4,8 +4,10 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"reflect"
 	"regexp"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/Masterminds/squirrel"
@@ -83,6 +85,25 @@ func TestBind(t *testing.T) {
 	}
 }
 
+func TestAsRunner(t *testing.T) {
+	stub := &DBStub{}
+	if r := AsRunner(stub); r != stub {
+		t.Errorf("Expected a Runner to be kept, got %T", r)
+	}
+	if r := AsRunner(nil); r != nil {
+		t.Errorf("Expected nil, got %T", r)
+	}
+	if _, ok := AsRunner(&sql.DB{}).(*stdRunner); !ok {
+		t.Error("Expected a *sql.DB to be wrapped with NewRunner")
+	}
+	if _, ok := AsRunner(struct{ squirrel.BaseRunner }{stub}).(*baseRunner); !ok {
+		t.Error("Expected a BaseRunner to get a QueryRow")
+	}
+	if New(&sql.DB{}, "mysql").DB() == nil {
+		t.Error("Expected New to take a *sql.DB")
+	}
+}
+
 func TestLoad(t *testing.T) {
 	stool := newStool()
 	db := &DBStub{}
@@ -114,19 +135,20 @@ func TestLoadWhere(t *testing.T) {
 
 	r := New(db, "mysql").Bind("test_table", stool)
 
-	if err := r.LoadWhere("number_of_legs = ?", 3); err != nil {
-		t.Errorf("Error running query: %s", err)
+	// The stub returns no rows.
+	if err := r.LoadWhere("number_of_legs = ?", 3); err != sql.ErrNoRows {
+		t.Errorf("Expected sql.ErrNoRows, got %v", err)
 	}
 
-	if len(db.LastQueryRowArgs) != 1 {
+	if len(db.LastQueryArgs) != 1 {
 		t.Errorf("Expected exactly one where arg.")
 	}
 
-	expect := "SELECT .* FROM test_table WHERE number_of_legs = ?"
-	if ok, err := regexp.MatchString(expect, db.LastQueryRowSql); err != nil {
+	expect := "SELECT .* FROM test_table WHERE number_of_legs = \\? LIMIT 1"
+	if ok, err := regexp.MatchString(expect, db.LastQuerySql); err != nil {
 		t.Errorf("Failed to run regexp: %s", err)
 	} else if !ok {
-		t.Errorf("%s did not match pattern %s", db.LastQueryRowSql, expect)
+		t.Errorf("%s did not match pattern %s", db.LastQuerySql, expect)
 	}
 
 }
@@ -138,11 +160,11 @@ func TestList(t *testing.T) {
 
 	r := New(db, "mysql").Bind("test_table", stool)
 
-	if _, err := List(r, 10, 0); err != nil {
+	if _, err := List(r, WithLimit(10), WithOffset(0)); err != nil {
 		t.Errorf("Error running query: %s", err)
 	}
 
-	expect := "SELECT number_of_legs, material, color FROM test_table LIMIT 10 OFFSET 0"
+	expect := "SELECT id, id_two, number_of_legs, material, color FROM test_table LIMIT 10 OFFSET 0"
 	if db.LastQuerySql != expect {
 		t.Errorf("Unexpected SQL: %q\nGot %q", expect, db.LastQuerySql)
 	}
@@ -282,10 +304,26 @@ func TestExists(t *testing.T) {
 		t.Errorf("Error calling Exists: %s", err)
 	}
 
-	expect := "SELECT COUNT(*) > 0 FROM test_table WHERE id = ? AND id_two = ?"
+	expect := "SELECT EXISTS(SELECT 1 FROM test_table WHERE id = ? AND id_two = ?)"
 	if db.LastQueryRowSql != expect {
 		t.Errorf("Unexpected SQL: expected %q, got %q", expect, db.LastQueryRowSql)
 	}
+
+	flavors := map[string]string{
+		"postgres": "SELECT EXISTS(SELECT 1 FROM test_table WHERE material = $1)",
+		"sqlite3":  "SELECT EXISTS(SELECT 1 FROM test_table WHERE material = ?)",
+		"mssql":    "SELECT CASE WHEN EXISTS(SELECT 1 FROM test_table WHERE material = ?) THEN 1 ELSE 0 END",
+		"oracle":   "SELECT CASE WHEN EXISTS(SELECT 1 FROM test_table WHERE material = ?) THEN 1 ELSE 0 END FROM DUAL",
+	}
+	for flavor, expect := range flavors {
+		r := New(db, flavor).Bind("test_table", stool)
+		if _, err := r.ExistsWhere("material = ?", "wood"); err != nil {
+			t.Errorf("%s: error calling ExistsWhere: %s", flavor, err)
+		}
+		if db.LastQueryRowSql != expect {
+			t.Errorf("%s: expected %q, got %q", flavor, expect, db.LastQueryRowSql)
+		}
+	}
 }
 
 func TestActiveRecord(t *testing.T) {
@@ -372,3 +410,64 @@ func (r *ResultStub) LastInsertId() (int64, error) {
 func (r *ResultStub) RowsAffected() (int64, error) {
 	return r.affectedRows, nil
 }
+
+func TestLoadColumns(t *testing.T) {
+	stool := newStool()
+	db := &DBStub{}
+	r := New(db, "mysql")
+	r.Bind("test_table", stool)
+
+	if err := r.LoadColumns("material", "color"); err != nil {
+		t.Fatal(err)
+	}
+	expect := "SELECT material, color FROM test_table WHERE id = ? AND id_two = ?"
+	if db.LastQueryRowSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQueryRowSql)
+	}
+
+	if err := r.LoadColumns("material", "nope"); err == nil {
+		t.Error("Expected unknown column to fail")
+	}
+}
+
+func TestClone(t *testing.T) {
+	db := new(DBStub)
+	tmpl := New(db, "postgres").SetLenient(true)
+	tmpl.Bind("test_table", newStool())
+
+	c := tmpl.Clone(nil)
+	if c.record == tmpl.record || !c.lenient || c.TableName() != "test_table" {
+		t.Error("Expected a lenient clone with its own record")
+	}
+	if &c.fields[0] != &tmpl.fields[0] {
+		t.Error("Expected the clone to share field metadata")
+	}
+
+	s := &Stool{Id: 7}
+	if c := tmpl.Clone(s); c.Interface() != s || c.WhereIds()["id"] != 7 {
+		t.Error("Expected the clone to be bound to the given record")
+	}
+	if c := tmpl.Clone(&account{}); !reflect.DeepEqual(c.Columns(true), []string{"id", "email", "name"}) {
+		t.Errorf("Expected a record of another type to be bound, got %v", c.Columns(true))
+	}
+
+	// Clones may be used concurrently; run with -race.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c := tmpl.Clone(&Stool{Id: i, Legs: i})
+			c.Columns(true)
+			c.WhereIds()
+		}(i)
+	}
+	wg.Wait()
+}
+
+func BenchmarkBind(b *testing.B) {
+	db := new(DBStub)
+	for i := 0; i < b.N; i++ {
+		New(db, "postgres").Bind("test_table", newStool())
+	}
+}
@@ -0,0 +1,90 @@
+package structable
+
+import (
+	"reflect"
+	"strings"
+)
+
+// TableNamer is implemented by Records that compute their table name at run
+// time, such as tables that are sharded by month or by tenant:
+//
+//	type Event struct {
+//		Id int64     `stbl:"id,PRIMARY_KEY,SERIAL"`
+//		At time.Time `stbl:"at"`
+//	}
+//
+//	func (e *Event) TableName() string {
+//		return e.At.Format("events_2006_01")
+//	}
+//
+// TableName is called for every statement, with the bound Record, so the
+// name may depend on the Record's fields. Records listed by List and
+// ListWhere are named by the Record that was bound to the DbRecorder that
+// ran the list, but each listed Record names its own table from then on. If
+// TableName returns "", the name given to Bind is used.
+//
+// Records that embed their Recorder, or anything else with a TableName
+// method, such as a *DbRecorder, already have a TableName method, which
+// returns the name given to Bind, so TableNamer does not apply to them.
+type TableNamer interface {
+	TableName() string
+}
+
+var tableNamerType = reflect.TypeOf((*TableNamer)(nil)).Elem()
+
+// isTableNamer returns true if a Record names its own table. A Record that
+// embeds a TableNamer, such as a Recorder, does not, since calling the
+// embedded TableName would call back into the DbRecorder.
+func isTableNamer(rec Record) bool {
+	if _, ok := rec.(TableNamer); !ok {
+		return false
+	}
+	t := reflect.Indirect(reflect.ValueOf(rec)).Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && (f.Type.Implements(tableNamerType) || reflect.PtrTo(f.Type).Implements(tableNamerType)) {
+			return false
+		}
+	}
+	return true
+}
+
+// SetTablePrefix sets a prefix for the table name, such as "test_" or
+// "tenant42_".
+//
+// If the table name is qualified with a schema, the prefix is added to the
+// table, not the schema: with the prefix "v2_", "analytics.events" becomes
+// "analytics.v2_events".
+func (s *DbRecorder) SetTablePrefix(prefix string) *DbRecorder {
+	s.tablePrefix = prefix
+	return s
+}
+
+// SetQuoteTables sets whether the table name is quoted in SQL, as by
+// QuoteIdent.
+//
+// Table names are used verbatim by default. Quote them when they are
+// reserved words, or contain upper case letters or other characters that
+// the database would otherwise change or reject.
+func (s *DbRecorder) SetQuoteTables(on bool) *DbRecorder {
+	s.quoteTables = on
+	return s
+}
+
+// QuoteIdent quotes an identifier, which may be qualified with a schema, for
+// a database flavor.
+//
+// Each part of a qualified name is quoted separately, so
+// "analytics.events" becomes "analytics"."events" (or `analytics`.`events`
+// for mysql). Quote characters inside a part are doubled.
+func QuoteIdent(flavor, name string) string {
+	q := `"`
+	if flavor == "mysql" {
+		q = "`"
+	}
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		parts[i] = q + strings.ReplaceAll(p, q, q+q) + q
+	}
+	return strings.Join(parts, ".")
+}
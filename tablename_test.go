@@ -0,0 +1,90 @@
+package structable
+
+import (
+	"testing"
+	"time"
+)
+
+type event struct {
+	Id int       `stbl:"id,PRIMARY_KEY"`
+	At time.Time `stbl:"at"`
+}
+
+func (e *event) TableName() string {
+	if e.At.IsZero() {
+		return ""
+	}
+	return e.At.Format("events_2006_01")
+}
+
+func TestQuoteIdent(t *testing.T) {
+	tests := []struct{ flavor, name, expect string }{
+		{"postgres", "analytics.events", `"analytics"."events"`},
+		{"sqlite3", `we"ird`, `"we""ird"`},
+		{"mysql", "analytics.events", "`analytics`.`events`"},
+	}
+	for _, tt := range tests {
+		if got := QuoteIdent(tt.flavor, tt.name); got != tt.expect {
+			t.Errorf("Expected %s, got %s", tt.expect, got)
+		}
+	}
+}
+
+func TestTableName(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "postgres").SetTablePrefix("v2_").SetQuoteTables(true)
+	r.Bind("analytics.test_table", newStool())
+
+	if name := r.TableName(); name != `"analytics"."v2_test_table"` {
+		t.Errorf("Unexpected table name %s", name)
+	}
+	r.Load()
+	expect := `SELECT number_of_legs, material, color FROM "analytics"."v2_test_table" WHERE id = $1 AND id_two = $2`
+	if db.LastQueryRowSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQueryRowSql)
+	}
+}
+
+func TestTableNamer(t *testing.T) {
+	db := &DBStub{}
+	e := &event{Id: 1}
+	r := New(db, "mysql")
+	r.Bind("events", e)
+
+	if name := r.TableName(); name != "events" {
+		t.Errorf("Expected the bound name for an empty name, got %s", name)
+	}
+	e.At = time.Date(2024, time.June, 3, 0, 0, 0, 0, time.UTC)
+	if err := r.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "DELETE FROM events_2024_06 WHERE id = ?"; db.LastExecSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastExecSql)
+	}
+
+	type embedded struct {
+		Recorder
+		Id int `stbl:"id,PRIMARY_KEY"`
+	}
+	if isTableNamer(&embedded{}) {
+		t.Error("Expected an embedded Recorder not to make a TableNamer")
+	}
+
+	// A Record that embeds a *DbRecorder must not call its own TableName.
+	type embeddedDb struct {
+		*DbRecorder
+		Id int `stbl:"id,PRIMARY_KEY"`
+	}
+	rec := &embeddedDb{Id: 3}
+	rec.DbRecorder = New(db, "mysql")
+	rec.Bind("things", rec)
+	if name := rec.TableName(); name != "things" {
+		t.Errorf("Expected things, got %s", name)
+	}
+	if err := rec.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "DELETE FROM things WHERE id = ?"; db.LastExecSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastExecSql)
+	}
+}
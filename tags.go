@@ -0,0 +1,128 @@
+package structable
+
+import (
+	"reflect"
+	"strings"
+)
+
+// tagKeys are the struct tags that are read for column mappings, in order.
+var tagKeys = []string{StructableTag}
+
+// SetTagKey sets the struct tags that Structable reads column mappings from,
+// for every DbRecorder that does not set its own with SetTagKeys.
+//
+// The first key that is present on a field is used, so existing structs can
+// be bound without adding stbl tags:
+//
+//	structable.SetTagKey("stbl", "db", "gorm")
+//
+// Tags other than db and gorm are read with the stbl grammar. A db tag (as
+// used by sqlx) gives only the column name. A gorm tag is mapped to the stbl
+// options it has an equivalent for: column, primaryKey, autoIncrement,
+// unique, not null, default, size, and type. A field whose tag is "-" is
+// skipped.
+//
+// SetTagKey should be called before any Record is bound. With no keys, it
+// restores the default, stbl.
+func SetTagKey(keys ...string) {
+	if len(keys) == 0 {
+		keys = []string{StructableTag}
+	}
+	tagKeys = keys
+}
+
+// SetTagKeys sets the struct tags that this DbRecorder reads column mappings
+// from, overriding SetTagKey. It takes effect on the next Bind.
+func (s *DbRecorder) SetTagKeys(keys ...string) *DbRecorder {
+	s.tagKeys = keys
+	return s
+}
+
+// TagKeys returns the struct tags that this DbRecorder reads column mappings
+// from.
+func (s *DbRecorder) TagKeys() []string {
+	if len(s.tagKeys) > 0 {
+		return s.tagKeys
+	}
+	return tagKeys
+}
+
+// LookupTag returns a field's column mapping in the stbl grammar, read from
+// the first of the given tag keys that is present on the field. With no keys,
+// the keys set by SetTagKey are used. If the tag omits the column name, it is
+// named by the NamingStrategy set with SetNamingStrategy.
+//
+// It returns false if the field is not mapped to a column.
+func LookupTag(sf reflect.StructField, keys ...string) (string, bool) {
+	if len(keys) == 0 {
+		keys = tagKeys
+	}
+	return lookupTag(sf, keys, naming)
+}
+
+// FieldTag returns a field's column mapping in the stbl grammar, as this
+// DbRecorder reads it: through its TagKeys and its NamingStrategy.
+//
+// It returns false if the field is not mapped to a column.
+func (s *DbRecorder) FieldTag(sf reflect.StructField) (string, bool) {
+	return lookupTag(sf, s.TagKeys(), s.NamingStrategy())
+}
+
+func lookupTag(sf reflect.StructField, keys []string, ns NamingStrategy) (string, bool) {
+	for _, k := range keys {
+		tag, ok := sf.Tag.Lookup(k)
+		if !ok {
+			continue
+		}
+		if tag == "-" {
+			return "", false
+		}
+		switch k {
+		case "db":
+			tag = strings.TrimSpace(strings.Split(tag, ",")[0])
+		case "gorm":
+			tag = gormTag(tag)
+		}
+		parts := SplitTag(tag)
+		if strings.TrimSpace(parts[0]) == "" {
+			parts[0] = ns.ColumnName(sf.Name)
+			tag = strings.Join(parts, ",")
+		}
+		return tag, true
+	}
+	return "", false
+}
+
+// gormTag maps a gorm tag to the stbl grammar. Settings without a stbl
+// equivalent are dropped.
+func gormTag(tag string) string {
+	column := ""
+	opts := []string{}
+	for _, setting := range strings.Split(tag, ";") {
+		kv := strings.SplitN(setting, ":", 2)
+		key := strings.ToUpper(strings.TrimSpace(kv[0]))
+		val := ""
+		if len(kv) == 2 {
+			val = strings.TrimSpace(kv[1])
+		}
+		switch key {
+		case "COLUMN":
+			column = val
+		case "PRIMARYKEY", "PRIMARY_KEY":
+			opts = append(opts, "PRIMARY_KEY")
+		case "AUTOINCREMENT", "AUTO_INCREMENT":
+			opts = append(opts, "AUTO_INCREMENT")
+		case "UNIQUE", "UNIQUEINDEX":
+			opts = append(opts, "UNIQUE")
+		case "NOT NULL":
+			opts = append(opts, "NOT_NULL")
+		case "DEFAULT":
+			opts = append(opts, "DEFAULT("+val+")")
+		case "SIZE":
+			opts = append(opts, "SIZE("+val+")")
+		case "TYPE":
+			opts = append(opts, "TYPE="+val)
+		}
+	}
+	return strings.Join(append([]string{column}, opts...), ",")
+}
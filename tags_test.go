@@ -0,0 +1,77 @@
+package structable
+
+import (
+	"reflect"
+	"testing"
+)
+
+type sqlxUser struct {
+	Id    int    `db:"id" stbl:"id,PRIMARY_KEY,SERIAL"`
+	Email string `db:"email,omitempty"`
+	Temp  string `db:"-"`
+}
+
+type gormUser struct {
+	UserID int    `gorm:"column:uid;primaryKey;autoIncrement"`
+	Email  string `gorm:"uniqueIndex;not null;size:64;default:'x'"`
+	Temp   string `gorm:"-"`
+}
+
+func TestLookupTag(t *testing.T) {
+	tests := []struct {
+		field  reflect.StructField
+		keys   []string
+		expect string
+		ok     bool
+	}{
+		{structField(sqlxUser{}, "Id"), nil, "id,PRIMARY_KEY,SERIAL", true},
+		{structField(sqlxUser{}, "Email"), nil, "", false},
+		{structField(sqlxUser{}, "Email"), []string{"stbl", "db"}, "email", true},
+		{structField(sqlxUser{}, "Temp"), []string{"stbl", "db"}, "", false},
+		{structField(gormUser{}, "UserID"), []string{"gorm"}, "uid,PRIMARY_KEY,AUTO_INCREMENT", true},
+		{structField(gormUser{}, "Email"), []string{"gorm"}, "email,UNIQUE,NOT_NULL,SIZE(64),DEFAULT('x')", true},
+		{structField(gormUser{}, "Temp"), []string{"gorm"}, "", false},
+	}
+	for _, tt := range tests {
+		tag, ok := LookupTag(tt.field, tt.keys...)
+		if tag != tt.expect || ok != tt.ok {
+			t.Errorf("%s %v: expected %q, %t, got %q, %t", tt.field.Name, tt.keys, tt.expect, tt.ok, tag, ok)
+		}
+	}
+
+	if got := snakeCase("UserIDHash"); got != "user_id_hash" {
+		t.Errorf("Expected user_id_hash, got %s", got)
+	}
+}
+
+func TestSetTagKeys(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "mysql").SetTagKeys("stbl", "db")
+	r.Bind("users", &sqlxUser{Email: "matt@example.com"})
+	if cols := r.Columns(true); !reflect.DeepEqual(cols, []string{"id", "email"}) {
+		t.Errorf("Expected the db tag to be read, got %v", cols)
+	}
+
+	// The same type, bound without the db key, is cached separately.
+	plain := New(db, "mysql")
+	plain.Bind("users", &sqlxUser{})
+	if cols := plain.Columns(true); !reflect.DeepEqual(cols, []string{"id"}) {
+		t.Errorf("Expected only the stbl tag to be read, got %v", cols)
+	}
+
+	SetTagKey("gorm")
+	defer SetTagKey()
+	g := New(db, "mysql")
+	g.Bind("users", &gormUser{UserID: 1})
+	if err := g.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "SELECT email FROM users WHERE uid = ?"; db.LastQueryRowSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQueryRowSql)
+	}
+}
+
+func structField(v interface{}, name string) reflect.StructField {
+	f, _ := reflect.TypeOf(v).FieldByName(name)
+	return f
+}
@@ -0,0 +1,110 @@
+package structable
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// ErrNoTenant is returned by every statement of a DbRecorder whose Record
+// has a TENANT column, until a tenant is set with WithTenant.
+var ErrNoTenant = errors.New("no tenant is set for a table with a TENANT column")
+
+// WithTenant returns a copy of the DbRecorder that is scoped to a tenant.
+//
+// The Record's TENANT column holds the tenant of each row:
+//
+//	type Invoice struct {
+//		Id    int `stbl:"id,PRIMARY_KEY,SERIAL"`
+//		OrgId int `stbl:"org_id,TENANT"`
+//		Total int `stbl:"total"`
+//	}
+//
+//	r := structable.New(db, "postgres").WithTenant(org.Id)
+//	r.Bind("invoices", inv)
+//
+// Every statement that the DbRecorder generates for its table (loads,
+// lists, counts, updates, and deletes) is restricted to rows where the TENANT
+// column equals the tenant. Insert and Update set the column to the tenant,
+// so a Record cannot be moved to another tenant. ApplyChanges never allows
+// the TENANT column.
+//
+// Statements written by hand, with QueryInto, Tree, or a WhereFunc that
+// replaces the FROM clause, are not restricted.
+//
+// Until a tenant is set, a DbRecorder whose Record has a TENANT column fails
+// every statement with ErrNoTenant, so that a forgotten tenant cannot leak
+// rows.
+func (s *DbRecorder) WithTenant(tenant interface{}) *DbRecorder {
+	c := *s
+	c.tenant = tenant
+	return &c
+}
+
+// Tenant returns the tenant set with WithTenant, or nil.
+func (s *DbRecorder) Tenant() interface{} {
+	return s.tenant
+}
+
+// tenantField returns the TENANT field, or nil if there is none.
+func (s *DbRecorder) tenantField() *field {
+	for _, f := range s.fields {
+		if f.isTenant {
+			return f
+		}
+	}
+	return nil
+}
+
+// tenantWhere returns the predicate that restricts statements to the
+// tenant's rows, or nil if the Record has no TENANT column.
+func (s *DbRecorder) tenantWhere() interface{} {
+	f := s.tenantField()
+	if f == nil || s.tenant == nil {
+		return nil
+	}
+	return squirrel.Eq{f.column: s.tenant}
+}
+
+// checkTenant returns ErrNoTenant if the Record has a TENANT column, but no
+// tenant is set.
+func (s *DbRecorder) checkTenant() error {
+	if s.tenant == nil && s.tenantField() != nil {
+		return fmt.Errorf("%w: %s", ErrNoTenant, s.table)
+	}
+	return nil
+}
+
+// setTenant sets the TENANT field of the bound Record to the tenant.
+func (s *DbRecorder) setTenant() error {
+	f := s.tenantField()
+	if f == nil || s.tenant == nil {
+		return nil
+	}
+	ref := reflect.Indirect(reflect.ValueOf(s.record)).FieldByName(f.name).Addr().Interface()
+	if err := convertAssign(ref, s.tenant); err != nil {
+		return fmt.Errorf("setting tenant of table %s: %w", s.table, err)
+	}
+	return nil
+}
+
+// checkTenants checks that there is at most one TENANT field, and that it is
+// not a primary key.
+func (s *DbRecorder) checkTenants() error {
+	var tenant *field
+	for _, f := range s.fields {
+		if !f.isTenant {
+			continue
+		}
+		if tenant != nil {
+			return fmt.Errorf("table %s has more than one TENANT column: %s and %s", s.table, tenant.column, f.column)
+		}
+		if f.isKey {
+			return fmt.Errorf("column %s on table %s cannot be both a PRIMARY_KEY and the TENANT", f.column, s.table)
+		}
+		tenant = f
+	}
+	return nil
+}
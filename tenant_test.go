@@ -0,0 +1,116 @@
+package structable
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type invoice struct {
+	Id    int `stbl:"id,PRIMARY_KEY,SERIAL"`
+	OrgId int `stbl:"org_id,TENANT"`
+	Total int `stbl:"total"`
+}
+
+func TestWithTenant(t *testing.T) {
+	db := &DBStub{}
+	inv := &invoice{Id: 1, OrgId: 9, Total: 100}
+	unscoped := New(db, "postgres")
+	unscoped.Bind("invoices", inv)
+
+	if err := unscoped.Load(); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("Expected ErrNoTenant, got %v", err)
+	}
+	if _, err := List(unscoped); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("Expected ErrNoTenant, got %v", err)
+	}
+
+	r := unscoped.WithTenant(7)
+	if r.Tenant() != 7 || unscoped.Tenant() != nil {
+		t.Error("Expected WithTenant to scope a copy")
+	}
+
+	r.Load()
+	expect := "SELECT org_id, total FROM invoices WHERE id = $1 AND org_id = $2"
+	if db.LastQueryRowSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQueryRowSql)
+	}
+
+	if err := r.Update(); err != nil {
+		t.Fatal(err)
+	}
+	expect = "UPDATE invoices SET org_id = $1, total = $2 WHERE id = $3 AND org_id = $4"
+	if db.LastExecSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastExecSql)
+	}
+	if inv.OrgId != 7 {
+		t.Errorf("Expected Update to set the tenant, got %d", inv.OrgId)
+	}
+
+	inv.OrgId = 9
+	r.Insert()
+	if inv.OrgId != 7 || db.LastQueryRowArgs[0] != 7 {
+		t.Errorf("Expected Insert to set the tenant, got %d", inv.OrgId)
+	}
+
+	if _, err := List(r); err != nil {
+		t.Fatal(err)
+	}
+	expect = "SELECT id, org_id, total FROM invoices WHERE org_id = $1"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+
+	if err := r.ApplyChanges(map[string]interface{}{"org_id": 9}, "org_id"); !errors.Is(err, ErrColumnNotAllowed) {
+		t.Errorf("Expected the TENANT column to be rejected, got %v", err)
+	}
+}
+
+func TestTenantTag(t *testing.T) {
+	type twoTenants struct {
+		Id    int `stbl:"id,PRIMARY_KEY"`
+		OrgId int `stbl:"org_id,TENANT"`
+		Team  int `stbl:"team_id,TENANT"`
+	}
+	r := New(&DBStub{}, "mysql").WithTenant(1)
+	r.Bind("teams", &twoTenants{})
+	if err := r.Load(); err == nil {
+		t.Error("Expected two TENANT columns to be rejected")
+	}
+}
+
+// orgCategoryRecord embeds its Recorder, as Records usually do.
+type orgCategoryRecord struct {
+	Recorder
+	Id       int `stbl:"id,PRIMARY_KEY,SERIAL"`
+	OrgId    int `stbl:"org_id,TENANT"`
+	ParentId int `stbl:"parent_id"`
+}
+
+func TestTenantEmbeddedRecorder(t *testing.T) {
+	db := &DBStub{}
+	c := &orgCategoryRecord{Id: 3}
+	c.Recorder = New(db, "postgres").WithTenant(7).Bind("categories", c)
+
+	if _, err := List(c); err != nil {
+		t.Fatal(err)
+	}
+	expect := "SELECT id, org_id, parent_id FROM categories WHERE org_id = $1"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+
+	if _, err := Paginate(c, PageRequest{Size: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(db.LastQuerySql, "org_id = $1") {
+		t.Errorf("Expected Paginate to be scoped to the tenant, got %q", db.LastQuerySql)
+	}
+
+	if _, err := LoadDescendants(c, "parent_id"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(db.LastQuerySql, "categories.org_id = $2") {
+		t.Errorf("Expected LoadDescendants to be scoped to the tenant, got %q", db.LastQuerySql)
+	}
+}
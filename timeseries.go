@@ -0,0 +1,192 @@
+package structable
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// TimeSeries appends and queries time-series Records, such as metrics or
+// events, that carry a bucket column.
+//
+// The bucket column holds the Record's timestamp truncated to the bucket size
+// (for example, the hour or the day). Indexing or partitioning on the bucket
+// column lets range queries skip whole buckets:
+//
+//	ts := structable.NewTimeSeries("recorded_at", "recorded_hour", time.Hour)
+//
+//	ev := NewEvent(db)
+//	ev.RecordedAt = time.Now()
+//	err := ts.Append(ev) // Sets ev.RecordedHour, then inserts.
+//
+//	events, err := ts.ListRange(ev, from, to)
+//
+// Buckets are computed in UTC.
+type TimeSeries struct {
+	// Column is the timestamp column.
+	Column string
+	// BucketColumn is the column that holds the truncated timestamp.
+	BucketColumn string
+	// Bucket is the bucket size.
+	Bucket time.Duration
+}
+
+// NewTimeSeries creates a TimeSeries.
+func NewTimeSeries(column, bucketColumn string, bucket time.Duration) *TimeSeries {
+	return &TimeSeries{
+		Column:       column,
+		BucketColumn: bucketColumn,
+		Bucket:       bucket,
+	}
+}
+
+// Aggregate is an aggregate function applied to a column when downsampling.
+type Aggregate struct {
+	// Func is one of AVG, SUM, MIN, MAX, or COUNT.
+	Func string
+	// Column is the column to aggregate.
+	Column string
+}
+
+// BucketRow is one bucket of a downsampled time series.
+type BucketRow struct {
+	// Bucket is the start of the bucket.
+	Bucket time.Time
+	// Values holds one value for each Aggregate, in order. Aggregates over no
+	// values (such as the AVG of only NULLs) are zero.
+	Values []float64
+}
+
+// BucketOf returns the bucket that a timestamp falls into.
+func (ts *TimeSeries) BucketOf(t time.Time) time.Time {
+	return t.UTC().Truncate(ts.Bucket)
+}
+
+// Append sets the Record's bucket field from its timestamp field, and then
+// inserts the Record.
+//
+// Both fields must be time.Time or *time.Time.
+func (ts *TimeSeries) Append(r Recorder) error {
+	stamp, err := fieldByColumn(r, ts.Column)
+	if err != nil {
+		return err
+	}
+	bucket, err := fieldByColumn(r, ts.BucketColumn)
+	if err != nil {
+		return err
+	}
+
+	if stamp.Kind() == reflect.Ptr && !stamp.IsNil() {
+		stamp = stamp.Elem()
+	}
+	t, ok := stamp.Interface().(time.Time)
+	if !ok {
+		return fmt.Errorf("column %s must be a time.Time to be used in a time series", ts.Column)
+	}
+	b := reflect.ValueOf(ts.BucketOf(t))
+	switch {
+	case bucket.Type() == b.Type():
+		bucket.Set(b)
+	case bucket.Type() == reflect.PtrTo(b.Type()):
+		bucket.Set(reflect.New(b.Type()))
+		bucket.Elem().Set(b)
+	default:
+		return fmt.Errorf("column %s must be a time.Time to be used in a time series", ts.BucketColumn)
+	}
+	return r.Insert()
+}
+
+// ListRange lists the Records whose timestamps are in [from, to), oldest first.
+//
+// The query is also restricted on the bucket column, so that only the buckets
+// that overlap the range are read. Any options are applied after the range.
+func (ts *TimeSeries) ListRange(r Recorder, from, to time.Time, opts ...WhereFunc) ([]Recorder, error) {
+	fn := func(desc Describer, q squirrel.SelectBuilder) (squirrel.SelectBuilder, error) {
+		if err := checkColumns(desc, ts.Column, ts.BucketColumn); err != nil {
+			return q, err
+		}
+		q = ts.where(q, from, to).OrderBy(ts.Column)
+		return Compose(opts...)(desc, q)
+	}
+	return ListWhere(protoRecorder(r), fn)
+}
+
+// Downsample aggregates the Records whose timestamps are in [from, to) by
+// bucket, oldest first.
+//
+//	rows, err := ts.Downsample(ev, from, to,
+//		structable.Aggregate{Func: "AVG", Column: "latency"},
+//		structable.Aggregate{Func: "COUNT", Column: "id"},
+//	)
+//
+// Buckets that contain no Records are not returned.
+func (ts *TimeSeries) Downsample(r Recorder, from, to time.Time, aggs ...Aggregate) ([]BucketRow, error) {
+	buf := []BucketRow{}
+	if err := checkColumns(r, ts.Column, ts.BucketColumn); err != nil {
+		return buf, err
+	}
+
+	cols := []string{ts.BucketColumn}
+	for _, a := range aggs {
+		switch f := strings.ToUpper(a.Func); f {
+		case "AVG", "SUM", "MIN", "MAX", "COUNT":
+			cols = append(cols, fmt.Sprintf("%s(%s)", f, a.Column))
+		default:
+			return buf, fmt.Errorf("unsupported aggregate %s", a.Func)
+		}
+		if err := checkColumns(r, a.Column); err != nil {
+			return buf, err
+		}
+	}
+
+	dr := protoRecorder(r)
+	q := ts.where(dr.builder.Select(cols...).From(dr.TableName()).Where(dr.tenantWhere()), from, to).
+		GroupBy(ts.BucketColumn).
+		OrderBy(ts.BucketColumn)
+	rows, err := dr.query(OpAggregate, q)
+	if err != nil || rows == nil {
+		return buf, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		row := BucketRow{Values: make([]float64, len(aggs))}
+		vals := make([]sql.NullFloat64, len(aggs))
+		dest := []interface{}{&row.Bucket}
+		for i := range vals {
+			dest = append(dest, &vals[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return buf, err
+		}
+		for i, v := range vals {
+			row.Values[i] = v.Float64
+		}
+		buf = append(buf, row)
+	}
+	return buf, rows.Err()
+}
+
+// where restricts a query to [from, to), on both the bucket and the timestamp.
+func (ts *TimeSeries) where(q squirrel.SelectBuilder, from, to time.Time) squirrel.SelectBuilder {
+	return q.Where(squirrel.And{
+		squirrel.GtOrEq{ts.BucketColumn: ts.BucketOf(from)},
+		squirrel.LtOrEq{ts.BucketColumn: ts.BucketOf(to)},
+		squirrel.GtOrEq{ts.Column: from},
+		squirrel.Lt{ts.Column: to},
+	})
+}
+
+// fieldByColumn returns the field of r's Record that is mapped to a column.
+func fieldByColumn(r Recorder, column string) (reflect.Value, error) {
+	for _, f := range protoRecorder(r).fields {
+		if f.column == column {
+			return reflect.Indirect(reflect.ValueOf(r.Interface())).FieldByName(f.name), nil
+		}
+	}
+	return reflect.Value{}, fmt.Errorf("unknown column %q on table %s", column, r.TableName())
+}
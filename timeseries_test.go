@@ -0,0 +1,71 @@
+package structable
+
+import (
+	"testing"
+	"time"
+)
+
+type sample struct {
+	Id         int        `stbl:"id,PRIMARY_KEY,SERIAL"`
+	RecordedAt time.Time  `stbl:"recorded_at"`
+	Hour       *time.Time `stbl:"recorded_hour"`
+	Latency    float64    `stbl:"latency"`
+}
+
+func TestTimeSeriesAppend(t *testing.T) {
+	db := &DBStub{}
+	s := &sample{RecordedAt: time.Date(2016, time.May, 4, 13, 45, 0, 0, time.UTC)}
+	r := New(db, "mysql").Bind("samples", s)
+	ts := NewTimeSeries("recorded_at", "recorded_hour", time.Hour)
+
+	if err := ts.Append(r); err != nil {
+		t.Fatal(err)
+	}
+	if expect := time.Date(2016, time.May, 4, 13, 0, 0, 0, time.UTC); s.Hour == nil || !s.Hour.Equal(expect) {
+		t.Errorf("Expected bucket %s, got %v", expect, s.Hour)
+	}
+	if db.LastExecArgs[1] != s.Hour {
+		t.Errorf("Expected bucket to be inserted, got %v", db.LastExecArgs)
+	}
+
+	ts = NewTimeSeries("latency", "recorded_hour", time.Hour)
+	if err := ts.Append(r); err == nil {
+		t.Error("Expected non-time column to fail")
+	}
+}
+
+func TestTimeSeriesQueries(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "postgres").Bind("samples", &sample{})
+	ts := NewTimeSeries("recorded_at", "recorded_hour", time.Hour)
+	from := time.Date(2016, time.May, 4, 13, 45, 0, 0, time.UTC)
+	to := from.Add(2 * time.Hour)
+
+	if _, err := ts.ListRange(r, from, to, WithLimit(5)); err != nil {
+		t.Fatal(err)
+	}
+	where := "WHERE (recorded_hour >= $1 AND recorded_hour <= $2 AND recorded_at >= $3 AND recorded_at < $4)"
+	expect := "SELECT id, recorded_at, recorded_hour, latency FROM samples " + where + " ORDER BY recorded_at LIMIT 5"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+	if bucket := db.LastQueryArgs[0].(time.Time); bucket.Minute() != 0 {
+		t.Errorf("Expected truncated bucket, got %s", bucket)
+	}
+
+	_, err := ts.Downsample(r, from, to, Aggregate{"avg", "latency"}, Aggregate{"COUNT", "id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect = "SELECT recorded_hour, AVG(latency), COUNT(id) FROM samples " + where + " GROUP BY recorded_hour ORDER BY recorded_hour"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+
+	if _, err := ts.Downsample(r, from, to, Aggregate{"MEDIAN", "latency"}); err == nil {
+		t.Error("Expected unsupported aggregate to fail")
+	}
+	if _, err := ts.Downsample(r, from, to, Aggregate{"SUM", "nope"}); err == nil {
+		t.Error("Expected unknown column to fail")
+	}
+}
@@ -0,0 +1,167 @@
+package structable
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// Tracer is notified of every statement that a DbRecorder runs.
+//
+// This is the place to plug in logging (log/slog, zap) or tracing
+// (OpenTelemetry) without wrapping the database handle.
+//
+// Trace is called after the statement completes. For statements that
+// return a single row, that is after the row has been scanned.
+type Tracer interface {
+	Trace(ctx context.Context, query string, args []interface{}, d time.Duration, err error)
+}
+
+// TracerFunc adapts an ordinary function to the Tracer interface.
+type TracerFunc func(ctx context.Context, query string, args []interface{}, d time.Duration, err error)
+
+// Trace calls f(ctx, query, args, d, err).
+func (f TracerFunc) Trace(ctx context.Context, query string, args []interface{}, d time.Duration, err error) {
+	f(ctx, query, args, d, err)
+}
+
+// SetTracer sets a Tracer that is called around every statement.
+//
+// Pass nil to remove a tracer.
+func (s *DbRecorder) SetTracer(t Tracer) *DbRecorder {
+	s.tracer = t
+	return s
+}
+
+// SetContext sets the context of the statements that the DbRecorder runs.
+//
+// It is passed to the Tracer, and to the ExecContext, QueryContext, and
+// QueryRowContext methods of the Runner, if it has them, so that a canceled
+// context or an expired deadline stops the statement. Runners made with
+// NewRunner have them if the handle does, as *sql.DB and *sql.Tx do.
+func (s *DbRecorder) SetContext(ctx context.Context) *DbRecorder {
+	s.ctx = ctx
+	return s
+}
+
+// Context returns the context set with SetContext, or context.Background().
+func (s *DbRecorder) Context() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
+func (s *DbRecorder) trace(op, query string, args []interface{}, start time.Time, err error) {
+	d := time.Since(start)
+	if s.tracer != nil {
+		s.tracer.Trace(s.Context(), query, args, d, err)
+	}
+	if s.metrics != nil {
+		s.metrics.ObserveQuery(op, s.table, d, err)
+	}
+	if s.history != nil {
+		s.history.record(Operation{Op: op, Query: query, Args: redact(args), At: start, Duration: d, Err: err})
+	}
+}
+
+// ready returns an error if the DbRecorder may not run statements.
+func (s *DbRecorder) ready() error {
+	if s.bindErr != nil {
+		return s.bindErr
+	}
+	return s.checkTenant()
+}
+
+// exec runs a statement that returns no rows.
+func (s *DbRecorder) exec(op string, q squirrel.Sqlizer) (sql.Result, error) {
+	query, args, err := s.build(q)
+	if err != nil {
+		return nil, err
+	}
+	if s.dryRun != nil {
+		s.dryRun.record(op, query, args)
+		return dryResult{}, nil
+	}
+	start := time.Now()
+	var res sql.Result
+	s.profile(op, func(ctx context.Context) { res, err = execContext(ctx, s.db, query, args...) })
+	s.trace(op, query, args, start, err)
+	s.invalidateLists()
+	return res, err
+}
+
+// query runs a statement that returns rows.
+func (s *DbRecorder) query(op string, q squirrel.Sqlizer) (*sql.Rows, error) {
+	query, args, err := s.build(q)
+	if err != nil {
+		return nil, err
+	}
+	if s.dryRun != nil {
+		s.dryRun.record(op, query, args)
+		return nil, ErrDryRun
+	}
+	start := time.Now()
+	var rows *sql.Rows
+	s.profile(op, func(ctx context.Context) { rows, err = queryContext(ctx, s.db, query, args...) })
+	s.trace(op, query, args, start, err)
+	return rows, err
+}
+
+// queryRow runs a statement that returns at most one row.
+func (s *DbRecorder) queryRow(op string, q squirrel.Sqlizer) squirrel.RowScanner {
+	query, args, err := s.build(q)
+	if err != nil {
+		return &errRow{err}
+	}
+	if s.dryRun != nil {
+		s.dryRun.record(op, query, args)
+		if isWrite(op) {
+			return dryRow{}
+		}
+		return &errRow{ErrDryRun}
+	}
+	start := time.Now()
+	var row squirrel.RowScanner
+	s.profile(op, func(ctx context.Context) { row = queryRowContext(ctx, s.db, query, args...) })
+	return &tracedRow{
+		RowScanner: row,
+		rec:        s,
+		op:         op,
+		query:      query,
+		args:       args,
+		start:      start,
+	}
+}
+
+// tracedRow defers tracing until the row has been scanned, since that is
+// when most drivers report errors.
+type tracedRow struct {
+	squirrel.RowScanner
+	rec   *DbRecorder
+	op    string
+	query string
+	args  []interface{}
+	start time.Time
+}
+
+func (r *tracedRow) Scan(dest ...interface{}) error {
+	var err error
+	r.rec.profile(r.op, func(context.Context) { err = r.RowScanner.Scan(dest...) })
+	r.rec.trace(r.op, r.query, r.args, r.start, err)
+	if r.op == OpInsert || r.op == OpUpdate {
+		r.rec.invalidateLists()
+	}
+	return err
+}
+
+// errRow is a RowScanner for statements that could not be built.
+type errRow struct {
+	err error
+}
+
+func (r *errRow) Scan(...interface{}) error {
+	return r.err
+}
@@ -0,0 +1,100 @@
+package structable
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+type ctxKey string
+
+func TestTracer(t *testing.T) {
+	stool := newStool()
+	db := &DBStub{}
+
+	var queries []string
+	var gotCtx context.Context
+	tracer := TracerFunc(func(ctx context.Context, query string, args []interface{}, d time.Duration, err error) {
+		gotCtx = ctx
+		queries = append(queries, query)
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+		}
+	})
+
+	ctx := context.WithValue(context.Background(), ctxKey("request"), "abc")
+	r := New(db, "mysql").SetTracer(tracer).SetContext(ctx)
+	r.Bind("test_table", stool)
+
+	if err := r.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Update(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := List(r, WithLimit(10), WithOffset(0)); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []string{db.LastQueryRowSql, db.LastExecSql, db.LastQuerySql}
+	if len(queries) != len(expect) {
+		t.Fatalf("Expected %d traces, got %d: %v", len(expect), len(queries), queries)
+	}
+	for i, q := range expect {
+		if queries[i] != q {
+			t.Errorf("Expected trace %q, got %q", q, queries[i])
+		}
+	}
+	if gotCtx.Value(ctxKey("request")) != "abc" {
+		t.Error("Expected tracer to receive the recorder's context")
+	}
+}
+
+// ctxStub records the contexts of the statements it runs.
+type ctxStub struct {
+	*DBStub
+	ctxs []context.Context
+}
+
+func (c *ctxStub) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	c.ctxs = append(c.ctxs, ctx)
+	return c.Exec(query, args...)
+}
+
+func (c *ctxStub) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	c.ctxs = append(c.ctxs, ctx)
+	return c.Query(query, args...)
+}
+
+func (c *ctxStub) QueryRowContext(ctx context.Context, query string, args ...interface{}) squirrel.RowScanner {
+	c.ctxs = append(c.ctxs, ctx)
+	return c.QueryRow(query, args...)
+}
+
+func TestContextRunner(t *testing.T) {
+	db := &ctxStub{DBStub: &DBStub{}}
+	ctx := context.WithValue(context.Background(), ctxKey("request"), "abc")
+	r := New(db, "mysql").SetContext(ctx)
+	r.Bind("test_table", newStool())
+
+	if err := r.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Update(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := List(r, WithLimit(10)); err != nil {
+		t.Fatal(err)
+	}
+	if len(db.ctxs) != 3 {
+		t.Fatalf("Expected 3 statements with a context, got %d", len(db.ctxs))
+	}
+	for _, c := range db.ctxs {
+		if c.Value(ctxKey("request")) != "abc" {
+			t.Error("Expected statements to run with the recorder's context")
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package structable
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// Node is a Recorder found by walking a hierarchy, along with its distance
+// from the Recorder the walk started at.
+//
+// Depth is 1 for direct children (or the direct parent), 2 for grandchildren
+// (or the grandparent), and so on.
+type Node struct {
+	Recorder
+	Depth int
+}
+
+// LoadDescendants loads every Record below d in an adjacency-list hierarchy.
+//
+// The hierarchy is described by parentColumn, a column on d's table that holds
+// the primary key of the parent row (for example, parent_id). The bound
+// Record must have exactly one primary key column.
+//
+// Nodes are ordered by depth, so children come before grandchildren:
+//
+//	nodes, err := structable.LoadDescendants(category, "parent_id")
+//	for _, n := range nodes {
+//		c := n.Interface().(*Category)
+//		fmt.Println(strings.Repeat("  ", n.Depth), c.Name)
+//	}
+//
+// If d is scoped to a tenant, as by WithTenant, only that tenant's rows are
+// walked.
+//
+// This uses WITH RECURSIVE, which is supported by Postgres, SQLite, and MySQL
+// 8.0 or later. The hierarchy must not contain cycles.
+func LoadDescendants(d Recorder, parentColumn string) ([]Node, error) {
+	key, err := treeKey(d, parentColumn)
+	if err != nil {
+		return []Node{}, err
+	}
+	tn := d.TableName()
+	scope, scopeArgs := treeScope(d)
+	anchor := fmt.Sprintf("SELECT %s, 1 AS structable_depth FROM %s WHERE %s = ?%s",
+		strings.Join(d.Columns(true), ", "), tn, parentColumn, and(scope))
+	join := fmt.Sprintf("%s.%s = structable_tree.%s", tn, parentColumn, key)
+	args := append([]interface{}{d.WhereIds()[key]}, scopeArgs...)
+	return walkTree(d, anchor, join, args)
+}
+
+// LoadAncestors loads every Record above d in an adjacency-list hierarchy.
+//
+// Nodes are ordered by depth, so the parent comes first and the root comes
+// last. See LoadDescendants.
+func LoadAncestors(d Recorder, parentColumn string) ([]Node, error) {
+	key, err := treeKey(d, parentColumn)
+	if err != nil {
+		return []Node{}, err
+	}
+	tn := d.TableName()
+	scope, scopeArgs := treeScope(d)
+	anchor := fmt.Sprintf("SELECT %s, 1 AS structable_depth FROM %s WHERE %s = (SELECT %s FROM %s WHERE %s = ?%s)%s",
+		strings.Join(d.Columns(true), ", "), tn, key, parentColumn, tn, key, and(scope), and(scope))
+	join := fmt.Sprintf("%s.%s = structable_tree.%s", tn, key, parentColumn)
+	args := append([]interface{}{d.WhereIds()[key]}, scopeArgs...)
+	return walkTree(d, anchor, join, append(args, scopeArgs...))
+}
+
+// treeKey checks that d can be walked, and returns its primary key column.
+func treeKey(d Recorder, parentColumn string) (string, error) {
+	switch d.Driver() {
+	case "postgres", "sqlite3", "sqlite", "mysql":
+	default:
+		return "", fmt.Errorf("recursive queries are not supported by %s", d.Driver())
+	}
+	if err := checkColumns(d, parentColumn); err != nil {
+		return "", err
+	}
+	keys := d.Key()
+	if len(keys) != 1 {
+		return "", fmt.Errorf("table %s must have exactly one primary key to be walked", d.TableName())
+	}
+	return keys[0], nil
+}
+
+// treeScope returns the tenant predicate of d's table, qualified with the
+// table name, and its argument. The predicate is empty if d is not scoped to
+// a tenant.
+func treeScope(d Recorder) (string, []interface{}) {
+	dr := protoRecorder(d)
+	f := dr.tenantField()
+	if f == nil || dr.tenant == nil {
+		return "", nil
+	}
+	return fmt.Sprintf("%s.%s = ?", d.TableName(), f.column), []interface{}{dr.tenant}
+}
+
+// and returns " AND pred", or "" for an empty predicate.
+func and(pred string) string {
+	if pred == "" {
+		return ""
+	}
+	return " AND " + pred
+}
+
+// walkTree runs a recursive query, starting with the rows selected by anchor
+// and adding rows from r's table that match the join condition. The args are
+// those of anchor; the rows that are added are scoped to r's tenant, if any.
+func walkTree(r Recorder, anchor, join string, args []interface{}) ([]Node, error) {
+	buf := []Node{}
+	d := protoRecorder(r)
+	tn := d.TableName()
+	cols := d.Columns(true)
+
+	qualified := make([]string, len(cols))
+	for i, c := range cols {
+		qualified[i] = tn + "." + c
+	}
+	scope, scopeArgs := treeScope(d)
+	cte := fmt.Sprintf("WITH RECURSIVE structable_tree AS (%s UNION ALL SELECT %s, structable_tree.structable_depth + 1 FROM %s JOIN structable_tree ON %s%s)",
+		anchor, strings.Join(qualified, ", "), tn, join, and(scope))
+
+	q := d.Builder().Select(append(cols, "structable_depth")...).
+		Prefix(cte, append(args, scopeArgs...)...).
+		From("structable_tree").
+		OrderBy("structable_depth")
+
+	rows, err := runQuery(d, q)
+	if err != nil || rows == nil {
+		return buf, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		n := Node{Recorder: d.Clone(nil)}
+		if err := n.Recorder.(*DbRecorder).scan(&depthRow{rows, &n.Depth}, true); err != nil {
+			return buf, err
+		}
+		buf = append(buf, n)
+	}
+	return buf, rows.Err()
+}
+
+// depthRow scans the trailing depth column of a tree query into depth.
+type depthRow struct {
+	squirrel.RowScanner
+	depth *int
+}
+
+func (r *depthRow) Scan(dest ...interface{}) error {
+	return r.RowScanner.Scan(append(dest, r.depth)...)
+}
@@ -0,0 +1,115 @@
+package structable
+
+import (
+	"errors"
+	"testing"
+)
+
+type category struct {
+	Id       int    `stbl:"id,PRIMARY_KEY,SERIAL"`
+	ParentId int    `stbl:"parent_id"`
+	Name     string `stbl:"name"`
+}
+
+func TestLoadDescendants(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "postgres").Bind("categories", &category{Id: 3})
+
+	if _, err := LoadDescendants(r, "parent_id"); err != nil {
+		t.Fatal(err)
+	}
+	expect := "WITH RECURSIVE structable_tree AS (" +
+		"SELECT id, parent_id, name, 1 AS structable_depth FROM categories WHERE parent_id = $1 " +
+		"UNION ALL SELECT categories.id, categories.parent_id, categories.name, structable_tree.structable_depth + 1 " +
+		"FROM categories JOIN structable_tree ON categories.parent_id = structable_tree.id) " +
+		"SELECT id, parent_id, name, structable_depth FROM structable_tree ORDER BY structable_depth"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+	if len(db.LastQueryArgs) != 1 || db.LastQueryArgs[0] != 3 {
+		t.Errorf("Unexpected args %v", db.LastQueryArgs)
+	}
+}
+
+func TestLoadAncestors(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "postgres").Bind("categories", &category{Id: 3})
+
+	if _, err := LoadAncestors(r, "parent_id"); err != nil {
+		t.Fatal(err)
+	}
+	expect := "WITH RECURSIVE structable_tree AS (" +
+		"SELECT id, parent_id, name, 1 AS structable_depth FROM categories WHERE id = (SELECT parent_id FROM categories WHERE id = $1) " +
+		"UNION ALL SELECT categories.id, categories.parent_id, categories.name, structable_tree.structable_depth + 1 " +
+		"FROM categories JOIN structable_tree ON categories.id = structable_tree.parent_id) " +
+		"SELECT id, parent_id, name, structable_depth FROM structable_tree ORDER BY structable_depth"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+
+	if _, err := LoadAncestors(r, "nope"); err == nil {
+		t.Error("Expected unknown column to fail")
+	}
+	r = New(db, "mssql").Bind("categories", &category{Id: 3})
+	if _, err := LoadAncestors(r, "parent_id"); err == nil {
+		t.Error("Expected unsupported flavor to fail")
+	}
+	r = New(db, "postgres").Bind("test_table", newStool())
+	if _, err := LoadAncestors(r, "number_of_legs"); err == nil {
+		t.Error("Expected composite key to fail")
+	}
+	type keyless struct {
+		ParentId int `stbl:"parent_id"`
+	}
+	r = New(db, "postgres").Bind("keyless", &keyless{})
+	if _, err := LoadDescendants(r, "parent_id"); err == nil {
+		t.Error("Expected a table without a primary key to fail")
+	}
+}
+
+type orgCategory struct {
+	Id       int `stbl:"id,PRIMARY_KEY,SERIAL"`
+	OrgId    int `stbl:"org_id,TENANT"`
+	ParentId int `stbl:"parent_id"`
+}
+
+func TestLoadTreeTenant(t *testing.T) {
+	db := &DBStub{}
+	unscoped := New(db, "postgres")
+	unscoped.Bind("categories", &orgCategory{Id: 3})
+	if _, err := LoadDescendants(unscoped, "parent_id"); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("Expected ErrNoTenant, got %v", err)
+	}
+
+	r := unscoped.WithTenant(7)
+	if _, err := LoadDescendants(r, "parent_id"); err != nil {
+		t.Fatal(err)
+	}
+	expect := "WITH RECURSIVE structable_tree AS (" +
+		"SELECT id, org_id, parent_id, 1 AS structable_depth FROM categories WHERE parent_id = $1 AND categories.org_id = $2 " +
+		"UNION ALL SELECT categories.id, categories.org_id, categories.parent_id, structable_tree.structable_depth + 1 " +
+		"FROM categories JOIN structable_tree ON categories.parent_id = structable_tree.id AND categories.org_id = $3) " +
+		"SELECT id, org_id, parent_id, structable_depth FROM structable_tree ORDER BY structable_depth"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+	if len(db.LastQueryArgs) != 3 || db.LastQueryArgs[0] != 3 || db.LastQueryArgs[1] != 7 || db.LastQueryArgs[2] != 7 {
+		t.Errorf("Unexpected args %v", db.LastQueryArgs)
+	}
+
+	if _, err := LoadAncestors(r, "parent_id"); err != nil {
+		t.Fatal(err)
+	}
+	expect = "WITH RECURSIVE structable_tree AS (" +
+		"SELECT id, org_id, parent_id, 1 AS structable_depth FROM categories " +
+		"WHERE id = (SELECT parent_id FROM categories WHERE id = $1 AND categories.org_id = $2) AND categories.org_id = $3 " +
+		"UNION ALL SELECT categories.id, categories.org_id, categories.parent_id, structable_tree.structable_depth + 1 " +
+		"FROM categories JOIN structable_tree ON categories.id = structable_tree.parent_id AND categories.org_id = $4) " +
+		"SELECT id, org_id, parent_id, structable_depth FROM structable_tree ORDER BY structable_depth"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+	if len(db.LastQueryArgs) != 4 {
+		t.Errorf("Unexpected args %v", db.LastQueryArgs)
+	}
+}
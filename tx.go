@@ -0,0 +1,144 @@
+package structable
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// ErrNoTransactions is returned by Transact and UnitOfWork for a Runner that
+// cannot begin a transaction.
+var ErrNoTransactions = errors.New("runner cannot begin a transaction")
+
+// Transact runs fn in a transaction, and commits it if fn returns nil. If fn
+// returns an error or panics, the transaction is rolled back.
+//
+// The db is usually a *sql.DB wrapped with NewRunner, or another Runner with a
+// Begin method. The Runner passed to fn runs on the transaction, so
+// DbRecorders made with it take part in the transaction:
+//
+//	err := structable.Transact(structable.NewRunner(db), "postgres", func(tx structable.Runner) error {
+//		if err := NewAccount(tx, "postgres").Debit(id, amount); err != nil {
+//			return err
+//		}
+//		return NewLedger(tx, "postgres").Record(id, amount)
+//	})
+//
+// Transact can be nested: if db is the Runner of an enclosing Transact, the
+// inner scope runs in a SAVEPOINT (SAVE TRANSACTION on mssql) of the
+// enclosing transaction. If the inner fn fails, only its changes are rolled
+// back, and the error is returned to the enclosing fn, which may go on. This
+// lets a helper that needs a transaction be called both on its own and from
+// a larger one. For flavors without savepoints, the inner scope simply joins
+// the enclosing transaction. The flavor of the enclosing Transact is used.
+func Transact(db Runner, flavor string, fn func(tx Runner) error) error {
+	if t, ok := db.(*txRunner); ok {
+		return t.nest(fn)
+	}
+
+	tx, err := begin(db)
+	if err != nil {
+		return err
+	}
+	t := &txRunner{Runner: NewRunner(tx), flavor: flavor}
+	if err := t.run(fn, tx.Rollback); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// begin begins a transaction on a Runner that has a Begin method, or that
+// was made with NewRunner from a handle that has one.
+func begin(db Runner) (*sql.Tx, error) {
+	b, ok := db.(interface{ Begin() (*sql.Tx, error) })
+	if sr, isStd := db.(*stdRunner); isStd {
+		b, ok = sr.db.(interface{ Begin() (*sql.Tx, error) })
+	}
+	if !ok {
+		return nil, ErrNoTransactions
+	}
+	return b.Begin()
+}
+
+// inTransaction reports whether db already runs on a transaction: a *sql.Tx
+// made into a Runner with NewRunner, or the Runner of a UnitOfWork.
+func inTransaction(db Runner) bool {
+	switch r := db.(type) {
+	case *stdRunner:
+		_, ok := r.db.(*sql.Tx)
+		return ok
+	case *uowRunner:
+		return true
+	}
+	return false
+}
+
+// txRunner is the Runner of a Transact scope.
+type txRunner struct {
+	Runner
+	flavor string
+	depth  int
+}
+
+func (t *txRunner) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return execContext(ctx, t.Runner, query, args...)
+}
+
+func (t *txRunner) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return queryContext(ctx, t.Runner, query, args...)
+}
+
+func (t *txRunner) QueryRowContext(ctx context.Context, query string, args ...interface{}) squirrel.RowScanner {
+	return queryRowContext(ctx, t.Runner, query, args...)
+}
+
+// run calls fn, and calls rollback if fn fails or panics.
+func (t *txRunner) run(fn func(tx Runner) error, rollback func() error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			rollback()
+			panic(p)
+		}
+	}()
+	if err := fn(t); err != nil {
+		if rerr := rollback(); rerr != nil {
+			return fmt.Errorf("%s (rollback failed: %s)", err, rerr)
+		}
+		return err
+	}
+	return nil
+}
+
+// nest runs fn in a savepoint of the transaction.
+func (t *txRunner) nest(fn func(tx Runner) error) error {
+	inner := &txRunner{Runner: t.Runner, flavor: t.flavor, depth: t.depth + 1}
+	name := fmt.Sprintf("structable_%d", inner.depth)
+
+	var save, rollback, release string
+	switch t.flavor {
+	case "postgres", "mysql", "sqlite3", "sqlite":
+		save, rollback, release = "SAVEPOINT "+name, "ROLLBACK TO SAVEPOINT "+name, "RELEASE SAVEPOINT "+name
+	case "oracle":
+		save, rollback = "SAVEPOINT "+name, "ROLLBACK TO SAVEPOINT "+name
+	case "mssql":
+		save, rollback = "SAVE TRANSACTION "+name, "ROLLBACK TRANSACTION "+name
+	default:
+		return fn(inner)
+	}
+
+	if _, err := t.Exec(save); err != nil {
+		return err
+	}
+	err := inner.run(fn, func() error {
+		_, err := t.Exec(rollback)
+		return err
+	})
+	if err != nil || release == "" {
+		return err
+	}
+	_, err = t.Exec(release)
+	return err
+}
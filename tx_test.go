@@ -0,0 +1,49 @@
+package structable
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// execLog is a DBStub that keeps every statement it runs.
+type execLog struct {
+	DBStub
+	stmts []string
+}
+
+func (l *execLog) Exec(query string, args ...interface{}) (sql.Result, error) {
+	l.stmts = append(l.stmts, query)
+	return l.DBStub.Exec(query, args...)
+}
+
+func TestTransactNested(t *testing.T) {
+	boom := errors.New("boom")
+	for flavor, expect := range map[string][]string{
+		"postgres": {"SAVEPOINT structable_1", "SAVEPOINT structable_2", "ROLLBACK TO SAVEPOINT structable_2", "RELEASE SAVEPOINT structable_1"},
+		"mssql":    {"SAVE TRANSACTION structable_1", "SAVE TRANSACTION structable_2", "ROLLBACK TRANSACTION structable_2"},
+		"other":    nil,
+	} {
+		db := &execLog{}
+		tx := &txRunner{Runner: db, flavor: flavor}
+		err := Transact(tx, flavor, func(tx Runner) error {
+			if err := Transact(tx, flavor, func(Runner) error { return boom }); err != boom {
+				t.Errorf("%s: expected the inner error, got %v", flavor, err)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("%s: %s", flavor, err)
+		}
+		if !reflect.DeepEqual(db.stmts, expect) {
+			t.Errorf("%s: expected %v, got %v", flavor, expect, db.stmts)
+		}
+	}
+}
+
+func TestTransactNoBegin(t *testing.T) {
+	if err := Transact(NewRunner(nil), "postgres", func(Runner) error { return nil }); err != ErrNoTransactions {
+		t.Errorf("Expected ErrNoTransactions, got %v", err)
+	}
+}
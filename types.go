@@ -0,0 +1,172 @@
+package structable
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// TypeMismatch describes a field whose Go type cannot hold its column's SQL type.
+type TypeMismatch struct {
+	// Field is the name of the struct field.
+	Field string
+	// Column is the name of the database column.
+	Column string
+	// GoType is the Go type of the field.
+	GoType reflect.Type
+	// SQLType is the declared (or discovered) SQL type of the column.
+	SQLType string
+}
+
+// TypeError reports every field on a Record whose Go type is incompatible
+// with its column's SQL type.
+type TypeError struct {
+	Table      string
+	Mismatches []TypeMismatch
+}
+
+func (e *TypeError) Error() string {
+	lines := make([]string, len(e.Mismatches))
+	for i, m := range e.Mismatches {
+		lines[i] = fmt.Sprintf("\tfield %s (%s) cannot hold column %s (%s)", m.Field, m.GoType, m.Column, m.SQLType)
+	}
+	return fmt.Sprintf("incompatible types on table %s:\n%s", e.Table, strings.Join(lines, "\n"))
+}
+
+// BindError returns the error found when the Record was bound, if any.
+//
+// When a field declares its SQL type with the TYPE= tag option, Bind checks
+// that the field's Go type can hold that SQL type:
+//
+//	Legs int `stbl:"number_of_legs,TYPE=INTEGER"`
+//
+// If any field cannot, the problem is reported as a *TypeError, and every
+// statement the Recorder runs returns the same error. Likewise, a COMPRESSED
+// field that is not a string or []byte is reported here. Call BindError after
+// Bind to fail fast, for example at startup.
+func (s *DbRecorder) BindError() error {
+	return s.bindErr
+}
+
+// checkTypes compares each field that declares a TYPE with its Go type.
+func (s *DbRecorder) checkTypes() error {
+	t := reflect.Indirect(reflect.ValueOf(s.record)).Type()
+	terr := &TypeError{Table: s.table}
+	for _, f := range s.fields {
+		if f.sqlType == "" {
+			continue
+		}
+		sf, _ := t.FieldByName(f.name)
+		if !CompatibleType(sf.Type, f.sqlType) {
+			terr.Mismatches = append(terr.Mismatches, TypeMismatch{
+				Field:   f.name,
+				Column:  f.column,
+				GoType:  sf.Type,
+				SQLType: f.sqlType,
+			})
+		}
+	}
+	if len(terr.Mismatches) > 0 {
+		return terr
+	}
+	return nil
+}
+
+// sqlClass is a broad family of SQL types.
+type sqlClass int
+
+const (
+	classUnknown sqlClass = iota
+	classInt
+	classFloat
+	classNumeric
+	classText
+	classBool
+	classTime
+	classBinary
+)
+
+var sqlClasses = map[string]sqlClass{
+	"INT": classInt, "INTEGER": classInt, "BIGINT": classInt, "SMALLINT": classInt,
+	"TINYINT": classInt, "MEDIUMINT": classInt, "INT2": classInt, "INT4": classInt,
+	"INT8": classInt, "SERIAL": classInt, "BIGSERIAL": classInt, "SMALLSERIAL": classInt,
+
+	"REAL": classFloat, "FLOAT": classFloat, "FLOAT4": classFloat, "FLOAT8": classFloat,
+	"DOUBLE": classFloat, "DOUBLE PRECISION": classFloat,
+
+	"NUMERIC": classNumeric, "DECIMAL": classNumeric,
+
+	"TEXT": classText, "VARCHAR": classText, "CHAR": classText, "CHARACTER": classText,
+	"CHARACTER VARYING": classText, "NVARCHAR": classText, "NCHAR": classText,
+	"STRING": classText, "CLOB": classText, "TINYTEXT": classText, "MEDIUMTEXT": classText,
+	"LONGTEXT": classText, "CITEXT": classText, "UUID": classText, "JSON": classText,
+	"JSONB": classText, "ENUM": classText,
+
+	"BOOL": classBool, "BOOLEAN": classBool,
+
+	"DATE": classTime, "TIME": classTime, "DATETIME": classTime, "TIMESTAMP": classTime,
+	"TIMESTAMPTZ": classTime, "TIMESTAMP WITH TIME ZONE": classTime,
+	"TIMESTAMP WITHOUT TIME ZONE": classTime,
+
+	"BLOB": classBinary, "TINYBLOB": classBinary, "MEDIUMBLOB": classBinary,
+	"LONGBLOB": classBinary, "BYTEA": classBinary, "BINARY": classBinary,
+	"VARBINARY": classBinary,
+}
+
+// classify returns the class of a SQL type name, ignoring any length,
+// precision, or UNSIGNED modifier.
+func classify(sqlType string) sqlClass {
+	name := strings.ToUpper(sqlType)
+	if i := strings.Index(name, "("); i >= 0 {
+		if j := strings.LastIndex(name, ")"); j > i {
+			name = name[:i] + name[j+1:]
+		}
+	}
+	name = strings.TrimSuffix(strings.TrimSpace(name), " UNSIGNED")
+	return sqlClasses[strings.Join(strings.Fields(name), " ")]
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+// CompatibleType reports whether a field of Go type t can hold a column of
+// the given SQL type.
+//
+// The check is deliberately loose: it only rejects pairings that cannot work,
+// such as a string field for an INTEGER column. Pointer fields are checked by
+// their element type. Types that implement sql.Scanner, []byte fields, and SQL
+// types that are not recognized are always considered compatible.
+func CompatibleType(t reflect.Type, sqlType string) bool {
+	class := classify(sqlType)
+	if class == classUnknown {
+		return true
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return class == classTime
+	}
+	if reflect.PtrTo(t).Implements(scannerType) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return class == classText || class == classNumeric || class == classTime || class == classBinary
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return class == classInt || class == classNumeric
+	case reflect.Float32, reflect.Float64:
+		return class == classFloat || class == classNumeric || class == classInt
+	case reflect.Bool:
+		return class == classBool || class == classInt
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8
+	}
+	return false
+}
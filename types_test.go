@@ -0,0 +1,81 @@
+package structable
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type typedStool struct {
+	Id       int     `stbl:"id,PRIMARY_KEY,TYPE=BIGINT"`
+	Legs     string  `stbl:"number_of_legs,TYPE=INTEGER"`
+	Price    float64 `stbl:"price,TYPE=NUMERIC(10,2),NUMERIC"`
+	Material string  `stbl:"material,TYPE=varchar(64)"`
+}
+
+func TestBindTypeCheck(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "mysql")
+	r.Bind("test_table", &typedStool{})
+
+	terr, ok := r.BindError().(*TypeError)
+	if !ok {
+		t.Fatalf("Expected a *TypeError, got %v", r.BindError())
+	}
+	if len(terr.Mismatches) != 1 || terr.Mismatches[0].Field != "Legs" || terr.Mismatches[0].SQLType != "INTEGER" {
+		t.Errorf("Unexpected mismatches: %+v", terr.Mismatches)
+	}
+	if !strings.Contains(terr.Error(), "field Legs (string) cannot hold column number_of_legs (INTEGER)") {
+		t.Errorf("Unexpected message: %s", terr)
+	}
+	if !r.fields[2].isNumeric || r.fields[2].sqlType != "NUMERIC(10,2)" {
+		t.Errorf("Expected TYPE with a comma to parse, got %+v", r.fields[2])
+	}
+
+	if err := r.Load(); err != terr {
+		t.Errorf("Expected Load to fail with the bind error, got %v", err)
+	}
+	if err := r.Insert(); err != terr {
+		t.Errorf("Expected Insert to fail with the bind error, got %v", err)
+	}
+	if db.LastExecSql != "" {
+		t.Errorf("Expected no statement to run, got %q", db.LastExecSql)
+	}
+
+	r.Bind("test_table", newStool())
+	if r.BindError() != nil {
+		t.Errorf("Expected no bind error, got %s", r.BindError())
+	}
+}
+
+func TestCompatibleType(t *testing.T) {
+	tests := []struct {
+		val     interface{}
+		sqlType string
+		expect  bool
+	}{
+		{0, "INTEGER", true},
+		{uint8(0), "tinyint(3) unsigned", true},
+		{0, "text", false},
+		{"", "INTEGER", false},
+		{"", "character varying(20)", true},
+		{"", "NUMERIC(10,2)", true},
+		{1.5, "double precision", true},
+		{1.5, "VARCHAR", false},
+		{true, "TINYINT(1)", true},
+		{time.Time{}, "timestamp with time zone", true},
+		{time.Time{}, "INTEGER", false},
+		{new(int64), "BIGINT", true},
+		{sql.NullString{}, "INTEGER", true},
+		{[]byte{}, "BYTEA", true},
+		{struct{}{}, "TEXT", false},
+		{"", "GEOMETRY", true},
+	}
+	for _, tt := range tests {
+		if got := CompatibleType(reflect.TypeOf(tt.val), tt.sqlType); got != tt.expect {
+			t.Errorf("%T with %s: expected %t", tt.val, tt.sqlType, tt.expect)
+		}
+	}
+}
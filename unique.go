@@ -0,0 +1,106 @@
+package structable
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrNotFound is returned by LoadByUnique when no row matches. It wraps
+// sql.ErrNoRows.
+var ErrNotFound = fmt.Errorf("record not found: %w", sql.ErrNoRows)
+
+// ErrAmbiguous is returned when a lookup by a UNIQUE column matches more than
+// one row, which means the database does not enforce the constraint.
+var ErrAmbiguous = errors.New("more than one record matches a unique column")
+
+// LoadByUnique loads the record whose UNIQUE column matches the value of that
+// column's field on the bound Record.
+//
+//	type User struct {
+//		structable.Recorder
+//		Id    int    `stbl:"id,PRIMARY_KEY,SERIAL"`
+//		Email string `stbl:"email,UNIQUE"`
+//	}
+//
+//	u := NewUser(db, "postgres")
+//	u.Email = "matt@example.com"
+//	err := u.LoadByUnique("email")
+//
+// The column must be tagged UNIQUE. If no row matches, ErrNotFound is
+// returned. If several rows match, ErrAmbiguous is returned. In both cases
+// the bound Record is left unchanged.
+func (s *DbRecorder) LoadByUnique(column string) error {
+	pred, err := s.uniqueWhere(column)
+	if err != nil {
+		return err
+	}
+
+	fresh := s.Clone(nil)
+	q := s.builder.Select(s.colList(true, false)...).From(s.TableName()).Where(pred).Where(s.tenantWhere())
+	rows, err := s.query(OpLoadWhere, s.lockSelect(s.limitRows(q, 2)))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return ErrNotFound
+	}
+	if err := fresh.scan(rows, true); err != nil {
+		return err
+	}
+	if rows.Next() {
+		return fmt.Errorf("%w: %s.%s", ErrAmbiguous, s.table, column)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	dest := reflect.Indirect(reflect.ValueOf(s.record))
+	src := reflect.Indirect(reflect.ValueOf(fresh.record))
+	for _, f := range s.fields {
+		dest.FieldByName(f.name).Set(src.FieldByName(f.name))
+	}
+	return nil
+}
+
+// ExistsByUnique returns true if a record's UNIQUE column matches the value
+// of that column's field on the bound Record.
+//
+// The column must be tagged UNIQUE. If several rows match, it returns true
+// along with ErrAmbiguous.
+func (s *DbRecorder) ExistsByUnique(column string) (bool, error) {
+	pred, err := s.uniqueWhere(column)
+	if err != nil {
+		return false, err
+	}
+
+	var n int
+	q := s.builder.Select("COUNT(*)").From(s.TableName()).Where(pred).Where(s.tenantWhere())
+	if err := s.queryRow(OpExistsWhere, q).Scan(&n); err != nil {
+		return false, err
+	}
+	if n > 1 {
+		return true, fmt.Errorf("%w: %s.%s", ErrAmbiguous, s.table, column)
+	}
+	return n == 1, nil
+}
+
+// uniqueWhere builds the predicate that matches a UNIQUE column to the
+// value of its field.
+func (s *DbRecorder) uniqueWhere(column string) (map[string]interface{}, error) {
+	f, err := s.fieldForColumn(column)
+	if err != nil {
+		return nil, err
+	}
+	if !f.isUnique {
+		return nil, fmt.Errorf("column %q on table %s is not declared UNIQUE", column, s.table)
+	}
+	v := reflect.Indirect(reflect.ValueOf(s.record)).FieldByName(f.name).Interface()
+	return map[string]interface{}{column: v}, nil
+}
@@ -0,0 +1,55 @@
+package structable
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+type account struct {
+	Id    int    `stbl:"id,PRIMARY_KEY,SERIAL"`
+	Email string `stbl:"email,UNIQUE"`
+	Name  string `stbl:"name"`
+}
+
+func TestUniqueTag(t *testing.T) {
+	r := New(&DBStub{}, "postgres")
+	r.Bind("accounts", &account{})
+	for _, f := range r.fields {
+		if f.isUnique != (f.column == "email") {
+			t.Errorf("Unexpected UNIQUE flag %t on %s", f.isUnique, f.column)
+		}
+	}
+}
+
+func TestExistsByUnique(t *testing.T) {
+	db := &DBStub{}
+	a := &account{Email: "matt@example.com"}
+	r := New(db, "postgres")
+	r.Bind("accounts", a)
+
+	// The stub scans nothing, so the count stays zero.
+	if ok, err := r.ExistsByUnique("email"); ok || err != nil {
+		t.Errorf("Expected no match, got %t, %v", ok, err)
+	}
+	expect := "SELECT COUNT(*) FROM accounts WHERE email = $1"
+	if db.LastQueryRowSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQueryRowSql)
+	}
+	if len(db.LastQueryRowArgs) != 1 || db.LastQueryRowArgs[0] != "matt@example.com" {
+		t.Errorf("Unexpected args %v", db.LastQueryRowArgs)
+	}
+
+	if _, err := r.ExistsByUnique("name"); err == nil {
+		t.Error("Expected a column without UNIQUE to fail")
+	}
+	if err := r.LoadByUnique("nope"); err == nil {
+		t.Error("Expected an unknown column to fail")
+	}
+}
+
+func TestErrNotFound(t *testing.T) {
+	if !errors.Is(ErrNotFound, sql.ErrNoRows) {
+		t.Error("Expected ErrNotFound to wrap sql.ErrNoRows")
+	}
+}
@@ -0,0 +1,235 @@
+package structable
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// UnitOfWork collects the inserts, updates, and deletes of several Records,
+// and writes them all in one transaction.
+//
+// The DbRecorders it vends with Bind all run on the same transaction, which
+// is begun by the first statement they run, so reads made while the work is
+// prepared see the same data that Flush writes to:
+//
+//	uow := structable.NewUnitOfWork(structable.NewRunner(db), "postgres")
+//	from := &Account{Id: fromId}
+//	to := &Account{Id: toId}
+//	fr, tr := uow.Bind("accounts", from), uow.Bind("accounts", to)
+//	if err := fr.WithLock(structable.LockForUpdate).Load(); err != nil { ... }
+//	if err := tr.WithLock(structable.LockForUpdate).Load(); err != nil { ... }
+//	from.Balance -= amount
+//	to.Balance += amount
+//	uow.Update(fr)
+//	uow.Update(tr)
+//	uow.Insert(uow.Bind("transfers", &Transfer{From: fromId, To: toId, Amount: amount}))
+//	err := uow.Flush()
+//
+// Writes are not sent to the database until Flush. Records given to Insert,
+// Update, and Delete should be bound with Bind, so that their statements run
+// on the transaction.
+//
+// Records loaded with Load are kept in an identity map until the transaction
+// ends, so that every part of the work that loads a row gets the same Record,
+// and no change to it is lost to a stale copy.
+//
+// Like a DbRecorder, a UnitOfWork is not safe for concurrent use.
+type UnitOfWork struct {
+	db       Runner
+	flavor   string
+	tx       *sql.Tx
+	pending  []pendingWrite
+	identity map[string]*DbRecorder
+}
+
+// pendingWrite is a write waiting for Flush.
+type pendingWrite struct {
+	op  string
+	rec Recorder
+}
+
+// NewUnitOfWork creates a UnitOfWork on db, which must be able to begin
+// transactions, as for Transact.
+func NewUnitOfWork(db Runner, flavor string) *UnitOfWork {
+	return &UnitOfWork{db: db, flavor: flavor}
+}
+
+// Bind returns a DbRecorder bound to a Record, whose statements run on the
+// UnitOfWork's transaction.
+func (u *UnitOfWork) Bind(table string, rec Record) *DbRecorder {
+	r := New(&uowRunner{u}, u.flavor)
+	r.Bind(table, rec)
+	return r
+}
+
+// Load loads a Record by its primary key, as by DbRecorder.Load, and returns
+// the DbRecorder that it is bound to.
+//
+// If the UnitOfWork has already loaded the row, rec is left alone, and the
+// DbRecorder of the Record that was loaded first is returned, with any changes
+// made to it since. The Record should therefore be taken from the returned
+// DbRecorder:
+//
+//	r, err := uow.Load("accounts", &Account{Id: id})
+//	if err != nil { ... }
+//	acct := r.Record().(*Account)
+//
+// The identity map is cleared when the transaction ends, by Flush or
+// Rollback, since the rows may change after that. Loads through DbRecorders
+// from Bind do not go through the identity map.
+func (u *UnitOfWork) Load(table string, rec Record) (*DbRecorder, error) {
+	r := u.Bind(table, rec)
+	if err := r.bindErr; err != nil {
+		return nil, err
+	}
+	id := table + "\x00" + keyString(r.WhereIds())
+	if known, ok := u.identity[id]; ok {
+		return known, nil
+	}
+	if err := r.Load(); err != nil {
+		return nil, err
+	}
+	if u.identity == nil {
+		u.identity = map[string]*DbRecorder{}
+	}
+	u.identity[id] = r
+	return r, nil
+}
+
+// Insert adds an insert of the Record to the work.
+func (u *UnitOfWork) Insert(r Recorder) {
+	u.pending = append(u.pending, pendingWrite{OpInsert, r})
+}
+
+// Update adds an update of the Record to the work.
+func (u *UnitOfWork) Update(r Recorder) {
+	u.pending = append(u.pending, pendingWrite{OpUpdate, r})
+}
+
+// Delete adds a delete of the Record to the work.
+func (u *UnitOfWork) Delete(r Recorder) {
+	u.pending = append(u.pending, pendingWrite{OpDelete, r})
+}
+
+// Pending returns the number of writes waiting for Flush.
+func (u *UnitOfWork) Pending() int {
+	return len(u.pending)
+}
+
+// Flush runs the pending writes, in the order they were added, and commits
+// the transaction.
+//
+// If a write fails, the transaction is rolled back, and the error is returned.
+// Either way, the pending writes are cleared, and the UnitOfWork can be used
+// again, in a new transaction. Fields set by the writes, such as the IDs set
+// by Insert, are not reset by a rollback. The identity map is cleared.
+func (u *UnitOfWork) Flush() error {
+	pending := u.pending
+	u.pending = nil
+	for _, w := range pending {
+		var err error
+		switch w.op {
+		case OpInsert:
+			err = w.rec.Insert()
+		case OpUpdate:
+			err = w.rec.Update()
+		case OpDelete:
+			err = w.rec.Delete()
+		}
+		if err != nil {
+			err = fmt.Errorf("%s on table %s: %w", w.op, w.rec.TableName(), err)
+			if rerr := u.Rollback(); rerr != nil {
+				return fmt.Errorf("%s (rollback failed: %s)", err, rerr)
+			}
+			return err
+		}
+	}
+
+	tx := u.tx
+	u.tx, u.identity = nil, nil
+	if tx == nil {
+		return nil
+	}
+	return tx.Commit()
+}
+
+// Rollback discards the pending writes, and rolls back the transaction, if
+// one was begun.
+func (u *UnitOfWork) Rollback() error {
+	u.pending = nil
+	tx := u.tx
+	u.tx, u.identity = nil, nil
+	if tx == nil {
+		return nil
+	}
+	return tx.Rollback()
+}
+
+// begin returns the transaction, beginning it if needed.
+func (u *UnitOfWork) begin() (*sql.Tx, error) {
+	if u.tx != nil {
+		return u.tx, nil
+	}
+	tx, err := begin(u.db)
+	if err != nil {
+		return nil, err
+	}
+	u.tx = tx
+	return tx, nil
+}
+
+// uowRunner runs statements on the transaction of a UnitOfWork.
+type uowRunner struct {
+	u *UnitOfWork
+}
+
+func (r *uowRunner) Exec(query string, args ...interface{}) (sql.Result, error) {
+	tx, err := r.u.begin()
+	if err != nil {
+		return nil, err
+	}
+	return tx.Exec(query, args...)
+}
+
+func (r *uowRunner) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	tx, err := r.u.begin()
+	if err != nil {
+		return nil, err
+	}
+	return tx.Query(query, args...)
+}
+
+func (r *uowRunner) QueryRow(query string, args ...interface{}) squirrel.RowScanner {
+	tx, err := r.u.begin()
+	if err != nil {
+		return &errRow{err}
+	}
+	return tx.QueryRow(query, args...)
+}
+
+func (r *uowRunner) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	tx, err := r.u.begin()
+	if err != nil {
+		return nil, err
+	}
+	return tx.ExecContext(ctx, query, args...)
+}
+
+func (r *uowRunner) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	tx, err := r.u.begin()
+	if err != nil {
+		return nil, err
+	}
+	return tx.QueryContext(ctx, query, args...)
+}
+
+func (r *uowRunner) QueryRowContext(ctx context.Context, query string, args ...interface{}) squirrel.RowScanner {
+	tx, err := r.u.begin()
+	if err != nil {
+		return &errRow{err}
+	}
+	return tx.QueryRowContext(ctx, query, args...)
+}
@@ -0,0 +1,31 @@
+package structable
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUnitOfWork(t *testing.T) {
+	u := NewUnitOfWork(NewRunner(nil), "mysql")
+	if err := u.Flush(); err != nil {
+		t.Errorf("Expected an empty flush to do nothing, got %v", err)
+	}
+
+	r := u.Bind("test_table", newStool())
+	u.Update(r)
+	u.Delete(r)
+	if u.Pending() != 2 {
+		t.Errorf("Expected 2 pending writes, got %d", u.Pending())
+	}
+	if err := u.Flush(); !errors.Is(err, ErrNoTransactions) {
+		t.Errorf("Expected ErrNoTransactions, got %v", err)
+	}
+	if u.Pending() != 0 {
+		t.Errorf("Expected a failed flush to clear the pending writes, got %d", u.Pending())
+	}
+
+	u.Insert(r)
+	if err := u.Rollback(); err != nil || u.Pending() != 0 {
+		t.Errorf("Expected Rollback to discard the pending writes, got %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package structable
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrColumnNotAllowed is returned by ApplyChanges for a column that is not in
+// its allowed list.
+var ErrColumnNotAllowed = errors.New("column may not be changed")
+
+// Values returns the fields of the bound Record, keyed by column name.
+//
+// Pointer fields are returned as pointers, so a nil pointer stands for NULL.
+func (s *DbRecorder) Values() map[string]interface{} {
+	ar := reflect.Indirect(reflect.ValueOf(s.record))
+	vals := make(map[string]interface{}, len(s.fields))
+	for _, f := range s.fields {
+		vals[f.column] = ar.FieldByName(f.name).Interface()
+	}
+	return vals
+}
+
+// SetValues sets fields of the bound Record from a map keyed by column name.
+//
+// Only the columns in the map are set. Values are converted to the field
+// types the way lenient scanning converts them (see SetLenient), so a value
+// decoded from JSON can be stored in an integer field:
+//
+//	var patch map[string]interface{}
+//	json.NewDecoder(req.Body).Decode(&patch)
+//	if err := user.SetValues(patch); err != nil { ... }
+//
+// A nil value sets a pointer field to nil, and any other field to its zero
+// value. If a column is unknown, or a value cannot be converted, an error is
+// returned and the Record is left unchanged.
+func (s *DbRecorder) SetValues(vals map[string]interface{}) error {
+	ar := reflect.Indirect(reflect.ValueOf(s.record))
+	set := make(map[*field]reflect.Value, len(vals))
+	for col, v := range vals {
+		f, err := s.fieldForColumn(col)
+		if err != nil {
+			return err
+		}
+		fv, err := convertField(ar.FieldByName(f.name).Type(), v)
+		if err != nil {
+			return fmt.Errorf("cannot set field %s from column %s: %w", f.name, f.column, err)
+		}
+		set[f] = fv
+	}
+
+	for f, fv := range set {
+		ar.FieldByName(f.name).Set(fv)
+	}
+	return nil
+}
+
+// ApplyChanges sets fields of the bound Record from a map keyed by column
+// name, and then updates only those columns in the database.
+//
+// It is meant for PATCH-style endpoints. Only the allowed columns may be
+// changed; any other column in changes is rejected with an error wrapping
+// ErrColumnNotAllowed, before anything is set:
+//
+//	var patch map[string]interface{}
+//	json.NewDecoder(req.Body).Decode(&patch)
+//	err := user.ApplyChanges(patch, "name", "email")
+//
+// Values are converted as by SetValues. Primary key columns are never
+// allowed, since they identify the row to update, and neither are the TENANT
+// column and READONLY or OMIT_UPDATE columns. If changes is empty,
+// nothing is updated.
+func (s *DbRecorder) ApplyChanges(changes map[string]interface{}, allowed ...string) error {
+	ok := make(map[string]bool, len(allowed))
+	for _, c := range allowed {
+		ok[c] = true
+	}
+	for _, f := range s.key {
+		ok[f.column] = false
+	}
+	if f := s.tenantField(); f != nil {
+		ok[f.column] = false
+	}
+	for _, f := range s.fields {
+		if f.omitUpdate {
+			ok[f.column] = false
+		}
+	}
+	for col := range changes {
+		if !ok[col] {
+			return fmt.Errorf("%w: %s.%s", ErrColumnNotAllowed, s.table, col)
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	if err := s.checkKeys(); err != nil {
+		return err
+	}
+
+	if err := s.SetValues(changes); err != nil {
+		return err
+	}
+	vals := s.Values()
+	set := make(map[string]interface{}, len(changes))
+	fields := make([]*field, 0, len(changes))
+	for col := range changes {
+		f, _ := s.fieldForColumn(col)
+		fields = append(fields, f)
+		set[col] = writeValue(f, vals[col])
+	}
+	if err := s.putExternal(fields); err != nil {
+		return err
+	}
+	q := s.builder.Update(s.TableName()).SetMap(set).Where(s.WhereIds()).Where(s.tenantWhere())
+	_, err := s.exec(OpUpdate, q)
+	return err
+}
+
+// convertField converts v to a new value of type t.
+func convertField(t reflect.Type, v interface{}) (reflect.Value, error) {
+	if v == nil {
+		return reflect.Zero(t), nil
+	}
+	if t.Kind() == reflect.Ptr {
+		p := reflect.New(t.Elem())
+		return p, convertAssign(p.Interface(), v)
+	}
+	p := reflect.New(t)
+	return p.Elem(), convertAssign(p.Interface(), v)
+}
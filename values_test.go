@@ -0,0 +1,84 @@
+package structable
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type patchable struct {
+	Id    int     `stbl:"id,PRIMARY_KEY,SERIAL"`
+	Name  string  `stbl:"name"`
+	Legs  int     `stbl:"legs"`
+	Color *string `stbl:"color"`
+}
+
+func TestValues(t *testing.T) {
+	color := "red"
+	r := New(&DBStub{}, "postgres")
+	r.Bind("things", &patchable{Id: 1, Name: "stool", Legs: 3, Color: &color})
+
+	expect := map[string]interface{}{"id": 1, "name": "stool", "legs": 3, "color": &color}
+	if vals := r.Values(); !reflect.DeepEqual(vals, expect) {
+		t.Errorf("Expected %v, got %v", expect, vals)
+	}
+}
+
+func TestSetValues(t *testing.T) {
+	p := &patchable{Id: 1, Name: "stool", Legs: 3}
+	r := New(&DBStub{}, "postgres")
+	r.Bind("things", p)
+
+	// JSON numbers decode as float64.
+	if err := r.SetValues(map[string]interface{}{"legs": float64(4), "color": "blue"}); err != nil {
+		t.Fatal(err)
+	}
+	if p.Legs != 4 || p.Color == nil || *p.Color != "blue" || p.Name != "stool" {
+		t.Errorf("Unexpected record %+v", p)
+	}
+
+	if err := r.SetValues(map[string]interface{}{"color": nil}); err != nil || p.Color != nil {
+		t.Errorf("Expected nil to clear the pointer, got %v", err)
+	}
+
+	err := r.SetValues(map[string]interface{}{"name": "bench", "legs": "many"})
+	if err == nil {
+		t.Error("Expected a bad value to fail")
+	}
+	if p.Name != "stool" {
+		t.Error("Expected a failed SetValues to leave the record unchanged")
+	}
+	if err := r.SetValues(map[string]interface{}{"nope": 1}); err == nil {
+		t.Error("Expected an unknown column to fail")
+	}
+}
+
+func TestApplyChanges(t *testing.T) {
+	db := &DBStub{}
+	p := &patchable{Id: 1, Name: "stool", Legs: 3}
+	r := New(db, "postgres")
+	r.Bind("things", p)
+
+	if err := r.ApplyChanges(map[string]interface{}{"legs": float64(4), "name": "bench"}, "name", "legs", "id"); err != nil {
+		t.Fatal(err)
+	}
+	expect := "UPDATE things SET legs = $1, name = $2 WHERE id = $3"
+	if db.LastExecSql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastExecSql)
+	}
+	if !reflect.DeepEqual(db.LastExecArgs, []interface{}{4, "bench", 1}) || p.Legs != 4 {
+		t.Errorf("Unexpected args %v", db.LastExecArgs)
+	}
+
+	db.LastExecSql = ""
+	err := r.ApplyChanges(map[string]interface{}{"name": "chair", "color": "red"}, "name")
+	if !errors.Is(err, ErrColumnNotAllowed) || p.Name != "bench" || db.LastExecSql != "" {
+		t.Errorf("Expected a column outside the list to be rejected, got %v", err)
+	}
+	if err := r.ApplyChanges(map[string]interface{}{"id": 2}, "id"); !errors.Is(err, ErrColumnNotAllowed) {
+		t.Errorf("Expected the primary key to be rejected, got %v", err)
+	}
+	if err := r.ApplyChanges(nil, "name"); err != nil || db.LastExecSql != "" {
+		t.Errorf("Expected no changes to do nothing, got %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package structable
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// Compose combines several WhereFuncs into one, applied in order.
+//
+// Composition stops at the first WhereFunc that returns an error.
+//
+//	items, err := structable.ListWhere(r, structable.Compose(
+//		structable.Distinct(),
+//		func(d structable.Describer, q squirrel.SelectBuilder) (squirrel.SelectBuilder, error) {
+//			return q.Where("legs > ?", 3), nil
+//		},
+//	))
+func Compose(fns ...WhereFunc) WhereFunc {
+	return func(desc Describer, q squirrel.SelectBuilder) (squirrel.SelectBuilder, error) {
+		var err error
+		for _, fn := range fns {
+			if q, err = fn(desc, q); err != nil {
+				return q, err
+			}
+		}
+		return q, nil
+	}
+}
+
+// Distinct returns a WhereFunc that makes a list SELECT DISTINCT.
+func Distinct() WhereFunc {
+	return func(desc Describer, q squirrel.SelectBuilder) (squirrel.SelectBuilder, error) {
+		return q.Distinct(), nil
+	}
+}
+
+// DistinctOn returns a WhereFunc that makes a list SELECT DISTINCT ON (cols).
+//
+// DISTINCT ON is only supported by Postgres; on other flavors the WhereFunc
+// returns an error. Every column must be one of the columns on the bound
+// Record. Postgres requires that the ORDER BY clause, if there is one, begin
+// with the same columns.
+func DistinctOn(cols ...string) WhereFunc {
+	return func(desc Describer, q squirrel.SelectBuilder) (squirrel.SelectBuilder, error) {
+		if desc.Driver() != "postgres" {
+			return q, fmt.Errorf("DISTINCT ON is not supported by %s", desc.Driver())
+		}
+		if len(cols) == 0 {
+			return q, fmt.Errorf("DISTINCT ON requires at least one column")
+		}
+		if err := checkColumns(desc, cols...); err != nil {
+			return q, err
+		}
+		return q.Options(fmt.Sprintf("DISTINCT ON (%s)", strings.Join(cols, ", "))), nil
+	}
+}
+
+// checkColumns returns an error if any name is not a column on the Describer.
+//
+// Because column names are put into SQL verbatim, anything that takes a
+// column name from a caller should check it first.
+func checkColumns(desc Describer, names ...string) error {
+	known := map[string]bool{}
+	for _, c := range desc.Columns(true) {
+		known[c] = true
+	}
+	for _, n := range names {
+		if !known[n] {
+			return fmt.Errorf("unknown column %q on table %s", n, desc.TableName())
+		}
+	}
+	return nil
+}
+
+// WithLimit returns a WhereFunc that sets a list's LIMIT.
+func WithLimit(limit uint64) WhereFunc {
+	return func(desc Describer, q squirrel.SelectBuilder) (squirrel.SelectBuilder, error) {
+		return q.Limit(limit), nil
+	}
+}
+
+// WithOffset returns a WhereFunc that sets a list's OFFSET.
+func WithOffset(offset uint64) WhereFunc {
+	return func(desc Describer, q squirrel.SelectBuilder) (squirrel.SelectBuilder, error) {
+		return q.Offset(offset), nil
+	}
+}
+
+// WithOrderBy returns a WhereFunc that adds ORDER BY clauses to a list.
+//
+// Each clause is a column name, optionally followed by ASC or DESC:
+//
+//	structable.WithOrderBy("name", "dt_release DESC")
+//
+// Every column must be one of the columns on the bound Record.
+func WithOrderBy(orderBys ...string) WhereFunc {
+	return func(desc Describer, q squirrel.SelectBuilder) (squirrel.SelectBuilder, error) {
+		for _, o := range orderBys {
+			parts := strings.Fields(o)
+			if len(parts) == 0 || len(parts) > 2 {
+				return q, fmt.Errorf("invalid ORDER BY %q", o)
+			}
+			if len(parts) == 2 {
+				if dir := strings.ToUpper(parts[1]); dir != "ASC" && dir != "DESC" {
+					return q, fmt.Errorf("invalid ORDER BY %q", o)
+				}
+			}
+			if err := checkColumns(desc, parts[0]); err != nil {
+				return q, err
+			}
+		}
+		return q.OrderBy(orderBys...), nil
+	}
+}
+
+// WithWhereEq returns a WhereFunc that restricts a list to rows where the
+// column equals value.
+//
+// If value is a slice, this becomes an IN clause. The column must be one of the
+// columns on the bound Record.
+func WithWhereEq(column string, value interface{}) WhereFunc {
+	return func(desc Describer, q squirrel.SelectBuilder) (squirrel.SelectBuilder, error) {
+		if err := checkColumns(desc, column); err != nil {
+			return q, err
+		}
+		return q.Where(squirrel.Eq{column: value}), nil
+	}
+}
+
+// WithWhere returns a WhereFunc that adds a WHERE clause to a list.
+//
+// The predicate is given as for LoadWhere: a string with args, a map, or any
+// squirrel.Sqlizer. Several WithWhere clauses are joined with AND:
+//
+//	items, err := structable.List(r,
+//		structable.WithWhere(squirrel.Or{
+//			squirrel.Eq{"material": "wood"},
+//			squirrel.Gt{"number_of_legs": 3},
+//		}),
+//		structable.WithWhere("color IS NOT NULL"),
+//	)
+func WithWhere(pred interface{}, args ...interface{}) WhereFunc {
+	return func(desc Describer, q squirrel.SelectBuilder) (squirrel.SelectBuilder, error) {
+		return q.Where(pred, args...), nil
+	}
+}
+
+// WithColumns returns a WhereFunc that makes a list select only the given
+// columns.
+//
+// Only the fields for those columns are set on the listed Records; the rest
+// keep their zero values. This allows heavy columns (such as blobs or large
+// text) to be skipped:
+//
+//	items, err := structable.List(r, structable.WithColumns("id", "title"))
+//
+// Every column must be one of the columns on the bound Record.
+func WithColumns(cols ...string) WhereFunc {
+	return func(desc Describer, q squirrel.SelectBuilder) (squirrel.SelectBuilder, error) {
+		if len(cols) == 0 {
+			return q, fmt.Errorf("no columns selected on table %s", desc.TableName())
+		}
+		if err := checkColumns(desc, cols...); err != nil {
+			return q, err
+		}
+		return q.RemoveColumns().Columns(cols...), nil
+	}
+}
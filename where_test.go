@@ -0,0 +1,171 @@
+package structable
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+)
+
+func TestDistinct(t *testing.T) {
+	stool := newStool()
+	db := &DBStub{}
+	r := New(db, "mysql").Bind("test_table", stool)
+
+	if _, err := ListWhere(r, Distinct()); err != nil {
+		t.Fatal(err)
+	}
+	expect := "SELECT DISTINCT id, id_two, number_of_legs, material, color FROM test_table"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+
+	if _, err := ListWhere(r, DistinctOn("material")); err == nil {
+		t.Error("Expected DISTINCT ON to fail on MySQL")
+	}
+}
+
+func TestDistinctOn(t *testing.T) {
+	stool := newStool()
+	db := &DBStub{}
+	r := New(db, "postgres").Bind("test_table", stool)
+
+	if _, err := ListWhere(r, DistinctOn("material", "color")); err != nil {
+		t.Fatal(err)
+	}
+	expect := "SELECT DISTINCT ON (material, color) id, id_two, number_of_legs, material, color FROM test_table"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+
+	if _, err := ListWhere(r, DistinctOn("material; DROP TABLE test_table")); err == nil {
+		t.Error("Expected unknown column to fail")
+	}
+}
+
+func TestCompose(t *testing.T) {
+	stool := newStool()
+	db := &DBStub{}
+	r := New(db, "postgres").Bind("test_table", stool)
+
+	fn := Compose(DistinctOn("material"), Distinct())
+	if _, err := ListWhere(r, fn); err != nil {
+		t.Fatal(err)
+	}
+	expect := "SELECT DISTINCT ON (material) DISTINCT id, id_two, number_of_legs, material, color FROM test_table"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+
+	fn = Compose(DistinctOn("nope"), Distinct())
+	if _, err := ListWhere(r, fn); err == nil {
+		t.Error("Expected Compose to stop at the first error")
+	}
+}
+
+func TestListOptions(t *testing.T) {
+	stool := newStool()
+	db := &DBStub{}
+	r := New(db, "postgres").Bind("test_table", stool)
+
+	_, err := List(r,
+		WithWhereEq("material", "wood"),
+		WithOrderBy("number_of_legs DESC", "id"),
+		WithLimit(10),
+		WithOffset(20),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "SELECT id, id_two, number_of_legs, material, color FROM test_table WHERE material = $1 ORDER BY number_of_legs DESC, id LIMIT 10 OFFSET 20"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+
+	for _, o := range []string{"nope", "id SIDEWAYS", "id; DROP TABLE test_table", ""} {
+		if _, err := List(r, WithOrderBy(o)); err == nil {
+			t.Errorf("Expected ORDER BY %q to fail", o)
+		}
+	}
+	if _, err := List(r, WithWhereEq("nope", 1)); err == nil {
+		t.Error("Expected unknown column to fail")
+	}
+}
+
+func TestWithColumns(t *testing.T) {
+	stool := newStool()
+	db := &DBStub{}
+	r := New(db, "mysql")
+	r.Bind("test_table", stool)
+
+	if _, err := List(r, WithColumns("id", "material"), WithLimit(5)); err != nil {
+		t.Fatal(err)
+	}
+	expect := "SELECT id, material FROM test_table LIMIT 5"
+	if db.LastQuerySql != expect {
+		t.Errorf("Expected %q, got %q", expect, db.LastQuerySql)
+	}
+
+	if _, err := List(r, WithColumns("id", "nope")); err == nil {
+		t.Error("Expected unknown column to fail")
+	}
+	if _, err := List(r, WithColumns()); err == nil {
+		t.Error("Expected empty column list to fail")
+	}
+}
+
+func TestWherePredicates(t *testing.T) {
+	db := &DBStub{}
+	r := New(db, "postgres")
+	r.Bind("test_table", newStool())
+
+	tests := []struct {
+		pred   interface{}
+		args   []interface{}
+		expect string
+		vals   []interface{}
+	}{
+		{"material = ?", []interface{}{"wood"}, "material = $1", []interface{}{"wood"}},
+		{map[string]interface{}{"material": "wood"}, nil, "material = $1", []interface{}{"wood"}},
+		{squirrel.Eq{"number_of_legs": []int{3, 4}}, nil, "number_of_legs IN ($1,$2)", []interface{}{3, 4}},
+		{squirrel.Or{squirrel.Eq{"material": "wood"}, squirrel.Gt{"number_of_legs": 3}}, nil,
+			"(material = $1 OR number_of_legs > $2)", []interface{}{"wood", 3}},
+		{squirrel.And{
+			squirrel.Eq{"material": "wood"},
+			squirrel.Or{squirrel.Lt{"number_of_legs": 3}, squirrel.Expr("color IS NULL")},
+		}, nil, "(material = $1 AND (number_of_legs < $2 OR color IS NULL))", []interface{}{"wood", 3}},
+		{Named("material = :m", map[string]interface{}{"m": "wood"}), nil, "material = $1", []interface{}{"wood"}},
+	}
+
+	for _, tt := range tests {
+		r.LoadWhere(tt.pred, tt.args...)
+		if expect := "SELECT id, id_two, number_of_legs, material, color FROM test_table WHERE " + tt.expect + " LIMIT 1"; db.LastQuerySql != expect || !reflect.DeepEqual(db.LastQueryArgs, tt.vals) {
+			t.Errorf("LoadWhere: expected %q %v, got %q %v", expect, tt.vals, db.LastQuerySql, db.LastQueryArgs)
+		}
+
+		r.ExistsWhere(tt.pred, tt.args...)
+		if expect := "SELECT EXISTS(SELECT 1 FROM test_table WHERE " + tt.expect + ")"; db.LastQueryRowSql != expect || !reflect.DeepEqual(db.LastQueryRowArgs, tt.vals) {
+			t.Errorf("ExistsWhere: expected %q %v, got %q %v", expect, tt.vals, db.LastQueryRowSql, db.LastQueryRowArgs)
+		}
+
+		if n, err := r.DeleteWhere(tt.pred, tt.args...); err != nil || n != 1 {
+			t.Errorf("DeleteWhere: expected 1 row, got %d, %v", n, err)
+		}
+		if expect := "DELETE FROM test_table WHERE " + tt.expect; db.LastExecSql != expect || !reflect.DeepEqual(db.LastExecArgs, tt.vals) {
+			t.Errorf("DeleteWhere: expected %q %v, got %q %v", expect, tt.vals, db.LastExecSql, db.LastExecArgs)
+		}
+
+		if _, err := List(r, WithWhere(tt.pred, tt.args...)); err != nil {
+			t.Fatal(err)
+		}
+		if expect := "SELECT id, id_two, number_of_legs, material, color FROM test_table WHERE " + tt.expect; db.LastQuerySql != expect || !reflect.DeepEqual(db.LastQueryArgs, tt.vals) {
+			t.Errorf("WithWhere: expected %q %v, got %q %v", expect, tt.vals, db.LastQuerySql, db.LastQueryArgs)
+		}
+	}
+
+	for _, pred := range []interface{}{nil, ""} {
+		if _, err := r.DeleteWhere(pred); err == nil {
+			t.Errorf("Expected DeleteWhere(%#v) to fail", pred)
+		}
+	}
+}